@@ -11,10 +11,24 @@ import (
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/internal/config"
+	"github.com/influxdata/telegraf/internal/fixture"
+	"github.com/influxdata/telegraf/internal/limiter"
 	"github.com/influxdata/telegraf/internal/models"
+	"github.com/influxdata/telegraf/internal/proxy"
+	"github.com/influxdata/telegraf/internal/retry"
 	"github.com/influxdata/telegraf/selfstat"
 )
 
+// Outputs failing to connect are retried with a jittered backoff, so that the
+// agents restarting together do not reconnect together.
+var connectBackoff = retry.Backoff{
+	Initial: 15 * time.Second,
+	Max:     time.Minute,
+	Jitter:  0.5,
+}
+
+const connectAttempts = 3
+
 // Agent runs telegraf and collects data based on the given config
 type Agent struct {
 	Config *config.Config
@@ -39,6 +53,15 @@ func NewAgent(config *config.Config) (*Agent, error) {
 		config.Tags["host"] = a.Config.Agent.Hostname
 	}
 
+	err := proxy.SetDefault(a.Config.Agent.HTTPProxy, a.Config.Agent.NoProxy)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.Config.CheckRateLimiters(); err != nil {
+		return nil, err
+	}
+	limiter.SetNamed(a.Config.RateLimits)
+
 	return a, nil
 }
 
@@ -55,15 +78,16 @@ func (a *Agent) Connect() error {
 		}
 
 		log.Printf("D! Attempting connection to output: %s\n", o.Name)
-		err := o.Output.Connect()
-		if err != nil {
-			log.Printf("E! Failed to connect to output %s, retrying in 15s, "+
-				"error was '%s' \n", o.Name, err)
-			time.Sleep(15 * time.Second)
-			err = o.Output.Connect()
-			if err != nil {
-				return err
-			}
+		policy := retry.Policy{
+			Backoff:     connectBackoff,
+			MaxAttempts: connectAttempts,
+			Notify: func(err error, delay time.Duration) {
+				log.Printf("E! Failed to connect to output %s, retrying in %s, "+
+					"error was '%s' \n", o.Name, delay, err)
+			},
+		}
+		if err := policy.Do(nil, o.Output.Connect); err != nil {
+			return err
 		}
 		log.Printf("D! Successfully connected to output: %s\n", o.Name)
 	}
@@ -105,7 +129,7 @@ func (a *Agent) gatherer(
 ) {
 	defer panicRecover(input)
 
-	GatherTime := selfstat.RegisterTiming("gather",
+	GatherTime := selfstat.RegisterHistogram("gather",
 		"gather_time_ns",
 		map[string]string{"input": input.Config.Name},
 	)
@@ -174,6 +198,17 @@ func gatherWithTimeout(
 // Test verifies that we can 'Gather' from all inputs with their configured
 // Config struct
 func (a *Agent) Test() error {
+	return a.test(nil)
+}
+
+// TestFixture gathers from all inputs as Test, feeding them the recorded
+// data of the fixture instead of the data of the host.  Service inputs are
+// started when the fixture has payloads to send them.
+func (a *Agent) TestFixture(f *fixture.Fixture) error {
+	return a.test(f)
+}
+
+func (a *Agent) test(f *fixture.Fixture) error {
 	shutdown := make(chan struct{})
 	defer close(shutdown)
 	metricC := make(chan telegraf.Metric)
@@ -191,7 +226,8 @@ func (a *Agent) Test() error {
 	}()
 
 	for _, input := range a.Config.Inputs {
-		if _, ok := input.Input.(telegraf.ServiceInput); ok {
+		service, isService := models.ServiceInput(input.Input)
+		if isService && (f == nil || len(f.Payloads) == 0) {
 			fmt.Printf("\nWARNING: skipping plugin [[%s]]: service inputs not supported in --test mode\n",
 				input.Name())
 			continue
@@ -203,6 +239,19 @@ func (a *Agent) Test() error {
 		input.SetTrace(true)
 		input.SetDefaultTags(a.Config.Tags)
 
+		if f != nil {
+			if err := f.Apply(input.Input); err != nil {
+				return fmt.Errorf("%s: %s", input.Name(), err)
+			}
+		}
+
+		if isService {
+			if err := testService(service, acc, f); err != nil {
+				return err
+			}
+			continue
+		}
+
 		if err := input.Input.Gather(acc); err != nil {
 			return err
 		}
@@ -221,6 +270,23 @@ func (a *Agent) Test() error {
 	return nil
 }
 
+// testService starts the service input, sends it the payloads of the
+// fixture and gathers the metrics they produced.
+func testService(service telegraf.ServiceInput, acc telegraf.Accumulator, f *fixture.Fixture) error {
+	if err := service.Start(acc); err != nil {
+		return err
+	}
+	defer service.Stop()
+
+	if err := f.Send(); err != nil {
+		return err
+	}
+	// let the input process the payloads, the listeners read them
+	// asynchronously
+	time.Sleep(500 * time.Millisecond)
+	return service.Gather(acc)
+}
+
 // flush writes a list of metrics to all configured outputs
 func (a *Agent) flush() {
 	var wg sync.WaitGroup
@@ -4,6 +4,8 @@ package telegraf
 // the RunningAggregator wraps this interface and guarantees that
 // Add, Push, and Reset can not be called concurrently, so locking is not
 // required when implementing an Aggregator plugin.
+// Aggregators checking their options implement an Init() error method, which
+// is called once the options are loaded and stops telegraf on error.
 type Aggregator interface {
 	// SampleConfig returns the default configuration of the Input.
 	SampleConfig() string
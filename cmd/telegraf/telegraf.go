@@ -1,6 +1,7 @@
 package main
 
 import (
+	"encoding/json"
 	"flag"
 	"fmt"
 	"log"
@@ -15,6 +16,7 @@ import (
 	"github.com/influxdata/telegraf/agent"
 	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/internal/config"
+	"github.com/influxdata/telegraf/internal/fixture"
 	"github.com/influxdata/telegraf/logger"
 	_ "github.com/influxdata/telegraf/plugins/aggregators/all"
 	"github.com/influxdata/telegraf/plugins/inputs"
@@ -32,6 +34,8 @@ var pprofAddr = flag.String("pprof-addr", "",
 var fQuiet = flag.Bool("quiet", false,
 	"run in quiet mode")
 var fTest = flag.Bool("test", false, "gather metrics, print them out, and exit")
+var fFixture = flag.String("fixture", "",
+	"directory of recorded data fed to the inputs in test mode")
 var fConfig = flag.String("config", "", "configuration file to load")
 var fConfigDirectory = flag.String("config-directory", "",
 	"directory containing additional *.conf files")
@@ -88,11 +92,23 @@ func reloadLoop(
 	for <-reload {
 		reload <- false
 
+		var fix *fixture.Fixture
+		configPath := *fConfig
+		if *fFixture != "" {
+			var err error
+			if fix, err = fixture.Load(*fFixture); err != nil {
+				log.Fatal("E! " + err.Error())
+			}
+			if configPath == "" {
+				configPath = fix.Config
+			}
+		}
+
 		// If no other options are specified, load the config file and run.
 		c := config.NewConfig()
 		c.OutputFilters = outputFilters
 		c.InputFilters = inputFilters
-		err := c.LoadConfig(*fConfig)
+		err := c.LoadConfig(configPath)
 		if err != nil {
 			log.Fatal("E! " + err.Error())
 		}
@@ -133,7 +149,11 @@ func reloadLoop(
 		)
 
 		if *fTest {
-			err = ag.Test()
+			if fix != nil {
+				err = ag.TestFixture(fix)
+			} else {
+				err = ag.Test()
+			}
 			if err != nil {
 				log.Fatal("E! " + err.Error())
 			}
@@ -192,6 +212,38 @@ func reloadLoop(
 	}
 }
 
+// validateConfig prints the errors of the configuration files, returning the
+// exit code.
+func validateConfig() int {
+	c := config.NewConfig()
+	errs := c.ValidateConfig(*fConfig)
+	if *fConfigDirectory != "" {
+		errs = append(errs, c.ValidateDirectory(*fConfigDirectory)...)
+	}
+	errs = append(errs, c.ValidateRateLimiters()...)
+	if len(errs) == 0 {
+		fmt.Println("Configuration is valid")
+		return 0
+	}
+	for _, err := range errs {
+		fmt.Fprintln(os.Stderr, err)
+	}
+	fmt.Fprintf(os.Stderr, "Found %d errors\n", len(errs))
+	return 1
+}
+
+// printPluginsJSON prints the schemas of the plugins as JSON, returning the
+// exit code.
+func printPluginsJSON(schemas []*config.PluginSchema) int {
+	data, err := json.MarshalIndent(schemas, "", "  ")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "E! "+err.Error())
+		return 1
+	}
+	fmt.Println(string(data))
+	return 0
+}
+
 func usageExit(rc int) {
 	fmt.Println(internal.Usage)
 	os.Exit(rc)
@@ -234,6 +286,15 @@ func main() {
 	flag.Usage = func() { usageExit(0) }
 	flag.Parse()
 	args := flag.Args()
+	if len(args) > 0 && args[0] == "test" {
+		// "telegraf test <flags>" is "telegraf --test <flags>"
+		flag.CommandLine.Parse(args[1:])
+		args = flag.Args()
+		*fTest = true
+	}
+	if *fFixture != "" && !*fTest {
+		log.Fatal("E! --fixture is only supported in test mode")
+	}
 
 	inputFilters, outputFilters := []string{}, []string{}
 	if *fInputFilters != "" {
@@ -274,6 +335,9 @@ func main() {
 			fmt.Printf("Telegraf %s (git: %s %s)\n", displayVersion(), branch, commit)
 			return
 		case "config":
+			if len(args) > 1 && args[1] == "validate" {
+				os.Exit(validateConfig())
+			}
 			config.PrintSampleConfig(
 				inputFilters,
 				outputFilters,
@@ -281,6 +345,20 @@ func main() {
 				processorFilters,
 			)
 			return
+		case "plugins":
+			schemas := config.PluginSchemas(
+				inputFilters,
+				outputFilters,
+				aggregatorFilters,
+				processorFilters,
+			)
+			if len(args) > 1 && (args[1] == "--json" || args[1] == "-json") {
+				os.Exit(printPluginsJSON(schemas))
+			}
+			for _, s := range schemas {
+				fmt.Printf("%-40s %s\n", s.Type+"."+s.Name, s.Description)
+			}
+			return
 		}
 	}
 
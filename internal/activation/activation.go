@@ -0,0 +1,71 @@
+// Package activation returns the sockets passed to the process by systemd
+// socket activation, see sd_listen_fds(3).
+package activation
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// listenFdsStart is the first descriptor passed by systemd.
+const listenFdsStart = 3
+
+var (
+	once  sync.Once
+	files []*os.File
+)
+
+// Files returns the sockets passed to the process, named after
+// LISTEN_FDNAMES.  The sockets are shared by the callers, which should use
+// duplicates such as those of net.FileListener and net.FilePacketConn.
+func Files() []*os.File {
+	once.Do(func() {
+		files = listenFiles(os.Getenv("LISTEN_PID"), os.Getenv("LISTEN_FDS"),
+			os.Getenv("LISTEN_FDNAMES"), listenFdsStart)
+	})
+	return files
+}
+
+// File returns the socket of the name, the first socket when name is empty.
+func File(name string) (*os.File, error) {
+	files := Files()
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no socket passed by systemd")
+	}
+	if name == "" {
+		return files[0], nil
+	}
+	for _, f := range files {
+		if f.Name() == name {
+			return f, nil
+		}
+	}
+	return nil, fmt.Errorf("no socket named %q passed by systemd", name)
+}
+
+func listenFiles(pid, fds, names string, start int) []*os.File {
+	if p, err := strconv.Atoi(pid); err != nil || p != os.Getpid() {
+		return nil
+	}
+	n, err := strconv.Atoi(fds)
+	if err != nil || n <= 0 {
+		return nil
+	}
+
+	var fdNames []string
+	if names != "" {
+		fdNames = strings.Split(names, ":")
+	}
+	files := make([]*os.File, 0, n)
+	for i := 0; i < n; i++ {
+		name := "LISTEN_FD_" + strconv.Itoa(start+i)
+		if i < len(fdNames) {
+			name = fdNames[i]
+		}
+		files = append(files, os.NewFile(uintptr(start+i), name))
+	}
+	return files
+}
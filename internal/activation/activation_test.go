@@ -0,0 +1,33 @@
+package activation
+
+import (
+	"net"
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestListenFiles(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+	f, err := l.(*net.TCPListener).File()
+	require.NoError(t, err)
+	defer f.Close()
+
+	files := listenFiles(strconv.Itoa(os.Getpid()), "1", "metrics", int(f.Fd()))
+	require.Len(t, files, 1)
+	require.Equal(t, "metrics", files[0].Name())
+
+	fl, err := net.FileListener(files[0])
+	require.NoError(t, err)
+	defer fl.Close()
+	require.Equal(t, l.Addr().String(), fl.Addr().String())
+}
+
+func TestListenFilesOtherProcess(t *testing.T) {
+	require.Empty(t, listenFiles(strconv.Itoa(os.Getpid()+1), "1", "", listenFdsStart))
+	require.Empty(t, listenFiles("", "", "", listenFdsStart))
+}
@@ -0,0 +1,321 @@
+// Package cliparse parses the output of command line tools into tags and
+// fields, plugins describe the lines of the output with rules instead of
+// scanning them by hand.
+package cliparse
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/influxdata/telegraf/internal/linereader"
+)
+
+// Type is the type of the value of a column.
+type Type int
+
+const (
+	String Type = iota
+	Int
+	Uint
+	Float
+	Bool
+	// Size is a size with an optional unit, ie "1.5 GB" or "512KiB", units
+	// are powers of 1024; the value is an int64 number of bytes.
+	Size
+)
+
+// Column describes a value of a line and the tag or field it is stored as.
+type Column struct {
+	// Name of the subexpression of the rule regexp, unused when the line is
+	// split into columns
+	Name string
+	// Key of the tag or field, defaults to Name; columns without key are
+	// skipped
+	Key string
+	// Skip the value, ie a column between the values of a split line
+	Skip bool
+	// Store the value as a tag instead of a field
+	Tag bool
+	// Type of the field value
+	Type Type
+	// Empty values and values equal to one of Null are not stored, ie "-"
+	// or "N/A"
+	Null []string
+}
+
+func (c *Column) key() string {
+	if c.Key != "" {
+		return c.Key
+	}
+	return c.Name
+}
+
+// Rule describes the lines it matches.  With a regexp the columns are its
+// named subexpressions, otherwise lines are split on the separator, or on
+// whitespace, and the columns are the values in order.
+type Rule struct {
+	Regexp *regexp.Regexp
+	// Separator of the values of split lines, whitespace when empty
+	Separator string
+	// Split lines with other numbers of values than columns are skipped,
+	// with Prefix only the lines starting with it are split
+	Prefix string
+
+	Columns []Column
+	// Tags and fields added to the records of the rule
+	Tags   map[string]string
+	Fields map[string]interface{}
+}
+
+// Record holds the tags and fields of a matched line.
+type Record struct {
+	// Rule is the index of the rule matching the line
+	Rule   int
+	Line   int
+	Tags   map[string]string
+	Fields map[string]interface{}
+}
+
+// Errors are the errors of the lines of an output.
+type Errors []error
+
+func (e Errors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, err := range e {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Parser parses lines with the first matching rule.
+type Parser struct {
+	Rules []Rule
+	// Lines matching no rule are errors, empty lines are always skipped
+	Strict bool
+	// Longer lines are errors, defaults to linereader.DefaultMaxLineLength
+	MaxLineLength int
+}
+
+// Parse parses all lines of the output.  Errors of lines do not stop the
+// parsing, the records of the other lines are returned with the Errors.
+func (p *Parser) Parse(r io.Reader) ([]Record, error) {
+	var records []Record
+	var errs Errors
+
+	maxLineLength := p.MaxLineLength
+	if maxLineLength <= 0 {
+		maxLineLength = linereader.DefaultMaxLineLength
+	}
+	reader := linereader.NewReader(r, maxLineLength)
+	for {
+		line, truncated, err := reader.ReadLine()
+		if err != nil {
+			break
+		}
+		n := reader.Line()
+		if truncated {
+			errs = append(errs, fmt.Errorf("line %d: longer than %d bytes", n, maxLineLength))
+			continue
+		}
+
+		record, err := p.ParseLine(string(line))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("line %d: %s", n, err))
+		}
+		if record != nil {
+			record.Line = n
+			records = append(records, *record)
+		}
+	}
+	if err := reader.Err(); err != nil {
+		errs = append(errs, err)
+	}
+
+	if len(errs) > 0 {
+		return records, errs
+	}
+	return records, nil
+}
+
+// ParseBytes parses all lines of the output.
+func (p *Parser) ParseBytes(buf []byte) ([]Record, error) {
+	return p.Parse(bytes.NewReader(buf))
+}
+
+// ParseLine parses the line with the first matching rule, the record is nil
+// if no rule matches.  The values that can not be parsed are errors, the
+// record holds the other values.
+func (p *Parser) ParseLine(line string) (*Record, error) {
+	line = strings.TrimRight(line, "\r")
+	if strings.TrimSpace(line) == "" {
+		return nil, nil
+	}
+
+	for i := range p.Rules {
+		rule := &p.Rules[i]
+		values, ok := rule.match(line)
+		if !ok {
+			continue
+		}
+
+		record := &Record{
+			Rule:   i,
+			Tags:   make(map[string]string, len(rule.Tags)),
+			Fields: make(map[string]interface{}, len(rule.Fields)),
+		}
+		for k, v := range rule.Tags {
+			record.Tags[k] = v
+		}
+		for k, v := range rule.Fields {
+			record.Fields[k] = v
+		}
+
+		var errs Errors
+		for j := range rule.Columns {
+			c := &rule.Columns[j]
+			value := values[j]
+			if c.Skip || c.key() == "" || isNull(c, value) {
+				continue
+			}
+			if c.Tag {
+				record.Tags[c.key()] = value
+				continue
+			}
+			v, err := ParseValue(value, c.Type)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %s", c.key(), err))
+				continue
+			}
+			record.Fields[c.key()] = v
+		}
+		if len(errs) > 0 {
+			return record, errs
+		}
+		return record, nil
+	}
+
+	if p.Strict {
+		return nil, fmt.Errorf("unexpected line %q", line)
+	}
+	return nil, nil
+}
+
+// match returns the values of the columns of the line.
+func (r *Rule) match(line string) ([]string, bool) {
+	values := make([]string, len(r.Columns))
+	if r.Regexp != nil {
+		match := r.Regexp.FindStringSubmatch(line)
+		if match == nil {
+			return nil, false
+		}
+		for i, c := range r.Columns {
+			for j, name := range r.Regexp.SubexpNames() {
+				if name != "" && name == c.Name {
+					values[i] = strings.TrimSpace(match[j])
+				}
+			}
+		}
+		return values, true
+	}
+
+	if r.Prefix != "" {
+		if !strings.HasPrefix(line, r.Prefix) {
+			return nil, false
+		}
+		line = line[len(r.Prefix):]
+	}
+
+	var parts []string
+	if r.Separator == "" {
+		parts = strings.Fields(line)
+	} else {
+		parts = strings.Split(line, r.Separator)
+	}
+	if len(parts) != len(r.Columns) {
+		return nil, false
+	}
+	for i, part := range parts {
+		values[i] = strings.TrimSpace(part)
+	}
+	return values, true
+}
+
+func isNull(c *Column, value string) bool {
+	if value == "" {
+		return true
+	}
+	for _, null := range c.Null {
+		if value == null {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseValue parses the value as the type.
+func ParseValue(value string, typ Type) (interface{}, error) {
+	switch typ {
+	case Int:
+		return strconv.ParseInt(value, 10, 64)
+	case Uint:
+		return strconv.ParseUint(value, 10, 64)
+	case Float:
+		return strconv.ParseFloat(value, 64)
+	case Bool:
+		switch strings.ToLower(value) {
+		case "y", "yes", "on":
+			return true, nil
+		case "n", "no", "off":
+			return false, nil
+		}
+		return strconv.ParseBool(value)
+	case Size:
+		return ParseSize(value)
+	default:
+		return value, nil
+	}
+}
+
+var sizeUnits = map[string]float64{
+	"":  1,
+	"K": 1 << 10,
+	"M": 1 << 20,
+	"G": 1 << 30,
+	"T": 1 << 40,
+	"P": 1 << 50,
+	"E": 1 << 60,
+}
+
+// ParseSize parses a size with an optional unit into bytes, the units are
+// powers of 1024: "1.5GB", "1.5 GiB" and "1.5G" are 1610612736.
+func ParseSize(value string) (int64, error) {
+	s := strings.TrimSpace(value)
+	i := strings.IndexFunc(s, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.' && r != '-' && r != '+'
+	})
+	number, unit := s, ""
+	if i >= 0 {
+		number, unit = s[:i], strings.TrimSpace(s[i:])
+	}
+
+	unit = strings.ToUpper(unit)
+	if len(unit) > 2 && strings.HasSuffix(unit, "IB") {
+		unit = unit[:len(unit)-2]
+	} else {
+		unit = strings.TrimSuffix(unit, "B")
+	}
+	multiplier, ok := sizeUnits[unit]
+	if !ok {
+		return 0, fmt.Errorf("invalid size %q", value)
+	}
+
+	f, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size %q", value)
+	}
+	return int64(f * multiplier), nil
+}
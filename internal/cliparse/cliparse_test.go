@@ -0,0 +1,120 @@
+package cliparse
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const volumeStatus = `Status of volume: gv0
+Gluster process                             TCP Port  RDMA Port  Online  Pid
+------------------------------------------------------------------------------
+Brick server1:/data/brick1/gv0              49152     0          Y       1234
+Brick server2:/data/brick1/gv0              N/A       N/A        N       N/A
+Brick server3:/data/brick1/gv0              49152     0          Y       x12
+`
+
+func TestParseRegexp(t *testing.T) {
+	p := &Parser{
+		Rules: []Rule{
+			{
+				Regexp: regexp.MustCompile(`^Status of volume: (?P<volume>\S+)$`),
+				Columns: []Column{
+					{Name: "volume", Tag: true},
+				},
+			},
+			{
+				Regexp: regexp.MustCompile(`^Brick (?P<brick>\S+)\s+(?P<port>\S+)\s+(?P<rdma>\S+)\s+(?P<online>[YN])\s+(?P<pid>\S+)$`),
+				Columns: []Column{
+					{Name: "brick", Tag: true},
+					{Name: "port", Type: Int, Null: []string{"N/A"}},
+					{Name: "rdma", Skip: true},
+					{Name: "online", Type: Bool},
+					{Name: "pid", Type: Int, Null: []string{"N/A"}},
+				},
+				Tags: map[string]string{"kind": "brick"},
+			},
+		},
+	}
+
+	records, err := p.Parse(strings.NewReader(volumeStatus))
+	require.Error(t, err)
+	require.Len(t, err.(Errors), 1)
+	require.Contains(t, err.Error(), "line 6: pid:")
+
+	require.Len(t, records, 4)
+	require.Equal(t, Record{Rule: 0, Line: 1, Tags: map[string]string{"volume": "gv0"}, Fields: map[string]interface{}{}}, records[0])
+	require.Equal(t, Record{
+		Rule:   1,
+		Line:   4,
+		Tags:   map[string]string{"brick": "server1:/data/brick1/gv0", "kind": "brick"},
+		Fields: map[string]interface{}{"port": int64(49152), "online": true, "pid": int64(1234)},
+	}, records[1])
+	require.Equal(t, map[string]interface{}{"online": false}, records[2].Fields)
+	// the values of the line that could be parsed are kept
+	require.Equal(t, map[string]interface{}{"port": int64(49152), "online": true}, records[3].Fields)
+}
+
+func TestParseColumns(t *testing.T) {
+	p := &Parser{
+		Strict: true,
+		Rules: []Rule{
+			{
+				Separator: ":",
+				Columns: []Column{
+					{Key: "name", Tag: true},
+					{Key: "used", Type: Size},
+					{Key: "ratio", Type: Float},
+				},
+			},
+		},
+	}
+
+	records, err := p.ParseBytes([]byte("tank:1.5G:0.25\r\n\nbackup:512 KiB:1\nunexpected\n"))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), `line 4: unexpected line "unexpected"`)
+	require.Len(t, records, 2)
+	require.Equal(t, map[string]string{"name": "tank"}, records[0].Tags)
+	require.Equal(t, map[string]interface{}{"used": int64(1610612736), "ratio": 0.25}, records[0].Fields)
+	require.Equal(t, map[string]interface{}{"used": int64(524288), "ratio": float64(1)}, records[1].Fields)
+	require.Equal(t, 3, records[1].Line)
+}
+
+func TestParseSize(t *testing.T) {
+	tests := map[string]int64{
+		"0":        0,
+		"42":       42,
+		"10B":      10,
+		"1.5 GB":   1610612736,
+		"1.5GiB":   1610612736,
+		"2T":       2 << 40,
+		"3 kb":     3072,
+		"-1.0 MiB": -1 << 20,
+	}
+	for value, expected := range tests {
+		size, err := ParseSize(value)
+		require.NoError(t, err, value)
+		require.Equal(t, expected, size, value)
+	}
+
+	for _, value := range []string{"", "GB", "1.5 XB", "1 iB"} {
+		_, err := ParseSize(value)
+		require.Error(t, err, value)
+	}
+}
+
+func TestParseLongLine(t *testing.T) {
+	p := &Parser{
+		MaxLineLength: 16,
+		Rules: []Rule{
+			{Columns: []Column{{Key: "name", Tag: true}, {Key: "value", Type: Int}}},
+		},
+	}
+
+	records, err := p.Parse(strings.NewReader("a 1\n" + strings.Repeat("x", 32) + " 2\nb 3\n"))
+	require.EqualError(t, err, "line 2: longer than 16 bytes")
+	require.Len(t, records, 2)
+	require.Equal(t, 3, records[1].Line)
+}
@@ -0,0 +1,289 @@
+package command
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Config is the configuration of the commands run by a plugin, plugins
+// embed it to share the options for privilege elevation, wrappers, remote
+// execution and the environment.
+type Config struct {
+	// Run commands with sudo, kept for the plugins having this option; same
+	// as elevate = "sudo"
+	UseSudo bool `toml:"use_sudo"`
+	// Program used to run commands as root, "sudo" or "doas"
+	Elevate string `toml:"elevate"`
+	// Command and arguments prepended to the commands, ie ["nice", "-n19"]
+	Wrapper []string `toml:"command_wrapper"`
+
+	// Run commands on a remote host using ssh
+	SSHHost    string   `toml:"ssh_host"`
+	SSHUser    string   `toml:"ssh_user"`
+	SSHPort    int      `toml:"ssh_port"`
+	SSHKey     string   `toml:"ssh_key"`
+	SSHOptions []string `toml:"ssh_options"`
+
+	// Variables set in the environment of the commands, as "KEY=value"
+	Environment []string `toml:"environment"`
+	// Do not inherit the environment of Telegraf
+	ClearEnvironment bool `toml:"clear_environment"`
+}
+
+// Runner runs commands, returning their standard output.  Plugins use the
+// interface to be able to mock the commands in tests.
+type Runner interface {
+	Run(ctx context.Context, name string, args ...string) ([]byte, error)
+}
+
+// RunnerFunc is an adapter to use a function as a Runner, such as a mock of
+// the commands in tests.
+type RunnerFunc func(ctx context.Context, name string, args ...string) ([]byte, error)
+
+// Run calls f(ctx, name, args...).
+func (f RunnerFunc) Run(ctx context.Context, name string, args ...string) ([]byte, error) {
+	return f(ctx, name, args...)
+}
+
+// RunnerSetter is implemented by the plugins running commands with a Runner,
+// so that their commands can be replaced, such as by the replay of a fixture
+// in test mode.
+type RunnerSetter interface {
+	SetRunner(runner Runner)
+}
+
+// Error is the error of a command that failed, holding its standard error.
+type Error struct {
+	Command string
+	Err     error
+	Stderr  []byte
+}
+
+func (e *Error) Error() string {
+	stderr := strings.TrimSpace(string(e.Stderr))
+	if stderr == "" {
+		return fmt.Sprintf("command %q failed: %s", e.Command, e.Err)
+	}
+	return fmt.Sprintf("command %q failed: %s: %s", e.Command, e.Err, stderr)
+}
+
+// ExitStatus returns the exit status of the command of the error, false if
+// the command did not exit.  Errors of replayed commands implement
+// ExitStatus() int.
+func ExitStatus(err error) (int, bool) {
+	if e, ok := err.(*Error); ok {
+		err = e.Err
+	}
+	if e, ok := err.(interface {
+		ExitStatus() int
+	}); ok {
+		return e.ExitStatus(), true
+	}
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		if status, ok := exitErr.Sys().(syscall.WaitStatus); ok {
+			return status.ExitStatus(), true
+		}
+	}
+	return 0, false
+}
+
+type runner struct {
+	prefix   []string
+	ssh      []string
+	env      []string
+	clearEnv bool
+}
+
+// execCommand is used to mock commands in tests.
+var execCommand = exec.Command
+
+// waitDelay is the time the output of a command is read once it exited, the
+// processes it started may keep it open.
+var waitDelay = time.Second
+
+// Runner returns the runner of the configuration.
+func (c *Config) Runner() (Runner, error) {
+	r := &runner{
+		env:      c.Environment,
+		clearEnv: c.ClearEnvironment,
+	}
+
+	for _, kv := range c.Environment {
+		if !strings.Contains(kv, "=") || strings.HasPrefix(kv, "=") {
+			return nil, fmt.Errorf("invalid environment variable %q, expected KEY=value", kv)
+		}
+	}
+
+	elevate := c.Elevate
+	if elevate == "" && c.UseSudo {
+		elevate = "sudo"
+	}
+	switch elevate {
+	case "":
+	case "sudo", "doas":
+		// do not prompt for a password
+		r.prefix = append(r.prefix, elevate, "-n")
+	default:
+		return nil, fmt.Errorf("invalid elevate %q, expected \"sudo\" or \"doas\"", elevate)
+	}
+	r.prefix = append(r.prefix, c.Wrapper...)
+
+	if c.SSHHost == "" {
+		if c.SSHUser != "" || c.SSHPort != 0 || c.SSHKey != "" || len(c.SSHOptions) > 0 {
+			return nil, fmt.Errorf("ssh options require ssh_host")
+		}
+		return r, nil
+	}
+
+	// BatchMode fails instead of prompting for a password or passphrase
+	r.ssh = []string{"ssh", "-o", "BatchMode=yes"}
+	for _, option := range c.SSHOptions {
+		r.ssh = append(r.ssh, "-o", option)
+	}
+	if c.SSHPort != 0 {
+		r.ssh = append(r.ssh, "-p", strconv.Itoa(c.SSHPort))
+	}
+	if c.SSHKey != "" {
+		r.ssh = append(r.ssh, "-i", c.SSHKey)
+	}
+	host := c.SSHHost
+	if c.SSHUser != "" {
+		host = c.SSHUser + "@" + host
+	}
+	r.ssh = append(r.ssh, host, "--")
+	return r, nil
+}
+
+// Run runs the command, killing it and the processes it started when the
+// context is done.  When the
+// command fails the error is an *Error.
+func (r *runner) Run(ctx context.Context, name string, args ...string) ([]byte, error) {
+	argv := make([]string, 0, len(r.prefix)+len(args)+1)
+	argv = append(argv, r.prefix...)
+	argv = append(argv, name)
+	argv = append(argv, args...)
+
+	var env []string
+	if r.ssh != nil {
+		// the environment is set on the remote host, the command is a
+		// single argument run by the shell of the remote user
+		if r.clearEnv || len(r.env) > 0 {
+			remote := []string{"env"}
+			if r.clearEnv {
+				remote = append(remote, "-i")
+			}
+			remote = append(remote, r.env...)
+			argv = append(remote, argv...)
+		}
+		argv = append(r.ssh[:len(r.ssh):len(r.ssh)], quoteCommand(argv))
+		env = os.Environ()
+	} else {
+		// an empty environment must not be nil, a nil Env inherits it
+		env = []string{}
+		if !r.clearEnv {
+			env = os.Environ()
+		}
+		env = append(env, r.env...)
+	}
+
+	cmd := execCommand(argv[0], argv[1:]...)
+	cmd.Env = env
+	stdout, stderr, err := run(ctx, cmd)
+	if err != nil {
+		if ctx.Err() != nil {
+			err = ctx.Err()
+		}
+		return stdout, &Error{
+			Command: strings.Join(argv, " "),
+			Err:     err,
+			Stderr:  stderr,
+		}
+	}
+	return stdout, nil
+}
+
+// run runs the command in its own process group, killing the group when the
+// context is done, and returns its output.  The output is read from pipes
+// of the runner, so that the processes started by the command keeping them
+// open do not block once it exited: the pipes are closed after waitDelay.
+func run(ctx context.Context, cmd *exec.Cmd) ([]byte, []byte, error) {
+	stdoutR, stdoutW, err := os.Pipe()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer stdoutR.Close()
+	stderrR, stderrW, err := os.Pipe()
+	if err != nil {
+		stdoutW.Close()
+		return nil, nil, err
+	}
+	defer stderrR.Close()
+
+	cmd.Stdout = stdoutW
+	cmd.Stderr = stderrW
+	setProcessGroup(cmd)
+	err = cmd.Start()
+	stdoutW.Close()
+	stderrW.Close()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var stdout, stderr bytes.Buffer
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		stdout.ReadFrom(stdoutR)
+	}()
+	go func() {
+		defer wg.Done()
+		stderr.ReadFrom(stderrR)
+	}()
+
+	exited := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			killProcessGroup(cmd)
+		case <-exited:
+		}
+	}()
+	err = cmd.Wait()
+	close(exited)
+
+	read := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(read)
+	}()
+	select {
+	case <-read:
+	case <-time.After(waitDelay):
+		stdoutR.Close()
+		stderrR.Close()
+		<-read
+	}
+	return stdout.Bytes(), stderr.Bytes(), err
+}
+
+// quoteCommand joins the arguments into a POSIX shell command line.
+func quoteCommand(argv []string) string {
+	quoted := make([]string, 0, len(argv))
+	for _, arg := range argv {
+		if arg != "" && strings.Trim(arg, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_./=:,@%+") == "" {
+			quoted = append(quoted, arg)
+			continue
+		}
+		quoted = append(quoted, "'"+strings.Replace(arg, "'", `'"'"'`, -1)+"'")
+	}
+	return strings.Join(quoted, " ")
+}
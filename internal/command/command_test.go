@@ -0,0 +1,164 @@
+package command
+
+import (
+	"context"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// recordCommands replaces execCommand by a command recording the arguments
+// and running true instead.
+func recordCommands() *[]string {
+	var argv []string
+	execCommand = func(name string, args ...string) *exec.Cmd {
+		argv = append([]string{name}, args...)
+		return exec.Command("true")
+	}
+	return &argv
+}
+
+func TestRunnerArguments(t *testing.T) {
+	if _, err := exec.LookPath("true"); err != nil {
+		t.Skip("'true' binary not available on OS, skipping.")
+	}
+	defer func() { execCommand = exec.Command }()
+
+	tests := []struct {
+		name     string
+		config   Config
+		expected []string
+	}{
+		{
+			"plain",
+			Config{},
+			[]string{"gluster", "volume", "list"},
+		},
+		{
+			"use_sudo",
+			Config{UseSudo: true},
+			[]string{"sudo", "-n", "gluster", "volume", "list"},
+		},
+		{
+			"doas with wrapper",
+			Config{Elevate: "doas", Wrapper: []string{"nice", "-n19"}},
+			[]string{"doas", "-n", "nice", "-n19", "gluster", "volume", "list"},
+		},
+		{
+			"ssh",
+			Config{
+				SSHHost:     "storage01",
+				SSHUser:     "telegraf",
+				SSHPort:     2222,
+				SSHKey:      "/etc/telegraf/id_ed25519",
+				Elevate:     "sudo",
+				Environment: []string{"LC_ALL=C"},
+			},
+			[]string{
+				"ssh", "-o", "BatchMode=yes", "-p", "2222", "-i", "/etc/telegraf/id_ed25519",
+				"telegraf@storage01", "--", "env LC_ALL=C sudo -n gluster volume list",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			argv := recordCommands()
+			r, err := tt.config.Runner()
+			require.NoError(t, err)
+			_, err = r.Run(context.Background(), "gluster", "volume", "list")
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, *argv)
+		})
+	}
+}
+
+func TestRunnerOutput(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("'sh' binary not available on OS, skipping.")
+	}
+
+	c := Config{Environment: []string{"GREETING=hello"}, ClearEnvironment: true}
+	r, err := c.Runner()
+	require.NoError(t, err)
+
+	out, err := r.Run(context.Background(), "/bin/sh", "-c", `echo "$GREETING $HOME"`)
+	require.NoError(t, err)
+	require.Equal(t, "hello \n", string(out))
+
+	_, err = r.Run(context.Background(), "/bin/sh", "-c", "echo failed >&2; exit 3")
+	require.Error(t, err)
+	require.Equal(t, []byte("failed\n"), err.(*Error).Stderr)
+	status, ok := ExitStatus(err)
+	require.True(t, ok)
+	require.Equal(t, 3, status)
+}
+
+func TestRunnerContext(t *testing.T) {
+	if _, err := exec.LookPath("sleep"); err != nil {
+		t.Skip("'sleep' binary not available on OS, skipping.")
+	}
+
+	r, err := (&Config{}).Runner()
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err = r.Run(ctx, "sleep", "10")
+	require.Error(t, err)
+	require.Equal(t, context.DeadlineExceeded, err.(*Error).Err)
+	require.True(t, time.Since(start) < 5*time.Second)
+}
+
+func TestRunnerContextChildren(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("'sh' binary not available on OS, skipping.")
+	}
+
+	r, err := (&Config{}).Runner()
+	require.NoError(t, err)
+
+	// the sleep started by sh holds the output of the command
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err = r.Run(ctx, "sh", "-c", "sleep 3; true")
+	require.Error(t, err)
+	require.Equal(t, context.DeadlineExceeded, err.(*Error).Err)
+	require.True(t, time.Since(start) < 2*time.Second, "returned after %s", time.Since(start))
+}
+
+func TestRunnerWaitDelay(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("'sh' binary not available on OS, skipping.")
+	}
+	defer func(delay time.Duration) { waitDelay = delay }(waitDelay)
+	waitDelay = 100 * time.Millisecond
+
+	r, err := (&Config{}).Runner()
+	require.NoError(t, err)
+
+	// the command exits, the sleep left in the background keeps its output
+	start := time.Now()
+	out, err := r.Run(context.Background(), "sh", "-c", "echo done; sleep 3 &")
+	require.NoError(t, err)
+	require.Equal(t, "done\n", string(out))
+	require.True(t, time.Since(start) < 2*time.Second, "returned after %s", time.Since(start))
+}
+
+func TestConfigErrors(t *testing.T) {
+	for _, c := range []Config{
+		{Elevate: "su"},
+		{SSHUser: "telegraf"},
+		{Environment: []string{"LC_ALL"}},
+	} {
+		_, err := c.Runner()
+		require.Error(t, err)
+	}
+}
+
+func TestQuoteCommand(t *testing.T) {
+	require.Equal(t, `echo 'it'"'"'s' '' 'a b' x=1`, quoteCommand([]string{"echo", "it's", "", "a b", "x=1"}))
+}
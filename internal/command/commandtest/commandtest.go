@@ -0,0 +1,159 @@
+// Package commandtest records the commands run by plugins into fixture
+// files and replays them in tests, so that the parsers of command output can
+// be tested against captures of real systems.
+//
+// A fixture is a directory holding commands.json, the list of the recorded
+// commands, and the files of their standard output.  Tests run the plugin
+// with the Runner of the fixture and compare the gathered metrics with the
+// expected metrics of the fixture using AssertGolden:
+//
+//	commandtest.ForEachFixture(t, "testdata", func(t *testing.T, dir string) {
+//		plugin := &BeeGFS{Path: "beegfs-ctl", runner: commandtest.Runner(t, dir)}
+//		var acc testutil.Accumulator
+//		require.NoError(t, plugin.Gather(&acc))
+//		commandtest.AssertGolden(t, dir, acc.Metrics)
+//	})
+//
+// Running the tests with -record runs the real commands and records them into
+// the fixtures, -update writes the gathered metrics as the expected metrics.
+package commandtest
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/influxdata/telegraf/internal/command"
+)
+
+var (
+	record = flag.Bool("record", false, "record the commands of the fixtures")
+	update = flag.Bool("update", false, "update the expected metrics of the fixtures")
+)
+
+const expectedFile = "expected.out"
+
+// Runner returns the runner of the fixture of dir: the recorder of the
+// commands run by a runner of the default configuration with -record,
+// otherwise the replay of the recorded commands.
+func Runner(t *testing.T, dir string) command.Runner {
+	if *record {
+		runner, err := (&command.Config{}).Runner()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return NewRecorder(t, dir, runner)
+	}
+	replay, err := command.NewReplay(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return replay
+}
+
+// Recorder is a runner recording the commands it runs into a fixture, the
+// fixture is written after each command.
+type Recorder struct {
+	dir    string
+	runner command.Runner
+
+	mu          sync.Mutex
+	invocations []*command.Invocation
+}
+
+// NewRecorder returns a recorder of the commands run by runner into the
+// fixture of dir, replacing the recorded commands.
+func NewRecorder(t *testing.T, dir string, runner command.Runner) *Recorder {
+	r := &Recorder{dir: dir, runner: runner}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	outputs, err := filepath.Glob(filepath.Join(dir, "*.stdout"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, output := range outputs {
+		if err := os.Remove(output); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return r
+}
+
+// Run runs the command and records it.
+func (r *Recorder) Run(ctx context.Context, name string, args ...string) ([]byte, error) {
+	stdout, err := r.runner.Run(ctx, name, args...)
+
+	inv := &command.Invocation{Command: append([]string{name}, args...)}
+	if err != nil {
+		cause := err
+		if e, ok := err.(*command.Error); ok {
+			cause = e.Err
+			inv.Stderr = string(e.Stderr)
+		}
+		if status, ok := command.ExitStatus(err); ok {
+			inv.ExitStatus = status
+		} else {
+			inv.Error = cause.Error()
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.invocations = append(r.invocations, inv)
+	if len(stdout) > 0 {
+		inv.Stdout = fmt.Sprintf("%03d.stdout", len(r.invocations))
+		if werr := ioutil.WriteFile(filepath.Join(r.dir, inv.Stdout), stdout, 0644); werr != nil {
+			return stdout, werr
+		}
+	}
+	if werr := r.save(); werr != nil {
+		return stdout, werr
+	}
+	return stdout, err
+}
+
+// save writes the recorded commands, the caller holds the lock.
+func (r *Recorder) save() error {
+	data, err := json.MarshalIndent(r.invocations, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(r.dir, command.CommandsFile), append(data, '\n'), 0644)
+}
+
+// ForEachFixture runs fn as a subtest for each fixture, the subdirectories of
+// root holding a commands.json file.
+func ForEachFixture(t *testing.T, root string, fn func(t *testing.T, dir string)) {
+	entries, err := ioutil.ReadDir(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(root, entry.Name(), command.CommandsFile)); err == nil || *record {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+	if len(names) == 0 {
+		t.Fatalf("no fixture in %s", root)
+	}
+
+	for _, name := range names {
+		dir := filepath.Join(root, name)
+		t.Run(name, func(t *testing.T) {
+			fn(t, dir)
+		})
+	}
+}
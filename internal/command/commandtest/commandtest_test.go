@@ -0,0 +1,172 @@
+package commandtest
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/influxdata/telegraf/internal/command"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+// gatherBricks is a small plugin gathering the disk space of the bricks of
+// the gluster volumes.
+func gatherBricks(runner command.Runner, acc *testutil.Accumulator) {
+	ctx := context.Background()
+	out, err := runner.Run(ctx, "gluster", "volume", "list")
+	if err != nil {
+		acc.AddError(err)
+		return
+	}
+	for _, volume := range strings.Fields(string(out)) {
+		out, err := runner.Run(ctx, "gluster", "volume", "status", volume, "detail")
+		if err != nil {
+			acc.AddError(err)
+			continue
+		}
+		tags := map[string]string{"volume": volume}
+		fields := make(map[string]interface{})
+		for _, line := range strings.Split(string(out), "\n") {
+			parts := strings.SplitN(line, ":", 2)
+			if len(parts) != 2 {
+				continue
+			}
+			key, value := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+			switch key {
+			case "Brick":
+				tags["brick"] = strings.TrimPrefix(value, "Brick ")
+			case "Disk Space Free":
+				fields["disk_free"], _ = strconv.ParseInt(value, 10, 64)
+			case "Total Disk Space":
+				fields["disk_total"], _ = strconv.ParseInt(value, 10, 64)
+			}
+		}
+		acc.AddFields("brick", fields, tags)
+	}
+}
+
+func TestReplay(t *testing.T) {
+	ForEachFixture(t, "testdata", func(t *testing.T, dir string) {
+		var acc testutil.Accumulator
+		gatherBricks(Runner(t, dir), &acc)
+		AssertGolden(t, dir, acc.Metrics)
+
+		require.Len(t, acc.Errors, 1)
+		status, ok := command.ExitStatus(acc.Errors[0])
+		require.True(t, ok)
+		require.Equal(t, 2, status)
+		require.Contains(t, acc.Errors[0].Error(), "Volume gv1 is not started")
+	})
+}
+
+func TestReplayNotRecorded(t *testing.T) {
+	replay, err := command.NewReplay("testdata/replay")
+	require.NoError(t, err)
+	_, err = replay.Run(context.Background(), "gluster", "peer", "status")
+	require.EqualError(t, err, `command "gluster peer status" not recorded in testdata/replay`)
+}
+
+func TestReplayInOrder(t *testing.T) {
+	dir, err := ioutil.TempDir("", "commandtest")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	outputs := []string{"first", "second"}
+	r := NewRecorder(t, dir, runnerFunc(func(name string, args ...string) ([]byte, error) {
+		out := outputs[0]
+		outputs = outputs[1:]
+		return []byte(out), nil
+	}))
+	for i := 0; i < 2; i++ {
+		_, err := r.Run(context.Background(), "uptime")
+		require.NoError(t, err)
+	}
+
+	replay, err := command.NewReplay(dir)
+	require.NoError(t, err)
+	for _, expected := range []string{"first", "second", "second"} {
+		out, err := replay.Run(context.Background(), "uptime")
+		require.NoError(t, err)
+		require.Equal(t, expected, string(out))
+	}
+}
+
+type runnerFunc func(name string, args ...string) ([]byte, error)
+
+func (f runnerFunc) Run(ctx context.Context, name string, args ...string) ([]byte, error) {
+	return f(name, args...)
+}
+
+func TestRecorder(t *testing.T) {
+	dir, err := ioutil.TempDir("", "commandtest")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	// outputs of a previous recording are removed
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "009.stdout"), nil, 0644))
+
+	r := NewRecorder(t, dir, runnerFunc(func(name string, args ...string) ([]byte, error) {
+		switch args[0] {
+		case "list":
+			return []byte("gv0\n"), nil
+		case "missing":
+			return nil, &command.Error{Command: "gluster missing", Err: errors.New("executable file not found")}
+		}
+		return nil, &command.Error{Command: "gluster", Err: command.ExitError(1), Stderr: []byte("unknown command")}
+	}))
+	ctx := context.Background()
+	out, err := r.Run(ctx, "gluster", "list")
+	require.NoError(t, err)
+	require.Equal(t, "gv0\n", string(out))
+	_, err = r.Run(ctx, "gluster", "missing")
+	require.Error(t, err)
+	_, err = r.Run(ctx, "gluster", "bogus", "a b")
+	require.Error(t, err)
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.stdout"))
+	require.NoError(t, err)
+	require.Equal(t, []string{filepath.Join(dir, "001.stdout")}, files)
+
+	replay, err := command.NewReplay(dir)
+	require.NoError(t, err)
+	out, err = replay.Run(ctx, "gluster", "list")
+	require.NoError(t, err)
+	require.Equal(t, "gv0\n", string(out))
+
+	_, err = replay.Run(ctx, "gluster", "missing")
+	require.EqualError(t, err, `command "gluster missing" failed: executable file not found`)
+	_, ok := command.ExitStatus(err)
+	require.False(t, ok)
+
+	_, err = replay.Run(ctx, "gluster", "bogus", "a b")
+	status, ok := command.ExitStatus(err)
+	require.True(t, ok)
+	require.Equal(t, 1, status)
+	require.Equal(t, "unknown command", string(err.(*command.Error).Stderr))
+}
+
+func TestFormatMetrics(t *testing.T) {
+	metrics := []*testutil.Metric{
+		{
+			Measurement: "zfs",
+			Tags:        map[string]string{"pool": "tank"},
+			Fields:      map[string]interface{}{"size": uint64(4096), "health": "ONLINE"},
+		},
+		{
+			Measurement: "brick",
+			Tags:        map[string]string{"volume": "gv0", "brick": "b1"},
+			Fields:      map[string]interface{}{"ratio": 0.5, "online": true},
+		},
+	}
+	out, err := FormatMetrics(metrics)
+	require.NoError(t, err)
+	require.Equal(t,
+		"brick,brick=b1,volume=gv0 online=true,ratio=0.5\n"+
+			"zfs,pool=tank health=\"ONLINE\",size=4096u\n",
+		out)
+}
@@ -0,0 +1,90 @@
+package commandtest
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/plugins/serializers/influx"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+// AssertGolden compares the metrics with the expected metrics of the
+// fixture of dir, in expected.out.  The expected metrics are line protocol
+// without timestamps, one metric per line in any order; they are written
+// from the metrics with -update or -record.
+func AssertGolden(t *testing.T, dir string, metrics []*testutil.Metric) {
+	actual, err := FormatMetrics(metrics)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(dir, expectedFile)
+	if *update || *record {
+		if err := ioutil.WriteFile(path, []byte(actual), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := sortLines(string(data))
+	if expected != actual {
+		t.Errorf("metrics of %s differ from %s\nexpected:\n%s\nactual:\n%s",
+			dir, expectedFile, expected, actual)
+	}
+}
+
+// FormatMetrics returns the metrics as sorted lines of line protocol without
+// timestamps, with tags and fields sorted by key.
+func FormatMetrics(metrics []*testutil.Metric) (string, error) {
+	serializer := influx.NewSerializer()
+	serializer.SetFieldSortOrder(influx.SortFields)
+	serializer.SetTagSortOrder(influx.SortTags)
+	serializer.SetFieldTypeSupport(influx.UintSupport)
+
+	var lines []string
+	for _, m := range metrics {
+		tm, err := metric.New(m.Measurement, m.Tags, m.Fields, m.Time)
+		if err != nil {
+			return "", err
+		}
+		line, err := serializer.Serialize(tm)
+		if err != nil {
+			return "", fmt.Errorf("%s: %s", m.Measurement, err)
+		}
+		// strip the timestamp, it depends on the time of the test
+		text := strings.TrimSuffix(string(line), "\n")
+		if i := strings.LastIndexByte(text, ' '); i >= 0 {
+			text = text[:i]
+		}
+		lines = append(lines, text)
+	}
+	sort.Strings(lines)
+	return joinLines(lines), nil
+}
+
+func sortLines(text string) string {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	sort.Strings(lines)
+	return joinLines(lines)
+}
+
+func joinLines(lines []string) string {
+	if len(lines) == 0 {
+		return ""
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
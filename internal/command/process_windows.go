@@ -0,0 +1,16 @@
+// +build windows
+
+package command
+
+import (
+	"os/exec"
+)
+
+func setProcessGroup(cmd *exec.Cmd) {
+}
+
+// killProcessGroup kills the command, the processes it started keep running
+// and their output is closed after waitDelay.
+func killProcessGroup(cmd *exec.Cmd) {
+	cmd.Process.Kill()
+}
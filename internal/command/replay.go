@@ -0,0 +1,115 @@
+package command
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// CommandsFile is the file of the recorded commands of a fixture.
+const CommandsFile = "commands.json"
+
+// Invocation is a recorded command.
+type Invocation struct {
+	// Command and arguments
+	Command []string `json:"command"`
+	// File of the standard output, relative to the fixture
+	Stdout string `json:"stdout"`
+	// Standard error
+	Stderr string `json:"stderr,omitempty"`
+	// Exit status, the command failed if not 0
+	ExitStatus int `json:"exit_status,omitempty"`
+	// Error of a command that did not exit, such as a missing program
+	Error string `json:"error,omitempty"`
+}
+
+// Replay is a runner replaying the commands recorded in a fixture.  The
+// outputs of a command run several times are replayed in order, the last one
+// is replayed once they are all used.
+type Replay struct {
+	dir string
+
+	mu          sync.Mutex
+	invocations map[string][]*Invocation
+}
+
+// NewReplay returns the replay of the fixture of dir.
+func NewReplay(dir string) (*Replay, error) {
+	data, err := ioutil.ReadFile(filepath.Join(dir, CommandsFile))
+	if err != nil {
+		return nil, err
+	}
+	var invocations []*Invocation
+	if err := json.Unmarshal(data, &invocations); err != nil {
+		return nil, fmt.Errorf("%s: %s", filepath.Join(dir, CommandsFile), err)
+	}
+
+	r := &Replay{dir: dir, invocations: make(map[string][]*Invocation)}
+	for _, inv := range invocations {
+		key := commandLine(inv.Command)
+		r.invocations[key] = append(r.invocations[key], inv)
+	}
+	return r, nil
+}
+
+// Run returns the recorded output of the command, an error if it was not
+// recorded.
+func (r *Replay) Run(ctx context.Context, name string, args ...string) ([]byte, error) {
+	key := commandLine(append([]string{name}, args...))
+	r.mu.Lock()
+	invocations := r.invocations[key]
+	if len(invocations) == 0 {
+		r.mu.Unlock()
+		return nil, fmt.Errorf("command %q not recorded in %s", key, r.dir)
+	}
+	inv := invocations[0]
+	if len(invocations) > 1 {
+		r.invocations[key] = invocations[1:]
+	}
+	r.mu.Unlock()
+
+	var stdout []byte
+	if inv.Stdout != "" {
+		var err error
+		stdout, err = ioutil.ReadFile(filepath.Join(r.dir, inv.Stdout))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	switch {
+	case inv.Error != "":
+		return stdout, &Error{Command: key, Err: fmt.Errorf("%s", inv.Error), Stderr: []byte(inv.Stderr)}
+	case inv.ExitStatus != 0:
+		return stdout, &Error{Command: key, Err: ExitError(inv.ExitStatus), Stderr: []byte(inv.Stderr)}
+	}
+	return stdout, nil
+}
+
+// ExitError is the error of a replayed command exiting with a status.
+type ExitError int
+
+func (e ExitError) Error() string {
+	return fmt.Sprintf("exit status %d", int(e))
+}
+
+func (e ExitError) ExitStatus() int {
+	return int(e)
+}
+
+// commandLine joins the command and its arguments, quoting the arguments
+// with spaces.
+func commandLine(argv []string) string {
+	quoted := make([]string, len(argv))
+	for i, arg := range argv {
+		if arg == "" || strings.ContainsAny(arg, " \t\n\"") {
+			arg = fmt.Sprintf("%q", arg)
+		}
+		quoted[i] = arg
+	}
+	return strings.Join(quoted, " ")
+}
@@ -19,7 +19,10 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/globpath"
+	"github.com/influxdata/telegraf/internal/limiter"
 	"github.com/influxdata/telegraf/internal/models"
+	"github.com/influxdata/telegraf/internal/retry"
 	"github.com/influxdata/telegraf/plugins/aggregators"
 	"github.com/influxdata/telegraf/plugins/inputs"
 	"github.com/influxdata/telegraf/plugins/outputs"
@@ -39,8 +42,15 @@ var (
 	// Default output plugins
 	outputDefaults = []string{"influxdb"}
 
-	// envVarRe is a regex to find environment variables in the config file
-	envVarRe = regexp.MustCompile(`\$\w+`)
+	// Default backoff of the output writes after a failure
+	defaultRetryInitialInterval = time.Second
+	defaultRetryMaxInterval     = time.Minute
+	retryJitter                 = 0.5
+
+	// envVarRe is a regex to find environment variables in the config file:
+	// $VAR, ${VAR}, ${VAR:-default}, ${VAR-default}, ${VAR:?error},
+	// ${VAR?error}
+	envVarRe = regexp.MustCompile(`\$\{(\w+)(?:(:?[-?])([^}]*))?\}|\$(\w+)`)
 
 	envVarEscaper = strings.NewReplacer(
 		`"`, `\"`,
@@ -62,6 +72,17 @@ type Config struct {
 	Aggregators []*models.RunningAggregator
 	// Processors have a slice wrapper type because they need to be sorted
 	Processors models.RunningProcessors
+	// RateLimits are the rate limiters shared by name between plugins
+	RateLimits map[string]*limiter.TokenBucket
+
+	// files whose includes are being loaded, to detect cycles
+	including []string
+	// files loaded by includes
+	included map[string]bool
+
+	// rate limiters defined and referenced by the validated files
+	rateLimitNames  map[string]bool
+	rateLimiterRefs []rateLimiterRef
 }
 
 func NewConfig() *Config {
@@ -77,6 +98,7 @@ func NewConfig() *Config {
 		Inputs:        make([]*models.RunningInput, 0),
 		Outputs:       make([]*models.RunningOutput, 0),
 		Processors:    make([]*models.RunningProcessor, 0),
+		RateLimits:    make(map[string]*limiter.TokenBucket),
 		InputFilters:  make([]string, 0),
 		OutputFilters: make([]string, 0),
 	}
@@ -145,6 +167,12 @@ type AgentConfig struct {
 	Quiet        bool
 	Hostname     string
 	OmitHostname bool
+
+	// HTTPProxy is the proxy of the HTTP plugins without their own proxy,
+	// the proxy environment variables are used when empty.
+	HTTPProxy string `toml:"http_proxy"`
+	// NoProxy lists the hosts reached without HTTPProxy.
+	NoProxy []string `toml:"no_proxy"`
 }
 
 // Inputs returns a list of strings of the configured inputs.
@@ -258,6 +286,13 @@ var header = `# Telegraf Configuration
   ## If set to true, do no set the "host" tag in the telegraf agent.
   omit_hostname = false
 
+  ## Proxy of the plugins with an http_proxy option not set, supports http,
+  ## https and socks5 URLs. If empty, use the HTTP_PROXY, HTTPS_PROXY and
+  ## NO_PROXY environment variables.
+  # http_proxy = "http://proxy.example.com:3128"
+  ## Hosts, domains and networks reached without the proxy.
+  # no_proxy = ["localhost", ".example.com", "10.0.0.0/8"]
+
 
 ###############################################################################
 #                            OUTPUT PLUGINS                                   #
@@ -417,8 +452,7 @@ func printFilteredInputs(inputFilters []string, commented bool) {
 		creator := inputs.Inputs[pname]
 		input := creator()
 
-		switch p := input.(type) {
-		case telegraf.ServiceInput:
+		if p, ok := models.ServiceInput(input); ok {
 			servInputs[pname] = p
 			servInputNames = append(servInputNames, pname)
 			continue
@@ -514,6 +548,22 @@ func PrintOutputConfig(name string) error {
 }
 
 func (c *Config) LoadDirectory(path string) error {
+	files, err := directoryFiles(path)
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		if err := c.LoadConfig(file); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// directoryFiles returns the *.conf files of the directory and its
+// subdirectories.
+func directoryFiles(path string) ([]string, error) {
+	var files []string
 	walkfn := func(thispath string, info os.FileInfo, _ error) error {
 		if info == nil {
 			log.Printf("W! Telegraf is not permitted to read %s", thispath)
@@ -532,13 +582,11 @@ func (c *Config) LoadDirectory(path string) error {
 		if len(name) < 6 || name[len(name)-5:] != ".conf" {
 			return nil
 		}
-		err := c.LoadConfig(thispath)
-		if err != nil {
-			return err
-		}
+		files = append(files, thispath)
 		return nil
 	}
-	return filepath.Walk(path, walkfn)
+	err := filepath.Walk(path, walkfn)
+	return files, err
 }
 
 // Try to find a default config file at these locations (in order):
@@ -578,6 +626,11 @@ func (c *Config) LoadConfig(path string) error {
 		return fmt.Errorf("Error parsing %s, %s", path, err)
 	}
 
+	includes, err := parseIncludes(tbl)
+	if err != nil {
+		return fmt.Errorf("Error parsing %s, %s", path, err)
+	}
+
 	// Parse tags tables first:
 	for _, tableName := range []string{"tags", "global_tags"} {
 		if val, ok := tbl.Fields[tableName]; ok {
@@ -604,6 +657,17 @@ func (c *Config) LoadConfig(path string) error {
 		}
 	}
 
+	// Parse rate limits table, before the plugins using them:
+	if val, ok := tbl.Fields["rate_limits"]; ok {
+		subTable, ok := val.(*ast.Table)
+		if !ok {
+			return fmt.Errorf("%s: invalid configuration", path)
+		}
+		if err = c.addRateLimits(subTable); err != nil {
+			return fmt.Errorf("Error parsing %s, %s", path, err)
+		}
+	}
+
 	// Parse all the rest of the plugins:
 	for name, val := range tbl.Fields {
 		subTable, ok := val.(*ast.Table)
@@ -612,7 +676,7 @@ func (c *Config) LoadConfig(path string) error {
 		}
 
 		switch name {
-		case "agent", "global_tags", "tags":
+		case "agent", "global_tags", "tags", "rate_limits":
 		case "outputs":
 			for pluginName, pluginVal := range subTable.Fields {
 				switch pluginSubTable := pluginVal.(type) {
@@ -691,9 +755,117 @@ func (c *Config) LoadConfig(path string) error {
 	if len(c.Processors) > 1 {
 		sort.Sort(c.Processors)
 	}
+
+	return c.loadIncludes(path, includes)
+}
+
+// parseIncludes returns the patterns of the includes directive, and removes
+// it from the table.
+func parseIncludes(tbl *ast.Table) ([]string, error) {
+	node, ok := tbl.Fields["includes"]
+	if !ok {
+		return nil, nil
+	}
+	delete(tbl.Fields, "includes")
+
+	var includes []string
+	if kv, ok := node.(*ast.KeyValue); ok {
+		if ary, ok := kv.Value.(*ast.Array); ok {
+			for _, elem := range ary.Value {
+				if str, ok := elem.(*ast.String); ok {
+					includes = append(includes, str.Value)
+					continue
+				}
+				return nil, fmt.Errorf("includes must be a list of strings")
+			}
+			return includes, nil
+		}
+	}
+	return nil, fmt.Errorf("includes must be a list of strings")
+}
+
+// loadIncludes loads the files matching the include patterns of the file,
+// relative patterns are relative to its directory.  The files matching a
+// pattern are loaded in sorted order, each file is loaded once.
+func (c *Config) loadIncludes(path string, patterns []string) error {
+	if len(patterns) == 0 {
+		return nil
+	}
+
+	c.including = append(c.including, absPath(path))
+	defer func() { c.including = c.including[:len(c.including)-1] }()
+	if c.included == nil {
+		c.included = make(map[string]bool)
+	}
+
+	for _, pattern := range patterns {
+		files, err := includeFiles(path, pattern)
+		if err != nil {
+			return fmt.Errorf("Error parsing %s, %s", path, err)
+		}
+
+		for _, file := range files {
+			if cycle := c.includeCycle(file); cycle != "" {
+				return fmt.Errorf("Error parsing %s, include cycle %s", path, cycle)
+			}
+			if c.included[file] {
+				continue
+			}
+			c.included[file] = true
+
+			if err := c.LoadConfig(file); err != nil {
+				return err
+			}
+		}
+	}
 	return nil
 }
 
+// includeFiles returns the files matching the include pattern of the file in
+// sorted order.
+func includeFiles(path, pattern string) ([]string, error) {
+	if !filepath.IsAbs(pattern) {
+		pattern = filepath.Join(filepath.Dir(absPath(path)), pattern)
+	}
+	g, err := globpath.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid include %q, %s", pattern, err)
+	}
+
+	var files []string
+	for file, info := range g.Match() {
+		if !info.IsDir() {
+			files = append(files, file)
+		}
+	}
+	if len(files) == 0 {
+		log.Printf("W! No files match the include %q of %s", pattern, path)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// includeCycle returns the cycle of files including the file, empty if the
+// file is not being included.
+func (c *Config) includeCycle(file string) string {
+	for i, f := range c.including {
+		if f == file {
+			cycle := append(c.including[i:len(c.including):len(c.including)], file)
+			return strings.Join(cycle, " -> ")
+		}
+	}
+	return ""
+}
+
+// absPath returns the absolute path, or the path itself if it can not be
+// made absolute.
+func absPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
+
 // trimBOM trims the Byte-Order-Marks from the beginning of the file.
 // this is for Windows compatibility only.
 // see https://github.com/influxdata/telegraf/issues/1378
@@ -717,18 +889,71 @@ func parseFile(fpath string) (*ast.Table, error) {
 	// ugh windows why
 	contents = trimBOM(contents)
 
-	env_vars := envVarRe.FindAll(contents, -1)
-	for _, env_var := range env_vars {
-		env_val, ok := os.LookupEnv(strings.TrimPrefix(string(env_var), "$"))
-		if ok {
-			env_val = escapeEnv(env_val)
-			contents = bytes.Replace(contents, env_var, []byte(env_val), 1)
-		}
+	contents, err = substituteEnvironment(contents)
+	if err != nil {
+		return nil, err
 	}
 
 	return toml.Parse(contents)
 }
 
+// substituteEnvironment replaces the environment variables of the contents.
+// Unset $VAR variables are left as is, unset ${VAR} variables are replaced
+// by their default or an empty string, ${VAR:?error} variables must be set.
+// The forms with a colon also use the default, or fail, for empty values.
+// Variables in comment lines are not replaced.  There is no escape of the
+// dollar sign, "$$" is kept for the values such as passwords containing it.
+func substituteEnvironment(contents []byte) ([]byte, error) {
+	var out bytes.Buffer
+	last := 0
+	for _, loc := range envVarRe.FindAllSubmatchIndex(contents, -1) {
+		out.Write(contents[last:loc[0]])
+		last = loc[1]
+
+		// variables of comment lines are left as is
+		lineStart := bytes.LastIndexByte(contents[:loc[0]], '\n') + 1
+		if bytes.HasPrefix(bytes.TrimLeft(contents[lineStart:loc[0]], " \t"), []byte("#")) {
+			out.Write(contents[loc[0]:loc[1]])
+			continue
+		}
+
+		group := func(i int) string {
+			if loc[2*i] < 0 {
+				return ""
+			}
+			return string(contents[loc[2*i]:loc[2*i+1]])
+		}
+
+		switch {
+		case loc[8] >= 0:
+			// $VAR
+			if value, ok := os.LookupEnv(group(4)); ok {
+				out.WriteString(escapeEnv(value))
+			} else {
+				out.WriteString(group(0))
+			}
+		default:
+			name, op, arg := group(1), group(2), group(3)
+			value, ok := os.LookupEnv(name)
+			unset := !ok || (strings.HasPrefix(op, ":") && value == "")
+			switch {
+			case !unset:
+				out.WriteString(escapeEnv(value))
+			case strings.HasSuffix(op, "?"):
+				line := bytes.Count(contents[:loc[0]], []byte("\n")) + 1
+				if arg == "" {
+					arg = "not set"
+				}
+				return nil, fmt.Errorf("line %d: environment variable %s: %s", line, name, arg)
+			case strings.HasSuffix(op, "-"):
+				out.WriteString(escapeEnv(arg))
+			}
+		}
+	}
+	out.Write(contents[last:])
+	return out.Bytes(), nil
+}
+
 func (c *Config) addAggregator(name string, table *ast.Table) error {
 	creator, ok := aggregators.Aggregators[name]
 	if !ok {
@@ -741,10 +966,23 @@ func (c *Config) addAggregator(name string, table *ast.Table) error {
 		return err
 	}
 
+	if err := resolveSecretFiles(table, aggregator); err != nil {
+		return err
+	}
+
 	if err := toml.UnmarshalTable(table, aggregator); err != nil {
 		return err
 	}
 
+	// aggregators checking their options implement Init
+	if a, ok := aggregator.(interface {
+		Init() error
+	}); ok {
+		if err := a.Init(); err != nil {
+			return fmt.Errorf("Error initializing aggregator %s: %s", name, err)
+		}
+	}
+
 	c.Aggregators = append(c.Aggregators, models.NewRunningAggregator(aggregator, conf))
 	return nil
 }
@@ -761,6 +999,10 @@ func (c *Config) addProcessor(name string, table *ast.Table) error {
 		return err
 	}
 
+	if err := resolveSecretFiles(table, processor); err != nil {
+		return err
+	}
+
 	if err := toml.UnmarshalTable(table, processor); err != nil {
 		return err
 	}
@@ -775,6 +1017,48 @@ func (c *Config) addProcessor(name string, table *ast.Table) error {
 	return nil
 }
 
+// CheckRateLimiters checks that the rate limiters referenced by the inputs
+// are defined, once all the configuration files are loaded.
+func (c *Config) CheckRateLimiters() error {
+	for _, input := range c.Inputs {
+		p, ok := input.Input.(interface {
+			RateLimiterName() string
+		})
+		if !ok || p.RateLimiterName() == "" {
+			continue
+		}
+		if _, ok := c.RateLimits[p.RateLimiterName()]; !ok {
+			return fmt.Errorf("%s: undefined rate limiter %q", input.Name(), p.RateLimiterName())
+		}
+	}
+	return nil
+}
+
+// addRateLimits adds the rate limiters of the [rate_limits] table, one
+// subtable per rate limiter name.
+func (c *Config) addRateLimits(table *ast.Table) error {
+	for name, val := range table.Fields {
+		subTable, ok := val.(*ast.Table)
+		if !ok {
+			return fmt.Errorf("Unsupported config format: rate_limits.%s", name)
+		}
+		if _, ok := c.RateLimits[name]; ok {
+			return fmt.Errorf("Duplicate rate limit: %s", name)
+		}
+
+		rateLimit := &limiter.RateLimit{}
+		if err := toml.UnmarshalTable(subTable, rateLimit); err != nil {
+			return err
+		}
+		bucket, err := rateLimit.TokenBucket()
+		if err != nil {
+			return fmt.Errorf("rate_limits.%s: %s", name, err)
+		}
+		c.RateLimits[name] = bucket
+	}
+	return nil
+}
+
 func (c *Config) addOutput(name string, table *ast.Table) error {
 	if len(c.OutputFilters) > 0 && !sliceContains(name, c.OutputFilters) {
 		return nil
@@ -801,6 +1085,10 @@ func (c *Config) addOutput(name string, table *ast.Table) error {
 		return err
 	}
 
+	if err := resolveSecretFiles(table, output); err != nil {
+		return err
+	}
+
 	if err := toml.UnmarshalTable(table, output); err != nil {
 		return err
 	}
@@ -842,6 +1130,10 @@ func (c *Config) addInput(name string, table *ast.Table) error {
 		return err
 	}
 
+	if err := resolveSecretFiles(table, input); err != nil {
+		return err
+	}
+
 	if err := toml.UnmarshalTable(table, input); err != nil {
 		return err
 	}
@@ -895,6 +1187,21 @@ func buildAggregator(name string, tbl *ast.Table) (*models.AggregatorConfig, err
 		}
 	}
 
+	var hasSlide bool
+	if node, ok := tbl.Fields["slide"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				dur, err := time.ParseDuration(str.Value)
+				if err != nil {
+					return nil, err
+				}
+
+				conf.Slide = dur
+				hasSlide = true
+			}
+		}
+	}
+
 	if node, ok := tbl.Fields["drop_original"]; ok {
 		if kv, ok := node.(*ast.KeyValue); ok {
 			if b, ok := kv.Value.(*ast.Boolean); ok {
@@ -942,6 +1249,7 @@ func buildAggregator(name string, tbl *ast.Table) (*models.AggregatorConfig, err
 
 	delete(tbl.Fields, "period")
 	delete(tbl.Fields, "delay")
+	delete(tbl.Fields, "slide")
 	delete(tbl.Fields, "drop_original")
 	delete(tbl.Fields, "name_prefix")
 	delete(tbl.Fields, "name_suffix")
@@ -952,6 +1260,10 @@ func buildAggregator(name string, tbl *ast.Table) (*models.AggregatorConfig, err
 	if err != nil {
 		return conf, err
 	}
+	if hasSlide && (conf.Slide <= 0 || conf.Slide >= conf.Period) {
+		return conf, fmt.Errorf("slide %s must be positive and less than the period %s",
+			conf.Slide, conf.Period)
+	}
 	return conf, nil
 }
 
@@ -1320,6 +1632,98 @@ func buildParser(name string, tbl *ast.Table) (parsers.Parser, error) {
 		}
 	}
 
+	for key, dst := range map[string]*string{
+		"json_v2_object_path":           &c.JSONV2ObjectPath,
+		"json_v2_measurement_path":      &c.JSONV2MeasurementPath,
+		"json_v2_timestamp_path":        &c.JSONV2TimestampPath,
+		"json_v2_timestamp_format":      &c.JSONV2TimestampFormat,
+		"xml_metric_selection":          &c.XMLMetricSelection,
+		"xml_metric_name":               &c.XMLMetricName,
+		"xml_timestamp":                 &c.XMLTimestamp,
+		"xml_timestamp_format":          &c.XMLTimestampFormat,
+		"xml_field_selection":           &c.XMLFieldSelection,
+		"protobuf_message_type":         &c.ProtobufMessageType,
+		"protobuf_timestamp_field":      &c.ProtobufTimestampField,
+		"avro_schema_registry":          &c.AvroSchemaRegistry,
+		"avro_schema_registry_username": &c.AvroSchemaRegistryUsername,
+		"avro_schema_registry_password": &c.AvroSchemaRegistryPassword,
+		"avro_schema":                   &c.AvroSchema,
+		"avro_measurement":              &c.AvroMeasurement,
+		"avro_timestamp":                &c.AvroTimestamp,
+		"avro_timestamp_format":         &c.AvroTimestampFormat,
+		"avro_field_separator":          &c.AvroFieldSeparator,
+		"csv_delimiter":                 &c.CSVDelimiter,
+		"csv_comment":                   &c.CSVComment,
+		"csv_measurement_column":        &c.CSVMeasurementColumn,
+		"csv_timestamp_column":          &c.CSVTimestampColumn,
+		"csv_timestamp_format":          &c.CSVTimestampFormat,
+		"syslog_format":                 &c.SyslogFormat,
+		"syslog_timezone":               &c.SyslogTimezone,
+	} {
+		if node, ok := tbl.Fields[key]; ok {
+			if kv, ok := node.(*ast.KeyValue); ok {
+				if str, ok := kv.Value.(*ast.String); ok {
+					*dst = str.Value
+				}
+			}
+		}
+	}
+	for key, dst := range map[string]*int{
+		"csv_header_row_count": &c.CSVHeaderRowCount,
+		"csv_skip_rows":        &c.CSVSkipRows,
+		"csv_skip_columns":     &c.CSVSkipColumns,
+		"syslog_year":          &c.SyslogYear,
+	} {
+		if node, ok := tbl.Fields[key]; ok {
+			if kv, ok := node.(*ast.KeyValue); ok {
+				if integer, ok := kv.Value.(*ast.Integer); ok {
+					v, err := integer.Int()
+					if err != nil {
+						return nil, err
+					}
+					*dst = int(v)
+				}
+			}
+		}
+	}
+	if node, ok := tbl.Fields["csv_trim_space"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if b, ok := kv.Value.(*ast.Boolean); ok {
+				var err error
+				c.CSVTrimSpace, err = b.Boolean()
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	if node, ok := tbl.Fields["openmetrics_exemplars"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if b, ok := kv.Value.(*ast.Boolean); ok {
+				var err error
+				c.OpenMetricsExemplars, err = b.Boolean()
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	c.JSONV2Tags = getStringTable(tbl, "json_v2_tags")
+	c.JSONV2Fields = getStringTable(tbl, "json_v2_fields")
+	c.JSONV2FieldTypes = getStringTable(tbl, "json_v2_field_types")
+	c.XMLTags = getStringTable(tbl, "xml_tags")
+	c.XMLFields = getStringTable(tbl, "xml_fields")
+	c.XMLFieldsInt = getStringTable(tbl, "xml_fields_int")
+	c.ProtobufDescriptorFiles = getStringArray(tbl, "protobuf_descriptor_files")
+	c.ProtobufImportPaths = getStringArray(tbl, "protobuf_import_paths")
+	c.ProtobufTagKeys = getStringArray(tbl, "protobuf_tag_keys")
+	c.AvroTags = getStringArray(tbl, "avro_tags")
+	c.AvroFields = getStringArray(tbl, "avro_fields")
+	c.CSVColumnNames = getStringArray(tbl, "csv_column_names")
+	c.CSVTagColumns = getStringArray(tbl, "csv_tag_columns")
+	c.CSVColumnTypes = getStringTable(tbl, "csv_column_types")
+	c.CSVSkipIf = getStringTable(tbl, "csv_skip_if")
+
 	c.MetricName = name
 
 	delete(tbl.Fields, "data_format")
@@ -1335,10 +1739,91 @@ func buildParser(name string, tbl *ast.Table) (parsers.Parser, error) {
 	delete(tbl.Fields, "dropwizard_time_format")
 	delete(tbl.Fields, "dropwizard_tags_path")
 	delete(tbl.Fields, "dropwizard_tag_paths")
+	delete(tbl.Fields, "json_v2_object_path")
+	delete(tbl.Fields, "json_v2_measurement_path")
+	delete(tbl.Fields, "json_v2_timestamp_path")
+	delete(tbl.Fields, "json_v2_timestamp_format")
+	delete(tbl.Fields, "json_v2_tags")
+	delete(tbl.Fields, "json_v2_fields")
+	delete(tbl.Fields, "json_v2_field_types")
+	delete(tbl.Fields, "xml_metric_selection")
+	delete(tbl.Fields, "xml_metric_name")
+	delete(tbl.Fields, "xml_timestamp")
+	delete(tbl.Fields, "xml_timestamp_format")
+	delete(tbl.Fields, "xml_field_selection")
+	delete(tbl.Fields, "xml_tags")
+	delete(tbl.Fields, "xml_fields")
+	delete(tbl.Fields, "xml_fields_int")
+	delete(tbl.Fields, "protobuf_descriptor_files")
+	delete(tbl.Fields, "protobuf_import_paths")
+	delete(tbl.Fields, "protobuf_message_type")
+	delete(tbl.Fields, "protobuf_tag_keys")
+	delete(tbl.Fields, "protobuf_timestamp_field")
+	delete(tbl.Fields, "avro_schema_registry")
+	delete(tbl.Fields, "avro_schema_registry_username")
+	delete(tbl.Fields, "avro_schema_registry_password")
+	delete(tbl.Fields, "avro_schema")
+	delete(tbl.Fields, "avro_measurement")
+	delete(tbl.Fields, "avro_tags")
+	delete(tbl.Fields, "avro_fields")
+	delete(tbl.Fields, "avro_timestamp")
+	delete(tbl.Fields, "avro_timestamp_format")
+	delete(tbl.Fields, "avro_field_separator")
+	delete(tbl.Fields, "csv_header_row_count")
+	delete(tbl.Fields, "csv_skip_rows")
+	delete(tbl.Fields, "csv_skip_columns")
+	delete(tbl.Fields, "csv_delimiter")
+	delete(tbl.Fields, "csv_comment")
+	delete(tbl.Fields, "csv_trim_space")
+	delete(tbl.Fields, "csv_column_names")
+	delete(tbl.Fields, "csv_column_types")
+	delete(tbl.Fields, "csv_tag_columns")
+	delete(tbl.Fields, "csv_measurement_column")
+	delete(tbl.Fields, "csv_timestamp_column")
+	delete(tbl.Fields, "csv_timestamp_format")
+	delete(tbl.Fields, "csv_skip_if")
+	delete(tbl.Fields, "openmetrics_exemplars")
+	delete(tbl.Fields, "syslog_format")
+	delete(tbl.Fields, "syslog_timezone")
+	delete(tbl.Fields, "syslog_year")
 
 	return parsers.NewParser(c)
 }
 
+// getStringArray returns the string elements of the array key of tbl.
+func getStringArray(tbl *ast.Table, key string) []string {
+	var a []string
+	if node, ok := tbl.Fields[key]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if ary, ok := kv.Value.(*ast.Array); ok {
+				for _, elem := range ary.Value {
+					if str, ok := elem.(*ast.String); ok {
+						a = append(a, str.Value)
+					}
+				}
+			}
+		}
+	}
+	return a
+}
+
+// getStringTable returns the string values of the sub-table key of tbl.
+func getStringTable(tbl *ast.Table, key string) map[string]string {
+	m := make(map[string]string)
+	if node, ok := tbl.Fields[key]; ok {
+		if subtbl, ok := node.(*ast.Table); ok {
+			for name, val := range subtbl.Fields {
+				if kv, ok := val.(*ast.KeyValue); ok {
+					if str, ok := kv.Value.(*ast.String); ok {
+						m[name] = str.Value
+					}
+				}
+			}
+		}
+	}
+	return m
+}
+
 // buildSerializer grabs the necessary entries from the ast.Table for creating
 // a serializers.Serializer object, and creates it, which can then be added onto
 // an Output object.
@@ -1373,6 +1858,14 @@ func buildSerializer(name string, tbl *ast.Table) (serializers.Serializer, error
 		}
 	}
 
+	if node, ok := tbl.Fields["batch_template"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				c.BatchTemplate = str.Value
+			}
+		}
+	}
+
 	if node, ok := tbl.Fields["influx_max_line_bytes"]; ok {
 		if kv, ok := node.(*ast.KeyValue); ok {
 			if integer, ok := kv.Value.(*ast.Integer); ok {
@@ -1397,6 +1890,30 @@ func buildSerializer(name string, tbl *ast.Table) (serializers.Serializer, error
 		}
 	}
 
+	if node, ok := tbl.Fields["influx_sort_tags"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if b, ok := kv.Value.(*ast.Boolean); ok {
+				var err error
+				c.InfluxSortTags, err = b.Boolean()
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["influx_timestamp_precision"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				precision, err := time.ParseDuration(str.Value)
+				if err != nil {
+					return nil, fmt.Errorf("Unable to parse influx_timestamp_precision as a duration, %s", err)
+				}
+				c.InfluxTimestampPrecision = precision
+			}
+		}
+	}
+
 	if node, ok := tbl.Fields["influx_uint_support"]; ok {
 		if kv, ok := node.(*ast.KeyValue); ok {
 			if b, ok := kv.Value.(*ast.Boolean); ok {
@@ -1421,6 +1938,26 @@ func buildSerializer(name string, tbl *ast.Table) (serializers.Serializer, error
 		}
 	}
 
+	if node, ok := tbl.Fields["graphite_sanitize_mode"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				c.GraphiteSanitizeMode = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["templates"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if ary, ok := kv.Value.(*ast.Array); ok {
+				for _, elem := range ary.Value {
+					if str, ok := elem.(*ast.String); ok {
+						c.Templates = append(c.Templates, str.Value)
+					}
+				}
+			}
+		}
+	}
+
 	if node, ok := tbl.Fields["json_timestamp_units"]; ok {
 		if kv, ok := node.(*ast.KeyValue); ok {
 			if str, ok := kv.Value.(*ast.String); ok {
@@ -1437,14 +1974,132 @@ func buildSerializer(name string, tbl *ast.Table) (serializers.Serializer, error
 		}
 	}
 
+	for key, dst := range map[string]*string{
+		"json_name_key":      &c.JSONNameKey,
+		"json_tags_key":      &c.JSONTagsKey,
+		"json_fields_key":    &c.JSONFieldsKey,
+		"json_timestamp_key": &c.JSONTimestampKey,
+		"json_batch_format":  &c.JSONBatchFormat,
+	} {
+		if node, ok := tbl.Fields[key]; ok {
+			if kv, ok := node.(*ast.KeyValue); ok {
+				if str, ok := kv.Value.(*ast.String); ok {
+					*dst = str.Value
+				}
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["parquet_compression"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				c.ParquetCompression = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["parquet_row_group_size"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if integer, ok := kv.Value.(*ast.Integer); ok {
+				v, err := integer.Int()
+				if err != nil {
+					return nil, err
+				}
+				c.ParquetRowGroupSize = int(v)
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["csv_columns"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if ary, ok := kv.Value.(*ast.Array); ok {
+				for _, elem := range ary.Value {
+					if str, ok := elem.(*ast.String); ok {
+						c.CSVColumns = append(c.CSVColumns, str.Value)
+					}
+				}
+			}
+		}
+	}
+
+	for key, dst := range map[string]*[]string{
+		"splunkmetric_dimensions_include": &c.SplunkmetricDimensionsInclude,
+		"splunkmetric_dimensions_exclude": &c.SplunkmetricDimensionsExclude,
+	} {
+		if node, ok := tbl.Fields[key]; ok {
+			if kv, ok := node.(*ast.KeyValue); ok {
+				if ary, ok := kv.Value.(*ast.Array); ok {
+					for _, elem := range ary.Value {
+						if str, ok := elem.(*ast.String); ok {
+							*dst = append(*dst, str.Value)
+						}
+					}
+				}
+			}
+		}
+	}
+
+	for key, dst := range map[string]*bool{
+		"csv_header":               &c.CSVHeader,
+		"csv_quote_all":            &c.CSVQuoteAll,
+		"splunkmetric_hec_routing": &c.SplunkmetricHecRouting,
+		"splunkmetric_multimetric": &c.SplunkmetricMultiMetric,
+	} {
+		if node, ok := tbl.Fields[key]; ok {
+			if kv, ok := node.(*ast.KeyValue); ok {
+				if b, ok := kv.Value.(*ast.Boolean); ok {
+					var err error
+					*dst, err = b.Boolean()
+					if err != nil {
+						return nil, err
+					}
+				}
+			}
+		}
+	}
+
+	for key, dst := range map[string]*string{
+		"csv_delimiter":        &c.CSVDelimiter,
+		"csv_timestamp_format": &c.CSVTimestampFormat,
+	} {
+		if node, ok := tbl.Fields[key]; ok {
+			if kv, ok := node.(*ast.KeyValue); ok {
+				if str, ok := kv.Value.(*ast.String); ok {
+					*dst = str.Value
+				}
+			}
+		}
+	}
+
 	delete(tbl.Fields, "influx_max_line_bytes")
 	delete(tbl.Fields, "influx_sort_fields")
+	delete(tbl.Fields, "influx_sort_tags")
 	delete(tbl.Fields, "influx_uint_support")
+	delete(tbl.Fields, "influx_timestamp_precision")
 	delete(tbl.Fields, "graphite_tag_support")
+	delete(tbl.Fields, "graphite_sanitize_mode")
 	delete(tbl.Fields, "data_format")
 	delete(tbl.Fields, "prefix")
 	delete(tbl.Fields, "template")
+	delete(tbl.Fields, "templates")
+	delete(tbl.Fields, "batch_template")
 	delete(tbl.Fields, "json_timestamp_units")
+	delete(tbl.Fields, "json_name_key")
+	delete(tbl.Fields, "json_tags_key")
+	delete(tbl.Fields, "json_fields_key")
+	delete(tbl.Fields, "json_timestamp_key")
+	delete(tbl.Fields, "json_batch_format")
+	delete(tbl.Fields, "parquet_compression")
+	delete(tbl.Fields, "parquet_row_group_size")
+	delete(tbl.Fields, "csv_columns")
+	delete(tbl.Fields, "csv_header")
+	delete(tbl.Fields, "csv_delimiter")
+	delete(tbl.Fields, "csv_quote_all")
+	delete(tbl.Fields, "csv_timestamp_format")
+	delete(tbl.Fields, "splunkmetric_hec_routing")
+	delete(tbl.Fields, "splunkmetric_multimetric")
+	delete(tbl.Fields, "splunkmetric_dimensions_include")
+	delete(tbl.Fields, "splunkmetric_dimensions_exclude")
 	return serializers.NewSerializer(c)
 }
 
@@ -1460,7 +2115,52 @@ func buildOutput(name string, tbl *ast.Table) (*models.OutputConfig, error) {
 	oc := &models.OutputConfig{
 		Name:   name,
 		Filter: filter,
+		Retry: retry.Policy{
+			Backoff: retry.Backoff{
+				Initial: defaultRetryInitialInterval,
+				Max:     defaultRetryMaxInterval,
+				Jitter:  retryJitter,
+			},
+		},
+	}
+
+	intervals := []struct {
+		key      string
+		interval *time.Duration
+	}{
+		{"retry_initial_interval", &oc.Retry.Initial},
+		{"retry_max_interval", &oc.Retry.Max},
+	}
+	for _, option := range intervals {
+		key, interval := option.key, option.interval
+		if node, ok := tbl.Fields[key]; ok {
+			if kv, ok := node.(*ast.KeyValue); ok {
+				if str, ok := kv.Value.(*ast.String); ok {
+					dur, err := time.ParseDuration(str.Value)
+					if err != nil {
+						return nil, err
+					}
+
+					*interval = dur
+				}
+			}
+		}
+		delete(tbl.Fields, key)
 	}
+
+	if node, ok := tbl.Fields["drop_rejected_metrics"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if b, ok := kv.Value.(*ast.Boolean); ok {
+				var err error
+				oc.DropRejected, err = b.Boolean()
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	delete(tbl.Fields, "drop_rejected_metrics")
+
 	// Outputs don't support FieldDrop/FieldPass, so set to NameDrop/NamePass
 	if len(oc.Filter.FieldDrop) > 0 {
 		oc.Filter.NameDrop = oc.Filter.FieldDrop
@@ -1,11 +1,17 @@
 package config
 
 import (
+	"fmt"
+	"io/ioutil"
 	"os"
+	"path/filepath"
+	"runtime"
 	"testing"
 	"time"
 
+	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal/models"
+	"github.com/influxdata/telegraf/plugins/aggregators"
 	"github.com/influxdata/telegraf/plugins/inputs"
 	"github.com/influxdata/telegraf/plugins/inputs/exec"
 	"github.com/influxdata/telegraf/plugins/inputs/memcached"
@@ -174,3 +180,328 @@ func TestConfig_LoadDirectory(t *testing.T) {
 	assert.Equal(t, pConfig, c.Inputs[3].Config,
 		"Merged Testdata did not produce correct procstat metadata.")
 }
+
+func TestSubstituteEnvironment(t *testing.T) {
+	os.Setenv("TELEGRAF_TEST_SET", `a"b`)
+	os.Setenv("TELEGRAF_TEST_EMPTY", "")
+	os.Unsetenv("TELEGRAF_TEST_UNSET")
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`v = "$TELEGRAF_TEST_SET"`, `v = "a\"b"`},
+		{`v = "$TELEGRAF_TEST_UNSET"`, `v = "$TELEGRAF_TEST_UNSET"`},
+		{`v = "${TELEGRAF_TEST_SET}"`, `v = "a\"b"`},
+		{`v = "${TELEGRAF_TEST_UNSET}"`, `v = ""`},
+		{`v = "${TELEGRAF_TEST_UNSET:-localhost:8086}"`, `v = "localhost:8086"`},
+		{`v = "${TELEGRAF_TEST_EMPTY:-default}"`, `v = "default"`},
+		{`v = "${TELEGRAF_TEST_EMPTY-default}"`, `v = ""`},
+		{`v = "${TELEGRAF_TEST_SET:-default}"`, `v = "a\"b"`},
+		{`v = "${TELEGRAF_TEST_SET:?required}"`, `v = "a\"b"`},
+		{`v = "${TELEGRAF_TEST_EMPTY?required}"`, `v = ""`},
+		{`v = "${TELEGRAF_TEST_UNSET:-say "hi"}"`, `v = "say \"hi\""`},
+		{`v = "pa$$word"`, `v = "pa$$word"`},
+		{"  # ${TELEGRAF_TEST_UNSET:?required}", "  # ${TELEGRAF_TEST_UNSET:?required}"},
+	}
+	for _, tt := range tests {
+		actual, err := substituteEnvironment([]byte(tt.input))
+		assert.NoError(t, err, tt.input)
+		assert.Equal(t, tt.expected, string(actual), tt.input)
+	}
+
+	_, err := substituteEnvironment([]byte("a = 1\nb = \"${TELEGRAF_TEST_EMPTY:?must be set}\""))
+	assert.EqualError(t, err, "line 2: environment variable TELEGRAF_TEST_EMPTY: must be set")
+
+	_, err = substituteEnvironment([]byte(`b = "${TELEGRAF_TEST_UNSET?}"`))
+	assert.EqualError(t, err, "line 1: environment variable TELEGRAF_TEST_UNSET: not set")
+}
+
+func TestConfig_LoadIncludes(t *testing.T) {
+	c := NewConfig()
+	err := c.LoadConfig("./testdata/includes/telegraf.toml")
+	assert.NoError(t, err)
+
+	var servers []string
+	for _, input := range c.Inputs {
+		servers = append(servers, input.Input.(*memcached.Memcached).Servers...)
+	}
+	// b.toml is included by a.toml and conf.d/*.toml, it is loaded once
+	assert.Equal(t, []string{"main", "a", "c", "b"}, servers)
+}
+
+func TestConfig_LoadIncludesCycle(t *testing.T) {
+	c := NewConfig()
+	err := c.LoadConfig("./testdata/cycle/first.toml")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "include cycle")
+}
+
+type requiredInput struct {
+	Servers  []string `toml:"servers,required"`
+	Optional string
+}
+
+func (*requiredInput) SampleConfig() string              { return "" }
+func (*requiredInput) Description() string               { return "" }
+func (*requiredInput) Gather(telegraf.Accumulator) error { return nil }
+
+func TestConfig_Validate(t *testing.T) {
+	inputs.Add("required", func() telegraf.Input { return &requiredInput{} })
+	defer delete(inputs.Inputs, "required")
+
+	c := NewConfig()
+	errs := c.ValidateConfig("./testdata/invalid.toml")
+
+	var msgs []string
+	for _, err := range errs {
+		msgs = append(msgs, err.Error())
+	}
+	assert.Equal(t, []string{
+		"./testdata/invalid.toml:3:3: [agent] flush_jitter: invalid duration \"5 minutes\"",
+		"./testdata/invalid.toml:7:3: [inputs.memcached] unix_socket: unknown option",
+		"./testdata/invalid.toml:12:3: [inputs.exec] timeout: invalid duration \"5x\"",
+		"./testdata/invalid.toml:15:1: [inputs.required] missing required option \"servers\"",
+		"./testdata/invalid.toml:18:1: [inputs.unknown] unknown input plugin",
+	}, msgs)
+}
+
+func TestConfig_ValidateValid(t *testing.T) {
+	c := NewConfig()
+	assert.Empty(t, c.ValidateConfig("./testdata/single_plugin.toml"))
+	assert.Empty(t, c.ValidateConfig("./testdata/includes/telegraf.toml"))
+}
+
+type secretInput struct {
+	Username string
+	Password string
+	PidFile  string `toml:"pid_file"`
+}
+
+func (*secretInput) SampleConfig() string              { return "" }
+func (*secretInput) Description() string               { return "" }
+func (*secretInput) Gather(telegraf.Accumulator) error { return nil }
+
+func writeConfig(t *testing.T, dir, name, contents string, perm os.FileMode) string {
+	path := filepath.Join(dir, name)
+	assert.NoError(t, ioutil.WriteFile(path, []byte(contents), perm))
+	assert.NoError(t, os.Chmod(path, perm))
+	return path
+}
+
+func TestConfig_LoadSecretFiles(t *testing.T) {
+	inputs.Add("secret", func() telegraf.Input { return &secretInput{} })
+	defer delete(inputs.Inputs, "secret")
+
+	dir, err := ioutil.TempDir("", "telegraf")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	secret := writeConfig(t, dir, "password", "s3cr3t\n", 0600)
+	config := writeConfig(t, dir, "telegraf.conf", fmt.Sprintf(`
+[[inputs.secret]]
+  username = "telegraf"
+  password_file = %q
+  pid_file = "/var/run/app.pid"
+`, secret), 0644)
+
+	c := NewConfig()
+	assert.NoError(t, c.LoadConfig(config))
+	assert.Len(t, c.Inputs, 1)
+	assert.Equal(t, &secretInput{
+		Username: "telegraf",
+		Password: "s3cr3t",
+		PidFile:  "/var/run/app.pid",
+	}, c.Inputs[0].Input)
+}
+
+func TestConfig_LoadSecretFilesPermissions(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("permissions are not checked on windows")
+	}
+	inputs.Add("secret", func() telegraf.Input { return &secretInput{} })
+	defer delete(inputs.Inputs, "secret")
+
+	dir, err := ioutil.TempDir("", "telegraf")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	secret := writeConfig(t, dir, "password", "s3cr3t\n", 0644)
+	config := writeConfig(t, dir, "telegraf.conf", fmt.Sprintf(`
+[[inputs.secret]]
+  password_file = %q
+`, secret), 0644)
+
+	c := NewConfig()
+	err = c.LoadConfig(config)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "line 3: password_file: ")
+	assert.Contains(t, err.Error(), "readable by all users")
+}
+
+func TestConfig_LoadRateLimits(t *testing.T) {
+	dir, err := ioutil.TempDir("", "telegraf")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	config := writeConfig(t, dir, "telegraf.conf", `
+[rate_limits.storage_api]
+  limit = 600
+  period = "1m"
+  burst = 10
+
+[rate_limits.cloudwatch]
+  limit = 400
+`, 0644)
+
+	c := NewConfig()
+	assert.NoError(t, c.LoadConfig(config))
+	assert.Len(t, c.RateLimits, 2)
+	assert.Contains(t, c.RateLimits, "storage_api")
+	assert.Contains(t, c.RateLimits, "cloudwatch")
+
+	errs := c.ValidateConfig(writeConfig(t, dir, "invalid.conf", `
+[rate_limits.storage_api]
+  period = "1m"
+
+[rate_limits.cloudwatch]
+  limit = -1
+`, 0644))
+	if assert.Len(t, errs, 2) {
+		assert.Contains(t, errs[0].Error(), `missing required option "limit"`)
+		assert.Contains(t, errs[1].Error(), "invalid rate limit")
+	}
+}
+
+type limitedInput struct {
+	RateLimiter string `toml:"rate_limiter"`
+}
+
+func (*limitedInput) SampleConfig() string              { return "" }
+func (*limitedInput) Description() string               { return "" }
+func (*limitedInput) Gather(telegraf.Accumulator) error { return nil }
+func (i *limitedInput) RateLimiterName() string         { return i.RateLimiter }
+
+func TestConfig_CheckRateLimiters(t *testing.T) {
+	inputs.Add("limited", func() telegraf.Input { return &limitedInput{} })
+	defer delete(inputs.Inputs, "limited")
+
+	dir, err := ioutil.TempDir("", "telegraf")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	config := writeConfig(t, dir, "telegraf.conf", `
+[rate_limits.storage_api]
+  limit = 600
+
+[[inputs.limited]]
+  rate_limiter = "storage_api"
+
+[[inputs.limited]]
+`, 0644)
+	c := NewConfig()
+	assert.NoError(t, c.LoadConfig(config))
+	assert.NoError(t, c.CheckRateLimiters())
+	assert.Empty(t, c.ValidateConfig(config))
+	assert.Empty(t, c.ValidateRateLimiters())
+
+	config = writeConfig(t, dir, "typo.conf", `
+[rate_limits.storage_api]
+  limit = 600
+
+[[inputs.limited]]
+  rate_limiter = "storage-api"
+`, 0644)
+	c = NewConfig()
+	assert.NoError(t, c.LoadConfig(config))
+	err = c.CheckRateLimiters()
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), `undefined rate limiter "storage-api"`)
+	}
+	assert.Empty(t, c.ValidateConfig(config))
+	errs := c.ValidateRateLimiters()
+	if assert.Len(t, errs, 1) {
+		assert.Equal(t, config+`:6:3: [inputs.limited] rate_limiter: undefined rate limiter "storage-api"`, errs[0].Error())
+	}
+}
+
+// initAggregator is an aggregator checking its options with Init.
+type initAggregator struct {
+	K int `toml:"k"`
+}
+
+func (a *initAggregator) SampleConfig() string      { return "" }
+func (a *initAggregator) Description() string       { return "" }
+func (a *initAggregator) Add(in telegraf.Metric)    {}
+func (a *initAggregator) Push(telegraf.Accumulator) {}
+func (a *initAggregator) Reset()                    {}
+
+func (a *initAggregator) Init() error {
+	if a.K < 1 {
+		return fmt.Errorf("k must be at least 1")
+	}
+	return nil
+}
+
+func TestConfig_LoadAggregatorInit(t *testing.T) {
+	aggregators.Add("init", func() telegraf.Aggregator { return &initAggregator{} })
+	defer delete(aggregators.Aggregators, "init")
+
+	dir, err := ioutil.TempDir("", "telegraf")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	config := writeConfig(t, dir, "telegraf.conf", `
+[[aggregators.init]]
+  k = 3
+`, 0644)
+	c := NewConfig()
+	assert.NoError(t, c.LoadConfig(config))
+	assert.Len(t, c.Aggregators, 1)
+
+	config = writeConfig(t, dir, "invalid.conf", `
+[[aggregators.init]]
+  k = 0
+`, 0644)
+	c = NewConfig()
+	err = c.LoadConfig(config)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "k must be at least 1")
+	assert.Len(t, c.ValidateConfig(config), 1)
+}
+
+func TestConfig_LoadAggregatorSlide(t *testing.T) {
+	aggregators.Add("init", func() telegraf.Aggregator { return &initAggregator{} })
+	defer delete(aggregators.Aggregators, "init")
+
+	dir, err := ioutil.TempDir("", "telegraf")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	config := writeConfig(t, dir, "telegraf.conf", `
+[[aggregators.init]]
+  period = "30s"
+  slide = "10s"
+  k = 3
+`, 0644)
+	c := NewConfig()
+	assert.NoError(t, c.LoadConfig(config))
+	if assert.Len(t, c.Aggregators, 1) {
+		assert.Equal(t, 10*time.Second, c.Aggregators[0].Config.Slide)
+	}
+
+	for _, slide := range []string{"0s", "-10s", "30s", "1m"} {
+		config = writeConfig(t, dir, "invalid.conf", `
+[[aggregators.init]]
+  period = "30s"
+  slide = "`+slide+`"
+  k = 3
+`, 0644)
+		c = NewConfig()
+		err = c.LoadConfig(config)
+		if assert.Error(t, err, slide) {
+			assert.Contains(t, err.Error(), "less than the period")
+		}
+		assert.Len(t, c.ValidateConfig(config), 1, slide)
+	}
+}
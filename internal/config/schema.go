@@ -0,0 +1,316 @@
+package config
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/aggregators"
+	"github.com/influxdata/telegraf/plugins/inputs"
+	"github.com/influxdata/telegraf/plugins/outputs"
+	"github.com/influxdata/telegraf/plugins/parsers"
+	"github.com/influxdata/telegraf/plugins/processors"
+	"github.com/influxdata/telegraf/plugins/serializers"
+)
+
+// PluginSchema describes a plugin and the options of its table.  The options
+// common to all the plugins of a type, such as interval or the metric
+// filters, are not included.
+type PluginSchema struct {
+	// Type of the plugin: inputs, outputs, processors or aggregators
+	Type        string `json:"type"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// The plugin takes the data_format options of the parsers, or of the
+	// serializers
+	Parser     bool         `json:"parser,omitempty"`
+	Serializer bool         `json:"serializer,omitempty"`
+	Deprecated *Deprecation `json:"deprecated,omitempty"`
+	Options    []*Option    `json:"options"`
+}
+
+// Option describes an option of a plugin.
+type Option struct {
+	Name string `json:"name"`
+	// Type of the value: string, boolean, integer, float, duration, array or
+	// table
+	Type string `json:"type"`
+	// Type of the elements of an array or of the values of a table
+	Element string `json:"element,omitempty"`
+	// Default value, omitted when it is the zero value of the type
+	Default    interface{}  `json:"default,omitempty"`
+	Required   bool         `json:"required,omitempty"`
+	Deprecated *Deprecation `json:"deprecated,omitempty"`
+	// Options of a table, or of the tables of an array
+	Options []*Option `json:"options,omitempty"`
+}
+
+// Deprecation is the notice of a deprecated plugin or option.
+type Deprecation struct {
+	// Version deprecating the plugin or option
+	Since  string `json:"since"`
+	Notice string `json:"notice"`
+}
+
+// deprecatedPlugins are the deprecated plugins, by table.  Options are
+// deprecated by the deprecated tag of their field, ie
+// `deprecated:"1.7;use enable_tls"`.
+var deprecatedPlugins = map[string]*Deprecation{
+	"inputs.cassandra":       {"1.7", "use inputs.jolokia2"},
+	"inputs.jolokia":         {"1.5", "use inputs.jolokia2"},
+	"inputs.snmp_legacy":     {"1.0", "use inputs.snmp"},
+	"inputs.tcp_listener":    {"1.3", "use inputs.socket_listener"},
+	"inputs.udp_listener":    {"1.3", "use inputs.socket_listener"},
+	"outputs.riemann_legacy": {"1.3", "use outputs.riemann"},
+}
+
+// PluginSchemas returns the schemas of the plugins compiled in, sorted by
+// type and name.  The filters select the plugins of each type; without
+// filters all the plugins are returned, otherwise only the plugins of the
+// types with a filter.
+func PluginSchemas(
+	inputFilters []string,
+	outputFilters []string,
+	aggregatorFilters []string,
+	processorFilters []string,
+) []*PluginSchema {
+	filtered := len(inputFilters)+len(outputFilters)+len(aggregatorFilters)+len(processorFilters) > 0
+
+	var schemas []*PluginSchema
+	add := func(kind string, filters []string, name string, plugin interface{}) {
+		if filtered && !sliceContains(name, filters) {
+			return
+		}
+		s := &PluginSchema{
+			Type:       kind,
+			Name:       name,
+			Deprecated: deprecatedPlugins[kind+"."+name],
+			Options:    pluginOptions(reflect.ValueOf(plugin)),
+		}
+		if d, ok := plugin.(interface {
+			Description() string
+		}); ok {
+			s.Description = d.Description()
+		}
+		_, s.Parser = plugin.(parsers.ParserInput)
+		_, s.Serializer = plugin.(serializers.SerializerOutput)
+		schemas = append(schemas, s)
+	}
+
+	for name, creator := range inputs.Inputs {
+		add("inputs", inputFilters, name, creator())
+	}
+	for name, creator := range outputs.Outputs {
+		add("outputs", outputFilters, name, creator())
+	}
+	for name, creator := range processors.Processors {
+		add("processors", processorFilters, name, creator())
+	}
+	for name, creator := range aggregators.Aggregators {
+		add("aggregators", aggregatorFilters, name, creator())
+	}
+
+	sort.Slice(schemas, func(i, j int) bool {
+		if schemas[i].Type != schemas[j].Type {
+			return schemas[i].Type < schemas[j].Type
+		}
+		return schemas[i].Name < schemas[j].Name
+	})
+	return schemas
+}
+
+var durationType = reflect.TypeOf(internal.Duration{})
+
+// pluginOptions returns the options of the fields of the struct v, and of
+// its embedded structs.  Unexported fields, fields tagged `toml:"-"` and
+// fields which cannot be set from a table, such as interfaces, are not
+// options.
+func pluginOptions(v reflect.Value) []*Option {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v = reflect.Zero(v.Type().Elem())
+			continue
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var options []*Option
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Anonymous {
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if configStruct(ft) {
+				options = append(options, pluginOptions(v.Field(i))...)
+			}
+			continue
+		}
+		if f.PkgPath != "" {
+			continue
+		}
+		parts := strings.Split(f.Tag.Get("toml"), ",")
+		if parts[0] == "-" {
+			continue
+		}
+
+		o := &Option{Name: strings.TrimSpace(parts[0])}
+		if o.Name == "" {
+			o.Name = optionName(f.Name)
+		}
+		if !describeValue(o, v.Field(i)) {
+			continue
+		}
+		for _, flag := range parts[1:] {
+			if strings.TrimSpace(flag) == "required" {
+				o.Required = true
+			}
+		}
+		if tag, ok := f.Tag.Lookup("deprecated"); ok {
+			d := strings.SplitN(tag, ";", 2)
+			o.Deprecated = &Deprecation{Since: strings.TrimSpace(d[0])}
+			if len(d) > 1 {
+				o.Deprecated.Notice = strings.TrimSpace(d[1])
+			}
+		}
+		options = append(options, o)
+	}
+	return options
+}
+
+// describeValue sets the type, and the default, of the option of the field
+// value v; false if the field is not an option.
+func describeValue(o *Option, v reflect.Value) bool {
+	t := v.Type()
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+		if v.IsNil() {
+			v = reflect.Zero(t)
+		} else {
+			v = v.Elem()
+		}
+	}
+
+	if t == durationType {
+		o.Type = "duration"
+		if d := v.Interface().(internal.Duration); d.Duration != 0 {
+			o.Default = d.Duration.String()
+		}
+		return true
+	}
+
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array:
+		o.Type = "array"
+		o.Element, o.Options = elementType(t.Elem())
+		if o.Element == "" {
+			return false
+		}
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return false
+		}
+		o.Type = "table"
+		o.Element, o.Options = elementType(t.Elem())
+		if o.Element == "" {
+			return false
+		}
+	case reflect.Struct:
+		if !configStruct(t) {
+			return false
+		}
+		o.Type = "table"
+		o.Options = pluginOptions(v)
+		return true
+	default:
+		if o.Type = scalarType(t); o.Type == "" {
+			return false
+		}
+	}
+
+	if v.Kind() == reflect.Slice || v.Kind() == reflect.Map {
+		if v.Len() != 0 {
+			o.Default = v.Interface()
+		}
+	} else if !reflect.DeepEqual(v.Interface(), reflect.Zero(t).Interface()) {
+		o.Default = v.Interface()
+	}
+	return true
+}
+
+// elementType returns the type of the elements of an array or table, and
+// the options of table elements; an empty type if they are not options.
+func elementType(t reflect.Type) (string, []*Option) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch {
+	case t == durationType:
+		return "duration", nil
+	case t.Kind() == reflect.Struct:
+		if !configStruct(t) {
+			return "", nil
+		}
+		return "table", pluginOptions(reflect.Zero(t))
+	case t.Kind() == reflect.Slice:
+		if elem, _ := elementType(t.Elem()); elem != "" {
+			return "array", nil
+		}
+		return "", nil
+	case t.Kind() == reflect.Map:
+		return "table", nil
+	}
+	return scalarType(t), nil
+}
+
+func scalarType(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "float"
+	}
+	return ""
+}
+
+// configStruct reports if t is a struct of settings, the structs of other
+// packages, such as clients of the plugins, are not.
+func configStruct(t reflect.Type) bool {
+	return t.PkgPath() == "" || strings.HasPrefix(t.PkgPath(), "github.com/influxdata/telegraf")
+}
+
+// optionName returns the key of the untagged field name, name in snake case
+// when the toml package maps it back to the field.
+func optionName(name string) string {
+	var b []rune
+	runes := []rune(name)
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) &&
+			(unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1]) ||
+				(i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+			b = append(b, '_')
+		}
+		b = append(b, unicode.ToLower(r))
+	}
+	snake := string(b)
+
+	switch name {
+	case camelCase(snake):
+		return snake
+	case strings.ToUpper(name):
+		return strings.ToLower(name)
+	}
+	return name
+}
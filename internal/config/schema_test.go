@@ -0,0 +1,92 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/tls"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type schemaTestPlugin struct {
+	Servers     []string `toml:"servers,required"`
+	Timeout     internal.Duration
+	Port        int
+	Ratio       float64 `toml:"ratio"`
+	HTTPHeaders map[string]string
+	URL         string `deprecated:"1.5;use servers"`
+	Tables      []struct {
+		Name   string
+		Fields []string `toml:"fields"`
+	} `toml:"table"`
+	Ignored string `toml:"-"`
+	tls.ClientConfig
+
+	client *time.Timer
+	Client *time.Location
+	Parse  func() error
+}
+
+func TestPluginOptions(t *testing.T) {
+	plugin := &schemaTestPlugin{
+		Servers: []string{"localhost"},
+		Timeout: internal.Duration{Duration: 5 * time.Second},
+		Port:    4242,
+	}
+	options := pluginOptions(reflect.ValueOf(plugin))
+
+	byName := make(map[string]*Option)
+	for _, o := range options {
+		byName[o.Name] = o
+	}
+	assert.Equal(t, &Option{Name: "servers", Type: "array", Element: "string",
+		Default: []string{"localhost"}, Required: true}, byName["servers"])
+	assert.Equal(t, &Option{Name: "timeout", Type: "duration", Default: "5s"}, byName["timeout"])
+	assert.Equal(t, &Option{Name: "port", Type: "integer", Default: 4242}, byName["port"])
+	assert.Equal(t, &Option{Name: "ratio", Type: "float"}, byName["ratio"])
+	assert.Equal(t, &Option{Name: "HTTPHeaders", Type: "table", Element: "string"}, byName["HTTPHeaders"])
+	assert.Equal(t, &Option{Name: "url", Type: "string",
+		Deprecated: &Deprecation{Since: "1.5", Notice: "use servers"}}, byName["url"])
+	assert.Equal(t, &Option{Name: "table", Type: "array", Element: "table", Options: []*Option{
+		{Name: "name", Type: "string"},
+		{Name: "fields", Type: "array", Element: "string"},
+	}}, byName["table"])
+	assert.Equal(t, "string", byName["tls_ca"].Type)
+	assert.Equal(t, &Deprecation{Since: "1.7", Notice: "use tls_key"}, byName["ssl_key"].Deprecated)
+
+	for _, name := range []string{"Ignored", "ignored", "client", "Client", "parse"} {
+		assert.Nil(t, byName[name], name)
+	}
+}
+
+func TestOptionName(t *testing.T) {
+	assert.Equal(t, "container_names", optionName("ContainerNames"))
+	assert.Equal(t, "url", optionName("URL"))
+	assert.Equal(t, "servers", optionName("Servers"))
+	assert.Equal(t, "metric_version2", optionName("MetricVersion2"))
+	// the toml package does not map http_timeout back to the field
+	assert.Equal(t, "HTTPTimeout", optionName("HTTPTimeout"))
+}
+
+func TestPluginSchemas(t *testing.T) {
+	schemas := PluginSchemas([]string{"exec", "memcached"}, nil, nil, nil)
+	assert.Len(t, schemas, 2)
+
+	assert.Equal(t, "inputs", schemas[0].Type)
+	assert.Equal(t, "exec", schemas[0].Name)
+	assert.True(t, schemas[0].Parser)
+	assert.NotEmpty(t, schemas[0].Description)
+	assert.Equal(t, []*Option{
+		{Name: "commands", Type: "array", Element: "string"},
+		{Name: "command", Type: "string"},
+		{Name: "timeout", Type: "duration", Default: "5s"},
+		{Name: "streaming", Type: "boolean"},
+		{Name: "restart_delay", Type: "duration", Default: "10s"},
+	}, schemas[0].Options)
+
+	assert.Equal(t, "memcached", schemas[1].Name)
+	assert.False(t, schemas[1].Parser)
+}
@@ -0,0 +1,131 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/influxdata/toml/ast"
+)
+
+// secretFileSuffix is the suffix of the options reading the value of another
+// option from a file, ie password_file for password.
+const secretFileSuffix = "_file"
+
+// resolveSecretFiles replaces the <option>_file options of the table by the
+// option, set to the contents of the file.  Only string options of the
+// plugin are read from files, options of the plugin having the suffix, ie
+// pid_file, are left as is.
+func resolveSecretFiles(tbl *ast.Table, plugin interface{}) error {
+	for key, val := range tbl.Fields {
+		option := strings.TrimSuffix(key, secretFileSuffix)
+		if option == key || option == "" {
+			continue
+		}
+		if _, ok := optionField(plugin, key); ok {
+			continue
+		}
+		field, ok := optionField(plugin, option)
+		if !ok || field.Type.Kind() != reflect.String {
+			continue
+		}
+
+		kv, ok := val.(*ast.KeyValue)
+		if !ok {
+			return fmt.Errorf("line %d: %s must be a path", lineOf(val), key)
+		}
+		path, ok := kv.Value.(*ast.String)
+		if !ok {
+			return fmt.Errorf("line %d: %s must be a path", kv.Line, key)
+		}
+		if _, ok := tbl.Fields[option]; ok {
+			return fmt.Errorf("line %d: %s and %s can not both be set", kv.Line, option, key)
+		}
+
+		secret, err := readSecretFile(path.Value)
+		if err != nil {
+			return fmt.Errorf("line %d: %s: %s", kv.Line, key, err)
+		}
+		delete(tbl.Fields, key)
+		tbl.Fields[option] = &ast.KeyValue{
+			Key:  option,
+			Line: kv.Line,
+			Value: &ast.String{
+				Position: path.Position,
+				Value:    secret,
+				Data:     []rune(strconv.Quote(secret)),
+			},
+		}
+	}
+	return nil
+}
+
+// readSecretFile returns the contents of the file without the trailing line
+// ending.  Files readable by all users are refused, except on Windows where
+// the permission bits do not apply.
+func readSecretFile(path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	if runtime.GOOS != "windows" && info.Mode().Perm()&0004 != 0 {
+		return "", fmt.Errorf("%s is readable by all users, remove the permission with chmod o-r", path)
+	}
+
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(contents), "\r\n"), nil
+}
+
+// optionField returns the field of the plugin set by the option, found as
+// the toml package finds it: by its toml tag or by the name of the field.
+func optionField(plugin interface{}, key string) (reflect.StructField, bool) {
+	t := reflect.TypeOf(plugin)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return reflect.StructField{}, false
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+		if name := strings.Split(f.Tag.Get("toml"), ",")[0]; strings.TrimSpace(name) == key {
+			return f, true
+		}
+	}
+	for _, name := range []string{strings.Title(key), camelCase(key), strings.ToUpper(key)} {
+		if f, ok := t.FieldByName(name); ok && f.PkgPath == "" {
+			return f, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// camelCase returns the key in camel case, ie "api_token" is "ApiToken".
+func camelCase(key string) string {
+	result := make([]rune, 0, len(key))
+	upper := true
+	for _, r := range key {
+		if r == '_' {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		result = append(result, r)
+	}
+	return string(result)
+}
@@ -0,0 +1,478 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/limiter"
+	"github.com/influxdata/telegraf/plugins/aggregators"
+	"github.com/influxdata/telegraf/plugins/inputs"
+	"github.com/influxdata/telegraf/plugins/outputs"
+	"github.com/influxdata/telegraf/plugins/parsers"
+	"github.com/influxdata/telegraf/plugins/processors"
+	"github.com/influxdata/telegraf/plugins/serializers"
+
+	"github.com/influxdata/toml"
+	"github.com/influxdata/toml/ast"
+)
+
+// tomlErrorRe matches the line, and the Go field, of the errors of the toml
+// package.
+var tomlErrorRe = regexp.MustCompile(`^(?:toml: )?line (\d+): (?:[\w.]+\.\w+: )?(.*)$`)
+
+// ValidationError is an error of a configuration file, with the position and
+// the table of the option when known.
+type ValidationError struct {
+	File   string
+	Line   int
+	Column int
+	// Table of the option, ie "inputs.disk"
+	Table string
+	// Key of the option, empty for errors of the table itself
+	Key string
+	Err error
+}
+
+func (e *ValidationError) Error() string {
+	pos := e.File
+	if e.Line > 0 {
+		pos += ":" + strconv.Itoa(e.Line)
+		if e.Column > 0 {
+			pos += ":" + strconv.Itoa(e.Column)
+		}
+	}
+	switch {
+	case e.Table == "":
+		return fmt.Sprintf("%s: %s", pos, e.Err)
+	case e.Key == "":
+		return fmt.Sprintf("%s: [%s] %s", pos, e.Table, e.Err)
+	default:
+		return fmt.Sprintf("%s: [%s] %s: %s", pos, e.Table, e.Key, e.Err)
+	}
+}
+
+// validator collects the errors of a configuration file.
+type validator struct {
+	config *Config
+	file   string
+	lines  []string
+	errs   []*ValidationError
+}
+
+// rateLimiterRef is a rate_limiter option, checked by ValidateRateLimiters
+// once the rate limiters of all the files are known.
+type rateLimiterRef struct {
+	name string
+	// error reported when the rate limiter is not defined
+	err error
+}
+
+// ValidateDirectory validates the *.conf files of the directory, as loaded by
+// LoadDirectory.
+func (c *Config) ValidateDirectory(path string) []error {
+	files, err := directoryFiles(path)
+	if err != nil {
+		return []error{err}
+	}
+	var errs []error
+	for _, file := range files {
+		errs = append(errs, c.ValidateConfig(file)...)
+	}
+	return errs
+}
+
+// ValidateRateLimiters checks that the rate limiters referenced by the
+// plugins of the validated files are defined in one of them, it is called
+// once all the files are validated.
+func (c *Config) ValidateRateLimiters() []error {
+	var errs []error
+	for _, ref := range c.rateLimiterRefs {
+		if !c.rateLimitNames[ref.name] {
+			errs = append(errs, ref.err)
+		}
+	}
+	return errs
+}
+
+// ValidateConfig checks the configuration file, and the files it includes,
+// without loading it.  Unlike LoadConfig it does not stop at the first error:
+// every option of every plugin table is checked against the options of the
+// plugin, reporting unknown options, values of the wrong type and missing
+// required options.  Options are required when the toml tag of their field
+// has the required flag, ie `toml:"servers,required"`.
+func (c *Config) ValidateConfig(path string) []error {
+	var err error
+	if path == "" {
+		if path, err = getDefaultConfigPath(); err != nil {
+			return []error{err}
+		}
+	}
+
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return []error{err}
+	}
+	if c.rateLimitNames == nil {
+		c.rateLimitNames = make(map[string]bool)
+	}
+	v := &validator{
+		config: c,
+		file:   path,
+		lines:  strings.Split(string(trimBOM(contents)), "\n"),
+	}
+
+	tbl, err := parseFile(path)
+	if err != nil {
+		v.addError(0, "", "", err)
+		return v.errors()
+	}
+	includes, err := parseIncludes(tbl)
+	if err != nil {
+		v.addError(0, "", "includes", err)
+	}
+
+	for name, val := range tbl.Fields {
+		subTable, ok := val.(*ast.Table)
+		if !ok {
+			v.addError(lineOf(val), "", name, fmt.Errorf("invalid configuration, expected a table"))
+			continue
+		}
+
+		switch name {
+		case "agent":
+			v.validatePlugin(name, subTable, &AgentConfig{})
+		case "global_tags", "tags":
+			v.validatePlugin(name, subTable, map[string]string{})
+		case "rate_limits":
+			for limitName, limitVal := range subTable.Fields {
+				limitTable, ok := limitVal.(*ast.Table)
+				if !ok {
+					v.addError(lineOf(limitVal), name, limitName, fmt.Errorf("unsupported config format"))
+					continue
+				}
+				c.rateLimitNames[limitName] = true
+				rateLimit := &limiter.RateLimit{}
+				table := name + "." + limitName
+				nErrs := len(v.errs)
+				v.validatePlugin(table, limitTable, rateLimit)
+				if len(v.errs) > nErrs {
+					continue
+				}
+				if _, err := rateLimit.TokenBucket(); err != nil {
+					v.addError(limitTable.Line, table, "", err)
+				}
+			}
+		case "outputs", "inputs", "plugins", "processors", "aggregators":
+			for pluginName, pluginVal := range subTable.Fields {
+				var tables []*ast.Table
+				switch pluginSubTable := pluginVal.(type) {
+				case *ast.Table:
+					if name == "processors" || name == "aggregators" {
+						v.addError(pluginSubTable.Line, name+"."+pluginName, "",
+							fmt.Errorf("unsupported config format, expected [[%s.%s]]", name, pluginName))
+						continue
+					}
+					tables = append(tables, pluginSubTable)
+				case []*ast.Table:
+					tables = pluginSubTable
+				default:
+					v.addError(lineOf(pluginVal), name, pluginName, fmt.Errorf("unsupported config format"))
+					continue
+				}
+				for _, t := range tables {
+					v.validateTable(name, pluginName, t)
+				}
+			}
+		default:
+			// legacy input tables
+			v.validateTable("inputs", name, subTable)
+		}
+	}
+	errs := v.errors()
+
+	c.including = append(c.including, absPath(path))
+	defer func() { c.including = c.including[:len(c.including)-1] }()
+	if c.included == nil {
+		c.included = make(map[string]bool)
+	}
+	for _, pattern := range includes {
+		files, err := includeFiles(path, pattern)
+		if err != nil {
+			errs = append(errs, &ValidationError{File: path, Key: "includes", Err: err})
+			continue
+		}
+		for _, file := range files {
+			if cycle := c.includeCycle(file); cycle != "" {
+				errs = append(errs, &ValidationError{File: path, Key: "includes",
+					Err: fmt.Errorf("include cycle %s", cycle)})
+				continue
+			}
+			if c.included[file] {
+				continue
+			}
+			c.included[file] = true
+			errs = append(errs, c.ValidateConfig(file)...)
+		}
+	}
+	return errs
+}
+
+// validateTable validates the table of a plugin, kind is the table of the
+// plugin type, ie "inputs".
+func (v *validator) validateTable(kind, name string, tbl *ast.Table) {
+	// build the plugin settings on a copy, the options they remove from the
+	// table are the options common to all plugins
+	rest := &ast.Table{Line: tbl.Line, Fields: make(map[string]interface{}, len(tbl.Fields))}
+	for k, val := range tbl.Fields {
+		rest.Fields[k] = val
+	}
+
+	var plugin interface{}
+	var err error
+	switch kind {
+	case "inputs", "plugins":
+		kind = "inputs"
+		// Legacy support renaming io input to diskio
+		if name == "io" {
+			name = "diskio"
+		}
+		creator, ok := inputs.Inputs[name]
+		if !ok {
+			v.addError(tbl.Line, kind+"."+name, "", fmt.Errorf("unknown input plugin"))
+			return
+		}
+		plugin = creator()
+		if _, ok := plugin.(parsers.ParserInput); ok {
+			if _, err := buildParser(name, rest); err != nil {
+				v.addError(tbl.Line, kind+"."+name, "", err)
+			}
+		}
+		_, err = buildInput(name, rest)
+	case "outputs":
+		creator, ok := outputs.Outputs[name]
+		if !ok {
+			v.addError(tbl.Line, kind+"."+name, "", fmt.Errorf("unknown output plugin"))
+			return
+		}
+		plugin = creator()
+		if _, ok := plugin.(serializers.SerializerOutput); ok {
+			if _, err := buildSerializer(name, rest); err != nil {
+				v.addError(tbl.Line, kind+"."+name, "", err)
+			}
+		}
+		_, err = buildOutput(name, rest)
+	case "processors":
+		creator, ok := processors.Processors[name]
+		if !ok {
+			v.addError(tbl.Line, kind+"."+name, "", fmt.Errorf("unknown processor plugin"))
+			return
+		}
+		plugin = creator()
+		_, err = buildProcessor(name, rest)
+	case "aggregators":
+		creator, ok := aggregators.Aggregators[name]
+		if !ok {
+			v.addError(tbl.Line, kind+"."+name, "", fmt.Errorf("unknown aggregator plugin"))
+			return
+		}
+		plugin = creator()
+		_, err = buildAggregator(name, rest)
+	}
+	if err != nil {
+		v.addError(tbl.Line, kind+"."+name, "", err)
+	}
+
+	if err := resolveSecretFiles(rest, plugin); err != nil {
+		v.addError(0, kind+"."+name, "", err)
+	}
+
+	nErrs := len(v.errs)
+	v.validatePlugin(kind+"."+name, rest, plugin)
+	if len(v.errs) > nErrs {
+		return
+	}
+	if p, ok := plugin.(interface {
+		RateLimiterName() string
+	}); ok && p.RateLimiterName() != "" {
+		line := lineOf(rest.Fields["rate_limiter"])
+		v.config.rateLimiterRefs = append(v.config.rateLimiterRefs, rateLimiterRef{
+			name: p.RateLimiterName(),
+			err: v.newError(line, kind+"."+name, "rate_limiter",
+				fmt.Errorf("undefined rate limiter %q", p.RateLimiterName())),
+		})
+	}
+	if p, ok := plugin.(interface {
+		Init() error
+	}); ok && kind == "aggregators" {
+		if err := p.Init(); err != nil {
+			v.addError(tbl.Line, kind+"."+name, "", err)
+		}
+	}
+}
+
+// validatePlugin unmarshals the options of the table one at a time, so
+// every option with an error is reported.
+func (v *validator) validatePlugin(table string, tbl *ast.Table, plugin interface{}) {
+	for _, key := range requiredOptions(reflect.TypeOf(plugin)) {
+		if _, ok := tbl.Fields[key]; !ok {
+			v.addError(tbl.Line, table, "", fmt.Errorf("missing required option %q", key))
+		}
+	}
+
+	for key, val := range tbl.Fields {
+		line := lineOf(val)
+		err := toml.UnmarshalTable(&ast.Table{Line: tbl.Line, Fields: map[string]interface{}{key: val}}, plugin)
+		if err == nil {
+			// invalid durations are not errors of the loading
+			err = checkDuration(plugin, key, val)
+		}
+		if err == nil {
+			continue
+		}
+
+		msg := err.Error()
+		if m := tomlErrorRe.FindStringSubmatch(msg); m != nil {
+			line, _ = strconv.Atoi(m[1])
+			msg = m[2]
+		}
+		if strings.Contains(msg, "is not defined in") {
+			msg = "unknown option"
+		}
+		v.addError(line, table, key, fmt.Errorf("%s", msg))
+	}
+}
+
+// checkDuration returns the error of the value of the option when its field
+// is an internal.Duration, which ignores the invalid durations.
+func checkDuration(plugin interface{}, key string, val interface{}) error {
+	kv, ok := val.(*ast.KeyValue)
+	if !ok {
+		return nil
+	}
+	rv := reflect.ValueOf(plugin)
+	for rv.Kind() == reflect.Ptr {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil
+	}
+
+	// the fields are found as by the toml package, by their tag or name
+	var field reflect.Value
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		if strings.TrimSpace(strings.Split(rt.Field(i).Tag.Get("toml"), ",")[0]) == key {
+			field = rv.Field(i)
+			break
+		}
+	}
+	if !field.IsValid() {
+		camel := strings.Replace(strings.Title(strings.Replace(key, "_", " ", -1)), " ", "", -1)
+		for _, name := range []string{strings.Title(key), camel, strings.ToUpper(key)} {
+			if field = rv.FieldByName(name); field.IsValid() {
+				break
+			}
+		}
+	}
+	if !field.IsValid() || field.Type() != reflect.TypeOf(internal.Duration{}) {
+		return nil
+	}
+
+	_, err := internal.ParseDuration([]byte(kv.Value.Source()))
+	return err
+}
+
+// requiredOptions returns the keys of the fields of the struct, and of its
+// embedded structs, with the required flag in their toml tag.
+func requiredOptions(t reflect.Type) []string {
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var keys []string
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Anonymous {
+			keys = append(keys, requiredOptions(f.Type)...)
+			continue
+		}
+		parts := strings.Split(f.Tag.Get("toml"), ",")
+		for _, flag := range parts[1:] {
+			if strings.TrimSpace(flag) == "required" && parts[0] != "" {
+				keys = append(keys, strings.TrimSpace(parts[0]))
+			}
+		}
+	}
+	return keys
+}
+
+// addError adds an error at the line, the column is the position of the key
+// on the line.
+func (v *validator) addError(line int, table, key string, err error) {
+	v.errs = append(v.errs, v.newError(line, table, key, err))
+}
+
+// newError returns the error of the option at the line, its column is the
+// position of the key on the line.
+func (v *validator) newError(line int, table, key string, err error) *ValidationError {
+	e := &ValidationError{
+		File:  v.file,
+		Line:  line,
+		Table: table,
+		Key:   key,
+		Err:   err,
+	}
+	if m := tomlErrorRe.FindStringSubmatch(err.Error()); m != nil && line == 0 {
+		e.Line, _ = strconv.Atoi(m[1])
+		e.Err = fmt.Errorf("%s", m[2])
+	}
+	if e.Line > 0 && e.Line <= len(v.lines) {
+		text := v.lines[e.Line-1]
+		e.Column = 1 + len(text) - len(strings.TrimLeft(text, " \t"))
+		if key != "" {
+			if i := strings.Index(text, key); i >= 0 {
+				e.Column = i + 1
+			}
+		}
+	}
+	return e
+}
+
+// errors returns the errors in the order of the file.
+func (v *validator) errors() []error {
+	sort.SliceStable(v.errs, func(i, j int) bool {
+		if v.errs[i].Line != v.errs[j].Line {
+			return v.errs[i].Line < v.errs[j].Line
+		}
+		return v.errs[i].Column < v.errs[j].Column
+	})
+	errs := make([]error, 0, len(v.errs))
+	for _, e := range v.errs {
+		errs = append(errs, e)
+	}
+	return errs
+}
+
+// lineOf returns the line of a node of the toml AST.
+func lineOf(node interface{}) int {
+	switch n := node.(type) {
+	case *ast.KeyValue:
+		return n.Line
+	case *ast.Table:
+		return n.Line
+	case []*ast.Table:
+		if len(n) > 0 {
+			return n[0].Line
+		}
+	}
+	return 0
+}
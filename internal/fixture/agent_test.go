@@ -0,0 +1,81 @@
+package fixture_test
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/influxdata/telegraf/agent"
+	"github.com/influxdata/telegraf/internal/config"
+	"github.com/influxdata/telegraf/internal/fixture"
+	_ "github.com/influxdata/telegraf/plugins/inputs/beegfs"
+	_ "github.com/influxdata/telegraf/plugins/inputs/nfsd"
+	_ "github.com/influxdata/telegraf/plugins/inputs/socket_listener"
+	"github.com/stretchr/testify/require"
+)
+
+// testFixture runs telegraf --test --fixture dir and returns the metrics it
+// printed.
+func testFixture(t *testing.T, dir string) string {
+	f, err := fixture.Load(dir)
+	require.NoError(t, err)
+
+	c := config.NewConfig()
+	c.Agent.OmitHostname = true
+	require.NoError(t, c.LoadConfig(f.Config))
+	a, err := agent.NewAgent(c)
+	require.NoError(t, err)
+
+	stdout := os.Stdout
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	os.Stdout = w
+	err = a.TestFixture(f)
+	os.Stdout = stdout
+	w.Close()
+	require.NoError(t, err)
+
+	out, err := ioutil.ReadAll(r)
+	require.NoError(t, err)
+	return string(out)
+}
+
+func TestAgentFixtures(t *testing.T) {
+	defer os.Unsetenv("HOST_PROC")
+
+	tests := []struct {
+		dir     string
+		metrics []string
+	}{
+		{
+			dir: "testdata/beegfs",
+			metrics: []string{
+				"> beegfs_server,node=storage01,node_id=1,node_type=storage busy_workers=0i,queue_length=0i,read_bytes=0i,requests=12i,write_bytes=524288i",
+				"> beegfs_target,consistency=Good,node_type=storage,reachability=Online,target_id=101 free_bytes=1099511627776i,",
+			},
+		},
+		{
+			dir: "testdata/nfsd",
+			metrics: []string{
+				"> nfsd_ops,version=3 access=1740i,",
+				"> nfsd_pool,pool=0 packets_arrived=4283925i,",
+			},
+		},
+		{
+			dir: "testdata/socket_listener",
+			metrics: []string{
+				"> cpu,cpu=cpu0 usage_idle=42",
+				"> mem used_percent=21.5",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.dir, func(t *testing.T) {
+			out := testFixture(t, tt.dir)
+			for _, m := range tt.metrics {
+				require.True(t, strings.Contains(out, m), "%q not in:\n%s", m, out)
+			}
+		})
+	}
+}
@@ -0,0 +1,214 @@
+// Package fixture feeds the data recorded in a fixture directory to input
+// plugins, so that their parsing can be checked with telegraf --test outside
+// of the systems the data comes from.
+//
+// A fixture is a directory holding any of:
+//
+//	telegraf.conf   configuration of the inputs, when no --config is given
+//	commands.json   commands replayed to the inputs running commands, as
+//	                recorded by the commandtest package
+//	files/          root of the files read by the inputs, absolute paths of
+//	                the options of the inputs are looked up in it, and
+//	                files/proc, files/sys, files/etc and files/var are set as
+//	                HOST_PROC, HOST_SYS, HOST_ETC and HOST_VAR
+//	payloads.json   payloads sent to service inputs once they are started
+package fixture
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal/command"
+)
+
+const (
+	configFile   = "telegraf.conf"
+	filesDir     = "files"
+	payloadsFile = "payloads.json"
+)
+
+// hostDirs are the environment variables of the directories of the host,
+// by subdirectory of the files of the fixture.
+var hostDirs = map[string]string{
+	"proc": "HOST_PROC",
+	"sys":  "HOST_SYS",
+	"etc":  "HOST_ETC",
+	"var":  "HOST_VAR",
+}
+
+// Payload is a message sent to a service input, such as a datagram of a
+// packet capture.
+type Payload struct {
+	// Network and address to send the payload to, ie "udp" and
+	// "127.0.0.1:8125"
+	Network string `json:"network"`
+	Address string `json:"address"`
+	// File of the payload, relative to the fixture, or the payload itself
+	File string `json:"file,omitempty"`
+	Data string `json:"data,omitempty"`
+}
+
+// Fixture is the recorded data of a fixture directory.
+type Fixture struct {
+	Dir string
+	// Configuration file of the fixture, empty if there is none
+	Config   string
+	Payloads []*Payload
+
+	replay *command.Replay
+	files  string
+}
+
+// Load returns the fixture of dir.
+func Load(dir string) (*Fixture, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("fixture %s is not a directory", dir)
+	}
+
+	f := &Fixture{Dir: dir}
+	if exists(filepath.Join(dir, configFile)) {
+		f.Config = filepath.Join(dir, configFile)
+	}
+	if exists(filepath.Join(dir, command.CommandsFile)) {
+		if f.replay, err = command.NewReplay(dir); err != nil {
+			return nil, err
+		}
+	}
+	if exists(filepath.Join(dir, filesDir)) {
+		f.files = filepath.Join(dir, filesDir)
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, payloadsFile))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, &f.Payloads); err != nil {
+			return nil, fmt.Errorf("%s: %s", filepath.Join(dir, payloadsFile), err)
+		}
+		for i, p := range f.Payloads {
+			if p.Network == "" || p.Address == "" {
+				return nil, fmt.Errorf("%s: payload %d has no network or address",
+					filepath.Join(dir, payloadsFile), i+1)
+			}
+		}
+	}
+	return f, nil
+}
+
+// Apply feeds the recorded data to the input: its commands are replayed and
+// the absolute paths of its options are replaced by the files of the
+// fixture.  The inputs running commands without a command.Runner cannot
+// replay them, this is an error.
+func (f *Fixture) Apply(input telegraf.Input) error {
+	if f.replay != nil {
+		setter, ok := input.(command.RunnerSetter)
+		if !ok {
+			return fmt.Errorf("fixture %s has commands, the input does not support replaying them", f.Dir)
+		}
+		setter.SetRunner(f.replay)
+	}
+
+	if f.files == "" {
+		return nil
+	}
+	for dir, env := range hostDirs {
+		path := filepath.Join(f.files, dir)
+		if !exists(path) {
+			continue
+		}
+		if err := os.Setenv(env, path); err != nil {
+			return err
+		}
+	}
+	f.rewritePaths(reflect.ValueOf(input))
+	return nil
+}
+
+// rewritePaths replaces the absolute paths of the string options of v, and
+// of its embedded structs, by the paths of the files of the fixture matching
+// them.  Paths without files in the fixture are kept.
+func (f *Fixture) rewritePaths(v reflect.Value) {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return
+	}
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := v.Field(i)
+		if t.Field(i).Anonymous {
+			f.rewritePaths(field.Addr())
+			continue
+		}
+		if !field.CanSet() {
+			continue
+		}
+		switch {
+		case field.Kind() == reflect.String:
+			field.SetString(f.path(field.String()))
+		case field.Kind() == reflect.Slice && field.Type().Elem().Kind() == reflect.String:
+			for j := 0; j < field.Len(); j++ {
+				field.Index(j).SetString(f.path(field.Index(j).String()))
+			}
+		}
+	}
+}
+
+// path returns the path of the fixture files matching the absolute path,
+// which may be a glob pattern, or the path if no file matches.
+func (f *Fixture) path(path string) string {
+	if !filepath.IsAbs(path) {
+		return path
+	}
+	fixturePath := filepath.Join(f.files, path)
+	if matches, err := filepath.Glob(fixturePath); err != nil || len(matches) == 0 {
+		return path
+	}
+	return fixturePath
+}
+
+// Send sends the payloads, in order, to the started service inputs.
+func (f *Fixture) Send() error {
+	for _, p := range f.Payloads {
+		data := []byte(p.Data)
+		if p.File != "" {
+			var err error
+			if data, err = ioutil.ReadFile(filepath.Join(f.Dir, p.File)); err != nil {
+				return err
+			}
+		}
+
+		conn, err := net.DialTimeout(p.Network, p.Address, 5*time.Second)
+		if err != nil {
+			return err
+		}
+		_, err = conn.Write(data)
+		conn.Close()
+		if err != nil {
+			return fmt.Errorf("sending payload to %s://%s: %s", p.Network, p.Address, err)
+		}
+	}
+	return nil
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
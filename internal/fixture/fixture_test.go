@@ -0,0 +1,90 @@
+package fixture
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/inputs/beegfs"
+	"github.com/influxdata/telegraf/plugins/inputs/nfsd"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+type plainInput struct{}
+
+func (p *plainInput) Description() string                   { return "" }
+func (p *plainInput) SampleConfig() string                  { return "" }
+func (p *plainInput) Gather(acc telegraf.Accumulator) error { return nil }
+
+func TestLoad(t *testing.T) {
+	f, err := Load("testdata/socket_listener")
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join("testdata/socket_listener", "telegraf.conf"), f.Config)
+	require.Equal(t, []*Payload{
+		{Network: "udp", Address: "127.0.0.1:18094", Data: "cpu,cpu=cpu0 usage_idle=42\n"},
+		{Network: "udp", Address: "127.0.0.1:18094", File: "metrics.txt"},
+	}, f.Payloads)
+
+	_, err = Load("testdata/missing")
+	require.Error(t, err)
+}
+
+func TestApplyCommands(t *testing.T) {
+	f, err := Load("testdata/beegfs")
+	require.NoError(t, err)
+
+	input := &beegfs.BeeGFS{Path: "/usr/bin/beegfs-ctl", NodeTypes: []string{"storage"}}
+	require.NoError(t, f.Apply(input))
+
+	var acc testutil.Accumulator
+	require.NoError(t, acc.GatherError(input.Gather))
+	require.True(t, acc.HasPoint("beegfs_server",
+		map[string]string{"node_type": "storage", "node": "storage01", "node_id": "1"},
+		"requests", uint64(12)))
+
+	require.Error(t, f.Apply(&plainInput{}))
+}
+
+func TestApplyFiles(t *testing.T) {
+	defer os.Unsetenv("HOST_PROC")
+
+	f, err := Load("testdata/nfsd")
+	require.NoError(t, err)
+
+	input := &nfsd.Nfsd{HostProc: "/proc"}
+	require.NoError(t, f.Apply(input))
+
+	files := filepath.Join("testdata/nfsd", "files")
+	require.Equal(t, filepath.Join(files, "proc"), input.HostProc)
+	require.Equal(t, filepath.Join(files, "proc"), os.Getenv("HOST_PROC"))
+
+	var acc testutil.Accumulator
+	require.NoError(t, acc.GatherError(input.Gather))
+	require.True(t, acc.HasPoint("nfsd_ops", map[string]string{"version": "3"}, "getattr", uint64(12750)))
+	require.True(t, acc.HasPoint("nfsd_pool", map[string]string{"pool": "0"}, "packets_arrived", uint64(4283925)))
+}
+
+func TestSend(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	f := &Fixture{
+		Dir: "testdata/socket_listener",
+		Payloads: []*Payload{
+			{Network: "udp", Address: conn.LocalAddr().String(), Data: "cpu usage=42"},
+			{Network: "udp", Address: conn.LocalAddr().String(), File: "metrics.txt"},
+		},
+	}
+	require.NoError(t, f.Send())
+
+	buf := make([]byte, 1024)
+	for _, expected := range []string{"cpu usage=42", "mem used_percent=21.5\n"} {
+		n, _, err := conn.ReadFrom(buf)
+		require.NoError(t, err)
+		require.Equal(t, expected, string(buf[:n]))
+	}
+}
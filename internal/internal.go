@@ -5,7 +5,9 @@ import (
 	"bytes"
 	"crypto/rand"
 	"errors"
+	"fmt"
 	"log"
+	"math"
 	"math/big"
 	"os"
 	"os/exec"
@@ -28,39 +30,44 @@ type Duration struct {
 	Duration time.Duration
 }
 
-// UnmarshalTOML parses the duration from the TOML config file
+// UnmarshalTOML parses the duration from the TOML config file.  Invalid
+// durations are zero, ParseDuration reports them.
 func (d *Duration) UnmarshalTOML(b []byte) error {
-	var err error
+	d.Duration, _ = ParseDuration(b)
+	return nil
+}
+
+// ParseDuration parses a duration of the TOML config file as a string, ie
+// "1s", or as a number of seconds.
+func ParseDuration(b []byte) (time.Duration, error) {
 	b = bytes.Trim(b, `'`)
 
 	// see if we can directly convert it
-	d.Duration, err = time.ParseDuration(string(b))
+	d, err := time.ParseDuration(string(b))
 	if err == nil {
-		return nil
+		return d, nil
 	}
 
 	// Parse string duration, ie, "1s"
 	if uq, err := strconv.Unquote(string(b)); err == nil && len(uq) > 0 {
-		d.Duration, err = time.ParseDuration(uq)
+		d, err = time.ParseDuration(uq)
 		if err == nil {
-			return nil
+			return d, nil
 		}
 	}
 
 	// First try parsing as integer seconds
 	sI, err := strconv.ParseInt(string(b), 10, 64)
 	if err == nil {
-		d.Duration = time.Second * time.Duration(sI)
-		return nil
+		return time.Second * time.Duration(sI), nil
 	}
 	// Second try parsing as float seconds
 	sF, err := strconv.ParseFloat(string(b), 64)
 	if err == nil {
-		d.Duration = time.Second * time.Duration(sF)
-		return nil
+		return time.Second * time.Duration(sF), nil
 	}
 
-	return nil
+	return 0, fmt.Errorf("invalid duration %s", b)
 }
 
 // ReadLines reads contents from a file and splits them by new lines.
@@ -193,3 +200,74 @@ func RandomSleep(max time.Duration, shutdown chan struct{}) {
 		return
 	}
 }
+
+// ParseTimestamp parses the timestamp with the format, one of "unix",
+// "unix_ms", "unix_us", "unix_ns" or a Go time layout, an empty format is
+// "unix".  The unix timestamps are parsed as decimals rather than floats, so
+// that the nanosecond timestamps keep their precision.
+func ParseTimestamp(timestamp string, format string) (time.Time, error) {
+	var digits int
+	switch format {
+	case "", "unix":
+		digits = 9
+	case "unix_ms":
+		digits = 6
+	case "unix_us":
+		digits = 3
+	case "unix_ns":
+		digits = 0
+	default:
+		t, err := time.Parse(format, timestamp)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("unable to parse timestamp %q: %s", timestamp, err)
+		}
+		return t, nil
+	}
+
+	ns, err := parseUnixNano(timestamp, digits)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("unable to parse timestamp %q: %s", timestamp, err)
+	}
+	return time.Unix(0, ns).UTC(), nil
+}
+
+// parseUnixNano parses a decimal number of units into nanoseconds, digits is
+// the number of digits of the nanoseconds of a unit.  The digits below the
+// nanosecond are truncated.
+func parseUnixNano(s string, digits int) (int64, error) {
+	// exponents are rare enough to go through a float
+	if strings.ContainsAny(s, "eE") {
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return 0, err
+		}
+		return int64(f * math.Pow10(digits)), nil
+	}
+
+	number := strings.TrimPrefix(s, "-")
+	whole, frac := number, ""
+	if i := strings.IndexByte(number, '.'); i >= 0 {
+		whole, frac = number[:i], number[i+1:]
+	}
+	if whole == "" && frac == "" {
+		return 0, errors.New("invalid syntax")
+	}
+	for _, c := range whole + frac {
+		if c < '0' || c > '9' {
+			return 0, errors.New("invalid syntax")
+		}
+	}
+	if len(frac) > digits {
+		frac = frac[:digits]
+	}
+	frac += strings.Repeat("0", digits-len(frac))
+
+	ns, err := strconv.ParseInt(whole+frac, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	if len(number) < len(s) {
+		ns = -ns
+	}
+	return ns, nil
+}
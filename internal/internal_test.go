@@ -161,4 +161,40 @@ func TestDuration(t *testing.T) {
 	d = Duration{}
 	d.UnmarshalTOML([]byte(`1.5`))
 	assert.Equal(t, time.Second, d.Duration)
+
+	// invalid durations are zero, as in the older versions
+	d = Duration{Duration: time.Second}
+	assert.NoError(t, d.UnmarshalTOML([]byte(`"5 minutes"`)))
+	assert.Equal(t, time.Duration(0), d.Duration)
+
+	_, err := ParseDuration([]byte(`"5 minutes"`))
+	assert.EqualError(t, err, `invalid duration "5 minutes"`)
+}
+
+func TestParseTimestamp(t *testing.T) {
+	tests := []struct {
+		timestamp string
+		format    string
+		expected  time.Time
+	}{
+		{"1536869008", "", time.Unix(1536869008, 0)},
+		{"1536869008.123", "unix", time.Unix(1536869008, 123000000)},
+		{"-1.5", "unix", time.Unix(-2, 500000000)},
+		{"1536869008123", "unix_ms", time.Unix(1536869008, 123000000)},
+		{"1536869008123456", "unix_us", time.Unix(1536869008, 123456000)},
+		{"1536869008123456789", "unix_ns", time.Unix(1536869008, 123456789)},
+		{"1536869008123456789.9", "unix_ns", time.Unix(1536869008, 123456789)},
+		{"1.536869008e9", "unix", time.Unix(1536869008, 0)},
+		{"2018-09-13T20:03:28Z", time.RFC3339, time.Unix(1536869008, 0)},
+	}
+	for _, tt := range tests {
+		ts, err := ParseTimestamp(tt.timestamp, tt.format)
+		assert.NoError(t, err, tt.timestamp)
+		assert.True(t, tt.expected.Equal(ts), "%s: %s != %s", tt.timestamp, tt.expected, ts)
+	}
+
+	for _, invalid := range []string{"", ".", "abc", "1.2.3", "--1", "99999999999999999999"} {
+		_, err := ParseTimestamp(invalid, "unix_ns")
+		assert.Error(t, err, invalid)
+	}
 }
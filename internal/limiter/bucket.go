@@ -0,0 +1,127 @@
+package limiter
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf/internal"
+)
+
+// RateLimit is the configuration of a named rate limiter, shared by the
+// plugins referencing its name.
+type RateLimit struct {
+	// Number of requests allowed every period
+	Limit int `toml:"limit,required"`
+	// Period of the limit, one second when unset
+	Period internal.Duration `toml:"period"`
+	// Number of requests allowed at once, one when unset
+	Burst int `toml:"burst"`
+}
+
+// TokenBucket returns the token bucket of the rate limit.
+func (r *RateLimit) TokenBucket() (*TokenBucket, error) {
+	period := r.Period.Duration
+	if period == 0 {
+		period = time.Second
+	}
+	if r.Limit <= 0 || period < 0 || r.Burst < 0 {
+		return nil, fmt.Errorf("invalid rate limit of %d requests every %s with a burst of %d",
+			r.Limit, period, r.Burst)
+	}
+	return NewTokenBucket(r.Limit, period, r.Burst), nil
+}
+
+// TokenBucket is a token bucket rate limiter allowing n requests every
+// period on average and burst requests at once.  It is safe for concurrent
+// use.
+type TokenBucket struct {
+	// interval between two tokens
+	interval time.Duration
+	burst    float64
+	now      func() time.Time
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// NewTokenBucket returns a full token bucket of n requests every period with
+// bursts of burst requests, bursts of one request when burst is 0.
+func NewTokenBucket(n int, period time.Duration, burst int) *TokenBucket {
+	if burst < 1 {
+		burst = 1
+	}
+	return &TokenBucket{
+		interval: period / time.Duration(n),
+		burst:    float64(burst),
+		tokens:   float64(burst),
+		now:      time.Now,
+	}
+}
+
+// refill adds the tokens accumulated since the last request, the caller
+// holds the lock.
+func (b *TokenBucket) refill(now time.Time) {
+	if !b.last.IsZero() && b.interval > 0 {
+		b.tokens += float64(now.Sub(b.last)) / float64(b.interval)
+	}
+	if b.tokens > b.burst || b.interval <= 0 {
+		b.tokens = b.burst
+	}
+	b.last = now
+}
+
+// Allow takes a token if one is available, false otherwise.
+func (b *TokenBucket) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill(b.now())
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// Reserve takes a token and returns the delay before it is available.
+func (b *TokenBucket) Reserve() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill(b.now())
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens * float64(b.interval))
+}
+
+// Wait blocks until a token is available and takes it.
+func (b *TokenBucket) Wait() {
+	if delay := b.Reserve(); delay > 0 {
+		time.Sleep(delay)
+	}
+}
+
+var (
+	namedMu sync.RWMutex
+	named   = make(map[string]*TokenBucket)
+)
+
+// SetNamed replaces the named rate limiters.
+func SetNamed(limiters map[string]*TokenBucket) {
+	namedMu.Lock()
+	defer namedMu.Unlock()
+	named = limiters
+}
+
+// Named returns the rate limiter of the given name.
+func Named(name string) (*TokenBucket, error) {
+	namedMu.RLock()
+	defer namedMu.RUnlock()
+	b, ok := named[name]
+	if !ok {
+		return nil, fmt.Errorf("undefined rate limiter %q", name)
+	}
+	return b, nil
+}
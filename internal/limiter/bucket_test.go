@@ -0,0 +1,56 @@
+package limiter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf/internal"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenBucket(t *testing.T) {
+	now := time.Now()
+	b := NewTokenBucket(10, time.Second, 2)
+	b.now = func() time.Time { return now }
+
+	require.True(t, b.Allow())
+	require.True(t, b.Allow())
+	require.False(t, b.Allow())
+
+	now = now.Add(100 * time.Millisecond)
+	require.True(t, b.Allow())
+	require.False(t, b.Allow())
+
+	// the bucket does not fill above the burst
+	now = now.Add(time.Hour)
+	require.Equal(t, time.Duration(0), b.Reserve())
+	require.Equal(t, time.Duration(0), b.Reserve())
+	require.Equal(t, 100*time.Millisecond, b.Reserve())
+	require.Equal(t, 200*time.Millisecond, b.Reserve())
+}
+
+func TestRateLimit(t *testing.T) {
+	r := &RateLimit{Limit: 60, Period: internal.Duration{Duration: time.Minute}}
+	b, err := r.TokenBucket()
+	require.NoError(t, err)
+	require.Equal(t, time.Second, b.interval)
+	require.Equal(t, float64(1), b.burst)
+
+	for _, r := range []*RateLimit{{}, {Limit: -1}, {Limit: 1, Burst: -1}} {
+		_, err := r.TokenBucket()
+		require.Error(t, err)
+	}
+}
+
+func TestNamed(t *testing.T) {
+	defer SetNamed(make(map[string]*TokenBucket))
+	b := NewTokenBucket(1, time.Second, 1)
+	SetNamed(map[string]*TokenBucket{"storage_api": b})
+
+	named, err := Named("storage_api")
+	require.NoError(t, err)
+	require.True(t, named == b)
+
+	_, err = Named("cloudwatch")
+	require.Error(t, err)
+}
@@ -0,0 +1,110 @@
+// Package linereader reads lines longer than the 64KB token limit of
+// bufio.Scanner, such as long JSON documents or command output lines.
+package linereader
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+)
+
+// DefaultMaxLineLength is the maximum line length of readers created with a
+// maximum of 0.
+const DefaultMaxLineLength = 16 * 1024 * 1024
+
+// Reader reads lines up to a maximum length.  Longer lines are truncated to
+// the maximum and reported, the rest of the line is discarded so reading
+// continues with the next line instead of failing.
+type Reader struct {
+	r             *bufio.Reader
+	maxLineLength int
+	line          int
+	err           error
+}
+
+func NewReader(r io.Reader, maxLineLength int) *Reader {
+	if maxLineLength <= 0 {
+		maxLineLength = DefaultMaxLineLength
+	}
+	bufSize := 64 * 1024
+	if maxLineLength < bufSize {
+		bufSize = maxLineLength
+	}
+	return &Reader{
+		r:             bufio.NewReaderSize(r, bufSize),
+		maxLineLength: maxLineLength,
+	}
+}
+
+// ReadLine returns the next line without the line ending, "\n" or "\r\n".
+// A last line without line ending is returned as well, the error is io.EOF
+// once all lines are read.  Truncated is true when the line was longer than the maximum.
+func (r *Reader) ReadLine() (line []byte, truncated bool, err error) {
+	if r.err != nil {
+		return nil, false, r.err
+	}
+
+	// room for the line ending beyond the maximum length
+	limit := r.maxLineLength + 2
+	var buf []byte
+	for {
+		chunk, err := r.r.ReadSlice('\n')
+		if n := limit - len(buf); len(chunk) > n {
+			chunk = chunk[:n]
+			truncated = true
+		}
+		buf = append(buf, chunk...)
+
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		if err != nil {
+			r.err = err
+			if len(buf) == 0 {
+				return nil, false, err
+			}
+		}
+		break
+	}
+
+	if !truncated {
+		buf = bytes.TrimSuffix(buf, []byte("\n"))
+		buf = bytes.TrimSuffix(buf, []byte("\r"))
+	}
+	if len(buf) > r.maxLineLength {
+		buf = buf[:r.maxLineLength]
+		truncated = true
+	}
+	r.line++
+	return buf, truncated, nil
+}
+
+// Line returns the number of the last line read, starting at 1.
+func (r *Reader) Line() int {
+	return r.line
+}
+
+// Err returns the error that stopped reading, nil at the end of the input.
+func (r *Reader) Err() error {
+	if r.err == io.EOF {
+		return nil
+	}
+	return r.err
+}
+
+// NewScanner returns a bufio.Scanner reading lines up to the maximum length,
+// for the callers that rather stop at longer lines.  As with bufio.ScanLines
+// the line endings, "\n" or "\r\n", are dropped.
+func NewScanner(r io.Reader, maxLineLength int) *bufio.Scanner {
+	if maxLineLength <= 0 {
+		maxLineLength = DefaultMaxLineLength
+	}
+	bufSize := 64 * 1024
+	if maxLineLength < bufSize {
+		bufSize = maxLineLength
+	}
+	scanner := bufio.NewScanner(r)
+	// the buffer holds the line ending as well
+	scanner.Buffer(make([]byte, 0, bufSize), maxLineLength+2)
+	return scanner
+}
@@ -0,0 +1,86 @@
+package linereader
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/require"
+)
+
+type line struct {
+	text      string
+	truncated bool
+}
+
+func readAll(t *testing.T, r *Reader) []line {
+	var lines []line
+	for {
+		text, truncated, err := r.ReadLine()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		lines = append(lines, line{string(text), truncated})
+	}
+	require.NoError(t, r.Err())
+	return lines
+}
+
+func TestReadLine(t *testing.T) {
+	r := NewReader(strings.NewReader("first\r\nsecond\n\nlast"), 0)
+	require.Equal(t, []line{
+		{"first", false},
+		{"second", false},
+		{"", false},
+		{"last", false},
+	}, readAll(t, r))
+	require.Equal(t, 4, r.Line())
+}
+
+func TestReadLineLongerThanBuffer(t *testing.T) {
+	long := strings.Repeat("0123456789", 20000)
+	r := NewReader(strings.NewReader(long+"\r\n"+long+"\n"), 0)
+	require.Equal(t, []line{{long, false}, {long, false}}, readAll(t, r))
+}
+
+func TestReadLineTruncated(t *testing.T) {
+	input := "12345678\r\n" + "123456789\n" + strings.Repeat("x", 100) + "\r\n" + "next"
+	// one byte at a time, so the lines span several reads
+	r := NewReader(iotest.OneByteReader(strings.NewReader(input)), 8)
+	require.Equal(t, []line{
+		{"12345678", false},
+		{"12345678", true},
+		{"xxxxxxxx", true},
+		{"next", false},
+	}, readAll(t, r))
+}
+
+func TestReadLineError(t *testing.T) {
+	// the second read times out, the partial line is returned first
+	r := NewReader(iotest.TimeoutReader(strings.NewReader("partial")), 0)
+	text, truncated, err := r.ReadLine()
+	require.NoError(t, err)
+	require.False(t, truncated)
+	require.Equal(t, "partial", string(text))
+
+	_, _, err = r.ReadLine()
+	require.Equal(t, iotest.ErrTimeout, err)
+	require.Equal(t, iotest.ErrTimeout, r.Err())
+}
+
+func TestNewScanner(t *testing.T) {
+	long := strings.Repeat("x", 100*1024)
+	scanner := NewScanner(strings.NewReader(long+"\r\nshort\n"), 200*1024)
+	require.True(t, scanner.Scan())
+	require.Equal(t, long, scanner.Text())
+	require.True(t, scanner.Scan())
+	require.Equal(t, "short", scanner.Text())
+	require.False(t, scanner.Scan())
+	require.NoError(t, scanner.Err())
+
+	scanner = NewScanner(strings.NewReader(long+"\n"), 1024)
+	require.False(t, scanner.Scan())
+	require.Error(t, scanner.Err())
+}
@@ -15,6 +15,9 @@ type RunningAggregator struct {
 
 	periodStart time.Time
 	periodEnd   time.Time
+
+	// window holds the metrics of the current period when sliding.
+	window []telegraf.Metric
 }
 
 func NewRunningAggregator(
@@ -42,6 +45,10 @@ type AggregatorConfig struct {
 
 	Period time.Duration
 	Delay  time.Duration
+	// Slide when set to less than Period makes the aggregation windows
+	// overlap: every Slide the aggregator is pushed with the metrics of the
+	// last Period.
+	Slide time.Duration
 }
 
 func (r *RunningAggregator) Name() string {
@@ -137,6 +144,12 @@ func (r *RunningAggregator) Run(
 	truncation := now.Sub(r.periodStart)
 	r.periodEnd = r.periodStart.Add(r.Config.Period)
 	time.Sleep(r.Config.Delay)
+
+	if r.sliding() {
+		r.runSliding(acc, shutdown, truncation)
+		return
+	}
+
 	periodT := time.NewTicker(r.Config.Period)
 	defer periodT.Stop()
 
@@ -164,3 +177,71 @@ func (r *RunningAggregator) Run(
 		}
 	}
 }
+
+func (r *RunningAggregator) sliding() bool {
+	return r.Config.Slide > 0
+}
+
+// runSliding is the counterpart of Run for sliding windows. The metrics of
+// the current period are kept in a window and, every slide, the window is
+// advanced and the aggregator is fed the metrics it still contains, pushed,
+// and reset.
+//
+// So if we start at 00:00 with a 30s period and a 10s slide:
+// 1st push: 00:00 - 00:30
+// 2nd push: 00:10 - 00:40
+// etc.
+func (r *RunningAggregator) runSliding(
+	acc telegraf.Accumulator,
+	shutdown chan struct{},
+	truncation time.Duration,
+) {
+	r.periodEnd = r.periodStart.Add(r.Config.Slide)
+	r.periodStart = r.periodEnd.Add(-r.Config.Period)
+	slideT := time.NewTicker(r.Config.Slide)
+	defer slideT.Stop()
+
+	for {
+		select {
+		case <-shutdown:
+			if len(r.metrics) > 0 {
+				// wait until metrics are flushed before exiting
+				continue
+			}
+			return
+		case m := <-r.metrics:
+			if m.Time().Before(r.periodStart) ||
+				m.Time().After(r.periodEnd.Add(truncation).Add(r.Config.Delay)) {
+				// the metric is outside the current aggregation period, so
+				// skip it.
+				continue
+			}
+			r.window = append(r.window, m)
+		case <-slideT.C:
+			for _, m := range r.window {
+				r.add(m)
+			}
+			r.push(acc)
+			r.reset()
+
+			r.periodEnd = r.periodEnd.Add(r.Config.Slide)
+			r.periodStart = r.periodEnd.Add(-r.Config.Period)
+			r.expire()
+		}
+	}
+}
+
+// expire drops the metrics that have fallen out of the window.
+func (r *RunningAggregator) expire() {
+	n := 0
+	for _, m := range r.window {
+		if !m.Time().Before(r.periodStart) {
+			r.window[n] = m
+			n++
+		}
+	}
+	for i := n; i < len(r.window); i++ {
+		r.window[i] = nil
+	}
+	r.window = r.window[:n]
+}
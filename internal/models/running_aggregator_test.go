@@ -136,6 +136,72 @@ func TestAddAndPushOnePeriod(t *testing.T) {
 	wg.Wait()
 }
 
+func TestAddAndPushSlidingWindow(t *testing.T) {
+	a := &TestAggregator{}
+	ra := NewRunningAggregator(a, &AggregatorConfig{
+		Name: "TestRunningAggregator",
+		Filter: Filter{
+			NamePass: []string{"*"},
+		},
+		Period: time.Millisecond * 900,
+		Slide:  time.Millisecond * 300,
+	})
+	assert.NoError(t, ra.Config.Filter.Compile())
+	acc := testutil.Accumulator{}
+	shutdown := make(chan struct{})
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ra.Run(&acc, shutdown)
+	}()
+
+	m := ra.MakeMetric(
+		"RITest",
+		map[string]interface{}{"value": int(101)},
+		map[string]string{},
+		telegraf.Untyped,
+		time.Now().Add(time.Millisecond*100),
+	)
+	assert.False(t, ra.Add(m))
+
+	// the metric is part of every window until it has slid out of the period
+	acc.Wait(2)
+	acc.Lock()
+	for _, m := range acc.Metrics[:2] {
+		assert.Equal(t, int64(101), m.Fields["sum"])
+	}
+	acc.Unlock()
+
+	close(shutdown)
+	wg.Wait()
+}
+
+func TestSlidingWindowExpire(t *testing.T) {
+	ra := NewRunningAggregator(&TestAggregator{}, &AggregatorConfig{
+		Name:   "TestRunningAggregator",
+		Period: time.Second * 30,
+		Slide:  time.Second * 10,
+	})
+
+	now := time.Now()
+	for _, ts := range []time.Time{now.Add(-time.Minute), now, now.Add(-time.Hour)} {
+		ra.window = append(ra.window, ra.MakeMetric(
+			"RITest",
+			map[string]interface{}{"value": int(101)},
+			map[string]string{},
+			telegraf.Untyped,
+			ts,
+		))
+	}
+	ra.periodStart = now.Add(-time.Second)
+
+	ra.expire()
+	assert.Len(t, ra.window, 1)
+	assert.Equal(t, now, ra.window[0].Time())
+}
+
 func TestAddDropOriginal(t *testing.T) {
 	ra := NewRunningAggregator(&TestAggregator{}, &AggregatorConfig{
 		Name: "TestRunningAggregator",
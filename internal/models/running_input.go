@@ -21,6 +21,19 @@ type RunningInput struct {
 	MetricsGathered selfstat.Stat
 }
 
+// ServiceInput returns the input as a service input, false if it is not one.
+// The inputs running as a service only in some configurations, such as the
+// streaming commands of exec, report it with IsService.
+func ServiceInput(input telegraf.Input) (telegraf.ServiceInput, bool) {
+	service, ok := input.(telegraf.ServiceInput)
+	if s, optional := input.(interface {
+		IsService() bool
+	}); ok && optional && !s.IsService() {
+		return nil, false
+	}
+	return service, ok
+}
+
 func NewRunningInput(
 	input telegraf.Input,
 	config *InputConfig,
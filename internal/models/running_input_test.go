@@ -221,8 +221,26 @@ func TestMakeMetricNameSuffix(t *testing.T) {
 	require.Equal(t, expected, m)
 }
 
+func TestServiceInput(t *testing.T) {
+	_, ok := ServiceInput(&testInput{})
+	require.False(t, ok)
+	_, ok = ServiceInput(&testServiceInput{service: true})
+	require.True(t, ok)
+	_, ok = ServiceInput(&testServiceInput{service: false})
+	require.False(t, ok)
+}
+
 type testInput struct{}
 
 func (t *testInput) Description() string                   { return "" }
 func (t *testInput) SampleConfig() string                  { return "" }
 func (t *testInput) Gather(acc telegraf.Accumulator) error { return nil }
+
+type testServiceInput struct {
+	testInput
+	service bool
+}
+
+func (t *testServiceInput) Start(acc telegraf.Accumulator) error { return nil }
+func (t *testServiceInput) Stop()                                {}
+func (t *testServiceInput) IsService() bool                      { return t.service }
@@ -7,6 +7,7 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal/buffer"
+	"github.com/influxdata/telegraf/internal/retry"
 	"github.com/influxdata/telegraf/metric"
 	"github.com/influxdata/telegraf/selfstat"
 )
@@ -35,6 +36,7 @@ type RunningOutput struct {
 
 	metrics     *buffer.Buffer
 	failMetrics *buffer.Buffer
+	retry       *retry.Schedule
 
 	// Guards against concurrent calls to the Output as described in #3009
 	sync.Mutex
@@ -59,6 +61,7 @@ func NewRunningOutput(
 		failMetrics:       buffer.NewBuffer(bufferLimit),
 		Output:            output,
 		Config:            conf,
+		retry:             retry.NewSchedule(conf.Retry),
 		MetricBufferLimit: bufferLimit,
 		MetricBatchSize:   batchSize,
 		MetricsWritten: selfstat.Register(
@@ -81,7 +84,7 @@ func NewRunningOutput(
 			"buffer_limit",
 			map[string]string{"output": name},
 		),
-		WriteTime: selfstat.RegisterTiming(
+		WriteTime: selfstat.RegisterHistogram(
 			"write",
 			"write_time_ns",
 			map[string]string{"output": name},
@@ -116,6 +119,10 @@ func (ro *RunningOutput) AddMetric(m telegraf.Metric) {
 	ro.metrics.Add(m)
 	if ro.metrics.Len() == ro.MetricBatchSize {
 		batch := ro.metrics.Batch(ro.MetricBatchSize)
+		if !ro.retry.Ready(time.Now()) {
+			ro.failMetrics.Add(batch...)
+			return
+		}
 		err := ro.write(batch)
 		if err != nil {
 			ro.failMetrics.Add(batch...)
@@ -129,6 +136,11 @@ func (ro *RunningOutput) Write() error {
 	ro.BufferSize.Set(int64(nFails + nMetrics))
 	log.Printf("D! Output [%s] buffer fullness: %d / %d metrics. ",
 		ro.Name, nFails+nMetrics, ro.MetricBufferLimit)
+	if !ro.retry.Ready(time.Now()) {
+		log.Printf("D! Output [%s] backing off until %s\n",
+			ro.Name, ro.retry.Next().Format(time.RFC3339))
+		return nil
+	}
 	var err error
 	if !ro.failMetrics.IsEmpty() {
 		// how many batches of failed writes we need to write.
@@ -183,12 +195,35 @@ func (ro *RunningOutput) write(metrics []telegraf.Metric) error {
 			ro.Name, nMetrics, elapsed)
 		ro.MetricsWritten.Incr(int64(nMetrics))
 		ro.WriteTime.Incr(elapsed.Nanoseconds())
+		ro.retry.Success()
+		return nil
+	}
+
+	// Retrying a permanent error would fail again, the batch is dropped
+	// when the output is configured to, otherwise it is retried as the
+	// batches of the other errors
+	delay, ok := ro.retry.Failure(err, time.Now())
+	if !ok && ro.Config.DropRejected {
+		buffer.MetricsDropped.Incr(int64(nMetrics))
+		ro.retry.Success()
+		log.Printf("E! Output [%s] dropped batch of %d metrics rejected by the output: %s\n",
+			ro.Name, nMetrics, err)
+		return nil
+	}
+	if delay > 0 {
+		log.Printf("D! Output [%s] write failed, retrying in %s\n",
+			ro.Name, delay)
 	}
 	return err
 }
 
-// OutputConfig containing name and filter
+// OutputConfig containing name, filter and retry policy
 type OutputConfig struct {
 	Name   string
 	Filter Filter
+	// Retry is the retry policy of the failed writes
+	Retry retry.Policy
+	// DropRejected drops the batches failing with a permanent error, such as
+	// metrics rejected by the server, instead of retrying them
+	DropRejected bool
 }
@@ -1,11 +1,15 @@
 package models
 
 import (
+	"errors"
 	"fmt"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal/buffer"
+	"github.com/influxdata/telegraf/internal/retry"
 	"github.com/influxdata/telegraf/testutil"
 
 	"github.com/stretchr/testify/assert"
@@ -330,6 +334,87 @@ func TestRunningOutputWriteFail(t *testing.T) {
 	assert.Len(t, m.Metrics(), 10)
 }
 
+// Verify that a failing output is not written to again before the backoff
+// delay and keeps its metrics.
+func TestRunningOutputWriteFailBackoff(t *testing.T) {
+	conf := &OutputConfig{
+		Filter: Filter{},
+		Retry: retry.Policy{
+			Backoff: retry.Backoff{Initial: time.Hour},
+		},
+	}
+
+	m := &mockOutput{}
+	m.failWrite = true
+	ro := NewRunningOutput("test", m, conf, 100, 1000)
+
+	for _, metric := range first5 {
+		ro.AddMetric(metric)
+	}
+	require.Error(t, ro.Write())
+	assert.False(t, ro.retry.Ready(time.Now()))
+
+	// backing off, the output is not written to
+	m.failWrite = false
+	require.NoError(t, ro.Write())
+	assert.Len(t, m.Metrics(), 0)
+
+	// once the delay is over, the buffered metrics are written
+	ro.retry.Success()
+	require.NoError(t, ro.Write())
+	assert.Equal(t, first5, m.Metrics())
+}
+
+// Verify that the metrics of permanent write failures are retried, unless
+// the output drops them.
+func TestRunningOutputWritePermanentFail(t *testing.T) {
+	conf := &OutputConfig{
+		Filter: Filter{},
+	}
+
+	m := &mockOutput{}
+	m.failWrite = true
+	m.failErr = retry.NewPermanent(errors.New("unable to parse"))
+	ro := NewRunningOutput("test", m, conf, 100, 1000)
+
+	for _, metric := range first5 {
+		ro.AddMetric(metric)
+	}
+	require.Error(t, ro.Write())
+
+	m.failWrite = false
+	require.NoError(t, ro.Write())
+	assert.Equal(t, first5, m.Metrics())
+}
+
+// Verify that the metrics of permanent write failures are dropped and
+// counted when the output drops rejected metrics.
+func TestRunningOutputWritePermanentFailDrop(t *testing.T) {
+	conf := &OutputConfig{
+		Filter:       Filter{},
+		DropRejected: true,
+	}
+
+	m := &mockOutput{}
+	m.failWrite = true
+	m.failErr = retry.NewPermanent(errors.New("unable to parse"))
+	ro := NewRunningOutput("test", m, conf, 100, 1000)
+
+	dropped := buffer.MetricsDropped.Get()
+	for _, metric := range first5 {
+		ro.AddMetric(metric)
+	}
+	require.NoError(t, ro.Write())
+	assert.Equal(t, dropped+5, buffer.MetricsDropped.Get())
+
+	m.failWrite = false
+	for _, metric := range next5 {
+		ro.AddMetric(metric)
+	}
+	require.NoError(t, ro.Write())
+	assert.Equal(t, next5, m.Metrics())
+}
+
 // Verify that the order of points is preserved during a write failure.
 func TestRunningOutputWriteFailOrder(t *testing.T) {
 	conf := &OutputConfig{
@@ -486,6 +571,8 @@ type mockOutput struct {
 
 	// if true, mock a write failure
 	failWrite bool
+	// error of the write failures, if unset a temporary error
+	failErr error
 }
 
 func (m *mockOutput) Connect() error {
@@ -508,6 +595,9 @@ func (m *mockOutput) Write(metrics []telegraf.Metric) error {
 	m.Lock()
 	defer m.Unlock()
 	if m.failWrite {
+		if m.failErr != nil {
+			return m.failErr
+		}
 		return fmt.Errorf("Failed Write!")
 	}
 
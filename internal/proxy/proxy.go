@@ -0,0 +1,193 @@
+// Package proxy selects the proxy of the requests of HTTP plugins.  Plugins
+// use their own proxy, otherwise the proxy of the agent, otherwise the proxy
+// of the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables.
+package proxy
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// Config is the proxy configuration of a plugin, plugins embed it.
+type Config struct {
+	// Proxy URL, the schemes are http and https for HTTP CONNECT proxies and
+	// socks5 for SOCKS5 proxies
+	HTTPProxy string `toml:"http_proxy"`
+	// Hosts, domains and networks reached without proxy
+	NoProxy []string `toml:"no_proxy"`
+}
+
+var (
+	mu sync.RWMutex
+	// proxy of the agent, used by the plugins without their own proxy
+	defaultProxy func(*http.Request) (*url.URL, error)
+)
+
+// SetDefault sets the proxy used by the plugins without their own proxy, the
+// proxy of the environment is used when proxyURL is empty.
+func SetDefault(proxyURL string, noProxy []string) error {
+	proxy, err := newProxyFunc(proxyURL, noProxy)
+	if err != nil {
+		return err
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	defaultProxy = proxy
+	return nil
+}
+
+// Default is the proxy function of the plugins without proxy options,
+// for http.Transport.Proxy.
+func Default(req *http.Request) (*url.URL, error) {
+	mu.RLock()
+	proxy := defaultProxy
+	mu.RUnlock()
+	if proxy == nil {
+		return http.ProxyFromEnvironment(req)
+	}
+	return proxy(req)
+}
+
+// Proxy returns the proxy function of the configuration for
+// http.Transport.Proxy, the default proxy when no proxy is set.
+func (c *Config) Proxy() (func(*http.Request) (*url.URL, error), error) {
+	if c.HTTPProxy == "" {
+		if len(c.NoProxy) == 0 {
+			return Default, nil
+		}
+		// exclude the hosts of the plugin from the default proxy
+		bypass, err := newMatcher(c.NoProxy)
+		if err != nil {
+			return nil, err
+		}
+		return func(req *http.Request) (*url.URL, error) {
+			if bypass.match(req.URL) {
+				return nil, nil
+			}
+			return Default(req)
+		}, nil
+	}
+	return newProxyFunc(c.HTTPProxy, c.NoProxy)
+}
+
+func newProxyFunc(proxyURL string, noProxy []string) (func(*http.Request) (*url.URL, error), error) {
+	if proxyURL == "" {
+		return nil, nil
+	}
+	u, err := url.Parse(proxyURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid http_proxy %q: %v", proxyURL, err)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5":
+	default:
+		return nil, fmt.Errorf("invalid http_proxy %q: unsupported scheme %q", proxyURL, u.Scheme)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("invalid http_proxy %q: missing host", proxyURL)
+	}
+
+	bypass, err := newMatcher(noProxy)
+	if err != nil {
+		return nil, err
+	}
+	return func(req *http.Request) (*url.URL, error) {
+		if bypass.match(req.URL) {
+			return nil, nil
+		}
+		return u, nil
+	}, nil
+}
+
+// matcher matches the hosts of the no_proxy entries: "*" for all hosts,
+// domains with their subdomains, IP addresses and networks in CIDR notation,
+// optionally with a port.
+type matcher struct {
+	all      bool
+	domains  []hostPort
+	ips      []hostPort
+	networks []*net.IPNet
+}
+
+type hostPort struct {
+	host string
+	port string
+}
+
+func newMatcher(noProxy []string) (*matcher, error) {
+	m := &matcher{}
+	for _, entry := range noProxy {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		switch {
+		case entry == "":
+			continue
+		case entry == "*":
+			m.all = true
+			continue
+		}
+
+		if _, network, err := net.ParseCIDR(entry); err == nil {
+			m.networks = append(m.networks, network)
+			continue
+		}
+
+		host, port := entry, ""
+		if h, p, err := net.SplitHostPort(entry); err == nil {
+			host, port = h, p
+		}
+		if ip := net.ParseIP(host); ip != nil {
+			m.ips = append(m.ips, hostPort{ip.String(), port})
+			continue
+		}
+		host = strings.TrimPrefix(strings.TrimPrefix(host, "*"), ".")
+		if host == "" {
+			return nil, fmt.Errorf("invalid no_proxy entry %q", entry)
+		}
+		m.domains = append(m.domains, hostPort{host, port})
+	}
+	return m, nil
+}
+
+func (m *matcher) match(u *url.URL) bool {
+	if m.all {
+		return true
+	}
+
+	host, port := strings.ToLower(u.Hostname()), u.Port()
+	if port == "" {
+		switch u.Scheme {
+		case "https":
+			port = "443"
+		case "http":
+			port = "80"
+		}
+	}
+
+	if ip := net.ParseIP(host); ip != nil {
+		for _, network := range m.networks {
+			if network.Contains(ip) {
+				return true
+			}
+		}
+		for _, entry := range m.ips {
+			if entry.host == ip.String() && (entry.port == "" || entry.port == port) {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, entry := range m.domains {
+		if entry.port != "" && entry.port != port {
+			continue
+		}
+		if host == entry.host || strings.HasSuffix(host, "."+entry.host) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,80 @@
+package proxy
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func proxyOf(t *testing.T, proxy func(*http.Request) (*url.URL, error), rawurl string) string {
+	req, err := http.NewRequest("GET", rawurl, nil)
+	require.NoError(t, err)
+	u, err := proxy(req)
+	require.NoError(t, err)
+	if u == nil {
+		return ""
+	}
+	return u.String()
+}
+
+func TestProxy(t *testing.T) {
+	c := &Config{
+		HTTPProxy: "socks5://proxy.example.com:1080",
+		NoProxy:   []string{"localhost", ".internal.example.com", "10.0.0.0/8", "192.168.1.1", "example.org:8443"},
+	}
+	proxy, err := c.Proxy()
+	require.NoError(t, err)
+
+	tests := []struct {
+		url   string
+		proxy string
+	}{
+		{"http://influxdb.example.com:8086/write", "socks5://proxy.example.com:1080"},
+		{"http://localhost:8086/write", ""},
+		{"https://api.internal.example.com/v1", ""},
+		{"https://internal.example.com/v1", ""},
+		{"https://notinternal.example.com/v1", "socks5://proxy.example.com:1080"},
+		{"http://10.1.2.3/", ""},
+		{"http://192.168.1.1/", ""},
+		{"http://192.168.1.2/", "socks5://proxy.example.com:1080"},
+		{"https://example.org:8443/", ""},
+		{"https://example.org/", "socks5://proxy.example.com:1080"},
+	}
+	for _, tt := range tests {
+		require.Equal(t, tt.proxy, proxyOf(t, proxy, tt.url), tt.url)
+	}
+}
+
+func TestProxyInvalid(t *testing.T) {
+	for _, proxyURL := range []string{"ftp://proxy:21", "http://", "://proxy"} {
+		c := &Config{HTTPProxy: proxyURL}
+		_, err := c.Proxy()
+		require.Error(t, err, proxyURL)
+	}
+}
+
+func TestDefault(t *testing.T) {
+	defer SetDefault("", nil)
+	require.NoError(t, SetDefault("http://agent.proxy:3128", []string{"*.local"}))
+
+	c := &Config{}
+	proxy, err := c.Proxy()
+	require.NoError(t, err)
+	require.Equal(t, "http://agent.proxy:3128", proxyOf(t, proxy, "http://example.com/"))
+	require.Equal(t, "", proxyOf(t, proxy, "http://telegraf.local/"))
+
+	// the plugin excludes hosts from the default proxy
+	c = &Config{NoProxy: []string{"example.com"}}
+	proxy, err = c.Proxy()
+	require.NoError(t, err)
+	require.Equal(t, "", proxyOf(t, proxy, "http://example.com/"))
+	require.Equal(t, "http://agent.proxy:3128", proxyOf(t, proxy, "http://example.net/"))
+
+	// the proxy of the plugin overrides the default
+	c = &Config{HTTPProxy: "http://plugin.proxy:8080"}
+	proxy, err = c.Proxy()
+	require.NoError(t, err)
+	require.Equal(t, "http://plugin.proxy:8080", proxyOf(t, proxy, "http://example.com/"))
+}
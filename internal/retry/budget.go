@@ -0,0 +1,45 @@
+package retry
+
+import (
+	"sync"
+	"time"
+)
+
+// Budget limits the number of retries in a period, so that operations
+// failing together do not overload a recovering server with their retries.
+// It is safe for concurrent use.
+type Budget struct {
+	retries int
+	period  time.Duration
+	now     func() time.Time
+
+	mu    sync.Mutex
+	start time.Time
+	spent int
+}
+
+// NewBudget returns a budget of n retries every period.
+func NewBudget(n int, period time.Duration) *Budget {
+	return &Budget{
+		retries: n,
+		period:  period,
+		now:     time.Now,
+	}
+}
+
+// Allow spends one retry of the budget, false when the budget of the
+// current period is spent.
+func (b *Budget) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := b.now()
+	if now.Sub(b.start) >= b.period {
+		b.start = now
+		b.spent = 0
+	}
+	if b.spent >= b.retries {
+		return false
+	}
+	b.spent++
+	return true
+}
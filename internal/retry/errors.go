@@ -0,0 +1,139 @@
+package retry
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Class is the class of an error, which selects its retry policy.
+type Class int
+
+const (
+	// Temporary errors, such as connection failures, are retried with
+	// backoff.
+	Temporary Class = iota
+	// Throttled errors are retried once the server accepts requests again.
+	Throttled
+	// Permanent errors, such as rejected data, fail again on retry.
+	Permanent
+)
+
+func (c Class) String() string {
+	switch c {
+	case Throttled:
+		return "throttled"
+	case Permanent:
+		return "permanent"
+	}
+	return "temporary"
+}
+
+// Errors may implement these interfaces to select their class, the other
+// errors are temporary.
+type permanent interface {
+	Permanent() bool
+}
+
+type throttled interface {
+	RetryAfter() time.Duration
+}
+
+// Classify returns the class of err.
+func Classify(err error) Class {
+	if e, ok := err.(permanent); ok && e.Permanent() {
+		return Permanent
+	}
+	if e, ok := err.(throttled); ok && e.RetryAfter() > 0 {
+		return Throttled
+	}
+	return Temporary
+}
+
+// RetryAfter returns the delay asked by the server before the retry of err,
+// 0 when unknown.
+func RetryAfter(err error) time.Duration {
+	if e, ok := err.(throttled); ok {
+		return e.RetryAfter()
+	}
+	return 0
+}
+
+type classError struct {
+	err        error
+	permanent  bool
+	retryAfter time.Duration
+}
+
+func (e *classError) Error() string {
+	return e.err.Error()
+}
+
+func (e *classError) Permanent() bool {
+	return e.permanent
+}
+
+func (e *classError) RetryAfter() time.Duration {
+	return e.retryAfter
+}
+
+// NewPermanent marks err as permanent.
+func NewPermanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &classError{err: err, permanent: true}
+}
+
+// NewThrottled marks err as throttled, to be retried after the given delay.
+func NewThrottled(err error, after time.Duration) error {
+	if err == nil {
+		return nil
+	}
+	return &classError{err: err, retryAfter: after}
+}
+
+// StatusError returns the error of the unsuccessful HTTP response resp
+// described by msg, classified by its status code:
+//   - 429 Too Many Requests and 503 Service Unavailable are throttled when
+//     the response has a Retry-After header,
+//   - the status codes of malformed or too large requests are permanent,
+//   - the other status codes, such as authentication failures which may be
+//     fixed server side, are temporary.
+func StatusError(resp *http.Response, msg string) error {
+	err := errors.New(msg)
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		after := ParseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+		if after > 0 {
+			return NewThrottled(err, after)
+		}
+	case http.StatusBadRequest, http.StatusRequestEntityTooLarge,
+		http.StatusUnsupportedMediaType, http.StatusUnprocessableEntity:
+		return NewPermanent(err)
+	}
+	return err
+}
+
+// ParseRetryAfter parses the value of a Retry-After header, a delay in
+// seconds or an HTTP date, and returns the delay from now; 0 when the value is
+// invalid or in the past.
+func ParseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	t, err := http.ParseTime(value)
+	if err != nil || !t.After(now) {
+		return 0
+	}
+	return t.Sub(now)
+}
@@ -0,0 +1,178 @@
+// Package retry retries failed operations with exponential backoff, jitter,
+// retry budgets and policies by class of error.
+package retry
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+const defaultMultiplier = 2
+
+// Backoff computes the delay before a retry, the delay grows exponentially
+// from Initial up to Max.  The delay is reduced by a random fraction of up to
+// Jitter so that the clients failing together do not retry together.
+type Backoff struct {
+	Initial    time.Duration
+	Max        time.Duration
+	Multiplier float64
+	Jitter     float64
+}
+
+// Delay returns the delay before the retry following the given number of
+// consecutive failures.
+func (b *Backoff) Delay(failures int) time.Duration {
+	if b.Initial <= 0 || failures <= 0 {
+		return 0
+	}
+	multiplier := b.Multiplier
+	if multiplier < 1 {
+		multiplier = defaultMultiplier
+	}
+
+	delay := float64(b.Initial)
+	for i := 1; i < failures; i++ {
+		delay *= multiplier
+		if b.Max > 0 && delay >= float64(b.Max) {
+			break
+		}
+	}
+	if b.Max > 0 && delay > float64(b.Max) {
+		delay = float64(b.Max)
+	}
+	if b.Jitter > 0 {
+		jitter := b.Jitter
+		if jitter > 1 {
+			jitter = 1
+		}
+		delay -= delay * jitter * rand.Float64()
+	}
+	return time.Duration(delay)
+}
+
+// Policy is the retry policy of an operation:
+//   - permanent errors are not retried,
+//   - throttled errors are retried after the delay asked by the server, or the
+//     backoff delay when longer, without using the budget,
+//   - temporary errors are retried after the backoff delay while the budget and
+//     MaxAttempts allow.
+type Policy struct {
+	Backoff
+	// Maximum number of attempts, including the first one, unlimited when 0
+	MaxAttempts int
+	// Budget shared by the operations retrying together, unlimited when nil
+	Budget *Budget
+	// Notify is called before waiting for each retry
+	Notify func(err error, delay time.Duration)
+}
+
+// Do calls fn until it succeeds, returns a permanent error, the policy allows
+// no more retries or shutdown is closed; the last error is returned.
+func (p *Policy) Do(shutdown <-chan struct{}, fn func() error) error {
+	for failures := 1; ; failures++ {
+		err := fn()
+		if err == nil {
+			return nil
+		}
+		delay, ok := p.next(err, failures)
+		if !ok {
+			return err
+		}
+		if p.Notify != nil {
+			p.Notify(err, delay)
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-shutdown:
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+// next returns the delay before the retry of err, false when err is not
+// retried.
+func (p *Policy) next(err error, failures int) (time.Duration, bool) {
+	delay := p.Backoff.Delay(failures)
+	switch Classify(err) {
+	case Permanent:
+		return 0, false
+	case Throttled:
+		if after := RetryAfter(err); after > delay {
+			delay = after
+		}
+		return delay, true
+	}
+	if p.MaxAttempts > 0 && failures >= p.MaxAttempts {
+		return 0, false
+	}
+	if p.Budget != nil && !p.Budget.Allow() {
+		return 0, false
+	}
+	return delay, true
+}
+
+// Schedule tracks the failures of an operation retried by its caller, such
+// as the writes of an output retried on the next flush.  It is safe for
+// concurrent use.
+type Schedule struct {
+	Policy Policy
+
+	mu       sync.Mutex
+	failures int
+	next     time.Time
+}
+
+// NewSchedule returns the schedule of the policy.
+func NewSchedule(policy Policy) *Schedule {
+	return &Schedule{Policy: policy}
+}
+
+// Ready reports whether the operation may be attempted at now.
+func (s *Schedule) Ready(now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return !now.Before(s.next)
+}
+
+// Next returns the time of the next attempt, the zero time after a success.
+func (s *Schedule) Next() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.next
+}
+
+// Failure records the failure of an attempt at now and returns the delay
+// before the next attempt; false when err is permanent and the attempt would
+// fail again, the caller drops the operation or retries it after the delay.
+// Once MaxAttempts is reached or the budget is spent, the next attempt is
+// delayed by the maximum backoff.
+func (s *Schedule) Failure(err error, now time.Time) (time.Duration, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.failures++
+	if Classify(err) == Permanent {
+		delay := s.Policy.Backoff.Delay(s.failures)
+		s.next = now.Add(delay)
+		return delay, false
+	}
+
+	delay, ok := s.Policy.next(err, s.failures)
+	if !ok {
+		delay = s.Policy.Max
+	}
+	s.next = now.Add(delay)
+	return delay, true
+}
+
+// Success records the success of an attempt.
+func (s *Schedule) Success() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures = 0
+	s.next = time.Time{}
+}
@@ -0,0 +1,181 @@
+package retry
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackoffDelay(t *testing.T) {
+	b := &Backoff{Initial: time.Second, Max: 10 * time.Second}
+	require.Equal(t, time.Duration(0), b.Delay(0))
+	require.Equal(t, time.Second, b.Delay(1))
+	require.Equal(t, 2*time.Second, b.Delay(2))
+	require.Equal(t, 8*time.Second, b.Delay(4))
+	require.Equal(t, 10*time.Second, b.Delay(5))
+	require.Equal(t, 10*time.Second, b.Delay(1000))
+
+	b = &Backoff{Initial: time.Second, Max: 10 * time.Second, Multiplier: 3}
+	require.Equal(t, 9*time.Second, b.Delay(3))
+
+	require.Equal(t, time.Duration(0), (&Backoff{}).Delay(3))
+}
+
+func TestBackoffJitter(t *testing.T) {
+	b := &Backoff{Initial: 4 * time.Second, Jitter: 0.5}
+	for i := 0; i < 100; i++ {
+		delay := b.Delay(1)
+		require.True(t, delay > 2*time.Second && delay <= 4*time.Second, delay)
+	}
+}
+
+func TestClassify(t *testing.T) {
+	err := errors.New("failed")
+	require.Equal(t, Temporary, Classify(err))
+	require.Equal(t, Permanent, Classify(NewPermanent(err)))
+	require.Equal(t, Throttled, Classify(NewThrottled(err, time.Second)))
+	require.Equal(t, time.Second, RetryAfter(NewThrottled(err, time.Second)))
+	require.Equal(t, "failed", NewPermanent(err).Error())
+	require.Nil(t, NewPermanent(nil))
+}
+
+func TestStatusError(t *testing.T) {
+	tests := []struct {
+		code       int
+		retryAfter string
+		class      Class
+	}{
+		{http.StatusInternalServerError, "", Temporary},
+		{http.StatusUnauthorized, "", Temporary},
+		{http.StatusBadRequest, "", Permanent},
+		{http.StatusRequestEntityTooLarge, "", Permanent},
+		{http.StatusTooManyRequests, "", Temporary},
+		{http.StatusTooManyRequests, "120", Throttled},
+		{http.StatusServiceUnavailable, "10", Throttled},
+	}
+	for _, tt := range tests {
+		resp := &http.Response{StatusCode: tt.code, Header: http.Header{}}
+		if tt.retryAfter != "" {
+			resp.Header.Set("Retry-After", tt.retryAfter)
+		}
+		err := StatusError(resp, "received status code")
+		require.EqualError(t, err, "received status code")
+		require.Equal(t, tt.class, Classify(err), http.StatusText(tt.code))
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2018, 5, 1, 12, 0, 0, 0, time.UTC)
+	require.Equal(t, 120*time.Second, ParseRetryAfter("120", now))
+	require.Equal(t, 30*time.Second, ParseRetryAfter("Tue, 01 May 2018 12:00:30 GMT", now))
+	require.Equal(t, time.Duration(0), ParseRetryAfter("Tue, 01 May 2018 11:00:00 GMT", now))
+	require.Equal(t, time.Duration(0), ParseRetryAfter("-1", now))
+	require.Equal(t, time.Duration(0), ParseRetryAfter("soon", now))
+	require.Equal(t, time.Duration(0), ParseRetryAfter("", now))
+}
+
+func TestPolicyDo(t *testing.T) {
+	var delays []time.Duration
+	p := &Policy{
+		Backoff:     Backoff{Initial: time.Millisecond},
+		MaxAttempts: 3,
+		Notify: func(err error, delay time.Duration) {
+			delays = append(delays, delay)
+		},
+	}
+
+	calls := 0
+	err := p.Do(nil, func() error {
+		calls++
+		return errors.New("failed")
+	})
+	require.EqualError(t, err, "failed")
+	require.Equal(t, 3, calls)
+	require.Equal(t, []time.Duration{time.Millisecond, 2 * time.Millisecond}, delays)
+
+	calls = 0
+	err = p.Do(nil, func() error {
+		calls++
+		if calls < 2 {
+			return errors.New("failed")
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, 2, calls)
+
+	// permanent errors are not retried
+	calls = 0
+	err = p.Do(nil, func() error {
+		calls++
+		return NewPermanent(errors.New("rejected"))
+	})
+	require.EqualError(t, err, "rejected")
+	require.Equal(t, 1, calls)
+}
+
+func TestPolicyDoShutdown(t *testing.T) {
+	p := &Policy{Backoff: Backoff{Initial: time.Hour}}
+	shutdown := make(chan struct{})
+	close(shutdown)
+	err := p.Do(shutdown, func() error {
+		return errors.New("failed")
+	})
+	require.EqualError(t, err, "failed")
+}
+
+func TestPolicyThrottled(t *testing.T) {
+	p := &Policy{Backoff: Backoff{Initial: time.Second}, MaxAttempts: 1}
+	delay, ok := p.next(NewThrottled(errors.New("slow down"), time.Minute), 5)
+	require.True(t, ok)
+	require.Equal(t, time.Minute, delay)
+
+	delay, ok = p.next(NewThrottled(errors.New("slow down"), time.Millisecond), 2)
+	require.True(t, ok)
+	require.Equal(t, 2*time.Second, delay)
+}
+
+func TestBudget(t *testing.T) {
+	now := time.Now()
+	b := NewBudget(2, time.Minute)
+	b.now = func() time.Time { return now }
+
+	require.True(t, b.Allow())
+	require.True(t, b.Allow())
+	require.False(t, b.Allow())
+
+	now = now.Add(time.Minute)
+	require.True(t, b.Allow())
+
+	p := &Policy{Budget: NewBudget(0, time.Minute)}
+	_, ok := p.next(errors.New("failed"), 1)
+	require.False(t, ok)
+}
+
+func TestSchedule(t *testing.T) {
+	now := time.Now()
+	s := NewSchedule(Policy{Backoff: Backoff{Initial: time.Second, Max: 4 * time.Second}})
+	require.True(t, s.Ready(now))
+
+	delay, ok := s.Failure(errors.New("failed"), now)
+	require.True(t, ok)
+	require.Equal(t, time.Second, delay)
+	require.False(t, s.Ready(now))
+	require.True(t, s.Ready(now.Add(time.Second)))
+
+	delay, _ = s.Failure(errors.New("failed"), now)
+	require.Equal(t, 2*time.Second, delay)
+
+	// permanent errors are not to be retried, but back off if they are
+	delay, ok = s.Failure(NewPermanent(errors.New("rejected")), now)
+	require.False(t, ok)
+	require.Equal(t, 4*time.Second, delay)
+
+	s.Success()
+	require.True(t, s.Ready(now))
+	delay, _ = s.Failure(errors.New("failed"), now)
+	require.Equal(t, time.Second, delay)
+}
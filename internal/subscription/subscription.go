@@ -0,0 +1,168 @@
+// Package subscription runs the subscriptions of the streaming service
+// inputs, such as the topics of a broker or the telemetry paths of a device.
+// Subscriptions failing are resubscribed with a backoff, and the health of
+// each subscription is reported in the internal_subscription measurement.
+package subscription
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal/retry"
+	"github.com/influxdata/telegraf/selfstat"
+)
+
+// DefaultBackoff is the backoff of the resubscriptions of the managers
+// without a backoff.
+var DefaultBackoff = retry.Backoff{
+	Initial: time.Second,
+	Max:     time.Minute,
+	Jitter:  0.5,
+}
+
+// Subscription is a stream of messages of an input.
+type Subscription struct {
+	// Name of the subscription, ie the server and topic; it is the
+	// subscription tag of the health metrics
+	Name string
+
+	// Run subscribes and receives the messages of the subscription until it
+	// fails or ctx is done, and returns the error ending the subscription.
+	// Run reports the subscription to the session once subscribed, and each
+	// message received.  The subscriptions failing with a permanent error,
+	// see retry.NewPermanent, are not resubscribed.
+	Run func(ctx context.Context, s *Session) error
+}
+
+// Session is the state of an attempt to subscribe, the subscription reports
+// its health to it.
+type Session struct {
+	health *health
+
+	mu         sync.Mutex
+	subscribed bool
+}
+
+// Subscribed reports that the subscription is established, the following
+// failure is resubscribed without delay.
+func (s *Session) Subscribed() {
+	s.mu.Lock()
+	s.subscribed = true
+	s.mu.Unlock()
+	s.health.subscribed.Set(1)
+}
+
+// Received reports a message received by the subscription.
+func (s *Session) Received() {
+	s.health.messages.Incr(1)
+}
+
+func (s *Session) isSubscribed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.subscribed
+}
+
+type health struct {
+	subscribed   selfstat.Stat
+	resubscribes selfstat.Stat
+	messages     selfstat.Stat
+	errors       selfstat.Stat
+}
+
+// Manager runs the subscriptions of an input.
+type Manager struct {
+	// Name of the input, the input tag of the health metrics
+	Input string
+	// Backoff of the resubscriptions, DefaultBackoff if zero
+	Backoff retry.Backoff
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// Start runs the subscriptions until Stop is called.  The errors ending the
+// subscriptions are added to the accumulator.
+func (m *Manager) Start(acc telegraf.Accumulator, subscriptions ...Subscription) {
+	var ctx context.Context
+	ctx, m.cancel = context.WithCancel(context.Background())
+
+	for _, sub := range subscriptions {
+		tags := map[string]string{"input": m.Input, "subscription": sub.Name}
+		h := &health{
+			subscribed:   selfstat.Register("subscription", "subscribed", tags),
+			resubscribes: selfstat.Register("subscription", "resubscribes", tags),
+			messages:     selfstat.Register("subscription", "messages_received", tags),
+			errors:       selfstat.Register("subscription", "errors", tags),
+		}
+
+		m.wg.Add(1)
+		go func(sub Subscription) {
+			defer m.wg.Done()
+			m.run(ctx, acc, sub, h)
+		}(sub)
+	}
+}
+
+// run runs the subscription, resubscribing it until ctx is done or it fails
+// with a permanent error.
+func (m *Manager) run(ctx context.Context, acc telegraf.Accumulator, sub Subscription, h *health) {
+	backoff := m.Backoff
+	if backoff == (retry.Backoff{}) {
+		backoff = DefaultBackoff
+	}
+
+	failures := 0
+	for {
+		s := &Session{health: h}
+		err := sub.Run(ctx, s)
+		h.subscribed.Set(0)
+		if ctx.Err() != nil {
+			return
+		}
+		if err == nil {
+			err = fmt.Errorf("subscription ended")
+		}
+		h.errors.Incr(1)
+
+		if retry.Classify(err) == retry.Permanent {
+			acc.AddError(fmt.Errorf("subscription %s failed: %s", sub.Name, err))
+			return
+		}
+		acc.AddError(fmt.Errorf("subscription %s lost: %s", sub.Name, err))
+
+		// a subscription lost after subscribing is resubscribed at once, the
+		// backoff grows with the failures to subscribe
+		if s.isSubscribed() {
+			failures = 0
+		}
+		delay := backoff.Delay(failures)
+		failures++
+		if after := retry.RetryAfter(err); after > delay {
+			delay = after
+		}
+		log.Printf("D! %s: resubscribing %s in %s", m.Input, sub.Name, delay)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		h.resubscribes.Incr(1)
+	}
+}
+
+// Stop ends the subscriptions and waits for them to return.
+func (m *Manager) Stop() {
+	if m.cancel == nil {
+		return
+	}
+	m.cancel()
+	m.wg.Wait()
+}
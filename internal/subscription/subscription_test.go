@@ -0,0 +1,113 @@
+package subscription
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf/internal/retry"
+	"github.com/influxdata/telegraf/selfstat"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+var testBackoff = retry.Backoff{Initial: time.Millisecond, Max: 10 * time.Millisecond}
+
+func TestResubscribe(t *testing.T) {
+	var mu sync.Mutex
+	attempts := 0
+	done := make(chan struct{})
+
+	m := Manager{Input: "test_resubscribe", Backoff: testBackoff}
+	var acc testutil.Accumulator
+	m.Start(&acc, Subscription{
+		Name: "topic",
+		Run: func(ctx context.Context, s *Session) error {
+			mu.Lock()
+			attempts++
+			n := attempts
+			mu.Unlock()
+			switch n {
+			case 1:
+				return errors.New("connection refused")
+			case 2:
+				s.Subscribed()
+				s.Received()
+				s.Received()
+				return errors.New("connection lost")
+			}
+			s.Subscribed()
+			close(done)
+			<-ctx.Done()
+			return nil
+		},
+	})
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("not resubscribed")
+	}
+
+	tags := map[string]string{"input": "test_resubscribe", "subscription": "topic"}
+	require.Equal(t, int64(1), selfstat.Register("subscription", "subscribed", tags).Get())
+	require.Equal(t, int64(2), selfstat.Register("subscription", "resubscribes", tags).Get())
+	require.Equal(t, int64(2), selfstat.Register("subscription", "messages_received", tags).Get())
+	require.Equal(t, int64(2), selfstat.Register("subscription", "errors", tags).Get())
+
+	m.Stop()
+	require.Equal(t, int64(0), selfstat.Register("subscription", "subscribed", tags).Get())
+	require.Len(t, acc.Errors, 2)
+	require.EqualError(t, acc.Errors[1], "subscription topic lost: connection lost")
+}
+
+func TestPermanentError(t *testing.T) {
+	attempts := 0
+	m := Manager{Input: "test_permanent", Backoff: testBackoff}
+	var acc testutil.Accumulator
+	m.Start(&acc, Subscription{
+		Name: "sensor",
+		Run: func(ctx context.Context, s *Session) error {
+			attempts++
+			return retry.NewPermanent(errors.New("unknown path"))
+		},
+	})
+	acc.WaitError(1)
+	m.Stop()
+
+	require.Equal(t, 1, attempts)
+	require.EqualError(t, acc.Errors[0], "subscription sensor failed: unknown path")
+}
+
+func TestStopWhileWaiting(t *testing.T) {
+	m := Manager{Input: "test_stop", Backoff: retry.Backoff{Initial: time.Hour}}
+	var acc testutil.Accumulator
+	m.Start(&acc,
+		Subscription{
+			Name: "first",
+			Run: func(ctx context.Context, s *Session) error {
+				return errors.New("connection refused")
+			},
+		},
+		Subscription{
+			Name: "second",
+			Run: func(ctx context.Context, s *Session) error {
+				return errors.New("connection refused")
+			},
+		},
+	)
+	acc.WaitError(2)
+
+	stopped := make(chan struct{})
+	go func() {
+		m.Stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("not stopped")
+	}
+}
@@ -3,8 +3,12 @@ package tls
 import (
 	"crypto/tls"
 	"crypto/x509"
+	"encoding/pem"
 	"fmt"
 	"io/ioutil"
+
+	"github.com/influxdata/telegraf/internal/tls/keyuri"
+	"github.com/influxdata/telegraf/internal/tls/spiffe"
 )
 
 // ClientConfig represents the standard client TLS config.
@@ -14,10 +18,14 @@ type ClientConfig struct {
 	TLSKey             string `toml:"tls_key"`
 	InsecureSkipVerify bool   `toml:"insecure_skip_verify"`
 
+	// SPIFFE Workload API providing the certificate and trust bundle
+	SPIFFEWorkloadAPI string   `toml:"spiffe_workload_api"`
+	SPIFFEAllowedIDs  []string `toml:"spiffe_allowed_ids"`
+
 	// Deprecated in 1.7; use TLS variables above
-	SSLCA   string `toml:"ssl_ca"`
-	SSLCert string `toml:"ssl_cert"`
-	SSLKey  string `toml:"ssl_ca"`
+	SSLCA   string `toml:"ssl_ca" deprecated:"1.7;use tls_ca"`
+	SSLCert string `toml:"ssl_cert" deprecated:"1.7;use tls_cert"`
+	SSLKey  string `toml:"ssl_key" deprecated:"1.7;use tls_key"`
 }
 
 // ServerConfig represents the standard server TLS config.
@@ -25,6 +33,10 @@ type ServerConfig struct {
 	TLSCert           string   `toml:"tls_cert"`
 	TLSKey            string   `toml:"tls_key"`
 	TLSAllowedCACerts []string `toml:"tls_allowed_cacerts"`
+
+	// SPIFFE Workload API providing the certificate and trust bundle
+	SPIFFEWorkloadAPI string   `toml:"spiffe_workload_api"`
+	SPIFFEAllowedIDs  []string `toml:"spiffe_allowed_ids"`
 }
 
 // TLSConfig returns a tls.Config, may be nil without error if TLS is not
@@ -41,6 +53,13 @@ func (c *ClientConfig) TLSConfig() (*tls.Config, error) {
 		c.TLSKey = c.SSLKey
 	}
 
+	if c.SPIFFEWorkloadAPI != "" {
+		if c.TLSCA != "" || c.TLSKey != "" || c.TLSCert != "" || c.InsecureSkipVerify {
+			return nil, fmt.Errorf("spiffe_workload_api can not be used with tls_ca, tls_cert, tls_key or insecure_skip_verify")
+		}
+		return spiffeConfig(c.SPIFFEWorkloadAPI, c.SPIFFEAllowedIDs, false)
+	}
+
 	// TODO: return default tls.Config; plugins should not call if they don't
 	// want TLS, this will require using another option to determine.  In the
 	// case of an HTTP plugin, you could use `https`.  Other plugins may need
@@ -75,6 +94,13 @@ func (c *ClientConfig) TLSConfig() (*tls.Config, error) {
 // TLSConfig returns a tls.Config, may be nil without error if TLS is not
 // configured.
 func (c *ServerConfig) TLSConfig() (*tls.Config, error) {
+	if c.SPIFFEWorkloadAPI != "" {
+		if c.TLSCert != "" || c.TLSKey != "" || len(c.TLSAllowedCACerts) != 0 {
+			return nil, fmt.Errorf("spiffe_workload_api can not be used with tls_cert, tls_key or tls_allowed_cacerts")
+		}
+		return spiffeConfig(c.SPIFFEWorkloadAPI, c.SPIFFEAllowedIDs, true)
+	}
+
 	if c.TLSCert == "" && c.TLSKey == "" && len(c.TLSAllowedCACerts) == 0 {
 		return nil, nil
 	}
@@ -100,6 +126,35 @@ func (c *ServerConfig) TLSConfig() (*tls.Config, error) {
 	return tlsConfig, nil
 }
 
+// spiffeConfig returns a tls.Config using the SVIDs of the Workload API, the
+// SVID of the peer is verified against the trust bundle instead of the host
+// name and must have one of the allowed SPIFFE IDs.
+func spiffeConfig(address string, allowedIDs []string, server bool) (*tls.Config, error) {
+	source, err := spiffe.NewSource(address)
+	if err != nil {
+		return nil, err
+	}
+	verify, err := source.VerifyPeerCertificate(allowedIDs)
+	if err != nil {
+		return nil, fmt.Errorf("invalid spiffe_allowed_ids: %v", err)
+	}
+
+	tlsConfig := &tls.Config{
+		VerifyPeerCertificate: verify,
+	}
+	if server {
+		tlsConfig.GetCertificate = source.GetCertificate
+		tlsConfig.ClientAuth = tls.RequireAnyClientCert
+	} else {
+		tlsConfig.GetClientCertificate = source.GetClientCertificate
+		tlsConfig.Renegotiation = tls.RenegotiateNever
+		// the server is verified by VerifyPeerCertificate, SVIDs have
+		// a SPIFFE ID instead of host names
+		tlsConfig.InsecureSkipVerify = true
+	}
+	return tlsConfig, nil
+}
+
 func makeCertPool(certFiles []string) (*x509.CertPool, error) {
 	pool := x509.NewCertPool()
 	for _, certFile := range certFiles {
@@ -118,6 +173,10 @@ func makeCertPool(certFiles []string) (*x509.CertPool, error) {
 }
 
 func loadCertificate(config *tls.Config, certFile, keyFile string) error {
+	if keyuri.IsKeyURI(keyFile) {
+		return loadDeviceCertificate(config, certFile, keyFile)
+	}
+
 	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
 	if err != nil {
 		return fmt.Errorf(
@@ -128,3 +187,38 @@ func loadCertificate(config *tls.Config, certFile, keyFile string) error {
 	config.BuildNameToCertificate()
 	return nil
 }
+
+// loadDeviceCertificate loads the certificate of a key held in a PKCS#11
+// token or a TPM, given by its key URI.
+func loadDeviceCertificate(config *tls.Config, certFile, keyURI string) error {
+	certPEM, err := ioutil.ReadFile(certFile)
+	if err != nil {
+		return fmt.Errorf("could not read certificate %q: %v", certFile, err)
+	}
+
+	var cert tls.Certificate
+	for {
+		var block *pem.Block
+		block, certPEM = pem.Decode(certPEM)
+		if block == nil {
+			break
+		}
+		if block.Type == "CERTIFICATE" {
+			cert.Certificate = append(cert.Certificate, block.Bytes)
+		}
+	}
+	if len(cert.Certificate) == 0 {
+		return fmt.Errorf("could not parse any PEM certificates %q", certFile)
+	}
+	if cert.Leaf, err = x509.ParseCertificate(cert.Certificate[0]); err != nil {
+		return fmt.Errorf("could not parse certificate %q: %v", certFile, err)
+	}
+
+	if cert.PrivateKey, err = keyuri.Signer(keyURI, cert.Leaf.PublicKey); err != nil {
+		return fmt.Errorf("could not load key of %s: %v", certFile, err)
+	}
+
+	config.Certificates = []tls.Certificate{cert}
+	config.BuildNameToCertificate()
+	return nil
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/influxdata/telegraf/internal/tls"
 	"github.com/influxdata/telegraf/testutil"
+	"github.com/influxdata/toml"
 	"github.com/stretchr/testify/require"
 )
 
@@ -105,6 +106,19 @@ func TestClientConfig(t *testing.T) {
 	}
 }
 
+func TestClientConfigDeprecatedSSL(t *testing.T) {
+	var client tls.ClientConfig
+	err := toml.Unmarshal([]byte(`
+ssl_ca = "/etc/telegraf/ca.pem"
+ssl_cert = "/etc/telegraf/cert.pem"
+ssl_key = "/etc/telegraf/key.pem"
+`), &client)
+	require.NoError(t, err)
+	require.Equal(t, "/etc/telegraf/ca.pem", client.SSLCA)
+	require.Equal(t, "/etc/telegraf/cert.pem", client.SSLCert)
+	require.Equal(t, "/etc/telegraf/key.pem", client.SSLKey)
+}
+
 func TestServerConfig(t *testing.T) {
 	tests := []struct {
 		name   string
@@ -0,0 +1,163 @@
+// Package keyuri provides the private keys held in PKCS#11 tokens or in a
+// TPM as crypto.Signers, the keys are given by URI instead of a key file and
+// never leave the device.
+//
+// PKCS#11 keys use the URI syntax of RFC 7512, ie
+//
+//	pkcs11:token=telegraf;object=client?module-path=/usr/lib/softhsm/libsofthsm2.so&pin-source=/etc/telegraf/pin
+//
+// TPM keys are persistent keys given by their handle, ie
+//
+//	tpm:handle=0x81000001?device=/dev/tpmrm0
+package keyuri
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"encoding/asn1"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/url"
+	"strings"
+)
+
+// IsKeyURI returns true if the key is a key URI instead of a file.
+func IsKeyURI(key string) bool {
+	return strings.HasPrefix(key, "pkcs11:") || strings.HasPrefix(key, "tpm:")
+}
+
+// Signer returns the signer of the key of the URI, public is the public key
+// of the certificate of the key.
+func Signer(uri string, public crypto.PublicKey) (crypto.Signer, error) {
+	switch public.(type) {
+	case *rsa.PublicKey, *ecdsa.PublicKey:
+	default:
+		return nil, fmt.Errorf("unsupported public key type %T", public)
+	}
+
+	u, err := parse(uri)
+	if err != nil {
+		return nil, fmt.Errorf("invalid key URI %q: %v", uri, err)
+	}
+	switch u.scheme {
+	case "pkcs11":
+		return newPKCS11Signer(u, public)
+	case "tpm":
+		return newTPMSigner(u, public)
+	}
+	return nil, fmt.Errorf("invalid key URI %q: unknown scheme", uri)
+}
+
+// keyURI holds the path attributes, separated by ";", and the query
+// attributes of a key URI.
+type keyURI struct {
+	scheme string
+	path   map[string]string
+	query  url.Values
+}
+
+func parse(uri string) (*keyURI, error) {
+	i := strings.Index(uri, ":")
+	if i < 0 {
+		return nil, fmt.Errorf("missing scheme")
+	}
+	u := &keyURI{
+		scheme: uri[:i],
+		path:   make(map[string]string),
+	}
+
+	rest := uri[i+1:]
+	var query string
+	if i := strings.Index(rest, "?"); i >= 0 {
+		rest, query = rest[:i], rest[i+1:]
+	}
+
+	if rest != "" {
+		for _, attr := range strings.Split(rest, ";") {
+			kv := strings.SplitN(attr, "=", 2)
+			if len(kv) != 2 || kv[0] == "" {
+				return nil, fmt.Errorf("invalid attribute %q", attr)
+			}
+			value, err := url.PathUnescape(kv[1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid attribute %q: %v", attr, err)
+			}
+			u.path[kv[0]] = value
+		}
+	}
+
+	var err error
+	if u.query, err = url.ParseQuery(query); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// pin returns the PIN of the URI, given by the pin-value attribute or read
+// from the file of the pin-source attribute.
+func (u *keyURI) pin() (string, error) {
+	if value := u.query.Get("pin-value"); value != "" {
+		return value, nil
+	}
+	source := u.query.Get("pin-source")
+	if source == "" {
+		return "", nil
+	}
+	source = strings.TrimPrefix(source, "file:")
+	pin, err := ioutil.ReadFile(source)
+	if err != nil {
+		return "", fmt.Errorf("could not read pin-source: %v", err)
+	}
+	return strings.TrimRight(string(pin), "\r\n"), nil
+}
+
+// digestInfoPrefixes are the DER prefixes of the PKCS #1 v1.5 DigestInfo
+// of the hashes, prepended to the digests signed by raw RSA mechanisms.
+var digestInfoPrefixes = map[crypto.Hash][]byte{
+	crypto.SHA1:   {0x30, 0x21, 0x30, 0x09, 0x06, 0x05, 0x2b, 0x0e, 0x03, 0x02, 0x1a, 0x05, 0x00, 0x04, 0x14},
+	crypto.SHA224: {0x30, 0x2d, 0x30, 0x0d, 0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x04, 0x05, 0x00, 0x04, 0x1c},
+	crypto.SHA256: {0x30, 0x31, 0x30, 0x0d, 0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x01, 0x05, 0x00, 0x04, 0x20},
+	crypto.SHA384: {0x30, 0x41, 0x30, 0x0d, 0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x02, 0x05, 0x00, 0x04, 0x30},
+	crypto.SHA512: {0x30, 0x51, 0x30, 0x0d, 0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x03, 0x05, 0x00, 0x04, 0x40},
+}
+
+// digestInfo returns the DER DigestInfo of the digest.
+func digestInfo(hash crypto.Hash, digest []byte) ([]byte, error) {
+	prefix, ok := digestInfoPrefixes[hash]
+	if !ok {
+		return nil, fmt.Errorf("unsupported hash %v", hash)
+	}
+	if len(digest) != hash.Size() {
+		return nil, fmt.Errorf("invalid digest length %d for %v", len(digest), hash)
+	}
+	return append(prefix[:len(prefix):len(prefix)], digest...), nil
+}
+
+// pssSaltLength returns the salt length of the PSS options, Go uses the hash
+// size for PSSSaltLengthEqualsHash and PSSSaltLengthAuto when signing.
+func pssSaltLength(opts *rsa.PSSOptions, hash crypto.Hash) int {
+	if opts.SaltLength > 0 {
+		return opts.SaltLength
+	}
+	return hash.Size()
+}
+
+// ecdsaSignature returns the DER encoding of a signature of r and s, as
+// expected by crypto/tls.
+func ecdsaSignature(r, s *big.Int) ([]byte, error) {
+	return asn1.Marshal(struct {
+		R, S *big.Int
+	}{r, s})
+}
+
+// rawECDSASignature returns the DER encoding of a PKCS #11 signature, the
+// concatenation of r and s.
+func rawECDSASignature(raw []byte) ([]byte, error) {
+	if len(raw) == 0 || len(raw)%2 != 0 {
+		return nil, fmt.Errorf("invalid ECDSA signature length %d", len(raw))
+	}
+	n := len(raw) / 2
+	return ecdsaSignature(new(big.Int).SetBytes(raw[:n]), new(big.Int).SetBytes(raw[n:]))
+}
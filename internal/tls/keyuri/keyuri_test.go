@@ -0,0 +1,116 @@
+package keyuri
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/asn1"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsKeyURI(t *testing.T) {
+	require.True(t, IsKeyURI("pkcs11:token=telegraf;object=client"))
+	require.True(t, IsKeyURI("tpm:handle=0x81000001"))
+	require.False(t, IsKeyURI("/etc/telegraf/key.pem"))
+	require.False(t, IsKeyURI(`C:\telegraf\key.pem`))
+}
+
+func TestParse(t *testing.T) {
+	u, err := parse("pkcs11:token=My%20Token;object=client;id=%01%02?module-path=/usr/lib/softhsm/libsofthsm2.so&pin-value=1234")
+	require.NoError(t, err)
+	require.Equal(t, "pkcs11", u.scheme)
+	require.Equal(t, map[string]string{
+		"token":  "My Token",
+		"object": "client",
+		"id":     "\x01\x02",
+	}, u.path)
+	require.Equal(t, "/usr/lib/softhsm/libsofthsm2.so", u.query.Get("module-path"))
+
+	pin, err := u.pin()
+	require.NoError(t, err)
+	require.Equal(t, "1234", pin)
+
+	_, err = parse("pkcs11:token")
+	require.Error(t, err)
+	_, err = parse("telegraf")
+	require.Error(t, err)
+}
+
+func TestPinSource(t *testing.T) {
+	f, err := ioutil.TempFile("", "pin")
+	require.NoError(t, err)
+	defer os.Remove(f.Name())
+	_, err = f.WriteString("s3cr3t\n")
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	u, err := parse("tpm:handle=0x81000001?pin-source=file:" + f.Name())
+	require.NoError(t, err)
+	pin, err := u.pin()
+	require.NoError(t, err)
+	require.Equal(t, "s3cr3t", pin)
+}
+
+func TestSignerErrors(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	_, err = Signer("pkcs11:object=client", &key.PublicKey)
+	require.Error(t, err)
+	_, err = Signer("tpm:", &key.PublicKey)
+	require.Error(t, err)
+	_, err = Signer("tpm:handle=0x81000001", "not a key")
+	require.Error(t, err)
+}
+
+// Signing the DigestInfo with raw RSA must give the PKCS #1 v1.5 signature.
+func TestDigestInfo(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+	digest := sha256.Sum256([]byte("telegraf"))
+
+	expected, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	require.NoError(t, err)
+
+	data, err := digestInfo(crypto.SHA256, digest[:])
+	require.NoError(t, err)
+	actual, err := rsa.SignPKCS1v15(rand.Reader, key, 0, data)
+	require.NoError(t, err)
+	require.Equal(t, expected, actual)
+
+	_, err = digestInfo(crypto.SHA256, digest[:16])
+	require.Error(t, err)
+}
+
+func TestRawECDSASignature(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	digest := sha256.Sum256([]byte("telegraf"))
+	r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+	require.NoError(t, err)
+
+	raw := make([]byte, 64)
+	rBytes, sBytes := r.Bytes(), s.Bytes()
+	copy(raw[32-len(rBytes):32], rBytes)
+	copy(raw[64-len(sBytes):], sBytes)
+
+	der, err := rawECDSASignature(raw)
+	require.NoError(t, err)
+	var sig struct {
+		R, S *big.Int
+	}
+	_, err = asn1.Unmarshal(der, &sig)
+	require.NoError(t, err)
+	require.True(t, ecdsa.Verify(&key.PublicKey, digest[:], sig.R, sig.S))
+
+	_, err = rawECDSASignature(raw[:63])
+	require.Error(t, err)
+}
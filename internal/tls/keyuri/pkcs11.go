@@ -0,0 +1,213 @@
+// +build cgo,pkcs11
+
+package keyuri
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/miekg/pkcs11"
+)
+
+var (
+	modulesMu sync.Mutex
+	// initialized modules by path, a module is initialized once per process
+	modules = make(map[string]*pkcs11.Ctx)
+)
+
+var pkcs11Hashes = map[crypto.Hash][2]uint{
+	crypto.SHA1:   {pkcs11.CKM_SHA_1, pkcs11.CKG_MGF1_SHA1},
+	crypto.SHA224: {pkcs11.CKM_SHA224, pkcs11.CKG_MGF1_SHA224},
+	crypto.SHA256: {pkcs11.CKM_SHA256, pkcs11.CKG_MGF1_SHA256},
+	crypto.SHA384: {pkcs11.CKM_SHA384, pkcs11.CKG_MGF1_SHA384},
+	crypto.SHA512: {pkcs11.CKM_SHA512, pkcs11.CKG_MGF1_SHA512},
+}
+
+type pkcs11Signer struct {
+	ctx    *pkcs11.Ctx
+	key    pkcs11.ObjectHandle
+	public crypto.PublicKey
+
+	// sessions are not safe for concurrent use
+	mu      sync.Mutex
+	session pkcs11.SessionHandle
+}
+
+func loadModule(path string) (*pkcs11.Ctx, error) {
+	modulesMu.Lock()
+	defer modulesMu.Unlock()
+	if ctx, ok := modules[path]; ok {
+		return ctx, nil
+	}
+
+	ctx := pkcs11.New(path)
+	if ctx == nil {
+		return nil, fmt.Errorf("could not load PKCS#11 module %s", path)
+	}
+	if err := ctx.Initialize(); err != nil {
+		return nil, fmt.Errorf("could not initialize PKCS#11 module %s: %v", path, err)
+	}
+	modules[path] = ctx
+	return ctx, nil
+}
+
+func newPKCS11Signer(u *keyURI, public crypto.PublicKey) (crypto.Signer, error) {
+	module := u.query.Get("module-path")
+	if module == "" {
+		return nil, fmt.Errorf("PKCS#11 key URI requires module-path")
+	}
+	ctx, err := loadModule(module)
+	if err != nil {
+		return nil, err
+	}
+
+	slot, err := findSlot(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+	session, err := ctx.OpenSession(slot, pkcs11.CKF_SERIAL_SESSION)
+	if err != nil {
+		return nil, fmt.Errorf("could not open PKCS#11 session: %v", err)
+	}
+	pin, err := u.pin()
+	if err != nil {
+		ctx.CloseSession(session)
+		return nil, err
+	}
+	if pin != "" {
+		err := ctx.Login(session, pkcs11.CKU_USER, pin)
+		if err != nil && err != pkcs11.Error(pkcs11.CKR_USER_ALREADY_LOGGED_IN) {
+			ctx.CloseSession(session)
+			return nil, fmt.Errorf("could not log in to PKCS#11 token: %v", err)
+		}
+	}
+
+	key, err := findKey(ctx, session, u)
+	if err != nil {
+		ctx.CloseSession(session)
+		return nil, err
+	}
+	return &pkcs11Signer{
+		ctx:     ctx,
+		session: session,
+		key:     key,
+		public:  public,
+	}, nil
+}
+
+// findSlot returns the slot of the token of the slot-id, token or serial
+// attributes, the first slot with a token if none is given.
+func findSlot(ctx *pkcs11.Ctx, u *keyURI) (uint, error) {
+	slots, err := ctx.GetSlotList(true)
+	if err != nil {
+		return 0, fmt.Errorf("could not list PKCS#11 slots: %v", err)
+	}
+
+	var slotID *uint64
+	if id, ok := u.path["slot-id"]; ok {
+		n, err := strconv.ParseUint(id, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid slot-id %q", id)
+		}
+		slotID = &n
+	}
+	for _, slot := range slots {
+		if slotID != nil && uint64(slot) != *slotID {
+			continue
+		}
+		info, err := ctx.GetTokenInfo(slot)
+		if err != nil {
+			continue
+		}
+		if token, ok := u.path["token"]; ok && strings.TrimSpace(info.Label) != token {
+			continue
+		}
+		if serial, ok := u.path["serial"]; ok && strings.TrimSpace(info.SerialNumber) != serial {
+			continue
+		}
+		return slot, nil
+	}
+	return 0, fmt.Errorf("PKCS#11 token not found")
+}
+
+// findKey returns the private key of the object and id attributes.
+func findKey(ctx *pkcs11.Ctx, session pkcs11.SessionHandle, u *keyURI) (pkcs11.ObjectHandle, error) {
+	template := []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_PRIVATE_KEY),
+	}
+	if object, ok := u.path["object"]; ok {
+		template = append(template, pkcs11.NewAttribute(pkcs11.CKA_LABEL, object))
+	}
+	if id, ok := u.path["id"]; ok {
+		template = append(template, pkcs11.NewAttribute(pkcs11.CKA_ID, []byte(id)))
+	}
+	if len(template) == 1 {
+		return 0, fmt.Errorf("PKCS#11 key URI requires object or id")
+	}
+
+	if err := ctx.FindObjectsInit(session, template); err != nil {
+		return 0, fmt.Errorf("could not find PKCS#11 key: %v", err)
+	}
+	objects, _, err := ctx.FindObjects(session, 2)
+	ctx.FindObjectsFinal(session)
+	switch {
+	case err != nil:
+		return 0, fmt.Errorf("could not find PKCS#11 key: %v", err)
+	case len(objects) == 0:
+		return 0, fmt.Errorf("PKCS#11 key not found")
+	case len(objects) > 1:
+		return 0, fmt.Errorf("PKCS#11 key URI matches several keys")
+	}
+	return objects[0], nil
+}
+
+func (s *pkcs11Signer) Public() crypto.PublicKey {
+	return s.public
+}
+
+func (s *pkcs11Signer) Sign(_ io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	var mechanism *pkcs11.Mechanism
+	data := digest
+	switch s.public.(type) {
+	case *rsa.PublicKey:
+		hash := opts.HashFunc()
+		if pss, ok := opts.(*rsa.PSSOptions); ok {
+			params, ok := pkcs11Hashes[hash]
+			if !ok {
+				return nil, fmt.Errorf("unsupported hash %v", hash)
+			}
+			saltLength := pssSaltLength(pss, hash)
+			mechanism = pkcs11.NewMechanism(pkcs11.CKM_RSA_PKCS_PSS,
+				pkcs11.NewPSSParams(params[0], params[1], uint(saltLength)))
+		} else {
+			var err error
+			if data, err = digestInfo(hash, digest); err != nil {
+				return nil, err
+			}
+			mechanism = pkcs11.NewMechanism(pkcs11.CKM_RSA_PKCS, nil)
+		}
+	case *ecdsa.PublicKey:
+		mechanism = pkcs11.NewMechanism(pkcs11.CKM_ECDSA, nil)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.ctx.SignInit(s.session, []*pkcs11.Mechanism{mechanism}, s.key); err != nil {
+		return nil, fmt.Errorf("PKCS#11 sign failed: %v", err)
+	}
+	signature, err := s.ctx.Sign(s.session, data)
+	if err != nil {
+		return nil, fmt.Errorf("PKCS#11 sign failed: %v", err)
+	}
+
+	if _, ok := s.public.(*ecdsa.PublicKey); ok {
+		return rawECDSASignature(signature)
+	}
+	return signature, nil
+}
@@ -0,0 +1,12 @@
+// +build !cgo !pkcs11
+
+package keyuri
+
+import (
+	"crypto"
+	"fmt"
+)
+
+func newPKCS11Signer(u *keyURI, public crypto.PublicKey) (crypto.Signer, error) {
+	return nil, fmt.Errorf("PKCS#11 keys require Telegraf built with cgo and the pkcs11 build tag")
+}
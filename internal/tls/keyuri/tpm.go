@@ -0,0 +1,141 @@
+// +build linux,tpm
+
+package keyuri
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+
+	"github.com/google/go-tpm/tpm2"
+	"github.com/google/go-tpm/tpmutil"
+)
+
+// defaultTPMDevice is the in-kernel resource manager, shared by the processes
+// using the TPM.
+const defaultTPMDevice = "/dev/tpmrm0"
+
+var (
+	devicesMu sync.Mutex
+	// open devices by path, commands to a device are serialized
+	devices = make(map[string]*tpmDevice)
+)
+
+var tpmHashes = map[crypto.Hash]tpm2.Algorithm{
+	crypto.SHA1:   tpm2.AlgSHA1,
+	crypto.SHA256: tpm2.AlgSHA256,
+	crypto.SHA384: tpm2.AlgSHA384,
+	crypto.SHA512: tpm2.AlgSHA512,
+}
+
+type tpmDevice struct {
+	mu sync.Mutex
+	rw io.ReadWriteCloser
+}
+
+type tpmSigner struct {
+	device   *tpmDevice
+	handle   tpmutil.Handle
+	password string
+	public   crypto.PublicKey
+}
+
+func openTPM(path string) (*tpmDevice, error) {
+	devicesMu.Lock()
+	defer devicesMu.Unlock()
+	if device, ok := devices[path]; ok {
+		return device, nil
+	}
+
+	rw, err := tpmutil.OpenTPM(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not open TPM %s: %v", path, err)
+	}
+	device := &tpmDevice{rw: rw}
+	devices[path] = device
+	return device, nil
+}
+
+func newTPMSigner(u *keyURI, public crypto.PublicKey) (crypto.Signer, error) {
+	h, ok := u.path["handle"]
+	if !ok {
+		return nil, fmt.Errorf("TPM key URI requires handle")
+	}
+	handle, err := strconv.ParseUint(h, 0, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid TPM handle %q", h)
+	}
+	password, err := u.pin()
+	if err != nil {
+		return nil, err
+	}
+
+	path := u.query.Get("device")
+	if path == "" {
+		path = defaultTPMDevice
+	}
+	device, err := openTPM(path)
+	if err != nil {
+		return nil, err
+	}
+	return &tpmSigner{
+		device:   device,
+		handle:   tpmutil.Handle(handle),
+		password: password,
+		public:   public,
+	}, nil
+}
+
+func (s *tpmSigner) Public() crypto.PublicKey {
+	return s.public
+}
+
+func (s *tpmSigner) Sign(_ io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	scheme, err := tpmScheme(s.public, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	s.device.mu.Lock()
+	signature, err := tpm2.Sign(s.device.rw, s.handle, s.password, digest, scheme)
+	s.device.mu.Unlock()
+	if err != nil {
+		return nil, fmt.Errorf("TPM sign failed: %v", err)
+	}
+
+	switch {
+	case signature.RSA != nil:
+		return signature.RSA.Signature, nil
+	case signature.ECC != nil:
+		return ecdsaSignature(signature.ECC.R, signature.ECC.S)
+	}
+	return nil, fmt.Errorf("TPM sign failed: unexpected signature algorithm %v", signature.Alg)
+}
+
+// tpmScheme returns the signature scheme of the key for the options, RSA keys
+// sign with PSS when the options are *rsa.PSSOptions.
+func tpmScheme(public crypto.PublicKey, opts crypto.SignerOpts) (*tpm2.SigScheme, error) {
+	hash, ok := tpmHashes[opts.HashFunc()]
+	if !ok {
+		return nil, fmt.Errorf("unsupported hash %v", opts.HashFunc())
+	}
+	scheme := &tpm2.SigScheme{Hash: hash}
+	switch public.(type) {
+	case *rsa.PublicKey:
+		scheme.Alg = tpm2.AlgRSASSA
+		if pss, ok := opts.(*rsa.PSSOptions); ok {
+			// the TPM uses salts of the size of the hash, as TLS does
+			if pssSaltLength(pss, opts.HashFunc()) != opts.HashFunc().Size() {
+				return nil, fmt.Errorf("unsupported RSA-PSS salt length %d, TPM keys use the hash size", pss.SaltLength)
+			}
+			scheme.Alg = tpm2.AlgRSAPSS
+		}
+	case *ecdsa.PublicKey:
+		scheme.Alg = tpm2.AlgECDSA
+	}
+	return scheme, nil
+}
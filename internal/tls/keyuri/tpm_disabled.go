@@ -0,0 +1,12 @@
+// +build !linux !tpm
+
+package keyuri
+
+import (
+	"crypto"
+	"fmt"
+)
+
+func newTPMSigner(u *keyURI, public crypto.PublicKey) (crypto.Signer, error) {
+	return nil, fmt.Errorf("TPM keys require Telegraf built for Linux with the tpm build tag")
+}
@@ -0,0 +1,41 @@
+// +build linux,tpm
+
+package keyuri
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+
+	"github.com/google/go-tpm/tpm2"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTPMScheme(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	scheme, err := tpmScheme(&rsaKey.PublicKey, crypto.SHA256)
+	require.NoError(t, err)
+	require.Equal(t, &tpm2.SigScheme{Alg: tpm2.AlgRSASSA, Hash: tpm2.AlgSHA256}, scheme)
+
+	// crypto/tls signs with the salt length of the hash size
+	scheme, err = tpmScheme(&rsaKey.PublicKey, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash, Hash: crypto.SHA384})
+	require.NoError(t, err)
+	require.Equal(t, &tpm2.SigScheme{Alg: tpm2.AlgRSAPSS, Hash: tpm2.AlgSHA384}, scheme)
+
+	_, err = tpmScheme(&rsaKey.PublicKey, &rsa.PSSOptions{SaltLength: 20, Hash: crypto.SHA256})
+	require.Error(t, err)
+
+	scheme, err = tpmScheme(&ecKey.PublicKey, crypto.SHA256)
+	require.NoError(t, err)
+	require.Equal(t, &tpm2.SigScheme{Alg: tpm2.AlgECDSA, Hash: tpm2.AlgSHA256}, scheme)
+
+	_, err = tpmScheme(&ecKey.PublicKey, crypto.MD5)
+	require.Error(t, err)
+}
@@ -0,0 +1,252 @@
+// Package spiffe obtains the X.509 SVIDs of Telegraf from the SPIFFE Workload
+// API, ie of a SPIRE agent, and keeps them up to date as they are rotated.
+package spiffe
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+
+	"github.com/influxdata/telegraf/filter"
+)
+
+var (
+	// time to wait for the first SVID
+	fetchTimeout = 30 * time.Second
+	// maximum time between reconnects to the Workload API
+	maxBackoff = 30 * time.Second
+
+	sourcesMu sync.Mutex
+	// sources by address, the plugins using the same Workload API share the
+	// stream
+	sources = make(map[string]*Source)
+)
+
+// Source holds the current SVID and trust bundle received from the Workload
+// API.
+type Source struct {
+	address string
+
+	mu     sync.RWMutex
+	id     string
+	cert   *tls.Certificate
+	bundle *x509.CertPool
+	ready  chan struct{}
+	err    error
+}
+
+// NewSource returns the source of the Workload API at the address, a unix
+// socket given as "unix:///path/to/socket" or its path.  The first SVID is
+// waited for, the SVIDs received later replace it.
+func NewSource(address string) (*Source, error) {
+	sourcesMu.Lock()
+	s, ok := sources[address]
+	if !ok {
+		s = &Source{
+			address: address,
+			ready:   make(chan struct{}),
+		}
+		sources[address] = s
+		go s.watch()
+	}
+	sourcesMu.Unlock()
+
+	select {
+	case <-s.ready:
+		return s, nil
+	case <-time.After(fetchTimeout):
+		s.mu.RLock()
+		defer s.mu.RUnlock()
+		if s.err != nil {
+			return nil, fmt.Errorf("could not fetch SVID from %s: %v", address, s.err)
+		}
+		return nil, fmt.Errorf("could not fetch SVID from %s: timeout", address)
+	}
+}
+
+// ID returns the SPIFFE ID of the current SVID.
+func (s *Source) ID() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.id
+}
+
+// GetCertificate returns the current SVID, for tls.Config.GetCertificate.
+func (s *Source) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.cert, nil
+}
+
+// GetClientCertificate returns the current SVID, for
+// tls.Config.GetClientCertificate.
+func (s *Source) GetClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.cert, nil
+}
+
+// VerifyPeerCertificate returns a function for tls.Config.VerifyPeerCertificate
+// verifying the SVID of the peer against the current trust bundle.  The
+// SPIFFE ID of the peer must match one of the allowed IDs, which may contain
+// wildcards, any ID of the trust bundle is allowed when empty.
+func (s *Source) VerifyPeerCertificate(allowedIDs []string) (func([][]byte, [][]*x509.Certificate) error, error) {
+	allowed, err := filter.Compile(allowedIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+		if len(rawCerts) == 0 {
+			return fmt.Errorf("peer sent no certificate")
+		}
+		certs := make([]*x509.Certificate, 0, len(rawCerts))
+		for _, raw := range rawCerts {
+			cert, err := x509.ParseCertificate(raw)
+			if err != nil {
+				return err
+			}
+			certs = append(certs, cert)
+		}
+
+		intermediates := x509.NewCertPool()
+		for _, cert := range certs[1:] {
+			intermediates.AddCert(cert)
+		}
+		s.mu.RLock()
+		bundle := s.bundle
+		s.mu.RUnlock()
+		_, err := certs[0].Verify(x509.VerifyOptions{
+			Roots:         bundle,
+			Intermediates: intermediates,
+			KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+		})
+		if err != nil {
+			return err
+		}
+
+		id, err := spiffeID(certs[0])
+		if err != nil {
+			return err
+		}
+		if allowed != nil && !allowed.Match(id) {
+			return fmt.Errorf("SPIFFE ID %q of the peer is not allowed", id)
+		}
+		return nil
+	}, nil
+}
+
+// spiffeID returns the SPIFFE ID of the SVID, its spiffe URI SAN.
+func spiffeID(cert *x509.Certificate) (string, error) {
+	for _, uri := range cert.URIs {
+		if uri.Scheme == "spiffe" {
+			return uri.String(), nil
+		}
+	}
+	return "", fmt.Errorf("certificate has no SPIFFE ID")
+}
+
+// watch receives the SVIDs from the Workload API, reconnecting when the
+// stream fails.
+func (s *Source) watch() {
+	backoff := time.Second
+	for {
+		err := s.receive()
+		s.mu.Lock()
+		s.err = err
+		s.mu.Unlock()
+		log.Printf("E! Error receiving SVID from %s, reconnecting in %s: %v", s.address, backoff, err)
+
+		time.Sleep(backoff)
+		if backoff *= 2; backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+}
+
+// receive updates the source with the responses of a stream until it fails.
+func (s *Source) receive() error {
+	path := strings.TrimPrefix(s.address, "unix://")
+	conn, err := grpc.Dial(path,
+		grpc.WithInsecure(),
+		grpc.WithDialer(func(addr string, timeout time.Duration) (net.Conn, error) {
+			return net.DialTimeout("unix", addr, timeout)
+		}))
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream, err := newX509SVIDStream(ctx, conn)
+	if err != nil {
+		return err
+	}
+	for {
+		resp, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+		if err := s.update(resp); err != nil {
+			return err
+		}
+	}
+}
+
+// update sets the first SVID of the response as the current SVID.
+func (s *Source) update(resp *X509SVIDResponse) error {
+	if len(resp.Svids) == 0 {
+		return fmt.Errorf("response has no SVID")
+	}
+	svid := resp.Svids[0]
+
+	chain, err := x509.ParseCertificates(svid.X509Svid)
+	if err != nil {
+		return fmt.Errorf("could not parse SVID of %s: %v", svid.SpiffeId, err)
+	}
+	if len(chain) == 0 {
+		return fmt.Errorf("SVID of %s has no certificate", svid.SpiffeId)
+	}
+	key, err := x509.ParsePKCS8PrivateKey(svid.X509SvidKey)
+	if err != nil {
+		return fmt.Errorf("could not parse key of %s: %v", svid.SpiffeId, err)
+	}
+	roots, err := x509.ParseCertificates(svid.Bundle)
+	if err != nil {
+		return fmt.Errorf("could not parse bundle of %s: %v", svid.SpiffeId, err)
+	}
+
+	cert := &tls.Certificate{
+		PrivateKey: key,
+		Leaf:       chain[0],
+	}
+	for _, c := range chain {
+		cert.Certificate = append(cert.Certificate, c.Raw)
+	}
+	bundle := x509.NewCertPool()
+	for _, root := range roots {
+		bundle.AddCert(root)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	first := s.cert == nil
+	s.id = svid.SpiffeId
+	s.cert = cert
+	s.bundle = bundle
+	s.err = nil
+	if first {
+		close(s.ready)
+	}
+	log.Printf("D! Received SVID %s from %s, valid until %s", svid.SpiffeId, s.address, chain[0].NotAfter)
+	return nil
+}
@@ -0,0 +1,186 @@
+package spiffe
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newTestCA(t *testing.T) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "example.org"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return &testCA{cert: cert, key: key}
+}
+
+func (ca *testCA) svid(t *testing.T, id string) *X509SVID {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	uri, err := url.Parse(id)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		URIs:         []*url.URL{uri},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	return &X509SVID{
+		SpiffeId:    id,
+		X509Svid:    der,
+		X509SvidKey: keyDER,
+		Bundle:      ca.cert.Raw,
+	}
+}
+
+// workloadAPI sends the responses of the channel to the streams.
+type workloadAPI struct {
+	responses chan *X509SVIDResponse
+}
+
+func (w *workloadAPI) fetchX509SVID(_ interface{}, stream grpc.ServerStream) error {
+	if err := stream.RecvMsg(&X509SVIDRequest{}); err != nil {
+		return err
+	}
+	for resp := range w.responses {
+		if err := stream.SendMsg(resp); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func startWorkloadAPI(t *testing.T, w *workloadAPI) (string, func()) {
+	dir, err := ioutil.TempDir("", "spiffe")
+	require.NoError(t, err)
+	path := filepath.Join(dir, "agent.sock")
+	listener, err := net.Listen("unix", path)
+	require.NoError(t, err)
+
+	server := grpc.NewServer()
+	server.RegisterService(&grpc.ServiceDesc{
+		ServiceName: "SpiffeWorkloadAPI",
+		HandlerType: (*interface{})(nil),
+		Streams: []grpc.StreamDesc{{
+			StreamName:    "FetchX509SVID",
+			Handler:       w.fetchX509SVID,
+			ServerStreams: true,
+		}},
+	}, w)
+	go server.Serve(listener)
+
+	return "unix://" + path, func() {
+		server.Stop()
+		os.RemoveAll(dir)
+	}
+}
+
+func handshake(t *testing.T, client, server *tls.Config) error {
+	clientConn, serverConn := net.Pipe()
+	defer clientConn.Close()
+	defer serverConn.Close()
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- tls.Server(serverConn, server).Handshake()
+	}()
+	err := tls.Client(clientConn, client).Handshake()
+	if serverErr := <-errs; err == nil {
+		err = serverErr
+	}
+	return err
+}
+
+func TestSource(t *testing.T) {
+	ca := newTestCA(t)
+	w := &workloadAPI{responses: make(chan *X509SVIDResponse, 2)}
+	address, stop := startWorkloadAPI(t, w)
+	defer stop()
+
+	w.responses <- &X509SVIDResponse{Svids: []*X509SVID{ca.svid(t, "spiffe://example.org/telegraf")}}
+	source, err := NewSource(address)
+	require.NoError(t, err)
+	require.Equal(t, "spiffe://example.org/telegraf", source.ID())
+
+	allowed, err := source.VerifyPeerCertificate([]string{"spiffe://example.org/*"})
+	require.NoError(t, err)
+	denied, err := source.VerifyPeerCertificate([]string{"spiffe://example.org/influxdb"})
+	require.NoError(t, err)
+
+	server := &tls.Config{
+		GetCertificate:        source.GetCertificate,
+		ClientAuth:            tls.RequireAnyClientCert,
+		VerifyPeerCertificate: allowed,
+	}
+	client := &tls.Config{
+		GetClientCertificate:  source.GetClientCertificate,
+		InsecureSkipVerify:    true,
+		VerifyPeerCertificate: allowed,
+	}
+	require.NoError(t, handshake(t, client, server))
+
+	client.VerifyPeerCertificate = denied
+	err = handshake(t, client, server)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "is not allowed")
+
+	// rotated SVIDs replace the current one
+	w.responses <- &X509SVIDResponse{Svids: []*X509SVID{ca.svid(t, "spiffe://example.org/telegraf/rotated")}}
+	for i := 0; i < 500 && source.ID() != "spiffe://example.org/telegraf/rotated"; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	require.Equal(t, "spiffe://example.org/telegraf/rotated", source.ID())
+}
+
+func TestSourceUntrustedPeer(t *testing.T) {
+	ca := newTestCA(t)
+	other := newTestCA(t)
+	w := &workloadAPI{responses: make(chan *X509SVIDResponse, 1)}
+	address, stop := startWorkloadAPI(t, w)
+	defer stop()
+
+	w.responses <- &X509SVIDResponse{Svids: []*X509SVID{ca.svid(t, "spiffe://example.org/telegraf")}}
+	source, err := NewSource(address)
+	require.NoError(t, err)
+	verify, err := source.VerifyPeerCertificate(nil)
+	require.NoError(t, err)
+
+	svid := other.svid(t, "spiffe://example.org/telegraf")
+	require.Error(t, verify([][]byte{svid.X509Svid}, nil))
+}
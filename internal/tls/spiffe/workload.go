@@ -0,0 +1,87 @@
+package spiffe
+
+import (
+	"github.com/golang/protobuf/proto"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// The messages of the X.509 SVID method of the SPIFFE Workload API, as
+// defined in workload.proto of the SPIFFE specification.
+
+// X509SVIDRequest requests the X.509 SVIDs of the workload.
+type X509SVIDRequest struct {
+}
+
+func (m *X509SVIDRequest) Reset()         { *m = X509SVIDRequest{} }
+func (m *X509SVIDRequest) String() string { return proto.CompactTextString(m) }
+func (*X509SVIDRequest) ProtoMessage()    {}
+
+// X509SVIDResponse holds the X.509 SVIDs of the workload, the Workload API
+// sends a new response whenever they are rotated.
+type X509SVIDResponse struct {
+	Svids []*X509SVID `protobuf:"bytes,1,rep,name=svids" json:"svids,omitempty"`
+}
+
+func (m *X509SVIDResponse) Reset()         { *m = X509SVIDResponse{} }
+func (m *X509SVIDResponse) String() string { return proto.CompactTextString(m) }
+func (*X509SVIDResponse) ProtoMessage()    {}
+
+// X509SVID is an SVID with its private key and the trust bundle of its
+// trust domain.
+type X509SVID struct {
+	// The SPIFFE ID of the SVID
+	SpiffeId string `protobuf:"bytes,1,opt,name=spiffe_id,json=spiffeId" json:"spiffe_id,omitempty"`
+	// ASN.1 DER encoded certificate chain, the leaf certificate first
+	X509Svid []byte `protobuf:"bytes,2,opt,name=x509_svid,json=x509Svid,proto3" json:"x509_svid,omitempty"`
+	// ASN.1 DER encoded PKCS#8 private key
+	X509SvidKey []byte `protobuf:"bytes,3,opt,name=x509_svid_key,json=x509SvidKey,proto3" json:"x509_svid_key,omitempty"`
+	// ASN.1 DER encoded CA certificates of the trust domain
+	Bundle []byte `protobuf:"bytes,4,opt,name=bundle,proto3" json:"bundle,omitempty"`
+}
+
+func (m *X509SVID) Reset()         { *m = X509SVID{} }
+func (m *X509SVID) String() string { return proto.CompactTextString(m) }
+func (*X509SVID) ProtoMessage()    {}
+
+// fetchX509SVID is the streaming method of the Workload API sending the
+// X.509 SVIDs.
+var fetchX509SVID = &grpc.StreamDesc{
+	StreamName:    "FetchX509SVID",
+	ServerStreams: true,
+}
+
+const fetchX509SVIDMethod = "/SpiffeWorkloadAPI/FetchX509SVID"
+
+// securityHeader must be set on the calls to the Workload API, it protects
+// against server side request forgery.
+const securityHeader = "workload.spiffe.io"
+
+// x509SVIDStream is the stream of X.509 SVID responses.
+type x509SVIDStream struct {
+	grpc.ClientStream
+}
+
+func (s *x509SVIDStream) Recv() (*X509SVIDResponse, error) {
+	m := new(X509SVIDResponse)
+	if err := s.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func newX509SVIDStream(ctx context.Context, cc *grpc.ClientConn) (*x509SVIDStream, error) {
+	ctx = metadata.NewOutgoingContext(ctx, metadata.Pairs(securityHeader, "true"))
+	stream, err := grpc.NewClientStream(ctx, fetchX509SVID, cc, fetchX509SVIDMethod)
+	if err != nil {
+		return nil, err
+	}
+	if err := stream.SendMsg(&X509SVIDRequest{}); err != nil {
+		return nil, err
+	}
+	if err := stream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return &x509SVIDStream{stream}, nil
+}
@@ -11,10 +11,15 @@ Usage:
 The commands & flags are:
 
   config              print out full sample configuration to stdout
+  config validate     check the configuration files and print their errors
+  plugins             print the available plugins
+  plugins --json      print the available plugins and their options as JSON
+  test                gather metrics once, print them to stdout, and exit
   version             print the version to stdout
 
   --config <file>     configuration file to load
   --test              gather metrics once, print them to stdout, and exit
+  --fixture <dir>     feed the recorded data of the directory to the inputs in test mode
   --config-directory  directory containing additional *.conf files
   --input-filter      filter the input plugins to enable, separator is :
   --output-filter     filter the output plugins to enable, separator is :
@@ -31,12 +36,21 @@ Examples:
   # generate a telegraf config file:
   telegraf config > telegraf.conf
 
+  # check a telegraf config file for unknown or invalid options
+  telegraf --config telegraf.conf config validate
+
+  # list the options of the cpu input plugin for tooling
+  telegraf --input-filter cpu plugins --json
+
   # generate config with only cpu input & influxdb output plugins defined
   telegraf --input-filter cpu --output-filter influxdb config
 
   # run a single telegraf collection, outputing metrics to stdout
   telegraf --config telegraf.conf --test
 
+  # check the parsing of the input of recorded data, printing its metrics
+  telegraf test --input-filter socket_listener --fixture testdata/statsd
+
   # run telegraf with all plugins defined in config file
   telegraf --config telegraf.conf
 
@@ -0,0 +1,70 @@
+// Package vsock implements stream sockets of the AF_VSOCK address family,
+// which connect virtual machines to their host without a network.
+package vsock
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+const (
+	// ContextIDAny is the context ID of listeners accepting connections
+	// from any context.
+	ContextIDAny = 0xffffffff
+	// ContextIDHost is the context ID of the host.
+	ContextIDHost = 2
+)
+
+// errClosed is returned by the operations on closed sockets, it has the
+// message of the net package error.
+var errClosed = errors.New("use of closed network connection")
+
+// Addr is the address of a vsock socket.
+type Addr struct {
+	ContextID uint32
+	Port      uint32
+}
+
+func (a *Addr) Network() string {
+	return "vsock"
+}
+
+func (a *Addr) String() string {
+	return fmt.Sprintf("%d:%d", a.ContextID, a.Port)
+}
+
+// ParseAddr parses an address of the form "cid:port".  The context ID is
+// either a number, "host" or empty for any context.
+func ParseAddr(address string) (*Addr, error) {
+	i := strings.LastIndex(address, ":")
+	if i < 0 {
+		return nil, fmt.Errorf("missing port in vsock address %q", address)
+	}
+
+	addr := &Addr{ContextID: ContextIDAny}
+	switch cid := address[:i]; cid {
+	case "":
+	case "host":
+		addr.ContextID = ContextIDHost
+	default:
+		n, err := strconv.ParseUint(cid, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid context ID in vsock address %q", address)
+		}
+		addr.ContextID = uint32(n)
+	}
+
+	port, err := strconv.ParseUint(address[i+1:], 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid port in vsock address %q", address)
+	}
+	addr.Port = uint32(port)
+	return addr, nil
+}
+
+func opError(op string, addr net.Addr, err error) error {
+	return &net.OpError{Op: op, Net: "vsock", Addr: addr, Err: err}
+}
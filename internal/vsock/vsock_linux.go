@@ -0,0 +1,128 @@
+package vsock
+
+import (
+	"net"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+type listener struct {
+	f    *os.File
+	addr *Addr
+}
+
+// Listen listens for connections on the address.
+func Listen(addr *Addr) (net.Listener, error) {
+	fd, err := unix.Socket(unix.AF_VSOCK, unix.SOCK_STREAM|unix.SOCK_NONBLOCK|unix.SOCK_CLOEXEC, 0)
+	if err != nil {
+		return nil, opError("listen", addr, err)
+	}
+	if err := unix.Bind(fd, &unix.SockaddrVM{CID: addr.ContextID, Port: addr.Port}); err != nil {
+		unix.Close(fd)
+		return nil, opError("listen", addr, err)
+	}
+	if err := unix.Listen(fd, unix.SOMAXCONN); err != nil {
+		unix.Close(fd)
+		return nil, opError("listen", addr, err)
+	}
+
+	local, err := localAddr(fd)
+	if err != nil {
+		unix.Close(fd)
+		return nil, opError("listen", addr, err)
+	}
+	// the file of a non-blocking descriptor waits with the runtime poller
+	return &listener{f: os.NewFile(uintptr(fd), "vsock:"+local.String()), addr: local}, nil
+}
+
+func (l *listener) Accept() (net.Conn, error) {
+	rc, err := l.f.SyscallConn()
+	if err != nil {
+		return nil, opError("accept", l.addr, errClosed)
+	}
+
+	var (
+		nfd int
+		sa  unix.Sockaddr
+	)
+	rerr := rc.Read(func(fd uintptr) bool {
+		nfd, sa, err = unix.Accept4(int(fd), unix.SOCK_NONBLOCK|unix.SOCK_CLOEXEC)
+		return err != unix.EAGAIN
+	})
+	if rerr != nil {
+		return nil, opError("accept", l.addr, errClosed)
+	}
+	if err != nil {
+		return nil, opError("accept", l.addr, err)
+	}
+
+	remote := &Addr{}
+	if vm, ok := sa.(*unix.SockaddrVM); ok {
+		remote = &Addr{ContextID: vm.CID, Port: vm.Port}
+	}
+	return newConn(nfd, l.addr, remote), nil
+}
+
+func (l *listener) Close() error {
+	return l.f.Close()
+}
+
+func (l *listener) Addr() net.Addr {
+	return l.addr
+}
+
+type conn struct {
+	*os.File
+	local  *Addr
+	remote *Addr
+}
+
+func newConn(fd int, local, remote *Addr) *conn {
+	return &conn{
+		File:   os.NewFile(uintptr(fd), "vsock:"+remote.String()),
+		local:  local,
+		remote: remote,
+	}
+}
+
+// Dial connects to the address.
+func Dial(addr *Addr) (net.Conn, error) {
+	fd, err := unix.Socket(unix.AF_VSOCK, unix.SOCK_STREAM|unix.SOCK_CLOEXEC, 0)
+	if err != nil {
+		return nil, opError("dial", addr, err)
+	}
+	if err := unix.Connect(fd, &unix.SockaddrVM{CID: addr.ContextID, Port: addr.Port}); err != nil {
+		unix.Close(fd)
+		return nil, opError("dial", addr, err)
+	}
+	local, err := localAddr(fd)
+	if err == nil {
+		err = unix.SetNonblock(fd, true)
+	}
+	if err != nil {
+		unix.Close(fd)
+		return nil, opError("dial", addr, err)
+	}
+	return newConn(fd, local, addr), nil
+}
+
+func (c *conn) LocalAddr() net.Addr {
+	return c.local
+}
+
+func (c *conn) RemoteAddr() net.Addr {
+	return c.remote
+}
+
+func localAddr(fd int) (*Addr, error) {
+	sa, err := unix.Getsockname(fd)
+	if err != nil {
+		return nil, err
+	}
+	vm, ok := sa.(*unix.SockaddrVM)
+	if !ok {
+		return nil, unix.EAFNOSUPPORT
+	}
+	return &Addr{ContextID: vm.CID, Port: vm.Port}, nil
+}
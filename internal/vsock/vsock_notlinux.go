@@ -0,0 +1,20 @@
+// +build !linux
+
+package vsock
+
+import (
+	"errors"
+	"net"
+)
+
+var errUnsupported = errors.New("vsock sockets are only supported on Linux")
+
+// Listen listens for connections on the address.
+func Listen(addr *Addr) (net.Listener, error) {
+	return nil, opError("listen", addr, errUnsupported)
+}
+
+// Dial connects to the address.
+func Dial(addr *Addr) (net.Conn, error) {
+	return nil, opError("dial", addr, errUnsupported)
+}
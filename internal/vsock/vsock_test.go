@@ -0,0 +1,28 @@
+package vsock
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAddr(t *testing.T) {
+	tests := []struct {
+		address string
+		addr    *Addr
+	}{
+		{":1234", &Addr{ContextID: ContextIDAny, Port: 1234}},
+		{"host:1234", &Addr{ContextID: ContextIDHost, Port: 1234}},
+		{"3:8094", &Addr{ContextID: 3, Port: 8094}},
+	}
+	for _, tt := range tests {
+		addr, err := ParseAddr(tt.address)
+		require.NoError(t, err, tt.address)
+		require.Equal(t, tt.addr, addr)
+	}
+
+	for _, address := range []string{"1234", "vm:1234", "3:", "3:http"} {
+		_, err := ParseAddr(address)
+		require.Error(t, err, address)
+	}
+}
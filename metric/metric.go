@@ -9,6 +9,11 @@ import (
 	"github.com/influxdata/telegraf"
 )
 
+// metric holds its tags and fields in lists sorted by key for the tags.  The
+// lists of a copy are shared with the original metric until one of them
+// changes: the lists are never changed in place but replaced, and the
+// capacity of the lists of the copy is limited to their length so that
+// appending to them reallocates.
 type metric struct {
 	name   string
 	tags   []*telegraf.Tag
@@ -41,22 +46,26 @@ func New(
 		tp:     vtype,
 	}
 
+	// The tags and fields are allocated together, rather than one at a time
 	if len(tags) > 0 {
+		tagValues := make([]telegraf.Tag, 0, len(tags))
 		m.tags = make([]*telegraf.Tag, 0, len(tags))
 		for k, v := range tags {
-			m.tags = append(m.tags,
-				&telegraf.Tag{Key: k, Value: v})
+			tagValues = append(tagValues, telegraf.Tag{Key: k, Value: v})
+			m.tags = append(m.tags, &tagValues[len(tagValues)-1])
 		}
 		sort.Slice(m.tags, func(i, j int) bool { return m.tags[i].Key < m.tags[j].Key })
 	}
 
+	fieldValues := make([]telegraf.Field, 0, len(fields))
 	m.fields = make([]*telegraf.Field, 0, len(fields))
 	for k, v := range fields {
 		v := convertField(v)
 		if v == nil {
 			continue
 		}
-		m.AddField(k, v)
+		fieldValues = append(fieldValues, telegraf.Field{Key: k, Value: v})
+		m.fields = append(m.fields, &fieldValues[len(fieldValues)-1])
 	}
 
 	return m, nil
@@ -122,13 +131,11 @@ func (m *metric) AddTag(key, value string) {
 		}
 
 		if key == tag.Key {
-			tag.Value = value
+			m.tags = replaceTag(m.tags, i, 1, &telegraf.Tag{Key: key, Value: value})
 			return
 		}
 
-		m.tags = append(m.tags, nil)
-		copy(m.tags[i+1:], m.tags[i:])
-		m.tags[i] = &telegraf.Tag{Key: key, Value: value}
+		m.tags = replaceTag(m.tags, i, 0, &telegraf.Tag{Key: key, Value: value})
 		return
 	}
 
@@ -156,9 +163,7 @@ func (m *metric) GetTag(key string) (string, bool) {
 func (m *metric) RemoveTag(key string) {
 	for i, tag := range m.tags {
 		if tag.Key == key {
-			copy(m.tags[i:], m.tags[i+1:])
-			m.tags[len(m.tags)-1] = nil
-			m.tags = m.tags[:len(m.tags)-1]
+			m.tags = replaceTag(m.tags, i, 1)
 			return
 		}
 	}
@@ -167,7 +172,8 @@ func (m *metric) RemoveTag(key string) {
 func (m *metric) AddField(key string, value interface{}) {
 	for i, field := range m.fields {
 		if key == field.Key {
-			m.fields[i] = &telegraf.Field{Key: key, Value: convertField(value)}
+			m.fields = replaceField(m.fields, i, 1, &telegraf.Field{Key: key, Value: convertField(value)})
+			return
 		}
 	}
 	m.fields = append(m.fields, &telegraf.Field{Key: key, Value: convertField(value)})
@@ -194,9 +200,7 @@ func (m *metric) GetField(key string) (interface{}, bool) {
 func (m *metric) RemoveField(key string) {
 	for i, field := range m.fields {
 		if field.Key == key {
-			copy(m.fields[i:], m.fields[i+1:])
-			m.fields[len(m.fields)-1] = nil
-			m.fields = m.fields[:len(m.fields)-1]
+			m.fields = replaceField(m.fields, i, 1)
 			return
 		}
 	}
@@ -206,24 +210,34 @@ func (m *metric) SetTime(t time.Time) {
 	m.tm = t
 }
 
+// Copy returns a copy sharing the tag and field lists of the metric, they are
+// copied on the first change of either metric.
 func (m *metric) Copy() telegraf.Metric {
-	m2 := &metric{
-		name:      m.name,
-		tags:      make([]*telegraf.Tag, len(m.tags)),
-		fields:    make([]*telegraf.Field, len(m.fields)),
-		tm:        m.tm,
-		tp:        m.tp,
-		aggregate: m.aggregate,
-	}
+	m2 := *m
+	// the original is not changed, metrics are copied concurrently; it only
+	// appends beyond the length of the lists of the copy
+	m2.tags = m.tags[:len(m.tags):len(m.tags)]
+	m2.fields = m.fields[:len(m.fields):len(m.fields)]
+	return &m2
+}
 
-	for i, tag := range m.tags {
-		m2.tags[i] = tag
-	}
+// replaceTag returns a new list of the tags with the n tags at i replaced by
+// the given tags, the list may be shared with copies so it is not changed.
+func replaceTag(tags []*telegraf.Tag, i, n int, with ...*telegraf.Tag) []*telegraf.Tag {
+	out := make([]*telegraf.Tag, 0, len(tags)-n+len(with))
+	out = append(out, tags[:i]...)
+	out = append(out, with...)
+	return append(out, tags[i+n:]...)
+}
 
-	for i, field := range m.fields {
-		m2.fields[i] = field
-	}
-	return m2
+// replaceField returns a new list of the fields with the n fields at i
+// replaced by the given fields, the list may be shared with copies so it is
+// not changed.
+func replaceField(fields []*telegraf.Field, i, n int, with ...*telegraf.Field) []*telegraf.Field {
+	out := make([]*telegraf.Field, 0, len(fields)-n+len(with))
+	out = append(out, fields[:i]...)
+	out = append(out, with...)
+	return append(out, fields[i+n:]...)
 }
 
 func (m *metric) SetAggregate(b bool) {
@@ -1,6 +1,7 @@
 package metric
 
 import (
+	"sync"
 	"testing"
 	"time"
 
@@ -335,3 +336,91 @@ func TestCopyAggreate(t *testing.T) {
 	m2 := m1.Copy()
 	assert.True(t, m2.IsAggregate())
 }
+
+func TestCopyOnWrite(t *testing.T) {
+	m1, err := New("cpu",
+		map[string]string{"host": "localhost", "cpu": "cpu0"},
+		map[string]interface{}{"usage_idle": 42.0, "usage_user": 8.0},
+		time.Now(),
+	)
+	require.NoError(t, err)
+	m2 := m1.Copy()
+
+	m2.AddTag("host", "remote")
+	m2.AddTag("datacenter", "us-east-1")
+	m2.RemoveTag("cpu")
+	m2.AddField("usage_idle", 40.0)
+	m2.RemoveField("usage_user")
+	m2.AddField("usage_system", 2.0)
+
+	require.Equal(t, map[string]string{"host": "localhost", "cpu": "cpu0"}, m1.Tags())
+	require.Equal(t, map[string]interface{}{"usage_idle": 42.0, "usage_user": 8.0}, m1.Fields())
+	require.Equal(t, map[string]string{"host": "remote", "datacenter": "us-east-1"}, m2.Tags())
+	require.Equal(t, map[string]interface{}{"usage_idle": 40.0, "usage_system": 2.0}, m2.Fields())
+
+	// changing the original does not change the copy
+	m3 := m1.Copy()
+	m1.AddTag("host", "changed")
+	m1.AddField("usage_guest", 0.0)
+	require.Equal(t, map[string]string{"host": "localhost", "cpu": "cpu0"}, m3.Tags())
+	require.Len(t, m3.FieldList(), 2)
+}
+
+func TestCopyAppend(t *testing.T) {
+	// the skipped nil field leaves room to append to the list of m1
+	m1, err := New("cpu",
+		map[string]string{"host": "localhost"},
+		map[string]interface{}{"usage_idle": 42.0, "usage_user": nil},
+		time.Now(),
+	)
+	require.NoError(t, err)
+	m2 := m1.Copy()
+
+	m1.AddField("usage_guest", 0.0)
+	m2.AddField("usage_system", 2.0)
+	require.Equal(t, map[string]interface{}{"usage_idle": 42.0, "usage_guest": 0.0}, m1.Fields())
+	require.Equal(t, map[string]interface{}{"usage_idle": 42.0, "usage_system": 2.0}, m2.Fields())
+}
+
+// Outputs copy the same metric concurrently, run with -race.
+func TestCopyConcurrent(t *testing.T) {
+	m := baseMetric()
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c := m.Copy()
+			c.AddTag("output", "file")
+			c.AddField("count", 1)
+		}()
+	}
+	wg.Wait()
+	require.False(t, m.HasTag("output"))
+}
+
+func TestAddFieldOverwriteKeepsOneField(t *testing.T) {
+	m := baseMetric()
+	m.AddField("value", 42.0)
+	require.Len(t, m.FieldList(), 1)
+}
+
+func BenchmarkNew(b *testing.B) {
+	tags := map[string]string{"host": "localhost", "cpu": "cpu0", "datacenter": "us-east-1"}
+	fields := map[string]interface{}{"usage_idle": 42.0, "usage_user": 8.0, "usage_system": 2.0}
+	now := time.Now()
+	for n := 0; n < b.N; n++ {
+		New("cpu", tags, fields, now)
+	}
+}
+
+func BenchmarkCopy(b *testing.B) {
+	m, _ := New("cpu",
+		map[string]string{"host": "localhost", "cpu": "cpu0", "datacenter": "us-east-1"},
+		map[string]interface{}{"usage_idle": 42.0, "usage_user": 8.0, "usage_system": 2.0},
+		time.Now(),
+	)
+	for n := 0; n < b.N; n++ {
+		m.Copy()
+	}
+}
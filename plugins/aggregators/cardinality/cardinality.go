@@ -0,0 +1,164 @@
+package cardinality
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/aggregators"
+)
+
+const (
+	defaultPrecision = 14
+	minPrecision     = 4
+	maxPrecision     = 16
+)
+
+type Cardinality struct {
+	Tags      []string `toml:"tags"`
+	Fields    []string `toml:"fields"`
+	Precision int      `toml:"precision"`
+
+	cache map[string]aggregate
+}
+
+func NewCardinality() *Cardinality {
+	c := &Cardinality{
+		Precision: defaultPrecision,
+	}
+	c.Reset()
+	return c
+}
+
+type aggregate struct {
+	name     string
+	tags     map[string]string
+	counters map[string]*hyperLogLog
+}
+
+var sampleConfig = `
+  ## General Aggregator Arguments:
+  ## The period on which to flush & clear the aggregator.
+  period = "30s"
+  ## If true, the original metric will be dropped by the
+  ## aggregator and will not get sent to the output plugins.
+  drop_original = false
+
+  ## Cardinality Arguments:
+  ## Tag keys whose distinct values are counted.  Counted tags are removed
+  ## from the series the estimate is reported on.
+  # tags = ["client"]
+
+  ## String fields whose distinct values are counted.
+  # fields = ["src_ip"]
+
+  ## Precision of the estimate, between 4 and 16.  Higher precision uses
+  ## 2^precision bytes per counter and gives a standard error of about
+  ## 1.04/sqrt(2^precision).
+  # precision = 14
+`
+
+func (c *Cardinality) SampleConfig() string {
+	return sampleConfig
+}
+
+func (c *Cardinality) Description() string {
+	return "Estimate the number of distinct values of tags or string fields."
+}
+
+func (c *Cardinality) Init() error {
+	if c.Precision < minPrecision || c.Precision > maxPrecision {
+		return fmt.Errorf("precision must be between %d and %d, got %d",
+			minPrecision, maxPrecision, c.Precision)
+	}
+	return nil
+}
+
+func (c *Cardinality) Add(in telegraf.Metric) {
+	values := make(map[string]string)
+	tags := make(map[string]string)
+	for k, v := range in.Tags() {
+		if contains(c.Tags, k) {
+			values[k] = v
+			continue
+		}
+		tags[k] = v
+	}
+	for _, k := range c.Fields {
+		if v, ok := in.GetField(k); ok {
+			if sv, ok := v.(string); ok {
+				values[k] = sv
+			}
+		}
+	}
+	if len(values) == 0 {
+		return
+	}
+
+	id := groupID(in.Name(), tags)
+	a, ok := c.cache[id]
+	if !ok {
+		a = aggregate{
+			name:     in.Name(),
+			tags:     tags,
+			counters: make(map[string]*hyperLogLog),
+		}
+		c.cache[id] = a
+	}
+
+	for k, v := range values {
+		hll, ok := a.counters[k]
+		if !ok {
+			hll = newHyperLogLog(uint8(c.Precision))
+			a.counters[k] = hll
+		}
+		hll.Insert(v)
+	}
+}
+
+func (c *Cardinality) Push(acc telegraf.Accumulator) {
+	for _, a := range c.cache {
+		fields := make(map[string]interface{}, len(a.counters))
+		for k, hll := range a.counters {
+			fields[k+"_cardinality"] = int64(hll.Estimate())
+		}
+		acc.AddFields(a.name, fields, a.tags)
+	}
+}
+
+func (c *Cardinality) Reset() {
+	c.cache = make(map[string]aggregate)
+}
+
+// groupID identifies a series by its name and the tags that are not being
+// counted.
+func groupID(name string, tags map[string]string) string {
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys)+1)
+	parts = append(parts, name)
+	for _, k := range keys {
+		parts = append(parts, k+"="+tags[k])
+	}
+	return strings.Join(parts, "\n")
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func init() {
+	aggregators.Add("cardinality", func() telegraf.Aggregator {
+		return NewCardinality()
+	})
+}
@@ -0,0 +1,100 @@
+package cardinality
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func newMetric(volume, client, ip string) telegraf.Metric {
+	m, _ := metric.New("connections",
+		map[string]string{"volume": volume, "client": client},
+		map[string]interface{}{"src_ip": ip, "bytes": int64(1)},
+		time.Now(),
+	)
+	return m
+}
+
+func TestCardinalityExact(t *testing.T) {
+	acc := testutil.Accumulator{}
+	c := NewCardinality()
+	c.Tags = []string{"client"}
+	c.Fields = []string{"src_ip"}
+
+	c.Add(newMetric("vol1", "a", "10.0.0.1"))
+	c.Add(newMetric("vol1", "b", "10.0.0.1"))
+	c.Add(newMetric("vol1", "c", "10.0.0.2"))
+	c.Add(newMetric("vol1", "a", "10.0.0.2"))
+	c.Add(newMetric("vol2", "a", "10.0.0.3"))
+	c.Push(&acc)
+
+	assert.Len(t, acc.Metrics, 2)
+	acc.AssertContainsTaggedFields(t, "connections",
+		map[string]interface{}{
+			"client_cardinality": int64(3),
+			"src_ip_cardinality": int64(2),
+		},
+		map[string]string{"volume": "vol1"})
+	acc.AssertContainsTaggedFields(t, "connections",
+		map[string]interface{}{
+			"client_cardinality": int64(1),
+			"src_ip_cardinality": int64(1),
+		},
+		map[string]string{"volume": "vol2"})
+}
+
+func TestCardinalityEstimate(t *testing.T) {
+	acc := testutil.Accumulator{}
+	c := NewCardinality()
+	c.Fields = []string{"src_ip"}
+
+	n := 100000
+	for i := 0; i < n; i++ {
+		c.Add(newMetric("vol1", "a", fmt.Sprintf("ip-%d", i)))
+		c.Add(newMetric("vol1", "a", fmt.Sprintf("ip-%d", i)))
+	}
+	c.Push(&acc)
+
+	est, ok := acc.Int64Field("connections", "src_ip_cardinality")
+	assert.True(t, ok)
+	assert.InEpsilon(t, float64(n), float64(est), 0.03)
+}
+
+func TestCardinalityNothingCounted(t *testing.T) {
+	acc := testutil.Accumulator{}
+	c := NewCardinality()
+	c.Tags = []string{"missing"}
+
+	c.Add(newMetric("vol1", "a", "10.0.0.1"))
+	c.Push(&acc)
+
+	assert.Len(t, acc.Metrics, 0)
+}
+
+func TestCardinalityInit(t *testing.T) {
+	c := NewCardinality()
+	assert.NoError(t, c.Init())
+
+	c.Precision = 30
+	assert.Error(t, c.Init())
+
+	c.Precision = 0
+	assert.Error(t, c.Init())
+}
+
+func TestCardinalityReset(t *testing.T) {
+	acc := testutil.Accumulator{}
+	c := NewCardinality()
+	c.Tags = []string{"client"}
+
+	c.Add(newMetric("vol1", "a", "10.0.0.1"))
+	c.Reset()
+	c.Push(&acc)
+
+	assert.Len(t, acc.Metrics, 0)
+}
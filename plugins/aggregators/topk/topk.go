@@ -0,0 +1,250 @@
+package topk
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/aggregators"
+)
+
+type TopK struct {
+	K            int    `toml:"k"`
+	Field        string `toml:"field"`
+	Aggregation  string `toml:"aggregation"`
+	Bottomk      bool   `toml:"bottomk"`
+	AddRemainder bool   `toml:"add_remainder"`
+	RemainderTag string `toml:"remainder_tag"`
+
+	cache map[uint64]aggregate
+}
+
+func NewTopK() *TopK {
+	tk := &TopK{
+		K:            10,
+		Field:        "value",
+		Aggregation:  "mean",
+		RemainderTag: "topk_remainder",
+	}
+	tk.Reset()
+	return tk
+}
+
+type aggregate struct {
+	name string
+	tags map[string]string
+	// key orders the series with the same aggregate
+	key   string
+	count float64
+	sum   float64
+	min   float64
+	max   float64
+}
+
+var sampleConfig = `
+  ## General Aggregator Arguments:
+  ## The period on which to flush & clear the aggregator.
+  period = "30s"
+  ## If true, the original metric will be dropped by the
+  ## aggregator and will not get sent to the output plugins.
+  drop_original = false
+
+  ## TopK Arguments:
+  ## How many series to keep per measurement each period.
+  # k = 10
+
+  ## The field the series are ranked by.
+  # field = "value"
+
+  ## What aggregation of the field to rank by. Options: sum, mean, min, max
+  # aggregation = "mean"
+
+  ## Instead of the top k largest series, keep the bottom k lowest series.
+  # bottomk = false
+
+  ## If true, the series that did not make the cut are combined into one
+  ## additional series per measurement tagged with remainder_tag="true".
+  # add_remainder = false
+  # remainder_tag = "topk_remainder"
+`
+
+func (t *TopK) SampleConfig() string {
+	return sampleConfig
+}
+
+func (t *TopK) Description() string {
+	return "Keep only the k series with the highest (or lowest) aggregate of a field each period."
+}
+
+func (t *TopK) Init() error {
+	if t.K < 1 {
+		return fmt.Errorf("k must be at least 1, got %d", t.K)
+	}
+	switch t.Aggregation {
+	case "sum", "mean", "min", "max":
+	default:
+		return fmt.Errorf("unknown aggregation %q, must be sum, mean, min or max", t.Aggregation)
+	}
+	return nil
+}
+
+func (t *TopK) Add(in telegraf.Metric) {
+	v, ok := in.GetField(t.Field)
+	if !ok {
+		return
+	}
+	fv, ok := convert(v)
+	if !ok {
+		return
+	}
+
+	id := in.HashID()
+	a, ok := t.cache[id]
+	if !ok {
+		a = aggregate{
+			name: in.Name(),
+			tags: in.Tags(),
+			key:  seriesKey(in.Tags()),
+			min:  fv,
+			max:  fv,
+		}
+	}
+	a.count++
+	a.sum += fv
+	if fv < a.min {
+		a.min = fv
+	}
+	if fv > a.max {
+		a.max = fv
+	}
+	t.cache[id] = a
+}
+
+func (t *TopK) Push(acc telegraf.Accumulator) {
+	byName := make(map[string][]aggregate)
+	for _, a := range t.cache {
+		byName[a.name] = append(byName[a.name], a)
+	}
+
+	fieldName := t.Field + "_" + t.Aggregation
+	for name, aggs := range byName {
+		t.sort(aggs)
+
+		k := t.K
+		if k < 0 {
+			k = 0
+		}
+		if k > len(aggs) {
+			k = len(aggs)
+		}
+		for _, a := range aggs[:k] {
+			fields := map[string]interface{}{
+				fieldName: t.value(a),
+			}
+			acc.AddFields(name, fields, a.tags)
+		}
+
+		if t.AddRemainder && len(aggs) > k {
+			t.pushRemainder(acc, name, fieldName, aggs[k:])
+		}
+	}
+}
+
+// pushRemainder combines the series that were cut into a single series
+// carrying the tags they have in common.
+func (t *TopK) pushRemainder(
+	acc telegraf.Accumulator,
+	name string,
+	fieldName string,
+	aggs []aggregate,
+) {
+	rest := aggs[0]
+	tags := make(map[string]string)
+	for k, v := range rest.tags {
+		tags[k] = v
+	}
+	for _, a := range aggs[1:] {
+		rest.count += a.count
+		rest.sum += a.sum
+		if a.min < rest.min {
+			rest.min = a.min
+		}
+		if a.max > rest.max {
+			rest.max = a.max
+		}
+		for k, v := range tags {
+			if a.tags[k] != v {
+				delete(tags, k)
+			}
+		}
+	}
+	tags[t.RemainderTag] = "true"
+
+	fields := map[string]interface{}{
+		fieldName:      t.value(rest),
+		"series_count": int64(len(aggs)),
+	}
+	acc.AddFields(name, fields, tags)
+}
+
+// sort orders the aggregates so that the series to keep come first, the
+// series with the same aggregate are ordered by their tags.
+func (t *TopK) sort(aggs []aggregate) {
+	sort.SliceStable(aggs, func(i, j int) bool {
+		vi, vj := t.value(aggs[i]), t.value(aggs[j])
+		if vi == vj {
+			return aggs[i].key < aggs[j].key
+		}
+		if t.Bottomk {
+			return vi < vj
+		}
+		return vi > vj
+	})
+}
+
+// seriesKey returns the sorted tags of a series.
+func seriesKey(tags map[string]string) string {
+	pairs := make([]string, 0, len(tags))
+	for k, v := range tags {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+func (t *TopK) value(a aggregate) float64 {
+	switch t.Aggregation {
+	case "sum":
+		return a.sum
+	case "min":
+		return a.min
+	case "max":
+		return a.max
+	default:
+		return a.sum / a.count
+	}
+}
+
+func (t *TopK) Reset() {
+	t.cache = make(map[uint64]aggregate)
+}
+
+func convert(in interface{}) (float64, bool) {
+	switch v := in.(type) {
+	case float64:
+		return v, true
+	case int64:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	default:
+		return 0, false
+	}
+}
+
+func init() {
+	aggregators.Add("topk", func() telegraf.Aggregator {
+		return NewTopK()
+	})
+}
@@ -0,0 +1,140 @@
+package topk
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func newMetric(host string, value float64) telegraf.Metric {
+	m, _ := metric.New("cpu",
+		map[string]string{"host": host, "cpu": "total"},
+		map[string]interface{}{"value": value},
+		time.Now(),
+	)
+	return m
+}
+
+func addAll(tk *TopK) {
+	tk.Add(newMetric("a", 1))
+	tk.Add(newMetric("a", 3))
+	tk.Add(newMetric("b", 10))
+	tk.Add(newMetric("c", 5))
+	tk.Add(newMetric("c", 7))
+	tk.Add(newMetric("d", 0))
+}
+
+func TestTopKMean(t *testing.T) {
+	acc := testutil.Accumulator{}
+	tk := NewTopK()
+	tk.K = 2
+	addAll(tk)
+	tk.Push(&acc)
+
+	assert.Len(t, acc.Metrics, 2)
+	acc.AssertContainsTaggedFields(t, "cpu",
+		map[string]interface{}{"value_mean": float64(10)},
+		map[string]string{"host": "b", "cpu": "total"})
+	acc.AssertContainsTaggedFields(t, "cpu",
+		map[string]interface{}{"value_mean": float64(6)},
+		map[string]string{"host": "c", "cpu": "total"})
+}
+
+func TestTopKBottomSum(t *testing.T) {
+	acc := testutil.Accumulator{}
+	tk := NewTopK()
+	tk.K = 2
+	tk.Aggregation = "sum"
+	tk.Bottomk = true
+	addAll(tk)
+	tk.Push(&acc)
+
+	assert.Len(t, acc.Metrics, 2)
+	acc.AssertContainsTaggedFields(t, "cpu",
+		map[string]interface{}{"value_sum": float64(0)},
+		map[string]string{"host": "d", "cpu": "total"})
+	acc.AssertContainsTaggedFields(t, "cpu",
+		map[string]interface{}{"value_sum": float64(4)},
+		map[string]string{"host": "a", "cpu": "total"})
+}
+
+func TestTopKRemainder(t *testing.T) {
+	acc := testutil.Accumulator{}
+	tk := NewTopK()
+	tk.K = 1
+	tk.Aggregation = "max"
+	tk.AddRemainder = true
+	addAll(tk)
+	tk.Push(&acc)
+
+	assert.Len(t, acc.Metrics, 2)
+	acc.AssertContainsTaggedFields(t, "cpu",
+		map[string]interface{}{"value_max": float64(10)},
+		map[string]string{"host": "b", "cpu": "total"})
+	acc.AssertContainsTaggedFields(t, "cpu",
+		map[string]interface{}{
+			"value_max":    float64(7),
+			"series_count": int64(3),
+		},
+		map[string]string{"cpu": "total", "topk_remainder": "true"})
+}
+
+func TestTopKIgnoresOtherFields(t *testing.T) {
+	acc := testutil.Accumulator{}
+	tk := NewTopK()
+	m, _ := metric.New("cpu",
+		map[string]string{},
+		map[string]interface{}{"usage": float64(1), "value": "string"},
+		time.Now(),
+	)
+	tk.Add(m)
+	tk.Push(&acc)
+
+	assert.Len(t, acc.Metrics, 0)
+}
+
+func TestTopKReset(t *testing.T) {
+	acc := testutil.Accumulator{}
+	tk := NewTopK()
+	addAll(tk)
+	tk.Reset()
+	tk.Push(&acc)
+
+	assert.Len(t, acc.Metrics, 0)
+}
+
+func TestTopKInit(t *testing.T) {
+	tk := NewTopK()
+	assert.NoError(t, tk.Init())
+
+	tk.K = 0
+	assert.Error(t, tk.Init())
+
+	tk.K = -1
+	assert.Error(t, tk.Init())
+
+	tk.K = 1
+	tk.Aggregation = "median"
+	assert.Error(t, tk.Init())
+}
+
+func TestTopKTies(t *testing.T) {
+	for i := 0; i < 10; i++ {
+		acc := testutil.Accumulator{}
+		tk := NewTopK()
+		tk.K = 2
+		tk.Add(newMetric("d", 5))
+		tk.Add(newMetric("b", 5))
+		tk.Add(newMetric("c", 5))
+		tk.Add(newMetric("a", 5))
+		tk.Push(&acc)
+
+		assert.Len(t, acc.Metrics, 2)
+		assert.Equal(t, "a", acc.Metrics[0].Tags["host"])
+		assert.Equal(t, "b", acc.Metrics[1].Tags["host"])
+	}
+}
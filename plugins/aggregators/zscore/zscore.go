@@ -0,0 +1,220 @@
+package zscore
+
+import (
+	"math"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/aggregators"
+)
+
+type ZScore struct {
+	Fields     []string `toml:"fields"`
+	WindowSize int      `toml:"window_size"`
+	MinSamples int      `toml:"min_samples"`
+	Threshold  float64  `toml:"threshold"`
+	// SeriesTimeout is how long the history of a series not seen is kept
+	SeriesTimeout internal.Duration `toml:"series_timeout"`
+
+	cache map[uint64]*series
+}
+
+func NewZScore() *ZScore {
+	z := &ZScore{
+		WindowSize:    100,
+		MinSamples:    10,
+		Threshold:     3,
+		SeriesTimeout: internal.Duration{Duration: time.Hour},
+	}
+	z.cache = make(map[uint64]*series)
+	return z
+}
+
+// series holds the sliding history of each field of a series, which is kept
+// between periods, and the values seen during the current period.
+type series struct {
+	name    string
+	tags    map[string]string
+	history map[string]*window
+	current map[string][]float64
+	// updated is when a value of the series was last added
+	updated time.Time
+}
+
+// window is a fixed size ring of the most recent values of a field.
+type window struct {
+	values []float64
+	next   int
+}
+
+var sampleConfig = `
+  ## General Aggregator Arguments:
+  ## The period on which to flush & clear the aggregator.
+  period = "30s"
+  ## If true, the original metric will be dropped by the
+  ## aggregator and will not get sent to the output plugins.
+  drop_original = false
+
+  ## ZScore Arguments:
+  ## Fields to score, if empty all numeric fields are scored.
+  # fields = []
+
+  ## Number of most recent values per field that the mean and standard
+  ## deviation are computed over.  The history is kept across periods.
+  # window_size = 100
+
+  ## Number of values required in the history before a score is emitted.
+  # min_samples = 10
+
+  ## Absolute z-score above which the period is flagged as an anomaly.
+  # threshold = 3.0
+
+  ## The history of the series not seen for this long is dropped, to bound
+  ## the memory when tags have a high cardinality.  0 keeps it forever.
+  # series_timeout = "1h"
+`
+
+func (z *ZScore) SampleConfig() string {
+	return sampleConfig
+}
+
+func (z *ZScore) Description() string {
+	return "Score each period against the rolling mean and standard deviation of each field."
+}
+
+func (z *ZScore) Add(in telegraf.Metric) {
+	id := in.HashID()
+	s, ok := z.cache[id]
+	if !ok {
+		s = &series{
+			name:    in.Name(),
+			tags:    in.Tags(),
+			history: make(map[string]*window),
+			current: make(map[string][]float64),
+		}
+		z.cache[id] = s
+	}
+	s.updated = time.Now()
+
+	for k, v := range in.Fields() {
+		if len(z.Fields) > 0 && !contains(z.Fields, k) {
+			continue
+		}
+		if fv, ok := convert(v); ok {
+			s.current[k] = append(s.current[k], fv)
+		}
+	}
+}
+
+func (z *ZScore) Push(acc telegraf.Accumulator) {
+	for id, s := range z.cache {
+		if z.SeriesTimeout.Duration > 0 && time.Since(s.updated) > z.SeriesTimeout.Duration {
+			delete(z.cache, id)
+			continue
+		}
+		fields := map[string]interface{}{}
+		for k, values := range s.current {
+			w, ok := s.history[k]
+			if !ok {
+				w = &window{values: make([]float64, 0, z.windowSize())}
+				s.history[k] = w
+			}
+
+			if len(w.values) >= z.MinSamples && len(w.values) > 1 {
+				mean, stdev := w.stats()
+				avg := average(values)
+				switch {
+				case stdev > 0:
+					score := (avg - mean) / stdev
+					fields[k+"_zscore"] = score
+					fields[k+"_anomaly"] = math.Abs(score) > z.Threshold
+				case avg == mean:
+					fields[k+"_zscore"] = 0.0
+					fields[k+"_anomaly"] = false
+				default:
+					// any change of a constant history is an anomaly, its
+					// infinite score cannot be written to most outputs
+					fields[k+"_anomaly"] = true
+				}
+			}
+
+			for _, v := range values {
+				w.add(v)
+			}
+		}
+
+		if len(fields) > 0 {
+			acc.AddFields(s.name, fields, s.tags)
+		}
+	}
+}
+
+// Reset only clears the values of the current period, the history of each
+// series is kept so that it can be used to score the next period.
+func (z *ZScore) Reset() {
+	for _, s := range z.cache {
+		s.current = make(map[string][]float64)
+	}
+}
+
+func (z *ZScore) windowSize() int {
+	if z.WindowSize < 2 {
+		return 2
+	}
+	return z.WindowSize
+}
+
+func (w *window) add(v float64) {
+	if len(w.values) < cap(w.values) {
+		w.values = append(w.values, v)
+		return
+	}
+	w.values[w.next] = v
+	w.next = (w.next + 1) % len(w.values)
+}
+
+func (w *window) stats() (float64, float64) {
+	mean := average(w.values)
+	var m2 float64
+	for _, v := range w.values {
+		m2 += (v - mean) * (v - mean)
+	}
+	return mean, math.Sqrt(m2 / float64(len(w.values)-1))
+}
+
+func average(values []float64) float64 {
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func convert(in interface{}) (float64, bool) {
+	switch v := in.(type) {
+	case float64:
+		return v, true
+	case int64:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	default:
+		return 0, false
+	}
+}
+
+func init() {
+	aggregators.Add("zscore", func() telegraf.Aggregator {
+		return NewZScore()
+	})
+}
@@ -0,0 +1,152 @@
+package zscore
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func newMetric(value float64) telegraf.Metric {
+	m, _ := metric.New("latency",
+		map[string]string{"host": "tars"},
+		map[string]interface{}{"value": value, "other": int64(1)},
+		time.Now(),
+	)
+	return m
+}
+
+func TestZScoreWarmup(t *testing.T) {
+	acc := testutil.Accumulator{}
+	z := NewZScore()
+	z.Fields = []string{"value"}
+	z.MinSamples = 4
+
+	z.Add(newMetric(1))
+	z.Add(newMetric(2))
+	z.Push(&acc)
+	z.Reset()
+	z.Add(newMetric(3))
+	z.Push(&acc)
+	z.Reset()
+
+	assert.Len(t, acc.Metrics, 0)
+}
+
+func TestZScoreAnomaly(t *testing.T) {
+	acc := testutil.Accumulator{}
+	z := NewZScore()
+	z.Fields = []string{"value"}
+	z.MinSamples = 4
+
+	for _, v := range []float64{9, 11, 9, 11} {
+		z.Add(newMetric(v))
+	}
+	z.Push(&acc)
+	z.Reset()
+	assert.Len(t, acc.Metrics, 0)
+
+	z.Add(newMetric(10))
+	z.Push(&acc)
+	z.Reset()
+	acc.AssertContainsTaggedFields(t, "latency",
+		map[string]interface{}{
+			"value_zscore":  float64(0),
+			"value_anomaly": false,
+		},
+		map[string]string{"host": "tars"})
+
+	acc.ClearMetrics()
+	z.Add(newMetric(30))
+	z.Push(&acc)
+	z.Reset()
+
+	score, ok := acc.FloatField("latency", "value_zscore")
+	assert.True(t, ok)
+	assert.InDelta(t, 20.0, score, 0.001)
+	anomaly, ok := acc.BoolField("latency", "value_anomaly")
+	assert.True(t, ok)
+	assert.True(t, anomaly)
+}
+
+func TestZScoreConstantHistory(t *testing.T) {
+	acc := testutil.Accumulator{}
+	z := NewZScore()
+	z.Fields = []string{"value"}
+	z.MinSamples = 4
+
+	for _, v := range []float64{10, 10, 10, 10} {
+		z.Add(newMetric(v))
+	}
+	z.Push(&acc)
+	z.Reset()
+
+	z.Add(newMetric(10))
+	z.Push(&acc)
+	z.Reset()
+	acc.AssertContainsTaggedFields(t, "latency",
+		map[string]interface{}{
+			"value_zscore":  float64(0),
+			"value_anomaly": false,
+		},
+		map[string]string{"host": "tars"})
+
+	// a step away from the flat history
+	acc.ClearMetrics()
+	z.Add(newMetric(11))
+	z.Push(&acc)
+	z.Reset()
+	acc.AssertContainsTaggedFields(t, "latency",
+		map[string]interface{}{
+			"value_anomaly": true,
+		},
+		map[string]string{"host": "tars"})
+}
+
+func TestZScoreWindowSize(t *testing.T) {
+	z := NewZScore()
+	z.WindowSize = 3
+
+	for _, v := range []float64{1, 2, 3, 4, 5} {
+		z.Add(newMetric(v))
+	}
+	acc := testutil.Accumulator{}
+	z.Push(&acc)
+
+	for _, s := range z.cache {
+		// the window is a ring, its values are not in order
+		values := append([]float64{}, s.history["value"].values...)
+		sort.Float64s(values)
+		assert.Equal(t, []float64{3, 4, 5}, values)
+	}
+}
+
+func TestZScoreSeriesTimeout(t *testing.T) {
+	acc := testutil.Accumulator{}
+	z := NewZScore()
+	z.Fields = []string{"value"}
+	z.MinSamples = 2
+
+	z.Add(newMetric(9))
+	z.Add(newMetric(11))
+	z.Push(&acc)
+	z.Reset()
+	assert.Len(t, z.cache, 1)
+
+	// a series seen in the timeout is kept
+	z.Push(&acc)
+	z.Reset()
+	assert.Len(t, z.cache, 1)
+
+	for _, s := range z.cache {
+		s.updated = time.Now().Add(-2 * time.Hour)
+	}
+	z.Push(&acc)
+	z.Reset()
+	assert.Len(t, z.cache, 0)
+	assert.Len(t, acc.Metrics, 0)
+}
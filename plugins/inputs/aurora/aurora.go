@@ -12,6 +12,7 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/proxy"
 	"github.com/influxdata/telegraf/internal/tls"
 	"github.com/influxdata/telegraf/plugins/inputs"
 )
@@ -49,6 +50,7 @@ type Aurora struct {
 	Username   string            `toml:"username"`
 	Password   string            `toml:"password"`
 	tls.ClientConfig
+	proxy.Config
 
 	client *http.Client
 	urls   []*url.URL
@@ -77,6 +79,12 @@ var sampleConfig = `
   # tls_key = "/etc/telegraf/key.pem"
   ## Use TLS but skip chain & host verification
   # insecure_skip_verify = false
+
+  ## Optional proxy, supports http, https and socks5 URLs (telegraf uses the
+  ## agent or system wide proxy settings if it is not set)
+  # http_proxy = "http://localhost:8888"
+  ## Hosts, domains and networks reached without the proxy
+  # no_proxy = ["localhost", ".example.com", "10.0.0.0/8"]
 `
 
 func (a *Aurora) SampleConfig() string {
@@ -129,10 +137,14 @@ func (a *Aurora) initialize() error {
 	if err != nil {
 		return err
 	}
+	proxyFunc, err := a.Config.Proxy()
+	if err != nil {
+		return err
+	}
 
 	client := &http.Client{
 		Transport: &http.Transport{
-			Proxy:           http.ProxyFromEnvironment,
+			Proxy:           proxyFunc,
 			TLSClientConfig: tlsCfg,
 		},
 	}
@@ -0,0 +1,298 @@
+package beegfs
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/command"
+	"github.com/influxdata/telegraf/plugins/inputs"
+)
+
+var (
+	defaultNodeTypes = []string{"meta", "storage"}
+	defaultTimeout   = internal.Duration{Duration: 5 * time.Second}
+
+	// names of the columns of the server stats
+	serverStatsFields = map[string]string{
+		"write_KiB": "write_bytes",
+		"read_KiB":  "read_bytes",
+		"reqs":      "requests",
+		"qlen":      "queue_length",
+		"bsy":       "busy_workers",
+	}
+)
+
+type BeeGFS struct {
+	Path      string
+	NodeTypes []string
+	CfgFile   string
+	Timeout   internal.Duration
+	command.Config
+
+	runner command.Runner
+}
+
+var sampleConfig = `
+  ## Optionally specify the path to the beegfs-ctl executable
+  # path = "/usr/bin/beegfs-ctl"
+
+  ## Setting 'use_sudo' to true will make use of sudo to run beegfs-ctl.
+  ## Sudo must be configured to allow the telegraf user to run beegfs-ctl
+  ## without a password.
+  # use_sudo = false
+
+  ## Program used instead of sudo, "sudo" or "doas".
+  # elevate = "doas"
+
+  ## Run beegfs-ctl on another host of the cluster with ssh, which must not
+  ## prompt for a password.
+  # ssh_host = ""
+  # ssh_user = ""
+  # ssh_key = ""
+
+  ## Types of the nodes whose targets and servers are gathered, "meta" and
+  ## "storage".
+  # node_types = ["meta", "storage"]
+
+  ## Client configuration file of beegfs-ctl, to reach the management
+  ## service.
+  # cfg_file = "/etc/beegfs/beegfs-client.conf"
+
+  ## Timeout of each beegfs-ctl command.
+  # timeout = "5s"
+`
+
+func (b *BeeGFS) SampleConfig() string {
+	return sampleConfig
+}
+
+func (b *BeeGFS) Description() string {
+	return "Read the capacity of the targets and the request stats of the servers of BeeGFS"
+}
+
+// SetRunner sets the runner of the beegfs-ctl commands.
+func (b *BeeGFS) SetRunner(runner command.Runner) {
+	b.runner = runner
+}
+
+func (b *BeeGFS) Gather(acc telegraf.Accumulator) error {
+	if len(b.Path) == 0 {
+		return fmt.Errorf("beegfs-ctl not found: verify that beegfs-utils is installed and that beegfs-ctl is in your PATH")
+	}
+	if b.runner == nil {
+		runner, err := b.Config.Runner()
+		if err != nil {
+			return err
+		}
+		b.runner = runner
+	}
+
+	nodeTypes := b.NodeTypes
+	if len(nodeTypes) == 0 {
+		nodeTypes = defaultNodeTypes
+	}
+
+	for _, nodeType := range nodeTypes {
+		out, err := b.run("--listtargets", "--nodetype="+nodeType, "--spaceinfo", "--state")
+		if err != nil {
+			acc.AddError(err)
+		} else if err := gatherTargets(acc, nodeType, out); err != nil {
+			acc.AddError(fmt.Errorf("failed to parse %s targets: %s", nodeType, err))
+		}
+
+		out, err = b.run("--serverstats", "--nodetype="+nodeType, "--perserver", "--names", "--history=1")
+		if err != nil {
+			acc.AddError(err)
+		} else if err := gatherServerStats(acc, nodeType, out); err != nil {
+			acc.AddError(fmt.Errorf("failed to parse %s server stats: %s", nodeType, err))
+		}
+	}
+	return nil
+}
+
+// run runs beegfs-ctl with the arguments and returns its output
+func (b *BeeGFS) run(args ...string) (string, error) {
+	if b.CfgFile != "" {
+		args = append(args, "--cfgFile="+b.CfgFile)
+	}
+
+	timeout := b.Timeout.Duration
+	if timeout <= 0 {
+		timeout = defaultTimeout.Duration
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	out, err := b.runner.Run(ctx, b.Path, args...)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+// gatherTargets adds the state and the capacity of the targets of the output
+// of "beegfs-ctl --listtargets --spaceinfo --state":
+//
+//	TargetID  Reachability  Consistency  Total      Free       %    ITotal  IFree   %
+//	========  ============  ===========  =====      ====       =    ======  =====   =
+//	     101        Online         Good  7999.9GiB  5723.2GiB  72%  800.0M  789.9M  99%
+func gatherTargets(acc telegraf.Accumulator, nodeType string, out string) error {
+	for _, line := range strings.Split(out, "\n") {
+		cols := strings.Fields(line)
+		if len(cols) < 9 {
+			continue
+		}
+		// skip the header and the separators
+		if _, err := strconv.ParseUint(cols[0], 10, 32); err != nil {
+			continue
+		}
+
+		fields := make(map[string]interface{})
+		for i, name := range map[int]string{
+			3: "total_bytes",
+			4: "free_bytes",
+			6: "total_inodes",
+			7: "free_inodes",
+		} {
+			value, err := parseSize(cols[i])
+			if err != nil {
+				return err
+			}
+			fields[name] = value
+		}
+
+		tags := map[string]string{
+			"node_type":    nodeType,
+			"target_id":    cols[0],
+			"reachability": cols[1],
+			"consistency":  cols[2],
+		}
+		acc.AddFields("beegfs_target", fields, tags)
+	}
+	return nil
+}
+
+// gatherServerStats adds the request stats of the servers of the output of
+// "beegfs-ctl --serverstats --perserver --names", the values of the columns
+// of the header follow the name and the ID of each server:
+//
+//	                  write_KiB  read_KiB  reqs  qlen  bsy
+//	storage01 [ID: 1]      1024      2048    40     0    1
+func gatherServerStats(acc telegraf.Accumulator, nodeType string, out string) error {
+	var columns []string
+	for _, line := range strings.Split(out, "\n") {
+		cols := strings.Fields(line)
+		if len(cols) == 0 {
+			continue
+		}
+		if isServerStatsHeader(cols) {
+			columns = columns[:0]
+			for _, col := range cols {
+				// labels of the rows, such as "Sum:"
+				if !strings.HasSuffix(col, ":") {
+					columns = append(columns, col)
+				}
+			}
+			continue
+		}
+		if len(columns) == 0 || len(cols) <= len(columns) || strings.HasPrefix(cols[0], "Sum") {
+			continue
+		}
+
+		values := cols[len(cols)-len(columns):]
+		node, id := parseNode(cols[:len(cols)-len(columns)])
+
+		fields := make(map[string]interface{})
+		for i, column := range columns {
+			value, err := strconv.ParseUint(values[i], 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid %s of %s: %s", column, node, err)
+			}
+			name, ok := serverStatsFields[column]
+			if !ok {
+				name = strings.ToLower(column)
+			}
+			if strings.HasSuffix(column, "_KiB") {
+				value *= 1024
+			}
+			fields[name] = value
+		}
+
+		tags := map[string]string{
+			"node_type": nodeType,
+			"node":      node,
+		}
+		if id != "" {
+			tags["node_id"] = id
+		}
+		acc.AddFields("beegfs_server", fields, tags)
+	}
+	return nil
+}
+
+func isServerStatsHeader(cols []string) bool {
+	for _, col := range cols {
+		if col == "reqs" {
+			return true
+		}
+	}
+	return false
+}
+
+// parseNode parses the "storage01 [ID: 1]" name and ID of the nodes
+func parseNode(cols []string) (string, string) {
+	label := strings.TrimSuffix(strings.Join(cols, " "), ":")
+	i := strings.Index(label, "[ID:")
+	if i < 0 {
+		return label, ""
+	}
+	id := strings.TrimSpace(strings.TrimSuffix(label[i+len("[ID:"):], "]"))
+	return strings.TrimSpace(label[:i]), id
+}
+
+// parseSize parses the sizes of beegfs-ctl, with binary units for the bytes
+// (5723.2GiB) and decimal units for the counts (789.9M).
+func parseSize(s string) (uint64, error) {
+	multiplier := float64(1)
+	number := strings.TrimSuffix(s, "B")
+	base := float64(1000)
+	if strings.HasSuffix(number, "i") {
+		number = strings.TrimSuffix(number, "i")
+		base = 1024
+	}
+	if n := len(number); n > 0 {
+		if i := strings.IndexByte("kKMGTPE", number[n-1]); i >= 0 {
+			if i == 0 {
+				i = 1
+			}
+			multiplier = math.Pow(base, float64(i))
+			number = number[:n-1]
+		}
+	}
+	value, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	return uint64(value*multiplier + 0.5), nil
+}
+
+func init() {
+	inputs.Add("beegfs", func() telegraf.Input {
+		b := &BeeGFS{
+			NodeTypes: defaultNodeTypes,
+			Timeout:   defaultTimeout,
+		}
+		path, _ := exec.LookPath("beegfs-ctl")
+		if len(path) > 0 {
+			b.Path = path
+		}
+		return b
+	})
+}
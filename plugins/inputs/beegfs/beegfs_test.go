@@ -0,0 +1,154 @@
+package beegfs
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/influxdata/telegraf/internal/command"
+	"github.com/influxdata/telegraf/internal/command/commandtest"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	mockStorageTargets = `TargetID     Reachability  Consistency        Total         Free    %      ITotal       IFree    %
+========     ============  ===========        =====         ====    =      ======       =====    =
+     101           Online         Good    7999.9GiB    5723.2GiB  72%      800.0M      789.9M  99%
+     102  Probably-offline  Needs-resync   7999.9GiB    5723.1GiB  72%      800.0M      789.9M  99%
+`
+	mockMetaTargets = `TargetID     Reachability  Consistency        Total         Free    %      ITotal       IFree    %
+========     ============  ===========        =====         ====    =      ======       =====    =
+       1           Online         Good     223.0GiB     210.5GiB  94%       14.9M       14.6M  98%
+`
+	mockStorageStats = `====== 1 s ======
+                   write_KiB  read_KiB  reqs  qlen  bsy
+storage01 [ID: 1]       1024      2048    40     0    1
+storage02 [ID: 2]          0         0     3     2    0
+`
+	mockMetaStats = `====== 1 s ======
+Sum:             reqs  qlen  bsy
+meta01 [ID: 1]    128     1    2
+`
+)
+
+// runCommand returns the output of the beegfs-ctl commands of the tests
+func runCommand(ctx context.Context, name string, args ...string) ([]byte, error) {
+	if name != "beegfs-ctl" || len(args) < 2 {
+		return nil, fmt.Errorf("command not found")
+	}
+
+	switch args[0] + " " + args[1] {
+	case "--listtargets --nodetype=storage":
+		return []byte(mockStorageTargets), nil
+	case "--listtargets --nodetype=meta":
+		return []byte(mockMetaTargets), nil
+	case "--serverstats --nodetype=storage":
+		return []byte(mockStorageStats), nil
+	case "--serverstats --nodetype=meta":
+		return []byte(mockMetaStats), nil
+	}
+	return nil, fmt.Errorf("invalid argument")
+}
+
+func TestGather(t *testing.T) {
+	var acc testutil.Accumulator
+	b := &BeeGFS{Path: "beegfs-ctl", runner: command.RunnerFunc(runCommand)}
+	require.NoError(t, acc.GatherError(b.Gather))
+
+	acc.AssertContainsTaggedFields(t, "beegfs_target",
+		map[string]interface{}{
+			"total_bytes":  uint64(8589827217818),
+			"free_bytes":   uint64(6145239207117),
+			"total_inodes": uint64(800000000),
+			"free_inodes":  uint64(789900000),
+		},
+		map[string]string{
+			"node_type":    "storage",
+			"target_id":    "101",
+			"reachability": "Online",
+			"consistency":  "Good",
+		})
+	acc.AssertContainsTaggedFields(t, "beegfs_target",
+		map[string]interface{}{
+			"total_bytes":  uint64(239444426752),
+			"free_bytes":   uint64(226022653952),
+			"total_inodes": uint64(14900000),
+			"free_inodes":  uint64(14600000),
+		},
+		map[string]string{
+			"node_type":    "meta",
+			"target_id":    "1",
+			"reachability": "Online",
+			"consistency":  "Good",
+		})
+
+	acc.AssertContainsTaggedFields(t, "beegfs_server",
+		map[string]interface{}{
+			"write_bytes":  uint64(1048576),
+			"read_bytes":   uint64(2097152),
+			"requests":     uint64(40),
+			"queue_length": uint64(0),
+			"busy_workers": uint64(1),
+		},
+		map[string]string{
+			"node_type": "storage",
+			"node":      "storage01",
+			"node_id":   "1",
+		})
+	acc.AssertContainsTaggedFields(t, "beegfs_server",
+		map[string]interface{}{
+			"requests":     uint64(128),
+			"queue_length": uint64(1),
+			"busy_workers": uint64(2),
+		},
+		map[string]string{
+			"node_type": "meta",
+			"node":      "meta01",
+			"node_id":   "1",
+		})
+	require.Equal(t, 6, len(acc.Metrics))
+}
+
+// TestGatherFixtures gathers the beegfs-ctl outputs recorded in testdata, run
+// with -record on a BeeGFS client to add a fixture.
+func TestGatherFixtures(t *testing.T) {
+	commandtest.ForEachFixture(t, "testdata", func(t *testing.T, dir string) {
+		var acc testutil.Accumulator
+		b := &BeeGFS{Path: "beegfs-ctl", runner: commandtest.Runner(t, dir)}
+		require.NoError(t, b.Gather(&acc))
+		commandtest.AssertGolden(t, dir, acc.Metrics)
+	})
+}
+
+func TestGatherNoPath(t *testing.T) {
+	var acc testutil.Accumulator
+	b := &BeeGFS{}
+	require.Error(t, b.Gather(&acc))
+}
+
+func TestParseSize(t *testing.T) {
+	for s, expected := range map[string]uint64{
+		"0":       0,
+		"512B":    512,
+		"1.5KiB":  1536,
+		"2.0GiB":  2147483648,
+		"800.0M":  800000000,
+		"12.0k":   12000,
+		"1.0TiB":  1099511627776,
+		"789.9M":  789900000,
+		"14.6M":   14600000,
+		"2.0MiB":  2097152,
+		"100":     100,
+		"3.5G":    3500000000,
+		"1.0PiB":  1125899906842624,
+		"10.0KiB": 10240,
+	} {
+		value, err := parseSize(s)
+		require.NoError(t, err)
+		require.Equal(t, expected, value, s)
+	}
+
+	_, err := parseSize("-")
+	require.Error(t, err)
+}
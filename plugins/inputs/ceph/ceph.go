@@ -2,15 +2,20 @@ package ceph
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"log"
-	"os/exec"
+	"net/http"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/command"
+	"github.com/influxdata/telegraf/internal/tls"
 	"github.com/influxdata/telegraf/plugins/inputs"
 )
 
@@ -33,6 +38,17 @@ type Ceph struct {
 	CephConfig             string
 	GatherAdminSocketStats bool
 	GatherClusterStats     bool
+
+	// URL of the restful module of the manager, queried instead of running
+	// the ceph commands
+	MgrURL      string            `toml:"mgr_url"`
+	MgrUsername string            `toml:"mgr_username"`
+	MgrPassword string            `toml:"mgr_password"`
+	MgrTimeout  internal.Duration `toml:"mgr_timeout"`
+	tls.ClientConfig
+
+	client *http.Client
+	runner command.Runner
 }
 
 func (c *Ceph) Description() string {
@@ -70,13 +86,41 @@ var sampleConfig = `
 
   ## Whether to gather statistics via ceph commands
   gather_cluster_stats = false
+
+  ## URL of the restful module of the ceph manager.  If set, the cluster
+  ## statistics are requested from the manager instead of running the ceph
+  ## binary, so the agent needs no ceph configuration nor keyring.
+  # mgr_url = "https://localhost:8003"
+  # mgr_username = "telegraf"
+  # mgr_password = "api-key"
+  # mgr_timeout = "5s"
+
+  ## Optional TLS Config of the manager requests
+  # tls_ca = "/etc/telegraf/ca.pem"
+  # tls_cert = "/etc/telegraf/cert.pem"
+  # tls_key = "/etc/telegraf/key.pem"
+  ## Use TLS but skip chain & host verification
+  # insecure_skip_verify = false
 `
 
 func (c *Ceph) SampleConfig() string {
 	return sampleConfig
 }
 
+// SetRunner sets the runner of the ceph commands.
+func (c *Ceph) SetRunner(runner command.Runner) {
+	c.runner = runner
+}
+
 func (c *Ceph) Gather(acc telegraf.Accumulator) error {
+	if c.runner == nil {
+		runner, err := (&command.Config{}).Runner()
+		if err != nil {
+			return err
+		}
+		c.runner = runner
+	}
+
 	if c.GatherAdminSocketStats {
 		if err := c.gatherAdminSocketStats(acc); err != nil {
 			return err
@@ -99,7 +143,7 @@ func (c *Ceph) gatherAdminSocketStats(acc telegraf.Accumulator) error {
 	}
 
 	for _, s := range sockets {
-		dump, err := perfDump(c.CephBinary, s)
+		dump, err := c.perfDump(s)
 		if err != nil {
 			acc.AddError(fmt.Errorf("E! error reading from socket '%s': %v", s.socket, err))
 			continue
@@ -130,7 +174,13 @@ func (c *Ceph) gatherClusterStats(acc telegraf.Accumulator) error {
 
 	// For each job, execute against the cluster, parse and accumulate the data points
 	for _, job := range jobs {
-		output, err := c.exec(job.command)
+		var output string
+		var err error
+		if c.MgrURL != "" {
+			output, err = c.mgrCommand(job.command)
+		} else {
+			output, err = c.exec(job.command)
+		}
 		if err != nil {
 			return fmt.Errorf("error executing command: %v", err)
 		}
@@ -154,13 +204,14 @@ func init() {
 		CephConfig:             "/etc/ceph/ceph.conf",
 		GatherAdminSocketStats: true,
 		GatherClusterStats:     false,
+		MgrTimeout:             internal.Duration{Duration: 5 * time.Second},
 	}
 
 	inputs.Add(measurement, func() telegraf.Input { return &c })
 
 }
 
-var perfDump = func(binary string, socket *socket) (string, error) {
+func (c *Ceph) perfDump(socket *socket) (string, error) {
 	cmdArgs := []string{"--admin-daemon", socket.socket}
 	if socket.sockType == typeOsd {
 		cmdArgs = append(cmdArgs, "perf", "dump")
@@ -170,15 +221,12 @@ var perfDump = func(binary string, socket *socket) (string, error) {
 		return "", fmt.Errorf("ignoring unknown socket type: %s", socket.sockType)
 	}
 
-	cmd := exec.Command(binary, cmdArgs...)
-	var out bytes.Buffer
-	cmd.Stdout = &out
-	err := cmd.Run()
+	out, err := c.runner.Run(context.Background(), c.CephBinary, cmdArgs...)
 	if err != nil {
 		return "", fmt.Errorf("error running ceph dump: %s", err)
 	}
 
-	return out.String(), nil
+	return string(out), nil
 }
 
 var findSockets = func(c *Ceph) ([]*socket, error) {
@@ -298,16 +346,12 @@ func (c *Ceph) exec(command string) (string, error) {
 	cmdArgs := []string{"--conf", c.CephConfig, "--name", c.CephUser, "--format", "json"}
 	cmdArgs = append(cmdArgs, strings.Split(command, " ")...)
 
-	cmd := exec.Command(c.CephBinary, cmdArgs...)
-
-	var out bytes.Buffer
-	cmd.Stdout = &out
-	err := cmd.Run()
+	out, err := c.runner.Run(context.Background(), c.CephBinary, cmdArgs...)
 	if err != nil {
 		return "", fmt.Errorf("error running ceph %v: %s", command, err)
 	}
 
-	output := out.String()
+	output := string(out)
 
 	// Ceph doesn't sanitize its output, and may return invalid JSON.  Patch this
 	// up for them, as having some inaccurate data is better than none.
@@ -317,6 +361,72 @@ func (c *Ceph) exec(command string) (string, error) {
 	return output, nil
 }
 
+// mgrResponse is the response of the request endpoint of the restful module.
+type mgrResponse struct {
+	HasFailed bool `json:"has_failed"`
+	Finished  []struct {
+		Outb string `json:"outb"`
+		Outs string `json:"outs"`
+	} `json:"finished"`
+	Failed []struct {
+		Outs string `json:"outs"`
+	} `json:"failed"`
+	Message string `json:"message"`
+}
+
+// mgrCommand runs the command with the restful module of the manager and
+// returns its JSON output, as exec does.
+func (c *Ceph) mgrCommand(command string) (string, error) {
+	if c.client == nil {
+		tlsCfg, err := c.ClientConfig.TLSConfig()
+		if err != nil {
+			return "", err
+		}
+		c.client = &http.Client{
+			Transport: &http.Transport{TLSClientConfig: tlsCfg},
+			Timeout:   c.MgrTimeout.Duration,
+		}
+	}
+
+	body, err := json.Marshal(map[string]string{"prefix": command, "format": "json"})
+	if err != nil {
+		return "", err
+	}
+	url := strings.TrimSuffix(c.MgrURL, "/") + "/request?wait=1"
+	req, err := http.NewRequest("POST", url, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.SetBasicAuth(c.MgrUsername, c.MgrPassword)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("error requesting ceph %v: %s", command, err)
+	}
+	defer resp.Body.Close()
+
+	var r mgrResponse
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		return "", fmt.Errorf("error decoding ceph %v response: %s", command, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("error requesting ceph %v: %s %s", command, resp.Status, r.Message)
+	}
+	if r.HasFailed || len(r.Finished) == 0 {
+		outs := "no output"
+		if len(r.Failed) > 0 {
+			outs = r.Failed[0].Outs
+		}
+		return "", fmt.Errorf("error running ceph %v: %s", command, outs)
+	}
+
+	output := r.Finished[0].Outb
+	output = strings.Replace(output, "-inf", "0", -1)
+	output = strings.Replace(output, "inf", "0", -1)
+	return output, nil
+}
+
 func decodeStatus(acc telegraf.Accumulator, input string) error {
 	data := make(map[string]interface{})
 	err := json.Unmarshal([]byte(input), &data)
@@ -324,6 +434,11 @@ func decodeStatus(acc telegraf.Accumulator, input string) error {
 		return fmt.Errorf("failed to parse json: '%s': %v", input, err)
 	}
 
+	err = decodeStatusHealth(acc, data)
+	if err != nil {
+		return err
+	}
+
 	err = decodeStatusOsdmap(acc, data)
 	if err != nil {
 		return err
@@ -342,14 +457,52 @@ func decodeStatus(acc telegraf.Accumulator, input string) error {
 	return nil
 }
 
+// healthCodes are the status codes of the health statuses, the greater the
+// worse.
+var healthCodes = map[string]int{
+	"HEALTH_OK":   0,
+	"HEALTH_WARN": 1,
+	"HEALTH_ERR":  2,
+}
+
+func decodeStatusHealth(acc telegraf.Accumulator, data map[string]interface{}) error {
+	health, ok := data["health"].(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("WARNING %s - unable to decode health", measurement)
+	}
+	// overall_status is the status of the releases before luminous
+	status, ok := health["status"].(string)
+	if !ok {
+		status, ok = health["overall_status"].(string)
+	}
+	if !ok {
+		return fmt.Errorf("WARNING %s - unable to decode health status", measurement)
+	}
+	code, ok := healthCodes[status]
+	if !ok {
+		code = healthCodes["HEALTH_ERR"]
+	}
+
+	fields := map[string]interface{}{
+		"status":      status,
+		"status_code": code,
+	}
+	if checks, ok := health["checks"].(map[string]interface{}); ok {
+		fields["num_checks"] = len(checks)
+	}
+	acc.AddFields("ceph_health", fields, map[string]string{})
+	return nil
+}
+
 func decodeStatusOsdmap(acc telegraf.Accumulator, data map[string]interface{}) error {
 	osdmap, ok := data["osdmap"].(map[string]interface{})
 	if !ok {
 		return fmt.Errorf("WARNING %s - unable to decode osdmap", measurement)
 	}
+	// the osdmap is no longer nested since nautilus
 	fields, ok := osdmap["osdmap"].(map[string]interface{})
 	if !ok {
-		return fmt.Errorf("WARNING %s - unable to decode osdmap", measurement)
+		fields = osdmap
 	}
 	acc.AddFields("ceph_osdmap", fields, map[string]string{})
 	return nil
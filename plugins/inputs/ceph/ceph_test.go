@@ -1,15 +1,19 @@
 package ceph
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path"
 	"strconv"
 	"strings"
 	"testing"
 
+	"github.com/influxdata/telegraf/internal/command"
 	"github.com/influxdata/telegraf/testutil"
 	"github.com/stretchr/testify/assert"
 )
@@ -60,25 +64,97 @@ func TestDecodeStatusPgmapState(t *testing.T) {
 	}
 }
 
+func TestDecodeStatusHealth(t *testing.T) {
+	data := make(map[string]interface{})
+	err := json.Unmarshal([]byte(clusterStatusDump), &data)
+	assert.NoError(t, err)
+
+	acc := &testutil.Accumulator{}
+	assert.NoError(t, decodeStatusHealth(acc, data))
+	acc.AssertContainsFields(t, "ceph_health",
+		map[string]interface{}{"status": "HEALTH_OK", "status_code": 0})
+
+	data = map[string]interface{}{
+		"health": map[string]interface{}{
+			"status": "HEALTH_WARN",
+			"checks": map[string]interface{}{
+				"OSD_DOWN": map[string]interface{}{"severity": "HEALTH_WARN"},
+			},
+		},
+	}
+	acc = &testutil.Accumulator{}
+	assert.NoError(t, decodeStatusHealth(acc, data))
+	acc.AssertContainsFields(t, "ceph_health",
+		map[string]interface{}{"status": "HEALTH_WARN", "status_code": 1, "num_checks": 1})
+}
+
+func TestDecodeStatusOsdmapNautilus(t *testing.T) {
+	data := map[string]interface{}{
+		"osdmap": map[string]interface{}{
+			"epoch":       float64(120),
+			"num_osds":    float64(3),
+			"num_up_osds": float64(2),
+			"num_in_osds": float64(3),
+		},
+	}
+	acc := &testutil.Accumulator{}
+	assert.NoError(t, decodeStatusOsdmap(acc, data))
+	acc.AssertContainsFields(t, "ceph_osdmap", data["osdmap"].(map[string]interface{}))
+}
+
+func TestMgrCommand(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, password, _ := r.BasicAuth()
+		if user != "telegraf" || password != "key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"message": "Unauthorized"}`)
+			return
+		}
+		var command map[string]string
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&command))
+		assert.Equal(t, "1", r.URL.Query().Get("wait"))
+		if command["prefix"] != "status" {
+			fmt.Fprint(w, `{"has_failed": true, "failed": [{"outs": "unknown command"}]}`)
+			return
+		}
+		out, _ := json.Marshal(clusterStatusDump)
+		fmt.Fprintf(w, `{"has_failed": false, "finished": [{"outb": %s, "outs": ""}]}`, out)
+	}))
+	defer ts.Close()
+
+	c := &Ceph{MgrURL: ts.URL, MgrUsername: "telegraf", MgrPassword: "key"}
+	output, err := c.mgrCommand("status")
+	assert.NoError(t, err)
+	acc := &testutil.Accumulator{}
+	assert.NoError(t, decodeStatus(acc, output))
+	assert.True(t, acc.HasMeasurement("ceph_osdmap"))
+
+	_, err = c.mgrCommand("df")
+	assert.EqualError(t, err, "error running ceph df: unknown command")
+
+	c = &Ceph{MgrURL: ts.URL}
+	_, err = c.mgrCommand("status")
+	assert.Error(t, err)
+}
+
 func TestGather(t *testing.T) {
 	saveFind := findSockets
-	saveDump := perfDump
 	defer func() {
 		findSockets = saveFind
-		perfDump = saveDump
 	}()
 
 	findSockets = func(c *Ceph) ([]*socket, error) {
 		return []*socket{&socket{"osd.1", typeOsd, ""}}, nil
 	}
 
-	perfDump = func(binary string, s *socket) (string, error) {
-		return osdPerfDump, nil
+	runner := func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		return []byte(osdPerfDump), nil
 	}
 
 	acc := &testutil.Accumulator{}
-	c := &Ceph{}
-	c.Gather(acc)
+	c := &Ceph{GatherAdminSocketStats: true, runner: command.RunnerFunc(runner)}
+	assert.NoError(t, c.Gather(acc))
+	assert.True(t, acc.HasMeasurement(measurement))
 
 }
 
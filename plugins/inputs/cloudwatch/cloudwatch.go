@@ -33,6 +33,7 @@ type (
 		Metrics     []*Metric         `toml:"metrics"`
 		CacheTTL    internal.Duration `toml:"cache_ttl"`
 		RateLimit   int               `toml:"ratelimit"`
+		RateLimiter string            `toml:"rate_limiter"`
 		client      cloudwatchClient
 		metricCache *MetricCache
 	}
@@ -109,6 +110,11 @@ func (c *CloudWatch) SampleConfig() string {
   ## See http://docs.aws.amazon.com/AmazonCloudWatch/latest/monitoring/cloudwatch_limits.html
   ratelimit = 200
 
+  ## Name of a rate limiter of the [rate_limits] table shared with other
+  ## plugins, such as the cloudwatch inputs of the other namespaces, to stay
+  ## below the rate limit of the AWS account. Optional.
+  # rate_limiter = "cloudwatch"
+
   ## Metrics to Pull (optional)
   ## Defaults to all Metrics in Namespace if nothing is provided
   ## Refreshes Namespace available metrics every 1h
@@ -176,6 +182,12 @@ func SelectMetrics(c *CloudWatch) ([]*cloudwatch.Metric, error) {
 	return metrics, nil
 }
 
+// RateLimiterName returns the name of the shared rate limiter, checked once
+// the configuration is loaded.
+func (c *CloudWatch) RateLimiterName() string {
+	return c.RateLimiter
+}
+
 func (c *CloudWatch) Gather(acc telegraf.Accumulator) error {
 	if c.client == nil {
 		c.initializeCloudWatch()
@@ -186,6 +198,13 @@ func (c *CloudWatch) Gather(acc telegraf.Accumulator) error {
 		return err
 	}
 
+	var shared *limiter.TokenBucket
+	if c.RateLimiter != "" {
+		if shared, err = limiter.Named(c.RateLimiter); err != nil {
+			return err
+		}
+	}
+
 	now := time.Now()
 
 	// limit concurrency or we can easily exhaust user connection limit
@@ -197,6 +216,9 @@ func (c *CloudWatch) Gather(acc telegraf.Accumulator) error {
 	wg.Add(len(metrics))
 	for _, m := range metrics {
 		<-lmtr.C
+		if shared != nil {
+			shared.Wait()
+		}
 		go func(inm *cloudwatch.Metric) {
 			defer wg.Done()
 			acc.AddError(c.gatherMetric(acc, inm, now))
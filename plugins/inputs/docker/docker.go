@@ -27,7 +27,7 @@ import (
 // Docker object
 type Docker struct {
 	Endpoint       string
-	ContainerNames []string // deprecated in 1.4; use container_name_include
+	ContainerNames []string `deprecated:"1.4;use container_name_include"`
 
 	GatherServices bool `toml:"gather_services"`
 
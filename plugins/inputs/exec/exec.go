@@ -2,19 +2,23 @@ package exec
 
 import (
 	"bytes"
+	"context"
 	"fmt"
+	"log"
 	"os/exec"
 	"path/filepath"
 	"runtime"
 	"strings"
 	"sync"
-	"syscall"
 	"time"
 
 	"github.com/kballard/go-shellquote"
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/linereader"
+	"github.com/influxdata/telegraf/internal/retry"
+	"github.com/influxdata/telegraf/internal/subscription"
 	"github.com/influxdata/telegraf/plugins/inputs"
 	"github.com/influxdata/telegraf/plugins/parsers"
 	"github.com/influxdata/telegraf/plugins/parsers/nagios"
@@ -34,6 +38,14 @@ const sampleConfig = `
   ## measurement name suffix (for separating different commands)
   name_suffix = "_mycollector"
 
+  ## Keep the commands running and parse each line of their output as soon as
+  ## it is printed, instead of running them on every interval.  Commands
+  ## exiting after running for restart_delay are restarted at once, commands
+  ## exiting before it after restart_delay, doubling up to a minute while
+  ## they keep exiting early.  Their stderr is logged.
+  # streaming = false
+  # restart_delay = "10s"
+
   ## Data format to consume.
   ## Each data format has its own unique set of configuration options, read
   ## more about them here:
@@ -43,20 +55,28 @@ const sampleConfig = `
 
 const MaxStderrBytes = 512
 
+// Maximum delay before restarting a streaming command
+const maxRestartDelay = time.Minute
+
 type Exec struct {
 	Commands []string
 	Command  string
 	Timeout  internal.Duration
 
+	Streaming    bool              `toml:"streaming"`
+	RestartDelay internal.Duration `toml:"restart_delay"`
+
 	parser parsers.Parser
 
-	runner Runner
+	runner    Runner
+	processes subscription.Manager
 }
 
 func NewExec() *Exec {
 	return &Exec{
-		runner:  CommandRunner{},
-		Timeout: internal.Duration{Duration: time.Second * 5},
+		runner:       CommandRunner{},
+		Timeout:      internal.Duration{Duration: time.Second * 5},
+		RestartDelay: internal.Duration{Duration: time.Second * 10},
 	}
 }
 
@@ -66,26 +86,6 @@ type Runner interface {
 
 type CommandRunner struct{}
 
-func AddNagiosState(exitCode error, acc telegraf.Accumulator) error {
-	nagiosState := 0
-	if exitCode != nil {
-		exiterr, ok := exitCode.(*exec.ExitError)
-		if ok {
-			status, ok := exiterr.Sys().(syscall.WaitStatus)
-			if ok {
-				nagiosState = status.ExitStatus()
-			} else {
-				return fmt.Errorf("exec: unable to get nagios plugin exit code")
-			}
-		} else {
-			return fmt.Errorf("exec: unable to get nagios plugin exit code")
-		}
-	}
-	fields := map[string]interface{}{"state": nagiosState}
-	acc.AddFields("nagios_state", fields, nil)
-	return nil
-}
-
 func (c CommandRunner) Run(
 	e *Exec,
 	command string,
@@ -105,10 +105,11 @@ func (c CommandRunner) Run(
 	cmd.Stdout = &out
 	cmd.Stderr = &stderr
 
-	if err := internal.RunTimeout(cmd, e.Timeout.Duration); err != nil {
+	runErr := internal.RunTimeout(cmd, e.Timeout.Duration)
+	if runErr != nil {
 		switch e.parser.(type) {
 		case *nagios.NagiosParser:
-			AddNagiosState(err, acc)
+			// the exit code is the state of the check
 		default:
 			var errMessage = ""
 			if stderr.Len() > 0 {
@@ -132,17 +133,12 @@ func (c CommandRunner) Run(
 
 				errMessage = fmt.Sprintf(": %s", stderr.String())
 			}
-			return nil, fmt.Errorf("exec: %s for command '%s'%s", err, command, errMessage)
-		}
-	} else {
-		switch e.parser.(type) {
-		case *nagios.NagiosParser:
-			AddNagiosState(nil, acc)
+			return nil, fmt.Errorf("exec: %s for command '%s'%s", runErr, command, errMessage)
 		}
 	}
 
 	out = removeCarriageReturns(out)
-	return out.Bytes(), nil
+	return out.Bytes(), runErr
 }
 
 // removeCarriageReturns removes all carriage returns from the input if the
@@ -174,20 +170,28 @@ func removeCarriageReturns(b bytes.Buffer) bytes.Buffer {
 func (e *Exec) ProcessCommand(command string, acc telegraf.Accumulator, wg *sync.WaitGroup) {
 	defer wg.Done()
 
-	out, err := e.runner.Run(e, command, acc)
-	if err != nil {
-		acc.AddError(err)
+	out, runErr := e.runner.Run(e, command, acc)
+	_, isNagios := e.parser.(*nagios.NagiosParser)
+	if runErr != nil && !isNagios {
+		acc.AddError(runErr)
 		return
 	}
 
 	metrics, err := e.parser.Parse(out)
 	if err != nil {
 		acc.AddError(err)
-	} else {
-		for _, metric := range metrics {
-			acc.AddFields(metric.Name(), metric.Fields(), metric.Tags(), metric.Time())
+		return
+	}
+	if isNagios {
+		// the state is still reported when the check timed out
+		metrics, err = nagios.TryAddState(runErr, metrics)
+		if err != nil {
+			acc.AddError(fmt.Errorf("exec: %s for command '%s'", err, command))
 		}
 	}
+	for _, metric := range metrics {
+		acc.AddFields(metric.Name(), metric.Fields(), metric.Tags(), metric.Time())
+	}
 }
 
 func (e *Exec) SampleConfig() string {
@@ -202,8 +206,158 @@ func (e *Exec) SetParser(parser parsers.Parser) {
 	e.parser = parser
 }
 
+// IsService reports whether the plugin runs as a service input, only the
+// streaming commands run as a service.
+func (e *Exec) IsService() bool {
+	return e.Streaming
+}
+
+// Start starts the streaming commands.
+func (e *Exec) Start(acc telegraf.Accumulator) error {
+	if !e.Streaming {
+		return nil
+	}
+
+	var processes []subscription.Subscription
+	for _, command := range e.expandCommands(acc) {
+		command := command
+		processes = append(processes, subscription.Subscription{
+			Name: command,
+			Run: func(ctx context.Context, s *subscription.Session) error {
+				return e.stream(ctx, s, command, acc)
+			},
+		})
+	}
+
+	e.processes = subscription.Manager{
+		Input: "exec",
+		Backoff: retry.Backoff{
+			Initial: e.RestartDelay.Duration,
+			Max:     maxRestartDelay,
+		},
+	}
+	if e.RestartDelay.Duration > maxRestartDelay {
+		e.processes.Backoff.Max = e.RestartDelay.Duration
+	}
+	e.processes.Start(acc, processes...)
+	return nil
+}
+
+// Stop kills the streaming commands.
+func (e *Exec) Stop() {
+	e.processes.Stop()
+}
+
+// stream runs the command until it exits or ctx is done, parsing each line of
+// its output and logging its stderr.  The command is considered running once
+// it did not exit for restart_delay, it is then restarted at once when it
+// exits.
+func (e *Exec) stream(
+	ctx context.Context,
+	s *subscription.Session,
+	command string,
+	acc telegraf.Accumulator,
+) error {
+	splitCmd, err := shellquote.Split(command)
+	if err != nil || len(splitCmd) == 0 {
+		return retry.NewPermanent(fmt.Errorf("exec: unable to parse command, %s", err))
+	}
+
+	cmd := exec.Command(splitCmd[0], splitCmd[1:]...)
+	setProcessGroup(cmd)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("exec: %s for command '%s'", err, command)
+	}
+
+	// the processes started by the command are killed with it, the output
+	// is read until they all exit
+	exited := make(chan struct{})
+	defer close(exited)
+	go func() {
+		select {
+		case <-ctx.Done():
+			killProcessGroup(cmd)
+		case <-exited:
+		}
+	}()
+
+	running := time.AfterFunc(e.RestartDelay.Duration, s.Subscribed)
+	defer running.Stop()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		r := linereader.NewReader(stderr, MaxStderrBytes)
+		for {
+			line, _, err := r.ReadLine()
+			if err != nil {
+				return
+			}
+			log.Printf("E! exec: command '%s': %s", command, line)
+		}
+	}()
+
+	r := linereader.NewReader(stdout, 0)
+	for {
+		line, truncated, err := r.ReadLine()
+		if err != nil {
+			break
+		}
+		if truncated {
+			acc.AddError(fmt.Errorf("exec: line %d of command '%s' is too long, truncated",
+				r.Line(), command))
+			continue
+		}
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		s.Received()
+
+		metrics, err := e.parser.Parse(line)
+		if err != nil {
+			acc.AddError(err)
+			continue
+		}
+		for _, metric := range metrics {
+			acc.AddFields(metric.Name(), metric.Fields(), metric.Tags(), metric.Time())
+		}
+	}
+
+	// the pipes must be read before waiting for the command
+	wg.Wait()
+	if err := cmd.Wait(); err != nil {
+		return fmt.Errorf("exec: %s for command '%s'", err, command)
+	}
+	return fmt.Errorf("exec: command '%s' exited", command)
+}
+
 func (e *Exec) Gather(acc telegraf.Accumulator) error {
+	if e.Streaming {
+		return nil
+	}
+
 	var wg sync.WaitGroup
+	commands := e.expandCommands(acc)
+	wg.Add(len(commands))
+	for _, command := range commands {
+		go e.ProcessCommand(command, acc, &wg)
+	}
+	wg.Wait()
+	return nil
+}
+
+// expandCommands returns the commands, with the commands matching a glob
+// pattern replaced by the matches.
+func (e *Exec) expandCommands(acc telegraf.Accumulator) []string {
 	// Legacy single command support
 	if e.Command != "" {
 		e.Commands = append(e.Commands, e.Command)
@@ -240,13 +394,7 @@ func (e *Exec) Gather(acc telegraf.Accumulator) error {
 			}
 		}
 	}
-
-	wg.Add(len(commands))
-	for _, command := range commands {
-		go e.ProcessCommand(command, acc, &wg)
-	}
-	wg.Wait()
-	return nil
+	return commands
 }
 
 func init() {
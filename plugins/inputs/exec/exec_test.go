@@ -5,8 +5,10 @@ import (
 	"fmt"
 	"runtime"
 	"testing"
+	"time"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/plugins/parsers"
 
 	"github.com/influxdata/telegraf/testutil"
@@ -86,10 +88,7 @@ func newRunnerMock(out []byte, err error) Runner {
 }
 
 func (r runnerMock) Run(e *Exec, command string, acc telegraf.Accumulator) ([]byte, error) {
-	if r.err != nil {
-		return nil, r.err
-	}
-	return r.out, nil
+	return r.out, r.err
 }
 
 func TestExec(t *testing.T) {
@@ -144,6 +143,22 @@ func TestCommandError(t *testing.T) {
 	assert.Equal(t, acc.NFields(), 0, "No new points should have been added")
 }
 
+func TestNagiosTimeout(t *testing.T) {
+	parser, _ := parsers.NewNagiosParser()
+	e := &Exec{
+		runner:   newRunnerMock([]byte("PING OK - Packet loss = 0%|pl=0%"), internal.TimeoutErr),
+		Commands: []string{"check_ping"},
+		parser:   parser,
+	}
+
+	var acc testutil.Accumulator
+	require.NoError(t, e.Gather(&acc))
+	require.Len(t, acc.Errors, 1)
+	assert.Contains(t, acc.Errors[0].Error(), "check_ping")
+	assert.True(t, acc.HasPoint("nagios_state", map[string]string{}, "state", int64(3)))
+	assert.True(t, acc.HasMeasurement("pl"))
+}
+
 func TestExecCommandWithGlob(t *testing.T) {
 	parser, _ := parsers.NewValueParser("metric", "string", nil)
 	e := NewExec()
@@ -192,6 +207,52 @@ func TestExecCommandWithoutGlobAndPath(t *testing.T) {
 	acc.AssertContainsFields(t, "metric", fields)
 }
 
+func TestStreaming(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Skipping test on windows")
+	}
+	parser, _ := parsers.NewInfluxParser()
+	e := NewExec()
+	e.Streaming = true
+	e.Commands = []string{`sh -c "echo cpu usage_idle=99; echo warning >&2; echo cpu usage_idle=98; sleep 10"`}
+	e.SetParser(parser)
+
+	var acc testutil.Accumulator
+	require.NoError(t, e.Start(&acc))
+	acc.Wait(2)
+	require.NoError(t, acc.GatherError(e.Gather))
+
+	// the sleep started by the command must not keep it running
+	start := time.Now()
+	e.Stop()
+	assert.True(t, time.Since(start) < 5*time.Second, "stopped in %s", time.Since(start))
+
+	require.Len(t, acc.Metrics, 2)
+	assert.True(t, acc.HasPoint("cpu", map[string]string{}, "usage_idle", float64(99)))
+	assert.True(t, acc.HasPoint("cpu", map[string]string{}, "usage_idle", float64(98)))
+	assert.Empty(t, acc.Errors)
+}
+
+func TestStreamingRestart(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Skipping test on windows")
+	}
+	parser, _ := parsers.NewInfluxParser()
+	e := NewExec()
+	e.Streaming = true
+	e.RestartDelay.Duration = time.Millisecond
+	e.Commands = []string{"echo cpu usage_idle=99"}
+	e.SetParser(parser)
+
+	var acc testutil.Accumulator
+	require.NoError(t, e.Start(&acc))
+	acc.Wait(3)
+	e.Stop()
+
+	require.NotEmpty(t, acc.Errors)
+	assert.Contains(t, acc.Errors[0].Error(), "command 'echo cpu usage_idle=99' exited")
+}
+
 func TestRemoveCarriageReturns(t *testing.T) {
 	if runtime.GOOS == "windows" {
 		// Test that all carriage returns are removed
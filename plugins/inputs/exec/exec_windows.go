@@ -0,0 +1,15 @@
+// +build windows
+
+package exec
+
+import (
+	"os/exec"
+)
+
+func setProcessGroup(cmd *exec.Cmd) {
+}
+
+// killProcessGroup kills the command, the processes it started keep running.
+func killProcessGroup(cmd *exec.Cmd) {
+	cmd.Process.Kill()
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/proxy"
 	"github.com/influxdata/telegraf/plugins/inputs"
 )
 
@@ -22,6 +23,12 @@ const sampleConfig = `
 
   ## Amount of time allowed to complete the HTTP request
   # timeout = "5s"
+
+  ## Optional proxy, supports http, https and socks5 URLs (telegraf uses the
+  ## agent or system wide proxy settings if it is not set)
+  # http_proxy = "http://localhost:8888"
+  ## Hosts, domains and networks reached without the proxy
+  # no_proxy = ["localhost", ".example.com", "10.0.0.0/8"]
 `
 
 const description = "Read devices value(s) from a Fibaro controller"
@@ -35,6 +42,7 @@ type Fibaro struct {
 	Password string
 
 	Timeout internal.Duration
+	proxy.Config
 
 	client *http.Client
 }
@@ -118,9 +126,13 @@ func (f *Fibaro) getJSON(path string, dataStruct interface{}) error {
 func (f *Fibaro) Gather(acc telegraf.Accumulator) error {
 
 	if f.client == nil {
+		proxyFunc, err := f.Config.Proxy()
+		if err != nil {
+			return err
+		}
 		f.client = &http.Client{
 			Transport: &http.Transport{
-				Proxy: http.ProxyFromEnvironment,
+				Proxy: proxyFunc,
 			},
 			Timeout: f.Timeout.Duration,
 		}
@@ -11,6 +11,8 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/limiter"
+	"github.com/influxdata/telegraf/internal/proxy"
 	"github.com/influxdata/telegraf/internal/tls"
 	"github.com/influxdata/telegraf/plugins/inputs"
 	"github.com/influxdata/telegraf/plugins/parsers"
@@ -26,10 +28,19 @@ type HTTP struct {
 	Username string
 	Password string
 	tls.ClientConfig
+	proxy.Config
 
 	Timeout internal.Duration
 
+	// Name of the rate limiter of the requests shared with other plugins
+	RateLimiter string `toml:"rate_limiter"`
+
+	Pagination Pagination `toml:"pagination"`
+	OAuth2     OAuth2     `toml:"oauth2"`
+	JWT        JWT        `toml:"jwt"`
+
 	client *http.Client
+	tokens *tokenSource
 
 	// The parser will automatically be set by Telegraf core code because
 	// this plugin implements the ParserInput interface (i.e. the SetParser method)
@@ -62,14 +73,62 @@ var sampleConfig = `
   ## Use TLS but skip chain & host verification
   # insecure_skip_verify = false
 
+  ## Optional proxy, supports http, https and socks5 URLs (telegraf uses the
+  ## agent or system wide proxy settings if it is not set)
+  # http_proxy = "http://localhost:8888"
+  ## Hosts, domains and networks reached without the proxy
+  # no_proxy = ["localhost", ".example.com", "10.0.0.0/8"]
+
   ## Amount of time allowed to complete the HTTP request
   # timeout = "5s"
 
+  ## Name of a rate limiter of the [rate_limits] table, shared with the other
+  ## plugins polling the same API
+  # rate_limiter = "storage_api"
+
   ## Data format to consume.
   ## Each data format has its own unique set of configuration options, read
   ## more about them here:
   ## https://github.com/influxdata/telegraf/blob/master/docs/DATA_FORMATS_INPUT.md
   # data_format = "influx"
+
+  ## Pagination of the responses, the metrics of all the pages are gathered.
+  # [inputs.http.pagination]
+    ## "link" follows the next link of the Link header, "cursor" requests
+    ## next_url with the cursor of the response, "offset" requests next_url
+    ## with the offset of the next page until a page has no metrics.
+    # type = "link"
+    ## Template of the URL of the next page, with the {{.Page}} number (the
+    ## first page is 1), {{.Offset}} and {{.Cursor}} of the next page.
+    # next_url = "http://localhost/metrics?cursor={{.Cursor}}"
+    ## GJSON path of the cursor of the next page in the response, the last
+    ## page has none.
+    # cursor_path = "meta.next_cursor"
+    ## Number of items of the pages, the increment of the offset.
+    # page_size = 100
+    ## Maximum number of pages requested.
+    # max_pages = 100
+
+  ## Bearer tokens of the OAuth2 client credentials grant, requested again
+  ## before they expire.
+  # [inputs.http.oauth2]
+    # token_url = "https://localhost/oauth2/token"
+    # client_id = "telegraf"
+    # client_secret = "secret"
+    # scopes = ["metrics:read"]
+
+  ## Bearer tokens signed as JSON Web Tokens with an RSA key (RS256) or a
+  ## secret (HS256).  The signed tokens are exchanged with the JWT bearer
+  ## grant of token_url if set, otherwise they are sent as they are.
+  # [inputs.http.jwt]
+    # key_file = "/etc/telegraf/jwt.pem"
+    # secret = ""
+    # issuer = "telegraf"
+    # subject = ""
+    # audience = "https://localhost/oauth2/token"
+    # expiry = "1h"
+    # token_url = "https://localhost/oauth2/token"
+    # scopes = []
 `
 
 // SampleConfig returns the default configuration of the Input
@@ -82,6 +141,12 @@ func (*HTTP) Description() string {
 	return "Read formatted metrics from one or more HTTP endpoints"
 }
 
+// RateLimiterName returns the name of the shared rate limiter, checked once
+// the configuration is loaded.
+func (h *HTTP) RateLimiterName() string {
+	return h.RateLimiter
+}
+
 // Gather takes in an accumulator and adds the metrics that the Input
 // gathers. This is called every "interval"
 func (h *HTTP) Gather(acc telegraf.Accumulator) error {
@@ -94,10 +159,20 @@ func (h *HTTP) Gather(acc telegraf.Accumulator) error {
 		if err != nil {
 			return err
 		}
+		proxyFunc, err := h.Config.Proxy()
+		if err != nil {
+			return err
+		}
+		if err := h.Pagination.init(); err != nil {
+			return err
+		}
+		if h.tokens, err = h.newTokenSource(); err != nil {
+			return err
+		}
 		h.client = &http.Client{
 			Transport: &http.Transport{
 				TLSClientConfig: tlsCfg,
-				Proxy:           http.ProxyFromEnvironment,
+				Proxy:           proxyFunc,
 			},
 			Timeout: h.Timeout.Duration,
 		}
@@ -124,7 +199,7 @@ func (h *HTTP) SetParser(parser parsers.Parser) {
 	h.parser = parser
 }
 
-// Gathers data from a particular URL
+// Gathers data from a particular URL and its next pages
 // Parameters:
 //     acc    : The telegraf Accumulator to use
 //     url    : endpoint to send request to
@@ -135,55 +210,113 @@ func (h *HTTP) gatherURL(
 	acc telegraf.Accumulator,
 	url string,
 ) error {
-	request, err := http.NewRequest(h.Method, url, nil)
-	if err != nil {
-		return err
+	add := func(metric telegraf.Metric) error {
+		if !metric.HasTag("url") {
+			metric.AddTag("url", url)
+		}
+		acc.AddFields(metric.Name(), metric.Fields(), metric.Tags(), metric.Time())
+		return nil
 	}
 
-	for k, v := range h.Headers {
-		if strings.ToLower(k) == "host" {
-			request.Host = v
-		} else {
-			request.Header.Add(k, v)
+	// without pagination the body is not needed once parsed, parsers able
+	// to read it row by row do not hold it in memory
+	if parser, ok := h.parser.(parsers.StreamParser); ok && h.Pagination.Type == "" {
+		resp, err := h.request(url)
+		if err != nil {
+			return err
 		}
+		defer resp.Body.Close()
+		return parser.ParseStream(resp.Body, add)
 	}
 
-	if h.Username != "" || h.Password != "" {
-		request.SetBasicAuth(h.Username, h.Password)
-	}
+	next := url
+	for page := 1; ; page++ {
+		resp, err := h.request(next)
+		if err != nil {
+			return err
+		}
+		b, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return err
+		}
 
-	resp, err := h.client.Do(request)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
+		metrics, err := h.parser.Parse(b)
+		if err != nil {
+			return err
+		}
+		for _, metric := range metrics {
+			add(metric)
+		}
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("Received status code %d (%s), expected %d (%s)",
-			resp.StatusCode,
-			http.StatusText(resp.StatusCode),
-			http.StatusOK,
-			http.StatusText(http.StatusOK))
+		var ok bool
+		next, ok, err = h.Pagination.next(next, page, resp, b, len(metrics))
+		if err != nil {
+			return fmt.Errorf("page %d: %s", page+1, err)
+		}
+		if !ok {
+			return nil
+		}
 	}
+}
 
-	b, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return err
-	}
+// request returns the response of the URL, whose body must be closed.  The
+// request is retried once with a new bearer token when the token is
+// rejected.
+func (h *HTTP) request(url string) (*http.Response, error) {
+	for retried := false; ; retried = true {
+		request, err := http.NewRequest(h.Method, url, nil)
+		if err != nil {
+			return nil, err
+		}
 
-	metrics, err := h.parser.Parse(b)
-	if err != nil {
-		return err
-	}
+		for k, v := range h.Headers {
+			if strings.ToLower(k) == "host" {
+				request.Host = v
+			} else {
+				request.Header.Add(k, v)
+			}
+		}
 
-	for _, metric := range metrics {
-		if !metric.HasTag("url") {
-			metric.AddTag("url", url)
+		if h.Username != "" || h.Password != "" {
+			request.SetBasicAuth(h.Username, h.Password)
 		}
-		acc.AddFields(metric.Name(), metric.Fields(), metric.Tags(), metric.Time())
-	}
 
-	return nil
+		var token string
+		if h.tokens != nil {
+			if token, err = h.tokens.Token(); err != nil {
+				return nil, err
+			}
+			request.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		if h.RateLimiter != "" {
+			bucket, err := limiter.Named(h.RateLimiter)
+			if err != nil {
+				return nil, err
+			}
+			bucket.Wait()
+		}
+
+		resp, err := h.client.Do(request)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode == http.StatusOK {
+			return resp, nil
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode == http.StatusUnauthorized && h.tokens != nil && !retried {
+			h.tokens.Invalidate(token)
+			continue
+		}
+		return nil, fmt.Errorf("Received status code %d (%s), expected %d (%s)",
+			resp.StatusCode,
+			http.StatusText(resp.StatusCode),
+			http.StatusOK,
+			http.StatusText(http.StatusOK))
+	}
 }
 
 func init() {
@@ -1,10 +1,13 @@
 package http_test
 
 import (
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
+	jwt "github.com/dgrijalva/jwt-go"
 	plugin "github.com/influxdata/telegraf/plugins/inputs/http"
 	"github.com/influxdata/telegraf/plugins/parsers"
 	"github.com/influxdata/telegraf/testutil"
@@ -132,6 +135,222 @@ func TestParserNotSet(t *testing.T) {
 	require.Error(t, acc.GatherError(plugin.Gather))
 }
 
+func TestPaginationLink(t *testing.T) {
+	fakeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("page") {
+		case "":
+			w.Header().Set("Link", `</endpoint?page=2>; rel="next", </endpoint?page=2>; rel="last"`)
+			_, _ = w.Write([]byte(`{"a": 1}`))
+		case "2":
+			_, _ = w.Write([]byte(`{"a": 2}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer fakeServer.Close()
+
+	url := fakeServer.URL + "/endpoint"
+	plugin := &plugin.HTTP{
+		URLs:       []string{url},
+		Pagination: plugin.Pagination{Type: "link"},
+	}
+	p, _ := parsers.NewJSONParser("metricName", nil, nil)
+	plugin.SetParser(p)
+
+	var acc testutil.Accumulator
+	require.NoError(t, acc.GatherError(plugin.Gather))
+	require.Len(t, acc.Metrics, 2)
+	require.Equal(t, 2.0, acc.Metrics[1].Fields["a"])
+	require.Equal(t, url, acc.Metrics[1].Tags["url"])
+}
+
+func TestPaginationCursor(t *testing.T) {
+	fakeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("cursor") {
+		case "":
+			_, _ = w.Write([]byte(`{"a": 1, "next": "b c"}`))
+		case "b c":
+			_, _ = w.Write([]byte(`{"a": 2}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer fakeServer.Close()
+
+	plugin := &plugin.HTTP{
+		URLs: []string{fakeServer.URL},
+		Pagination: plugin.Pagination{
+			Type:       "cursor",
+			NextURL:    fakeServer.URL + "?cursor={{.Cursor}}",
+			CursorPath: "next",
+		},
+	}
+	p, _ := parsers.NewJSONParser("metricName", nil, nil)
+	plugin.SetParser(p)
+
+	var acc testutil.Accumulator
+	require.NoError(t, acc.GatherError(plugin.Gather))
+	require.Len(t, acc.Metrics, 2)
+}
+
+func TestPaginationOffset(t *testing.T) {
+	var requests []string
+	fakeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.RawQuery)
+		if r.URL.Query().Get("offset") == "4" {
+			_, _ = w.Write([]byte(`[]`))
+			return
+		}
+		_, _ = w.Write([]byte(`[{"a": 1}, {"a": 2}]`))
+	}))
+	defer fakeServer.Close()
+
+	plugin := &plugin.HTTP{
+		URLs: []string{fakeServer.URL + "?offset=0&limit=2"},
+		Pagination: plugin.Pagination{
+			Type:     "offset",
+			NextURL:  fakeServer.URL + "?offset={{.Offset}}&limit=2",
+			PageSize: 2,
+		},
+	}
+	p, _ := parsers.NewJSONParser("metricName", nil, nil)
+	plugin.SetParser(p)
+
+	var acc testutil.Accumulator
+	require.NoError(t, acc.GatherError(plugin.Gather))
+	require.Len(t, acc.Metrics, 4)
+	require.Equal(t, []string{"offset=0&limit=2", "offset=2&limit=2", "offset=4&limit=2"}, requests)
+}
+
+func TestHTTPStreamCSV(t *testing.T) {
+	fakeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("host,used\nserver01,70\nserver02,80\n"))
+		// the rows before an invalid row are gathered as they are read
+		_, _ = w.Write([]byte("server03,\"90\n"))
+	}))
+	defer fakeServer.Close()
+
+	plugin := &plugin.HTTP{
+		URLs: []string{fakeServer.URL},
+	}
+	p, err := parsers.NewParser(&parsers.Config{
+		DataFormat:        "csv",
+		MetricName:        "usage",
+		CSVHeaderRowCount: 1,
+		CSVTagColumns:     []string{"host"},
+	})
+	require.NoError(t, err)
+	plugin.SetParser(p)
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Gather(&acc))
+	require.Len(t, acc.Errors, 1)
+	require.Len(t, acc.Metrics, 2)
+	acc.AssertContainsTaggedFields(t, "usage",
+		map[string]interface{}{"used": int64(70)},
+		map[string]string{"host": "server01", "url": fakeServer.URL})
+	acc.AssertContainsTaggedFields(t, "usage",
+		map[string]interface{}{"used": int64(80)},
+		map[string]string{"host": "server02", "url": fakeServer.URL})
+}
+
+func TestPaginationMaxPages(t *testing.T) {
+	requests := 0
+	fakeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Link", `</endpoint>; rel="next"`)
+		_, _ = w.Write([]byte(simpleJSON))
+	}))
+	defer fakeServer.Close()
+
+	plugin := &plugin.HTTP{
+		URLs:       []string{fakeServer.URL + "/endpoint"},
+		Pagination: plugin.Pagination{Type: "link", MaxPages: 3},
+	}
+	p, _ := parsers.NewJSONParser("metricName", nil, nil)
+	plugin.SetParser(p)
+
+	var acc testutil.Accumulator
+	require.NoError(t, acc.GatherError(plugin.Gather))
+	require.Equal(t, 3, requests)
+}
+
+func TestOAuth2TokenRefresh(t *testing.T) {
+	tokens := 0
+	revoked := ""
+	fakeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/token":
+			id, secret, _ := r.BasicAuth()
+			require.Equal(t, "telegraf", id)
+			require.Equal(t, "secret", secret)
+			require.NoError(t, r.ParseForm())
+			require.Equal(t, "client_credentials", r.Form.Get("grant_type"))
+			tokens++
+			fmt.Fprintf(w, `{"access_token": "token%d", "token_type": "bearer", "expires_in": 3600}`, tokens)
+		case "/endpoint":
+			auth := r.Header.Get("Authorization")
+			if !strings.HasPrefix(auth, "Bearer token") || auth == "Bearer "+revoked {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			_, _ = w.Write([]byte(simpleJSON))
+		}
+	}))
+	defer fakeServer.Close()
+
+	plugin := &plugin.HTTP{
+		URLs: []string{fakeServer.URL + "/endpoint"},
+		OAuth2: plugin.OAuth2{
+			TokenURL:     fakeServer.URL + "/token",
+			ClientID:     "telegraf",
+			ClientSecret: "secret",
+		},
+	}
+	p, _ := parsers.NewJSONParser("metricName", nil, nil)
+	plugin.SetParser(p)
+
+	var acc testutil.Accumulator
+	require.NoError(t, acc.GatherError(plugin.Gather))
+	require.NoError(t, acc.GatherError(plugin.Gather))
+	require.Equal(t, 1, tokens)
+
+	// a rejected token is requested again
+	revoked = "token1"
+	require.NoError(t, acc.GatherError(plugin.Gather))
+	require.Equal(t, 2, tokens)
+	require.Len(t, acc.Metrics, 3)
+}
+
+func TestJWTBearer(t *testing.T) {
+	fakeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, err := jwt.Parse(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "),
+			func(*jwt.Token) (interface{}, error) {
+				return []byte("secret"), nil
+			})
+		if err != nil || token.Claims.(jwt.MapClaims)["iss"] != "telegraf" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(simpleJSON))
+	}))
+	defer fakeServer.Close()
+
+	plugin := &plugin.HTTP{
+		URLs: []string{fakeServer.URL},
+		JWT: plugin.JWT{
+			Secret: "secret",
+			Issuer: "telegraf",
+		},
+	}
+	p, _ := parsers.NewJSONParser("metricName", nil, nil)
+	plugin.SetParser(p)
+
+	var acc testutil.Accumulator
+	require.NoError(t, acc.GatherError(plugin.Gather))
+	require.Len(t, acc.Metrics, 1)
+}
+
 const simpleJSON = `
 {
     "a": 1.2
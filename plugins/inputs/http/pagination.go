@@ -0,0 +1,147 @@
+package http
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"text/template"
+
+	"github.com/tidwall/gjson"
+)
+
+const defaultMaxPages = 100
+
+// Pagination is the configuration of the requests of the next pages of a
+// paginated API.
+type Pagination struct {
+	// Type is "link" to follow the next URL of the Link header, "cursor" to
+	// request the next URL with the cursor of the response and "offset" to
+	// request the next URL with the offset of the next page.
+	Type string `toml:"type"`
+	// NextURL is the template of the URL of the next page, with the Page,
+	// Offset and Cursor of the next page
+	NextURL string `toml:"next_url"`
+	// CursorPath is the GJSON path of the cursor in the response
+	CursorPath string `toml:"cursor_path"`
+	// PageSize is the number of items of the pages, the increment of Offset
+	PageSize int `toml:"page_size"`
+	// MaxPages limits the number of pages requested
+	MaxPages int `toml:"max_pages"`
+
+	nextURL *template.Template
+}
+
+// nextPage is the data of the template of the URL of the next page.
+type nextPage struct {
+	// Page is the number of the next page, the first page is 1
+	Page   int
+	Offset int
+	Cursor string
+}
+
+func (p *Pagination) init() error {
+	switch p.Type {
+	case "", "link":
+		return nil
+	case "cursor":
+		if p.CursorPath == "" {
+			return fmt.Errorf("pagination cursor_path is required with type cursor")
+		}
+	case "offset":
+		if p.PageSize <= 0 {
+			return fmt.Errorf("pagination page_size is required with type offset")
+		}
+	default:
+		return fmt.Errorf("unknown pagination type %q", p.Type)
+	}
+
+	if p.NextURL == "" {
+		return fmt.Errorf("pagination next_url is required with type %s", p.Type)
+	}
+	tmpl, err := template.New("next_url").Parse(p.NextURL)
+	if err != nil {
+		return fmt.Errorf("invalid pagination next_url: %s", err)
+	}
+	p.nextURL = tmpl
+	return nil
+}
+
+// next returns the URL of the page following the page of the number
+// requested with current, false when it was the last page.
+func (p *Pagination) next(current string, page int, resp *http.Response, body []byte, metrics int) (string, bool, error) {
+	if p.Type == "" {
+		return "", false, nil
+	}
+	maxPages := p.MaxPages
+	if maxPages <= 0 {
+		maxPages = defaultMaxPages
+	}
+	if page >= maxPages {
+		return "", false, nil
+	}
+
+	switch p.Type {
+	case "link":
+		link := nextLink(resp.Header)
+		if link == "" {
+			return "", false, nil
+		}
+		base, err := url.Parse(current)
+		if err != nil {
+			return "", false, err
+		}
+		ref, err := url.Parse(link)
+		if err != nil {
+			return "", false, fmt.Errorf("invalid next link %q: %s", link, err)
+		}
+		return base.ResolveReference(ref).String(), true, nil
+	case "cursor":
+		cursor := gjson.GetBytes(body, p.CursorPath).String()
+		if cursor == "" {
+			return "", false, nil
+		}
+		return p.expand(nextPage{Page: page + 1, Cursor: url.QueryEscape(cursor)})
+	default:
+		// the items of the API are exhausted with the first empty page
+		if metrics == 0 {
+			return "", false, nil
+		}
+		return p.expand(nextPage{Page: page + 1, Offset: page * p.PageSize})
+	}
+}
+
+func (p *Pagination) expand(page nextPage) (string, bool, error) {
+	var buf bytes.Buffer
+	if err := p.nextURL.Execute(&buf, page); err != nil {
+		return "", false, err
+	}
+	return buf.String(), true, nil
+}
+
+// nextLink returns the URL of the link of relation "next" of the Link
+// header, see RFC 8288.
+func nextLink(header http.Header) string {
+	for _, value := range header["Link"] {
+		for _, link := range strings.Split(value, ",") {
+			parts := strings.Split(link, ";")
+			target := strings.TrimSpace(parts[0])
+			if !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+				continue
+			}
+			for _, param := range parts[1:] {
+				kv := strings.SplitN(strings.TrimSpace(param), "=", 2)
+				if len(kv) != 2 || strings.ToLower(kv[0]) != "rel" {
+					continue
+				}
+				for _, rel := range strings.Fields(strings.Trim(kv[1], `"`)) {
+					if strings.ToLower(rel) == "next" {
+						return target[1 : len(target)-1]
+					}
+				}
+			}
+		}
+	}
+	return ""
+}
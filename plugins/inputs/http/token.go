@@ -0,0 +1,206 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+
+	"github.com/influxdata/telegraf/internal"
+)
+
+const (
+	defaultJWTExpiry = time.Hour
+	// tokens are renewed when they expire within the margin, so that they
+	// do not expire during the requests
+	tokenExpiryMargin = 30 * time.Second
+)
+
+// OAuth2 is the configuration of the bearer tokens obtained with the OAuth2
+// client credentials grant.
+type OAuth2 struct {
+	TokenURL     string   `toml:"token_url"`
+	ClientID     string   `toml:"client_id"`
+	ClientSecret string   `toml:"client_secret"`
+	Scopes       []string `toml:"scopes"`
+}
+
+// JWT is the configuration of the JSON Web Tokens signed by the plugin.  The
+// signed tokens are exchanged for bearer tokens with the JWT bearer grant of
+// TokenURL, or are the bearer tokens when TokenURL is empty.
+type JWT struct {
+	// KeyFile is the PEM file of the RSA private key signing the tokens
+	KeyFile string `toml:"key_file"`
+	// Secret is the HMAC key signing the tokens, without KeyFile
+	Secret   string            `toml:"secret"`
+	Issuer   string            `toml:"issuer"`
+	Subject  string            `toml:"subject"`
+	Audience string            `toml:"audience"`
+	Expiry   internal.Duration `toml:"expiry"`
+
+	TokenURL string   `toml:"token_url"`
+	Scopes   []string `toml:"scopes"`
+}
+
+// tokenSource caches a bearer token until it expires.
+type tokenSource struct {
+	fetch func() (string, time.Time, error)
+
+	mu     sync.Mutex
+	token  string
+	expiry time.Time
+}
+
+// Token returns the cached token, a new one when it expired.
+func (s *tokenSource) Token() (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.token != "" && (s.expiry.IsZero() || time.Now().Add(tokenExpiryMargin).Before(s.expiry)) {
+		return s.token, nil
+	}
+	token, expiry, err := s.fetch()
+	if err != nil {
+		return "", err
+	}
+	s.token, s.expiry = token, expiry
+	return token, nil
+}
+
+// Invalidate discards the cached token, rejected by the server.
+func (s *tokenSource) Invalidate(token string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.token == token {
+		s.token = ""
+	}
+}
+
+// newTokenSource returns the source of the bearer tokens of the
+// configuration, nil without OAuth2 nor JWT tokens.
+func (h *HTTP) newTokenSource() (*tokenSource, error) {
+	switch {
+	case h.OAuth2.TokenURL != "" && (h.JWT.KeyFile != "" || h.JWT.Secret != ""):
+		return nil, fmt.Errorf("oauth2 and jwt tokens are exclusive")
+	case h.OAuth2.TokenURL != "":
+		return &tokenSource{fetch: h.oauth2Token}, nil
+	case h.JWT.KeyFile != "" || h.JWT.Secret != "":
+		key, method, err := h.JWT.signingKey()
+		if err != nil {
+			return nil, err
+		}
+		return &tokenSource{fetch: func() (string, time.Time, error) {
+			return h.jwtToken(key, method)
+		}}, nil
+	}
+	return nil, nil
+}
+
+func (h *HTTP) oauth2Token() (string, time.Time, error) {
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(h.OAuth2.Scopes) > 0 {
+		form.Set("scope", strings.Join(h.OAuth2.Scopes, " "))
+	}
+	request, err := http.NewRequest("POST", h.OAuth2.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	request.SetBasicAuth(url.QueryEscape(h.OAuth2.ClientID), url.QueryEscape(h.OAuth2.ClientSecret))
+	return h.requestToken(request)
+}
+
+func (j *JWT) signingKey() (interface{}, jwt.SigningMethod, error) {
+	if j.KeyFile == "" {
+		return []byte(j.Secret), jwt.SigningMethodHS256, nil
+	}
+	bs, err := ioutil.ReadFile(j.KeyFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	key, err := jwt.ParseRSAPrivateKeyFromPEM(bs)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid jwt key_file %s: %s", j.KeyFile, err)
+	}
+	return key, jwt.SigningMethodRS256, nil
+}
+
+func (h *HTTP) jwtToken(key interface{}, method jwt.SigningMethod) (string, time.Time, error) {
+	expiry := h.JWT.Expiry.Duration
+	if expiry <= 0 {
+		expiry = defaultJWTExpiry
+	}
+	now := time.Now()
+	claims := jwt.StandardClaims{
+		Issuer:    h.JWT.Issuer,
+		Subject:   h.JWT.Subject,
+		Audience:  h.JWT.Audience,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(expiry).Unix(),
+	}
+	signed, err := jwt.NewWithClaims(method, claims).SignedString(key)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	if h.JWT.TokenURL == "" {
+		return signed, now.Add(expiry), nil
+	}
+
+	form := url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {signed},
+	}
+	if len(h.JWT.Scopes) > 0 {
+		form.Set("scope", strings.Join(h.JWT.Scopes, " "))
+	}
+	request, err := http.NewRequest("POST", h.JWT.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return h.requestToken(request)
+}
+
+// tokenResponse is the response of the token endpoints, see RFC 6749.
+type tokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	ExpiresIn        int64  `json:"expires_in"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+func (h *HTTP) requestToken(request *http.Request) (string, time.Time, error) {
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Accept", "application/json")
+
+	resp, err := h.client.Do(request)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("requesting token: %s", err)
+	}
+	defer resp.Body.Close()
+
+	var token tokenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil && resp.StatusCode == http.StatusOK {
+		return "", time.Time{}, fmt.Errorf("invalid token response: %s", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		if token.Error != "" {
+			return "", time.Time{}, fmt.Errorf("requesting token: %s %s", token.Error, token.ErrorDescription)
+		}
+		return "", time.Time{}, fmt.Errorf("requesting token: received status code %d (%s)",
+			resp.StatusCode, http.StatusText(resp.StatusCode))
+	}
+	if token.AccessToken == "" {
+		return "", time.Time{}, fmt.Errorf("no access_token in token response")
+	}
+
+	var expiry time.Time
+	if token.ExpiresIn > 0 {
+		expiry = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+	}
+	return token.AccessToken, expiry, nil
+}
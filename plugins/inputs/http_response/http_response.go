@@ -16,6 +16,7 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/proxy"
 	"github.com/influxdata/telegraf/internal/tls"
 	"github.com/influxdata/telegraf/plugins/inputs"
 )
@@ -23,7 +24,6 @@ import (
 // HTTPResponse struct
 type HTTPResponse struct {
 	Address             string
-	HTTPProxy           string `toml:"http_proxy"`
 	Body                string
 	Method              string
 	ResponseTimeout     internal.Duration
@@ -31,6 +31,7 @@ type HTTPResponse struct {
 	FollowRedirects     bool
 	ResponseStringMatch string
 	tls.ClientConfig
+	proxy.Config
 
 	compiledStringMatch *regexp.Regexp
 	client              *http.Client
@@ -45,8 +46,11 @@ var sampleConfig = `
   ## Server address (default http://localhost)
   # address = "http://localhost"
 
-  ## Set http_proxy (telegraf uses the system wide proxy settings if it's is not set)
+  ## Set http_proxy, supports http, https and socks5 URLs (telegraf uses the
+  ## agent or system wide proxy settings if it is not set)
   # http_proxy = "http://localhost:8888"
+  ## Hosts, domains and networks reached without the proxy
+  # no_proxy = ["localhost", ".example.com", "10.0.0.0/8"]
 
   ## Set response_timeout (default 5 seconds)
   # response_timeout = "5s"
@@ -87,22 +91,6 @@ func (h *HTTPResponse) SampleConfig() string {
 // ErrRedirectAttempted indicates that a redirect occurred
 var ErrRedirectAttempted = errors.New("redirect")
 
-// Set the proxy. A configured proxy overwrites the system wide proxy.
-func getProxyFunc(http_proxy string) func(*http.Request) (*url.URL, error) {
-	if http_proxy == "" {
-		return http.ProxyFromEnvironment
-	}
-	proxyURL, err := url.Parse(http_proxy)
-	if err != nil {
-		return func(_ *http.Request) (*url.URL, error) {
-			return nil, errors.New("bad proxy: " + err.Error())
-		}
-	}
-	return func(r *http.Request) (*url.URL, error) {
-		return proxyURL, nil
-	}
-}
-
 // CreateHttpClient creates an http client which will timeout at the specified
 // timeout period and can follow redirects if specified
 func (h *HTTPResponse) createHttpClient() (*http.Client, error) {
@@ -110,9 +98,13 @@ func (h *HTTPResponse) createHttpClient() (*http.Client, error) {
 	if err != nil {
 		return nil, err
 	}
+	proxyFunc, err := h.Config.Proxy()
+	if err != nil {
+		return nil, err
+	}
 	client := &http.Client{
 		Transport: &http.Transport{
-			Proxy:             getProxyFunc(h.HTTPProxy),
+			Proxy:             proxyFunc,
 			DisableKeepAlives: true,
 			TLSClientConfig:   tlsCfg,
 		},
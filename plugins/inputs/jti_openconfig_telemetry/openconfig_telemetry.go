@@ -1,20 +1,21 @@
 package jti_openconfig_telemetry
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net"
 	"regexp"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/retry"
+	"github.com/influxdata/telegraf/internal/subscription"
 	"github.com/influxdata/telegraf/plugins/inputs"
 	"github.com/influxdata/telegraf/plugins/inputs/jti_openconfig_telemetry/auth"
 	"github.com/influxdata/telegraf/plugins/inputs/jti_openconfig_telemetry/oc"
-	"golang.org/x/net/context"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials"
@@ -34,9 +35,12 @@ type OpenConfigTelemetry struct {
 
 	sensorsConfig   []sensorConfig
 	grpcClientConns []*grpc.ClientConn
-	wg              *sync.WaitGroup
+	subscriptions   subscription.Manager
 }
 
+// Maximum delay between retry attempts, unless retry_delay is longer
+const maxRetryDelay = time.Minute
+
 var (
 	// Regex to match and extract data points from path value in received key
 	keyPathRegex = regexp.MustCompile("\\/([^\\/]*)\\[([A-Za-z0-9\\-\\/]*\\=[^\\[]*)\\]")
@@ -79,7 +83,8 @@ var (
   ssl_cert = "/etc/telegraf/cert.pem"
 
   ## Delay between retry attempts of failed RPC calls or streams. Defaults to 1000ms.
-  ## Failed streams/calls will not be retried if 0 is provided
+  ## The delay doubles with the consecutive failures, up to a minute.
+  ## Failed calls will not be retried if 0 is provided
   retry_delay = "1000ms"
 
   ## To treat all string values as tags, set this to true
@@ -100,10 +105,10 @@ func (m *OpenConfigTelemetry) Gather(acc telegraf.Accumulator) error {
 }
 
 func (m *OpenConfigTelemetry) Stop() {
+	m.subscriptions.Stop()
 	for _, grpcClientConn := range m.grpcClientConns {
 		grpcClientConn.Close()
 	}
-	m.wg.Wait()
 }
 
 // Takes in XML path with predicates and returns list of tags+values along with a final
@@ -271,75 +276,75 @@ func (m *OpenConfigTelemetry) splitSensorConfig() int {
 	return len(m.sensorsConfig)
 }
 
-// Subscribes and collects OpenConfig telemetry data from given server
-func (m *OpenConfigTelemetry) collectData(ctx context.Context,
-	grpcServer string, grpcClientConn *grpc.ClientConn,
-	acc telegraf.Accumulator) error {
+// Returns the subscriptions collecting OpenConfig telemetry data of the sensors
+// from given server
+func (m *OpenConfigTelemetry) collectData(grpcServer string,
+	grpcClientConn *grpc.ClientConn,
+	acc telegraf.Accumulator) []subscription.Subscription {
 	c := telemetry.NewOpenConfigTelemetryClient(grpcClientConn)
+	var subscriptions []subscription.Subscription
 	for _, sensor := range m.sensorsConfig {
-		m.wg.Add(1)
-		go func(ctx context.Context, sensor sensorConfig) {
-			defer m.wg.Done()
-
-			for {
-				stream, err := c.TelemetrySubscribe(ctx,
-					&telemetry.SubscriptionRequest{PathList: sensor.pathList})
-				if err != nil {
-					rpcStatus, _ := status.FromError(err)
-					// If service is currently unavailable and may come back later, retry
-					if rpcStatus.Code() != codes.Unavailable {
-						acc.AddError(fmt.Errorf("E! Could not subscribe to %s: %v", grpcServer,
-							err))
-						return
-					} else {
-						// Retry with delay. If delay is not provided, use default
-						if m.RetryDelay.Duration > 0 {
-							log.Printf("D! Retrying %s with timeout %v", grpcServer,
-								m.RetryDelay.Duration)
-							time.Sleep(m.RetryDelay.Duration)
-							continue
-						} else {
-							return
-						}
-					}
-				}
-				for {
-					r, err := stream.Recv()
-					if err != nil {
-						// If we encounter error in the stream, break so we can retry
-						// the connection
-						acc.AddError(fmt.Errorf("E! Failed to read from %s: %v", err, grpcServer))
-						break
-					}
-
-					log.Printf("D! Received from %s: %v", grpcServer, r)
-
-					// Create a point and add to batch
-					tags := make(map[string]string)
-
-					// Insert additional tags
-					tags["device"] = grpcServer
-
-					dgroups := m.extractData(r, grpcServer)
-
-					// Print final data collection
-					log.Printf("D! Available collection for %s is: %v", grpcServer, dgroups)
-
-					tnow := time.Now()
-					// Iterate through data groups and add them
-					for _, group := range dgroups {
-						if len(group.tags) == 0 {
-							acc.AddFields(sensor.measurementName, group.data, tags, tnow)
-						} else {
-							acc.AddFields(sensor.measurementName, group.data, group.tags, tnow)
-						}
-					}
-				}
-			}
-		}(ctx, sensor)
+		sensor := sensor
+		subscriptions = append(subscriptions, subscription.Subscription{
+			Name: grpcServer + " " + sensor.measurementName,
+			Run: func(ctx context.Context, s *subscription.Session) error {
+				return m.subscribe(ctx, s, c, grpcServer, sensor, acc)
+			},
+		})
 	}
 
-	return nil
+	return subscriptions
+}
+
+// Subscribes to the sensor and collects its data until the stream fails
+func (m *OpenConfigTelemetry) subscribe(ctx context.Context,
+	s *subscription.Session, c telemetry.OpenConfigTelemetryClient,
+	grpcServer string, sensor sensorConfig, acc telegraf.Accumulator) error {
+	stream, err := c.TelemetrySubscribe(ctx,
+		&telemetry.SubscriptionRequest{PathList: sensor.pathList})
+	if err != nil {
+		rpcStatus, _ := status.FromError(err)
+		// If service is currently unavailable and may come back later, retry
+		if rpcStatus.Code() != codes.Unavailable || m.RetryDelay.Duration <= 0 {
+			return retry.NewPermanent(fmt.Errorf("Could not subscribe to %s: %v",
+				grpcServer, err))
+		}
+		return err
+	}
+	s.Subscribed()
+
+	for {
+		r, err := stream.Recv()
+		if err != nil {
+			// If we encounter error in the stream, return so we can retry
+			// the connection
+			return fmt.Errorf("Failed to read from %s: %v", grpcServer, err)
+		}
+		s.Received()
+
+		log.Printf("D! Received from %s: %v", grpcServer, r)
+
+		// Create a point and add to batch
+		tags := make(map[string]string)
+
+		// Insert additional tags
+		tags["device"] = grpcServer
+
+		dgroups := m.extractData(r, grpcServer)
+
+		// Print final data collection
+		log.Printf("D! Available collection for %s is: %v", grpcServer, dgroups)
+
+		tnow := time.Now()
+		// Iterate through data groups and add them
+		for _, group := range dgroups {
+			if len(group.tags) == 0 {
+				acc.AddFields(sensor.measurementName, group.data, tags, tnow)
+			} else {
+				acc.AddFields(sensor.measurementName, group.data, group.tags, tnow)
+			}
+		}
+	}
 }
 
 func (m *OpenConfigTelemetry) Start(acc telegraf.Accumulator) error {
@@ -362,9 +367,8 @@ func (m *OpenConfigTelemetry) Start(acc telegraf.Accumulator) error {
 
 	// Connect to given list of servers and start collecting data
 	var grpcClientConn *grpc.ClientConn
-	var wg sync.WaitGroup
+	var subscriptions []subscription.Subscription
 	ctx := context.Background()
-	m.wg = &wg
 	for _, server := range m.Servers {
 		// Extract device address and port
 		grpcServer, grpcPort, err := net.SplitHostPort(server)
@@ -381,9 +385,9 @@ func (m *OpenConfigTelemetry) Start(acc telegraf.Accumulator) error {
 		}
 		if err != nil {
 			log.Printf("E! Failed to connect to %s: %v", server, err)
-		} else {
-			log.Printf("D! Opened a new gRPC session to %s on port %s", grpcServer, grpcPort)
+			continue
 		}
+		log.Printf("D! Opened a new gRPC session to %s on port %s", grpcServer, grpcPort)
 
 		// Add to the list of client connections
 		m.grpcClientConns = append(m.grpcClientConns, grpcClientConn)
@@ -406,8 +410,23 @@ func (m *OpenConfigTelemetry) Start(acc telegraf.Accumulator) error {
 		}
 
 		// Subscribe and gather telemetry data
-		m.collectData(ctx, grpcServer, grpcClientConn, acc)
+		subscriptions = append(subscriptions,
+			m.collectData(grpcServer, grpcClientConn, acc)...)
+	}
+
+	// Failed subscriptions are retried after retry_delay, doubling up to a
+	// minute
+	m.subscriptions = subscription.Manager{
+		Input: "jti_openconfig_telemetry",
+		Backoff: retry.Backoff{
+			Initial: m.RetryDelay.Duration,
+			Max:     maxRetryDelay,
+		},
+	}
+	if m.RetryDelay.Duration > maxRetryDelay {
+		m.subscriptions.Backoff.Max = m.RetryDelay.Duration
 	}
+	m.subscriptions.Start(acc, subscriptions...)
 
 	return nil
 }
@@ -3,11 +3,15 @@ package grok
 import (
 	"bufio"
 	"fmt"
+	"io/ioutil"
 	"log"
 	"os"
+	"path/filepath"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/vjeantet/grok"
@@ -71,7 +75,13 @@ type Parser struct {
 	namedPatterns      []string
 	CustomPatterns     string
 	CustomPatternFiles []string
-	Measurement        string
+	// CustomPatternDirs are directories whose files are all loaded as
+	// custom pattern files.
+	CustomPatternDirs []string
+	// ReloadPatterns recompiles the patterns whenever one of the custom
+	// pattern files is added, removed or modified.
+	ReloadPatterns bool
+	Measurement    string
 
 	// Timezone is an optional component to help render log dates to
 	// your chosen zone.
@@ -107,6 +117,15 @@ type Parser struct {
 	//          "RESPONSE_CODE": "%{NUMBER:rc:tag}"
 	//       }
 	patterns map[string]string
+	// tsLayouts is a map of the named timestamp layouts defined in the
+	// custom patterns, usable as "ts-<name>" modifier.
+	//   ie, {
+	//          "ts-apache_error": "Mon Jan 02 15:04:05.000000 2006"
+	//       }
+	tsLayouts map[string]string
+	// patternFiles is the modification time of every loaded pattern file,
+	// used to detect changes when reloading.
+	patternFiles map[string]time.Time
 	// foundTsLayouts is a slice of timestamp patterns that have been found
 	// in the log lines. This slice gets updated if the user uses the generic
 	// 'ts' modifier for timestamps. This slice is checked first for matches,
@@ -117,14 +136,67 @@ type Parser struct {
 	timeFunc func() time.Time
 	g        *grok.Grok
 	tsModder *tsModder
+
+	// mu protects the compiled patterns, which are replaced on reload.
+	mu sync.Mutex
 }
 
 // Compile is a bound method to Parser which will process the options for our parser
 func (p *Parser) Compile() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.tsModder = &tsModder{}
+	return p.compile()
+}
+
+// Reload recompiles the patterns if any of the custom pattern files has
+// changed since they were last compiled, it returns whether the patterns
+// were reloaded.  The previous patterns are kept if compiling fails.
+func (p *Parser) Reload() (bool, error) {
+	files, err := p.findPatternFiles()
+	if err != nil {
+		return false, err
+	}
+
+	p.mu.Lock()
+	changed := !sameFiles(files, p.patternFiles)
+	p.mu.Unlock()
+	if !changed {
+		return false, nil
+	}
+
+	next := &Parser{
+		Patterns:           p.Patterns,
+		CustomPatterns:     p.CustomPatterns,
+		CustomPatternFiles: p.CustomPatternFiles,
+		CustomPatternDirs:  p.CustomPatternDirs,
+		Measurement:        p.Measurement,
+		Timezone:           p.Timezone,
+		timeFunc:           p.timeFunc,
+	}
+	if err := next.compile(); err != nil {
+		return false, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.namedPatterns = next.namedPatterns
+	p.typeMap = next.typeMap
+	p.tsMap = next.tsMap
+	p.patterns = next.patterns
+	p.tsLayouts = next.tsLayouts
+	p.patternFiles = next.patternFiles
+	p.foundTsLayouts = nil
+	p.g = next.g
+	return true, nil
+}
+
+func (p *Parser) compile() error {
 	p.typeMap = make(map[string]map[string]string)
 	p.tsMap = make(map[string]map[string]string)
 	p.patterns = make(map[string]string)
-	p.tsModder = &tsModder{}
+	p.tsLayouts = make(map[string]string)
 	var err error
 	p.g, err = grok.NewWithConfig(&grok.Config{NamedCapturesOnly: true})
 	if err != nil {
@@ -133,6 +205,7 @@ func (p *Parser) Compile() error {
 
 	// Give Patterns fake names so that they can be treated as named
 	// "custom patterns"
+	customPatterns := p.CustomPatterns
 	p.namedPatterns = make([]string, 0, len(p.Patterns))
 	for i, pattern := range p.Patterns {
 		pattern = strings.TrimSpace(pattern)
@@ -140,7 +213,7 @@ func (p *Parser) Compile() error {
 			continue
 		}
 		name := fmt.Sprintf("GROK_INTERNAL_PATTERN_%d", i)
-		p.CustomPatterns += "\n" + name + " " + pattern + "\n"
+		customPatterns += "\n" + name + " " + pattern + "\n"
 		p.namedPatterns = append(p.namedPatterns, "%{"+name+"}")
 	}
 
@@ -150,14 +223,21 @@ func (p *Parser) Compile() error {
 
 	// Combine user-supplied CustomPatterns with DEFAULT_PATTERNS and parse
 	// them together as the same type of pattern.
-	p.CustomPatterns = DEFAULT_PATTERNS + p.CustomPatterns
-	if len(p.CustomPatterns) != 0 {
-		scanner := bufio.NewScanner(strings.NewReader(p.CustomPatterns))
-		p.addCustomPatterns(scanner)
-	}
+	customPatterns = DEFAULT_PATTERNS + customPatterns
+	scanner := bufio.NewScanner(strings.NewReader(customPatterns))
+	p.addCustomPatterns(scanner)
 
 	// Parse any custom pattern files supplied.
-	for _, filename := range p.CustomPatternFiles {
+	p.patternFiles, err = p.findPatternFiles()
+	if err != nil {
+		return err
+	}
+	filenames := make([]string, 0, len(p.patternFiles))
+	for filename := range p.patternFiles {
+		filenames = append(filenames, filename)
+	}
+	sort.Strings(filenames)
+	for _, filename := range filenames {
 		file, fileErr := os.Open(filename)
 		if fileErr != nil {
 			return fileErr
@@ -165,6 +245,7 @@ func (p *Parser) Compile() error {
 
 		scanner := bufio.NewScanner(bufio.NewReader(file))
 		p.addCustomPatterns(scanner)
+		file.Close()
 	}
 
 	if p.Measurement == "" {
@@ -184,8 +265,50 @@ func (p *Parser) Compile() error {
 	return p.compileCustomPatterns()
 }
 
+// findPatternFiles returns the modification time of the custom pattern files
+// and of the files in the custom pattern directories.
+func (p *Parser) findPatternFiles() (map[string]time.Time, error) {
+	files := make(map[string]time.Time)
+	for _, filename := range p.CustomPatternFiles {
+		info, err := os.Stat(filename)
+		if err != nil {
+			return nil, err
+		}
+		files[filename] = info.ModTime()
+	}
+
+	for _, dir := range p.CustomPatternDirs {
+		infos, err := ioutil.ReadDir(dir)
+		if err != nil {
+			return nil, err
+		}
+		for _, info := range infos {
+			if !info.Mode().IsRegular() || strings.HasPrefix(info.Name(), ".") {
+				continue
+			}
+			files[filepath.Join(dir, info.Name())] = info.ModTime()
+		}
+	}
+	return files, nil
+}
+
+func sameFiles(a, b map[string]time.Time) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for name, mod := range a {
+		if other, ok := b[name]; !ok || !other.Equal(mod) {
+			return false
+		}
+	}
+	return true
+}
+
 // ParseLine is the primary function to process individual lines, returning the metrics
 func (p *Parser) ParseLine(line string) (telegraf.Metric, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
 	var err error
 	// values are the parsed fields from the log line
 	var values map[string]string
@@ -356,6 +479,15 @@ func (p *Parser) addCustomPatterns(scanner *bufio.Scanner) {
 		line := strings.TrimSpace(scanner.Text())
 		if len(line) > 0 && line[0] != '#' {
 			names := strings.SplitN(line, " ", 2)
+			if len(names) < 2 {
+				continue
+			}
+			// named timestamp layouts, ie: ts-mylog "2006-01-02 15:04:05"
+			if strings.HasPrefix(names[0], "ts-") {
+				layout := strings.TrimSpace(names[1])
+				p.tsLayouts[names[0]] = strings.TrimSuffix(strings.TrimPrefix(layout, `"`), `"`)
+				continue
+			}
 			p.patterns[names[0]] = names[1]
 		}
 	}
@@ -420,6 +552,9 @@ func (p *Parser) parseTypedCaptures(name, pattern string) (string, error) {
 			if layout, ok := timeLayouts[match[2]]; ok {
 				// built-in time format
 				p.tsMap[patternName][match[1]] = layout
+			} else if layout, ok := p.tsLayouts[match[2]]; ok {
+				// named time format from the custom patterns
+				p.tsMap[patternName][match[1]] = layout
 			} else {
 				// custom time format
 				p.tsMap[patternName][match[1]] = strings.TrimSuffix(strings.TrimPrefix(match[2], `ts-"`), `"`)
@@ -1,6 +1,9 @@
 package grok
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -982,3 +985,71 @@ func TestSyslogTimestampParser(t *testing.T) {
 	require.NotNil(t, m)
 	require.Equal(t, 2018, m.Time().Year())
 }
+
+func TestNamedTimestampLayout(t *testing.T) {
+	p := &Parser{
+		Patterns: []string{"%{TEST_LOG}"},
+		CustomPatterns: `
+			ts-test "2006-01-02--15:04:05"
+			TEST_LOG %{NOTSPACE:timestamp:ts-test} value=%{NUMBER:value:int}
+		`,
+	}
+	require.NoError(t, p.Compile())
+
+	m, err := p.ParseLine("2018-04-01--12:30:00 value=42")
+	require.NoError(t, err)
+	require.NotNil(t, m)
+	assert.Equal(t, map[string]interface{}{"value": int64(42)}, m.Fields())
+	assert.Equal(t, time.Date(2018, time.April, 1, 12, 30, 0, 0, time.UTC), m.Time())
+}
+
+func TestCustomPatternDirsReload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "grok")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "test")
+	require.NoError(t, ioutil.WriteFile(filename,
+		[]byte("TEST_LOG value=%{NUMBER:value:int}\n"), 0644))
+
+	p := &Parser{
+		Patterns:          []string{"%{TEST_LOG}"},
+		CustomPatternDirs: []string{dir},
+		ReloadPatterns:    true,
+	}
+	require.NoError(t, p.Compile())
+
+	m, err := p.ParseLine("value=42")
+	require.NoError(t, err)
+	require.NotNil(t, m)
+	assert.Equal(t, map[string]interface{}{"value": int64(42)}, m.Fields())
+
+	reloaded, err := p.Reload()
+	require.NoError(t, err)
+	assert.False(t, reloaded)
+
+	require.NoError(t, ioutil.WriteFile(filename,
+		[]byte("TEST_LOG count=%{NUMBER:count:int}\n"), 0644))
+	later := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(filename, later, later))
+
+	reloaded, err = p.Reload()
+	require.NoError(t, err)
+	assert.True(t, reloaded)
+
+	m, err = p.ParseLine("count=7")
+	require.NoError(t, err)
+	require.NotNil(t, m)
+	assert.Equal(t, map[string]interface{}{"count": int64(7)}, m.Fields())
+
+	// invalid patterns keep the previous patterns
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "broken"),
+		[]byte("TEST_BROKEN %{HTTPDATE:ts1:ts-httpd} %{HTTPDATE:ts2:ts-httpd}\n"), 0644))
+	_, err = p.Reload()
+	assert.Error(t, err)
+
+	m, err = p.ParseLine("count=8")
+	require.NoError(t, err)
+	require.NotNil(t, m)
+	assert.Equal(t, map[string]interface{}{"count": int64(8)}, m.Fields())
+}
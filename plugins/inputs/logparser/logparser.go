@@ -85,6 +85,13 @@ const sampleConfig = `
     ## Full path(s) to custom pattern files.
     custom_pattern_files = []
 
+    ## Directories whose files are all loaded as custom pattern files.
+    # custom_pattern_dirs = ["/etc/telegraf/patterns.d"]
+
+    ## Recompile the patterns on every interval if the custom pattern files
+    ## were added, removed or modified.
+    # reload_patterns = false
+
     ## Custom patterns can also be defined here. Put one pattern per line.
     custom_patterns = '''
 
@@ -116,6 +123,15 @@ func (l *LogParserPlugin) Gather(acc telegraf.Accumulator) error {
 	l.Lock()
 	defer l.Unlock()
 
+	if l.GrokParser != nil && l.GrokParser.ReloadPatterns {
+		reloaded, err := l.GrokParser.Reload()
+		if err != nil {
+			acc.AddError(fmt.Errorf("unable to reload grok patterns: %s", err))
+		} else if reloaded {
+			log.Printf("I! Reloaded grok patterns")
+		}
+	}
+
 	// always start from the beginning of files that appear while we're running
 	return l.tailNewfiles(true)
 }
@@ -3,12 +3,13 @@ Lustre 2.x telegraf plugin
 
 Lustre (http://lustre.org/) is an open-source, parallel file system
 for HPC environments. It stores statistics about its activity in
-/proc
+/proc, on the servers and on the clients
 
 */
 package lustre2
 
 import (
+	"io/ioutil"
 	"path/filepath"
 	"strconv"
 	"strings"
@@ -21,8 +22,9 @@ import (
 // Lustre proc files can change between versions, so we want to future-proof
 // by letting people choose what to look at.
 type Lustre2 struct {
-	Ost_procfiles []string
-	Mds_procfiles []string
+	Ost_procfiles    []string
+	Mds_procfiles    []string
+	Client_procfiles []string
 
 	// allFields maps and OST name to the metric fields associated with that OST
 	allFields map[string]map[string]interface{}
@@ -36,10 +38,21 @@ var sampleConfig = `
   #   "/proc/fs/lustre/obdfilter/*/stats",
   #   "/proc/fs/lustre/osd-ldiskfs/*/stats",
   #   "/proc/fs/lustre/obdfilter/*/job_stats",
+  #   "/proc/fs/lustre/ost/OSS/*/stats",
   # ]
   # mds_procfiles = [
   #   "/proc/fs/lustre/mdt/*/md_stats",
   #   "/proc/fs/lustre/mdt/*/job_stats",
+  #   "/proc/fs/lustre/mds/MDS/*/stats",
+  # ]
+  ## The capacity of the targets is read from the kbytes* and files* files
+  ## next to their stats files.
+  ##
+  ## Client stats, of the mounts and of the RPCs to the OSTs and MDTs
+  # client_procfiles = [
+  #   "/proc/fs/lustre/llite/*/stats",
+  #   "/proc/fs/lustre/osc/*/stats",
+  #   "/proc/fs/lustre/mdc/*/stats",
   # ]
 `
 
@@ -54,7 +67,28 @@ type mapping struct {
 	tag      string // Additional tag to add for this metric
 }
 
-var wanted_ost_fields = []*mapping{
+// RPC stats of the services of the servers, and of the clients for each
+// target. The lines are the same as read_bytes and write_bytes, with the
+// total wait time in microseconds in the seventh column.
+var wanted_rpc_fields = []*mapping{
+	{
+		inProc:   "req_waittime",
+		field:    1,
+		reportAs: "rpc_calls",
+	},
+	{
+		inProc:   "req_waittime",
+		field:    6,
+		reportAs: "rpc_waittime",
+	},
+	{
+		inProc:   "req_timeout",
+		field:    1,
+		reportAs: "rpc_timeouts",
+	},
+}
+
+var wanted_ost_fields = append([]*mapping{
 	{
 		inProc:   "write_bytes",
 		field:    6,
@@ -84,6 +118,74 @@ var wanted_ost_fields = []*mapping{
 	{
 		inProc: "cache_access",
 	},
+}, wanted_rpc_fields...)
+
+var wanted_client_fields = append([]*mapping{
+	{
+		inProc:   "write_bytes",
+		field:    6,
+		reportAs: "write_bytes",
+	},
+	{
+		inProc:   "write_bytes",
+		field:    1,
+		reportAs: "write_calls",
+	},
+	{
+		inProc:   "read_bytes",
+		field:    6,
+		reportAs: "read_bytes",
+	},
+	{
+		inProc:   "read_bytes",
+		field:    1,
+		reportAs: "read_calls",
+	},
+	{
+		inProc: "open",
+	},
+	{
+		inProc: "close",
+	},
+	{
+		inProc: "mmap",
+	},
+	{
+		inProc: "seek",
+	},
+	{
+		inProc: "fsync",
+	},
+	{
+		inProc: "readdir",
+	},
+	{
+		inProc: "setattr",
+	},
+	{
+		inProc: "truncate",
+	},
+	{
+		inProc: "getattr",
+	},
+	{
+		inProc: "statfs",
+	},
+	{
+		inProc: "getxattr",
+	},
+	{
+		inProc: "setxattr",
+	},
+}, wanted_rpc_fields...)
+
+// Capacity of the targets, in single value files next to their stats
+var wanted_capacity_files = []string{
+	"kbytestotal",
+	"kbytesfree",
+	"kbytesavail",
+	"filestotal",
+	"filesfree",
 }
 
 var wanted_ost_jobstats_fields = []*mapping{
@@ -219,7 +321,7 @@ var wanted_ost_jobstats_fields = []*mapping{
 	},
 }
 
-var wanted_mds_fields = []*mapping{
+var wanted_mds_fields = append([]*mapping{
 	{
 		inProc: "open",
 	},
@@ -268,7 +370,7 @@ var wanted_mds_fields = []*mapping{
 	{
 		inProc: "crossdir_rename",
 	},
-}
+}, wanted_rpc_fields...)
 
 var wanted_mdt_jobstats_fields = []*mapping{
 	{
@@ -411,6 +513,38 @@ func (l *Lustre2) GetLustreProcStats(fileglob string, wanted_fields []*mapping,
 	return nil
 }
 
+// getLustreCapacity reads the capacity of the targets from the files in the
+// directories of the stats files of fileglob, if any.
+func (l *Lustre2) getLustreCapacity(fileglob string) error {
+	files, err := filepath.Glob(fileglob)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		dir := filepath.Dir(file)
+		name := filepath.Base(dir)
+		for _, capacity := range wanted_capacity_files {
+			contents, err := ioutil.ReadFile(filepath.Join(dir, capacity))
+			if err != nil {
+				// not a target, or a version without the file
+				continue
+			}
+			data, err := strconv.ParseUint(strings.TrimSpace(string(contents)), 10, 64)
+			if err != nil {
+				return err
+			}
+			fields, ok := l.allFields[name]
+			if !ok {
+				fields = make(map[string]interface{})
+				l.allFields[name] = fields
+			}
+			fields[capacity] = data
+		}
+	}
+	return nil
+}
+
 // SampleConfig returns sample configuration message
 func (l *Lustre2) SampleConfig() string {
 	return sampleConfig
@@ -418,7 +552,7 @@ func (l *Lustre2) SampleConfig() string {
 
 // Description returns description of Lustre2 plugin
 func (l *Lustre2) Description() string {
-	return "Read metrics from local Lustre service on OST, MDS"
+	return "Read metrics from local Lustre service on OST, MDS and clients"
 }
 
 // Gather reads stats from all lustre targets
@@ -444,6 +578,21 @@ func (l *Lustre2) Gather(acc telegraf.Accumulator) error {
 		if err != nil {
 			return err
 		}
+		// RPC statistics are in ost/OSS/<service>/stats
+		err = l.GetLustreProcStats("/proc/fs/lustre/ost/OSS/*/stats",
+			wanted_rpc_fields, acc)
+		if err != nil {
+			return err
+		}
+		// capacity of the OSTs, and of the MDTs with ldiskfs
+		err = l.getLustreCapacity("/proc/fs/lustre/obdfilter/*/stats")
+		if err != nil {
+			return err
+		}
+		err = l.getLustreCapacity("/proc/fs/lustre/osd-ldiskfs/*/stats")
+		if err != nil {
+			return err
+		}
 	}
 
 	if len(l.Mds_procfiles) == 0 {
@@ -460,6 +609,28 @@ func (l *Lustre2) Gather(acc telegraf.Accumulator) error {
 		if err != nil {
 			return err
 		}
+
+		// Metadata server RPC stats
+		err = l.GetLustreProcStats("/proc/fs/lustre/mds/MDS/*/stats",
+			wanted_rpc_fields, acc)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(l.Client_procfiles) == 0 {
+		// Client stats of the mounts, and RPC stats of the clients of the
+		// targets
+		for _, procfile := range []string{
+			"/proc/fs/lustre/llite/*/stats",
+			"/proc/fs/lustre/osc/*/stats",
+			"/proc/fs/lustre/mdc/*/stats",
+		} {
+			err := l.GetLustreProcStats(procfile, wanted_client_fields, acc)
+			if err != nil {
+				return err
+			}
+		}
 	}
 
 	for _, procfile := range l.Ost_procfiles {
@@ -471,6 +642,13 @@ func (l *Lustre2) Gather(acc telegraf.Accumulator) error {
 		if err != nil {
 			return err
 		}
+		if strings.HasSuffix(procfile, "job_stats") {
+			continue
+		}
+		err = l.getLustreCapacity(procfile)
+		if err != nil {
+			return err
+		}
 	}
 	for _, procfile := range l.Mds_procfiles {
 		mdt_fields := wanted_mds_fields
@@ -482,6 +660,12 @@ func (l *Lustre2) Gather(acc telegraf.Accumulator) error {
 			return err
 		}
 	}
+	for _, procfile := range l.Client_procfiles {
+		err := l.GetLustreProcStats(procfile, wanted_client_fields, acc)
+		if err != nil {
+			return err
+		}
+	}
 
 	for name, fields := range l.allFields {
 		tags := map[string]string{
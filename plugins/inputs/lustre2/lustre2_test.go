@@ -95,6 +95,43 @@ const mdtJobStatsContents = `job_stats:
   crossdir_rename: { samples:         200, unit:  reqs }
 `
 
+const ossProcContents = `snapshot_time             1438693264.341527 secs.usecs
+req_waittime              70520447 samples [usec] 2 1061932 5398812346 1207402573626
+req_qdepth                70520447 samples [reqs] 0 50 2079219 2359455
+req_active                70520447 samples [reqs] 1 64 339542372 3115062604
+req_timeout               70520447 samples [sec] 1 15 88014451 888185905
+reqbuf_avail              147911318 samples [bufs] 1 128 9318398626 597917302298
+ost_read                  40106312 samples [usec] 11 2076183 25432918542 90178011632218
+ost_write                 30414135 samples [usec] 56 1997925 81236871734 1342101474722134
+`
+
+const lliteProcContents = `snapshot_time             1540486371.386548461 secs.nsecs
+read_bytes                1254960 samples [bytes] 0 4194304 271093043806
+write_bytes               283526 samples [bytes] 1 4194304 96402735210
+open                      1087365 samples [regs]
+close                     1087364 samples [regs]
+seek                      2306 samples [regs]
+fsync                     12 samples [regs]
+readdir                   4219 samples [regs]
+setattr                   1331 samples [regs]
+truncate                  1277 samples [regs]
+getattr                   1195245 samples [regs]
+statfs                    2011 samples [regs]
+alloc_inode               8520 samples [regs]
+getxattr                  5069 samples [regs]
+inode_permission          5638620 samples [regs]
+`
+
+const oscProcContents = `snapshot_time             1540486371.398154373 secs.nsecs
+req_waittime              426812 samples [usec] 45 1517338 417893049 8213941434711
+req_active                426812 samples [reqs] 1 19 597015 1424047
+ldlm_extent_enqueue       2147 samples [reqs] 1 1 2147 2147
+read_bytes                177043 samples [bytes] 4096 4194304 271093043806 1066093163209289728
+write_bytes               92281 samples [bytes] 1 4194304 96402735210 367614766005325824
+ost_read                  177043 samples [usec] 224 1517338 113813497 3498052014391
+ost_write                 92281 samples [usec] 364 1496117 214025468 3890658587020
+`
+
 func TestLustre2GeneratesMetrics(t *testing.T) {
 
 	tempdir := os.TempDir() + "/telegraf/proc/fs/lustre/"
@@ -246,3 +283,134 @@ func TestLustre2GeneratesJobstatsMetrics(t *testing.T) {
 	err = os.RemoveAll(os.TempDir() + "/telegraf")
 	require.NoError(t, err)
 }
+
+func TestLustre2GeneratesCapacityAndRPCMetrics(t *testing.T) {
+
+	tempdir := os.TempDir() + "/telegraf/proc/fs/lustre/"
+	ost_name := "OST0001"
+
+	obddir := tempdir + "/obdfilter/"
+	err := os.MkdirAll(obddir+"/"+ost_name, 0755)
+	require.NoError(t, err)
+
+	ossdir := tempdir + "/ost/OSS/"
+	err = os.MkdirAll(ossdir+"/ost_io", 0755)
+	require.NoError(t, err)
+
+	err = ioutil.WriteFile(obddir+"/"+ost_name+"/stats", []byte(obdfilterProcContents), 0644)
+	require.NoError(t, err)
+
+	for file, contents := range map[string]string{
+		"kbytestotal": "30497713388\n",
+		"kbytesfree":  "12693101968\n",
+		"kbytesavail": "11153027344\n",
+		"filestotal":  "19070976\n",
+		"filesfree":   "18131284\n",
+	} {
+		err = ioutil.WriteFile(obddir+"/"+ost_name+"/"+file, []byte(contents), 0644)
+		require.NoError(t, err)
+	}
+
+	err = ioutil.WriteFile(ossdir+"/ost_io/stats", []byte(ossProcContents), 0644)
+	require.NoError(t, err)
+
+	m := &Lustre2{
+		Ost_procfiles: []string{obddir + "/*/stats", ossdir + "/*/stats"},
+		Mds_procfiles: []string{tempdir + "/mdt/*/md_stats"},
+	}
+
+	var acc testutil.Accumulator
+
+	err = m.Gather(&acc)
+	require.NoError(t, err)
+
+	acc.AssertContainsTaggedFields(t, "lustre2",
+		map[string]interface{}{
+			"read_bytes":  uint64(78026117632000),
+			"read_calls":  uint64(203238095),
+			"write_bytes": uint64(15201500833981),
+			"write_calls": uint64(71893382),
+			"kbytestotal": uint64(30497713388),
+			"kbytesfree":  uint64(12693101968),
+			"kbytesavail": uint64(11153027344),
+			"filestotal":  uint64(19070976),
+			"filesfree":   uint64(18131284),
+		},
+		map[string]string{"name": ost_name})
+
+	acc.AssertContainsTaggedFields(t, "lustre2",
+		map[string]interface{}{
+			"rpc_calls":    uint64(70520447),
+			"rpc_waittime": uint64(5398812346),
+			"rpc_timeouts": uint64(70520447),
+		},
+		map[string]string{"name": "ost_io"})
+
+	err = os.RemoveAll(os.TempDir() + "/telegraf")
+	require.NoError(t, err)
+}
+
+func TestLustre2GeneratesClientMetrics(t *testing.T) {
+
+	tempdir := os.TempDir() + "/telegraf/proc/fs/lustre/"
+	fs_name := "lustre-ffff88103b1ab800"
+	osc_name := "lustre-OST0001-osc-ffff88103b1ab800"
+
+	llitedir := tempdir + "/llite/"
+	err := os.MkdirAll(llitedir+"/"+fs_name, 0755)
+	require.NoError(t, err)
+
+	oscdir := tempdir + "/osc/"
+	err = os.MkdirAll(oscdir+"/"+osc_name, 0755)
+	require.NoError(t, err)
+
+	err = ioutil.WriteFile(llitedir+"/"+fs_name+"/stats", []byte(lliteProcContents), 0644)
+	require.NoError(t, err)
+
+	err = ioutil.WriteFile(oscdir+"/"+osc_name+"/stats", []byte(oscProcContents), 0644)
+	require.NoError(t, err)
+
+	m := &Lustre2{
+		Ost_procfiles:    []string{tempdir + "/obdfilter/*/stats"},
+		Mds_procfiles:    []string{tempdir + "/mdt/*/md_stats"},
+		Client_procfiles: []string{llitedir + "/*/stats", oscdir + "/*/stats"},
+	}
+
+	var acc testutil.Accumulator
+
+	err = m.Gather(&acc)
+	require.NoError(t, err)
+
+	acc.AssertContainsTaggedFields(t, "lustre2",
+		map[string]interface{}{
+			"read_bytes":  uint64(271093043806),
+			"read_calls":  uint64(1254960),
+			"write_bytes": uint64(96402735210),
+			"write_calls": uint64(283526),
+			"open":        uint64(1087365),
+			"close":       uint64(1087364),
+			"seek":        uint64(2306),
+			"fsync":       uint64(12),
+			"readdir":     uint64(4219),
+			"setattr":     uint64(1331),
+			"truncate":    uint64(1277),
+			"getattr":     uint64(1195245),
+			"statfs":      uint64(2011),
+			"getxattr":    uint64(5069),
+		},
+		map[string]string{"name": fs_name})
+
+	acc.AssertContainsTaggedFields(t, "lustre2",
+		map[string]interface{}{
+			"read_bytes":   uint64(271093043806),
+			"read_calls":   uint64(177043),
+			"write_bytes":  uint64(96402735210),
+			"write_calls":  uint64(92281),
+			"rpc_calls":    uint64(426812),
+			"rpc_waittime": uint64(417893049),
+		},
+		map[string]string{"name": osc_name})
+
+	err = os.RemoveAll(os.TempDir() + "/telegraf")
+	require.NoError(t, err)
+}
@@ -14,6 +14,7 @@ import (
 	"time"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal/proxy"
 	"github.com/influxdata/telegraf/internal/tls"
 	"github.com/influxdata/telegraf/plugins/inputs"
 	jsonparser "github.com/influxdata/telegraf/plugins/parsers/json"
@@ -34,6 +35,7 @@ type Mesos struct {
 	SlaveCols  []string `toml:"slave_collections"`
 	//SlaveTasks bool
 	tls.ClientConfig
+	proxy.Config
 
 	initialized bool
 	client      *http.Client
@@ -81,6 +83,12 @@ var sampleConfig = `
   # tls_key = "/etc/telegraf/key.pem"
   ## Use TLS but skip chain & host verification
   # insecure_skip_verify = false
+
+  ## Optional proxy, supports http, https and socks5 URLs (telegraf uses the
+  ## agent or system wide proxy settings if it is not set)
+  # http_proxy = "http://localhost:8888"
+  ## Hosts, domains and networks reached without the proxy
+  # no_proxy = ["localhost", ".example.com", "10.0.0.0/8"]
 `
 
 // SampleConfig returns a sample configuration block
@@ -212,10 +220,14 @@ func (m *Mesos) createHttpClient() (*http.Client, error) {
 	if err != nil {
 		return nil, err
 	}
+	proxyFunc, err := m.Config.Proxy()
+	if err != nil {
+		return nil, err
+	}
 
 	client := &http.Client{
 		Transport: &http.Transport{
-			Proxy:           http.ProxyFromEnvironment,
+			Proxy:           proxyFunc,
 			TLSClientConfig: tlsCfg,
 		},
 		Timeout: 4 * time.Second,
@@ -0,0 +1,408 @@
+package moosefs
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/cliparse"
+	"github.com/influxdata/telegraf/internal/command"
+	"github.com/influxdata/telegraf/plugins/inputs"
+)
+
+var (
+	defaultTimeout = internal.Duration{Duration: 5 * time.Second}
+
+	// labels of the master info of mfscli
+	mfsInfoFields = map[string]string{
+		"RAM used":             "memory_usage",
+		"total space":          "total_space",
+		"avail space":          "avail_space",
+		"trash space":          "trash_space",
+		"trash files":          "trash_files",
+		"sustained space":      "reserved_space",
+		"sustained files":      "reserved_files",
+		"all fs objects":       "fs_objects",
+		"directories":          "directories",
+		"files":                "files",
+		"chunks":               "chunks",
+		"all chunk copies":     "chunk_copies",
+		"regular chunk copies": "regular_chunk_copies",
+	}
+
+	// columns of the info of lizardfs-admin, following the version
+	lizardfsInfoFields = []string{
+		"memory_usage",
+		"total_space",
+		"avail_space",
+		"trash_space",
+		"trash_files",
+		"reserved_space",
+		"reserved_files",
+		"fs_objects",
+		"directories",
+		"files",
+		"chunks",
+		"chunk_copies",
+		"regular_chunk_copies",
+	}
+
+	// columns of the chunkservers of lizardfs-admin, the label is missing
+	// from the output of the versions before 3.10
+	lizardfsChunkserverColumns = []cliparse.Column{
+		{Key: "chunkserver", Tag: true},
+		{Key: "version", Tag: true},
+		{Key: "chunks", Type: cliparse.Uint},
+		{Key: "used_space", Type: cliparse.Uint},
+		{Key: "total_space", Type: cliparse.Uint},
+		{Key: "todel_chunks", Type: cliparse.Uint},
+		{Skip: true},
+		{Skip: true},
+		{Key: "errors", Type: cliparse.Uint},
+	}
+	lizardfsChunkservers = &cliparse.Parser{
+		Rules: []cliparse.Rule{
+			{Columns: append(lizardfsChunkserverColumns, cliparse.Column{Key: "label", Tag: true})},
+			{Columns: lizardfsChunkserverColumns},
+		},
+	}
+)
+
+type MooseFS struct {
+	Flavor  string
+	Path    string
+	Master  string
+	Port    int
+	Timeout internal.Duration
+
+	runner command.Runner
+}
+
+var sampleConfig = `
+  ## Flavor of the master, "moosefs" queried with mfscli or "lizardfs"
+  ## queried with lizardfs-admin.
+  # flavor = "moosefs"
+
+  ## Optionally specify the path to the mfscli or lizardfs-admin executable
+  # path = "/usr/bin/mfscli"
+
+  ## Address and port of the master
+  # master = "mfsmaster"
+  # port = 9421
+
+  ## Timeout of each command.
+  # timeout = "5s"
+`
+
+func (m *MooseFS) SampleConfig() string {
+	return sampleConfig
+}
+
+func (m *MooseFS) Description() string {
+	return "Read the chunk and space stats of the master and of the chunkservers of MooseFS or LizardFS"
+}
+
+// SetRunner sets the runner of the mfscli and lizardfs-admin commands.
+func (m *MooseFS) SetRunner(runner command.Runner) {
+	m.runner = runner
+}
+
+func (m *MooseFS) Gather(acc telegraf.Accumulator) error {
+	if m.runner == nil {
+		runner, err := (&command.Config{}).Runner()
+		if err != nil {
+			return err
+		}
+		m.runner = runner
+	}
+	tags := map[string]string{"master": m.Master}
+
+	switch m.Flavor {
+	case "", "moosefs":
+		return m.gatherMooseFS(acc, tags)
+	case "lizardfs":
+		return m.gatherLizardFS(acc, tags)
+	default:
+		return fmt.Errorf("unknown flavor %q", m.Flavor)
+	}
+}
+
+func (m *MooseFS) gatherMooseFS(acc telegraf.Accumulator, tags map[string]string) error {
+	path := m.path("mfscli")
+	if len(path) == 0 {
+		return fmt.Errorf("mfscli not found: verify that mfscli is installed and that mfscli is in your PATH")
+	}
+	args := []string{"-H", m.Master, "-P", strconv.Itoa(m.Port), "-p", "-s", "\t"}
+
+	fields := make(map[string]interface{})
+	out, err := m.run(path, append(args, "-SIN")...)
+	if err != nil {
+		return err
+	}
+	if err := parseMfsInfo(fields, out); err != nil {
+		return fmt.Errorf("failed to parse master info: %s", err)
+	}
+
+	out, err = m.run(path, append(args, "-SIC")...)
+	if err != nil {
+		acc.AddError(err)
+	} else if err := parseMfsChunks(fields, out); err != nil {
+		acc.AddError(fmt.Errorf("failed to parse chunk matrix: %s", err))
+	}
+	acc.AddFields("moosefs_master", fields, tags)
+
+	out, err = m.run(path, append(args, "-SCS")...)
+	if err != nil {
+		return err
+	}
+	if err := gatherMfsChunkservers(acc, tags, out); err != nil {
+		return fmt.Errorf("failed to parse chunkservers: %s", err)
+	}
+	return nil
+}
+
+func (m *MooseFS) gatherLizardFS(acc telegraf.Accumulator, tags map[string]string) error {
+	path := m.path("lizardfs-admin")
+	if len(path) == 0 {
+		return fmt.Errorf("lizardfs-admin not found: verify that lizardfs-adm is installed and that lizardfs-admin is in your PATH")
+	}
+	port := strconv.Itoa(m.Port)
+
+	fields := make(map[string]interface{})
+	out, err := m.run(path, "info", m.Master, port, "--porcelain")
+	if err != nil {
+		return err
+	}
+	if err := parseLizardfsInfo(fields, out); err != nil {
+		return fmt.Errorf("failed to parse master info: %s", err)
+	}
+
+	out, err = m.run(path, "chunks-health", m.Master, port, "--porcelain", "--availability", "--replication")
+	if err != nil {
+		acc.AddError(err)
+	} else if err := parseLizardfsChunksHealth(fields, out); err != nil {
+		acc.AddError(fmt.Errorf("failed to parse chunks health: %s", err))
+	}
+	acc.AddFields("moosefs_master", fields, tags)
+
+	out, err = m.run(path, "list-chunkservers", m.Master, port, "--porcelain")
+	if err != nil {
+		return err
+	}
+	if err := gatherLizardfsChunkservers(acc, tags, out); err != nil {
+		return fmt.Errorf("failed to parse chunkservers: %s", err)
+	}
+	return nil
+}
+
+// path returns the configured path of the command, or looks it up
+func (m *MooseFS) path(command string) string {
+	if len(m.Path) > 0 {
+		return m.Path
+	}
+	path, _ := exec.LookPath(command)
+	return path
+}
+
+func (m *MooseFS) run(path string, args ...string) (string, error) {
+	timeout := m.Timeout.Duration
+	if timeout <= 0 {
+		timeout = defaultTimeout.Duration
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	out, err := m.runner.Run(ctx, path, args...)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+// parseMfsInfo parses the master info of "mfscli -SIN -p -s <tab>", the
+// values follow their labels in the last columns:
+//
+//	master info	total space	107374182400
+func parseMfsInfo(fields map[string]interface{}, out string) error {
+	for _, line := range strings.Split(out, "\n") {
+		cols := strings.Split(line, "\t")
+		if len(cols) < 2 {
+			continue
+		}
+		name, ok := mfsInfoFields[strings.TrimSpace(cols[len(cols)-2])]
+		if !ok {
+			continue
+		}
+		value, err := strconv.ParseUint(strings.TrimSpace(cols[len(cols)-1]), 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %s", name, err)
+		}
+		fields[name] = value
+	}
+	return nil
+}
+
+// parseMfsChunks parses the matrix of the chunks of all the chunkservers of
+// "mfscli -SIC -p -s <tab>", with the counts of the chunks of each goal by
+// number of valid copies, from 0 to 10 and more:
+//
+//	chunk matrix	all	2	0	1	1042	0	0	0	0	0	0	0	0
+func parseMfsChunks(fields map[string]interface{}, out string) error {
+	var underGoal, overGoal, missing uint64
+	for _, line := range strings.Split(out, "\n") {
+		cols := strings.Split(line, "\t")
+		// section, matrix, goal and the 11 counts
+		if len(cols) != 14 || strings.TrimSpace(cols[1]) != "all" {
+			continue
+		}
+		goal, err := strconv.Atoi(strings.TrimSpace(cols[2]))
+		if err != nil {
+			continue
+		}
+		for copies, col := range cols[3:] {
+			count, err := strconv.ParseUint(strings.TrimSpace(col), 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid count of chunks of goal %d: %s", goal, err)
+			}
+			switch {
+			case copies == 0:
+				missing += count
+			case copies < goal:
+				underGoal += count
+			case copies > goal:
+				overGoal += count
+			}
+		}
+	}
+	fields["missing_chunks"] = missing
+	fields["under_goal_chunks"] = underGoal
+	fields["over_goal_chunks"] = overGoal
+	return nil
+}
+
+// gatherMfsChunkservers adds the chunkservers of "mfscli -SCS -p -s <tab>":
+//
+//	chunk servers	ip	port	id	labels	version	load	maintenance	chunks	used	total	...
+func gatherMfsChunkservers(acc telegraf.Accumulator, tags map[string]string, out string) error {
+	for _, line := range strings.Split(out, "\n") {
+		cols := strings.Split(line, "\t")
+		if len(cols) < 11 {
+			continue
+		}
+		// skip the headers
+		if _, err := strconv.ParseUint(cols[2], 10, 16); err != nil {
+			continue
+		}
+		fields := make(map[string]interface{})
+		for i, name := range map[int]string{
+			8:  "chunks",
+			9:  "used_space",
+			10: "total_space",
+		} {
+			value, err := strconv.ParseUint(strings.TrimSpace(cols[i]), 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid %s of %s: %s", name, cols[1], err)
+			}
+			fields[name] = value
+		}
+
+		csTags := map[string]string{
+			"chunkserver": cols[1] + ":" + cols[2],
+			"version":     cols[5],
+		}
+		for k, v := range tags {
+			csTags[k] = v
+		}
+		acc.AddFields("moosefs_chunkserver", fields, csTags)
+	}
+	return nil
+}
+
+// parseLizardfsInfo parses the master info of "lizardfs-admin info
+// --porcelain", the version followed by the values of lizardfsInfoFields
+func parseLizardfsInfo(fields map[string]interface{}, out string) error {
+	cols := strings.Fields(out)
+	if len(cols) < len(lizardfsInfoFields)+1 {
+		return fmt.Errorf("expected %d columns, got %d", len(lizardfsInfoFields)+1, len(cols))
+	}
+	for i, name := range lizardfsInfoFields {
+		value, err := strconv.ParseUint(cols[i+1], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %s", name, err)
+		}
+		fields[name] = value
+	}
+	return nil
+}
+
+// parseLizardfsChunksHealth parses the availability and the replication
+// state of the chunks of "lizardfs-admin chunks-health --porcelain", the
+// counts of the chunks of each goal:
+//
+//	AVA <goal> <safe> <endangered> <lost>
+//	REP <goal> <chunks needing 0 copies> <1 copy> ... <10 or more copies>
+func parseLizardfsChunksHealth(fields map[string]interface{}, out string) error {
+	var endangered, missing, underGoal uint64
+	for _, line := range strings.Split(out, "\n") {
+		cols := strings.Fields(line)
+		if len(cols) < 3 {
+			continue
+		}
+		counts := make([]uint64, 0, len(cols)-2)
+		for _, col := range cols[2:] {
+			count, err := strconv.ParseUint(col, 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid count of chunks of goal %s: %s", cols[1], err)
+			}
+			counts = append(counts, count)
+		}
+
+		switch cols[0] {
+		case "AVA":
+			if len(counts) != 3 {
+				return fmt.Errorf("expected 3 availability counts of goal %s, got %d", cols[1], len(counts))
+			}
+			endangered += counts[1]
+			missing += counts[2]
+		case "REP":
+			for _, count := range counts[1:] {
+				underGoal += count
+			}
+		}
+	}
+	fields["endangered_chunks"] = endangered
+	fields["missing_chunks"] = missing
+	fields["under_goal_chunks"] = underGoal
+	return nil
+}
+
+// gatherLizardfsChunkservers adds the chunkservers of "lizardfs-admin
+// list-chunkservers --porcelain":
+//
+//	<address> <version> <chunks> <used> <total> <todel chunks> <todel used> <todel total> <errors> <label>
+func gatherLizardfsChunkservers(acc telegraf.Accumulator, tags map[string]string, out string) error {
+	records, err := lizardfsChunkservers.ParseBytes([]byte(out))
+	for _, record := range records {
+		for k, v := range tags {
+			record.Tags[k] = v
+		}
+		acc.AddFields("moosefs_chunkserver", record.Fields, record.Tags)
+	}
+	return err
+}
+
+func init() {
+	inputs.Add("moosefs", func() telegraf.Input {
+		return &MooseFS{
+			Flavor:  "moosefs",
+			Master:  "mfsmaster",
+			Port:    9421,
+			Timeout: defaultTimeout,
+		}
+	})
+}
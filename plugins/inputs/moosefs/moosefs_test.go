@@ -0,0 +1,200 @@
+package moosefs
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/influxdata/telegraf/internal/command"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	mockMfsInfo = strings.Join([]string{
+		"master info\tmaster version\t3.0.103",
+		"master info\tRAM used\t325042176",
+		"master info\ttotal space\t10737418240000",
+		"master info\tavail space\t5368709120000",
+		"master info\ttrash space\t1073741824",
+		"master info\ttrash files\t42",
+		"master info\tsustained space\t0",
+		"master info\tsustained files\t0",
+		"master info\tall fs objects\t1245901",
+		"master info\tdirectories\t10231",
+		"master info\tfiles\t1235670",
+		"master info\tchunks\t1301042",
+		"master info\tall chunk copies\t2602080",
+		"master info\tregular chunk copies\t2602080",
+	}, "\n")
+
+	mockMfsChunks = strings.Join([]string{
+		"chunk matrix\tall\t1\t0\t258\t0\t0\t0\t0\t0\t0\t0\t0\t0",
+		"chunk matrix\tall\t2\t2\t1\t1300779\t2\t0\t0\t0\t0\t0\t0\t0",
+		"chunk matrix\tregular\t2\t2\t1\t1300779\t2\t0\t0\t0\t0\t0\t0\t0",
+	}, "\n")
+
+	mockMfsChunkservers = strings.Join([]string{
+		"chunk servers\t192.168.10.11\t9422\t1\t-\t3.0.103\t5\toff\t650521\t2684354560000\t5368709120000\t50.00",
+		"chunk servers\t192.168.10.12\t9422\t2\t-\t3.0.103\t2\toff\t650521\t2684354560000\t5368709120000\t50.00",
+	}, "\n")
+
+	mockLizardfsInfo = `3.12.0 325042176 10737418240000 5368709120000 1073741824 42 0 0 1245901 10231 1235670 1301042 2602080 2602080
+`
+	mockLizardfsChunksHealth = `AVA 1 258 0 0
+AVA 2 1300779 3 2
+REP 1 258 0 0 0 0 0 0 0 0 0 0
+REP 2 1300779 1 2 0 0 0 0 0 0 0 0
+`
+	mockLizardfsChunkservers = `192.168.10.11:9422 3.12.0 650521 2684354560000 5368709120000 0 0 0 0 ssd
+192.168.10.12:9422 3.12.0 650521 2684354560000 5368709120000 12 1073741824 5368709120000 1 _
+`
+)
+
+// runCommand returns the output of the mfscli and lizardfs-admin commands
+// of the tests
+func runCommand(ctx context.Context, name string, args ...string) ([]byte, error) {
+	switch name {
+	case "mfscli":
+		switch args[len(args)-1] {
+		case "-SIN":
+			return []byte(mockMfsInfo), nil
+		case "-SIC":
+			return []byte(mockMfsChunks), nil
+		case "-SCS":
+			return []byte(mockMfsChunkservers), nil
+		}
+	case "lizardfs-admin":
+		switch args[0] {
+		case "info":
+			return []byte(mockLizardfsInfo), nil
+		case "chunks-health":
+			return []byte(mockLizardfsChunksHealth), nil
+		case "list-chunkservers":
+			return []byte(mockLizardfsChunkservers), nil
+		}
+	default:
+		return nil, fmt.Errorf("command not found")
+	}
+	return nil, fmt.Errorf("invalid argument")
+}
+
+func TestGatherMooseFS(t *testing.T) {
+	var acc testutil.Accumulator
+	m := &MooseFS{Path: "mfscli", Master: "mfsmaster", Port: 9421, runner: command.RunnerFunc(runCommand)}
+	require.NoError(t, acc.GatherError(m.Gather))
+
+	acc.AssertContainsTaggedFields(t, "moosefs_master",
+		map[string]interface{}{
+			"memory_usage":         uint64(325042176),
+			"total_space":          uint64(10737418240000),
+			"avail_space":          uint64(5368709120000),
+			"trash_space":          uint64(1073741824),
+			"trash_files":          uint64(42),
+			"reserved_space":       uint64(0),
+			"reserved_files":       uint64(0),
+			"fs_objects":           uint64(1245901),
+			"directories":          uint64(10231),
+			"files":                uint64(1235670),
+			"chunks":               uint64(1301042),
+			"chunk_copies":         uint64(2602080),
+			"regular_chunk_copies": uint64(2602080),
+			"missing_chunks":       uint64(2),
+			"under_goal_chunks":    uint64(1),
+			"over_goal_chunks":     uint64(2),
+		},
+		map[string]string{"master": "mfsmaster"})
+
+	acc.AssertContainsTaggedFields(t, "moosefs_chunkserver",
+		map[string]interface{}{
+			"chunks":      uint64(650521),
+			"used_space":  uint64(2684354560000),
+			"total_space": uint64(5368709120000),
+		},
+		map[string]string{
+			"master":      "mfsmaster",
+			"chunkserver": "192.168.10.12:9422",
+			"version":     "3.0.103",
+		})
+	require.Equal(t, 3, len(acc.Metrics))
+}
+
+func TestGatherLizardFS(t *testing.T) {
+	var acc testutil.Accumulator
+	m := &MooseFS{
+		Flavor: "lizardfs",
+		Path:   "lizardfs-admin",
+		Master: "mfsmaster",
+		Port:   9421,
+		runner: command.RunnerFunc(runCommand),
+	}
+	require.NoError(t, acc.GatherError(m.Gather))
+
+	acc.AssertContainsTaggedFields(t, "moosefs_master",
+		map[string]interface{}{
+			"memory_usage":         uint64(325042176),
+			"total_space":          uint64(10737418240000),
+			"avail_space":          uint64(5368709120000),
+			"trash_space":          uint64(1073741824),
+			"trash_files":          uint64(42),
+			"reserved_space":       uint64(0),
+			"reserved_files":       uint64(0),
+			"fs_objects":           uint64(1245901),
+			"directories":          uint64(10231),
+			"files":                uint64(1235670),
+			"chunks":               uint64(1301042),
+			"chunk_copies":         uint64(2602080),
+			"regular_chunk_copies": uint64(2602080),
+			"endangered_chunks":    uint64(3),
+			"missing_chunks":       uint64(2),
+			"under_goal_chunks":    uint64(3),
+		},
+		map[string]string{"master": "mfsmaster"})
+
+	acc.AssertContainsTaggedFields(t, "moosefs_chunkserver",
+		map[string]interface{}{
+			"chunks":       uint64(650521),
+			"used_space":   uint64(2684354560000),
+			"total_space":  uint64(5368709120000),
+			"todel_chunks": uint64(12),
+			"errors":       uint64(1),
+		},
+		map[string]string{
+			"master":      "mfsmaster",
+			"chunkserver": "192.168.10.12:9422",
+			"version":     "3.12.0",
+			"label":       "_",
+		})
+	require.Equal(t, 3, len(acc.Metrics))
+}
+
+func TestGatherUnknownFlavor(t *testing.T) {
+	var acc testutil.Accumulator
+	m := &MooseFS{Flavor: "glusterfs"}
+	require.Error(t, m.Gather(&acc))
+}
+
+func TestGatherLizardfsChunkservers(t *testing.T) {
+	var acc testutil.Accumulator
+	out := `192.168.10.11:9422 3.9.4 650521 2684354560000 5368709120000 0 0 0 0
+192.168.10.12:9422 3.9.4 invalid 2684354560000 5368709120000 0 0 0 0
+`
+	err := gatherLizardfsChunkservers(&acc, map[string]string{"master": "mfsmaster"}, out)
+	require.Error(t, err)
+
+	acc.AssertContainsTaggedFields(t, "moosefs_chunkserver",
+		map[string]interface{}{
+			"chunks":       uint64(650521),
+			"used_space":   uint64(2684354560000),
+			"total_space":  uint64(5368709120000),
+			"todel_chunks": uint64(0),
+			"errors":       uint64(0),
+		},
+		map[string]string{
+			"master":      "mfsmaster",
+			"chunkserver": "192.168.10.11:9422",
+			"version":     "3.9.4",
+		})
+	require.False(t, acc.HasTag("moosefs_chunkserver", "label"))
+}
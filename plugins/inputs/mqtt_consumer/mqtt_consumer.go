@@ -1,6 +1,7 @@
 package mqtt_consumer
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"strings"
@@ -9,6 +10,7 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/subscription"
 	"github.com/influxdata/telegraf/internal/tls"
 	"github.com/influxdata/telegraf/plugins/inputs"
 	"github.com/influxdata/telegraf/plugins/parsers"
@@ -37,15 +39,14 @@ type MQTTConsumer struct {
 	tls.ClientConfig
 
 	sync.Mutex
-	client mqtt.Client
+	opts          *mqtt.ClientOptions
+	subscriptions subscription.Manager
 	// channel of all incoming raw mqtt messages
 	in   chan mqtt.Message
 	done chan struct{}
 
 	// keep the accumulator internally:
 	acc telegraf.Accumulator
-
-	connected bool
 }
 
 var sampleConfig = `
@@ -105,7 +106,6 @@ func (m *MQTTConsumer) SetParser(parser parsers.Parser) {
 func (m *MQTTConsumer) Start(acc telegraf.Accumulator) error {
 	m.Lock()
 	defer m.Unlock()
-	m.connected = false
 
 	if m.PersistentSession && m.ClientID == "" {
 		return fmt.Errorf("ERROR MQTT Consumer: When using persistent_session" +
@@ -126,49 +126,65 @@ func (m *MQTTConsumer) Start(acc telegraf.Accumulator) error {
 		return err
 	}
 
-	m.client = mqtt.NewClient(opts)
+	m.opts = opts
 	m.in = make(chan mqtt.Message, 1000)
 	m.done = make(chan struct{})
 
-	m.connect()
+	go m.receiver()
+
+	// the subscription reconnects to the brokers, instead of the client, so
+	// that the reconnections back off and are reported
+	m.subscriptions = subscription.Manager{Input: "mqtt_consumer"}
+	m.subscriptions.Start(acc, subscription.Subscription{
+		Name: strings.Join(m.Servers, ","),
+		Run:  m.subscribe,
+	})
 
 	return nil
 }
 
-func (m *MQTTConsumer) connect() error {
-	if token := m.client.Connect(); token.Wait() && token.Error() != nil {
-		err := token.Error()
-		log.Printf("D! MQTT Consumer, connection error - %v", err)
+// subscribe connects to the brokers and subscribes to the topics, until the
+// connection is lost or ctx is done.
+func (m *MQTTConsumer) subscribe(ctx context.Context, s *subscription.Session) error {
+	lost := make(chan error, 1)
+	m.opts.SetConnectionLostHandler(func(_ mqtt.Client, err error) {
+		select {
+		case lost <- err:
+		default:
+		}
+	})
 
-		return err
+	client := mqtt.NewClient(m.opts)
+	if token := client.Connect(); token.Wait() && token.Error() != nil {
+		log.Printf("D! MQTT Consumer, connection error - %v", token.Error())
+		return token.Error()
 	}
-
-	go m.receiver()
-
-	return nil
-}
-
-func (m *MQTTConsumer) onConnect(c mqtt.Client) {
+	defer client.Disconnect(200)
 	log.Printf("I! MQTT Client Connected")
-	if !m.PersistentSession || !m.connected {
-		topics := make(map[string]byte)
-		for _, topic := range m.Topics {
-			topics[topic] = byte(m.QoS)
-		}
-		subscribeToken := c.SubscribeMultiple(topics, m.recvMessage)
-		subscribeToken.Wait()
-		if subscribeToken.Error() != nil {
-			m.acc.AddError(fmt.Errorf("E! MQTT Subscribe Error\ntopics: %s\nerror: %s",
-				strings.Join(m.Topics[:], ","), subscribeToken.Error()))
-		}
-		m.connected = true
+
+	// a persistent session keeps the subscriptions on the broker,
+	// subscribing again is harmless
+	topics := make(map[string]byte)
+	for _, topic := range m.Topics {
+		topics[topic] = byte(m.QoS)
 	}
-	return
-}
+	subscribeToken := client.SubscribeMultiple(topics, func(_ mqtt.Client, msg mqtt.Message) {
+		s.Received()
+		m.recvMessage(msg)
+	})
+	subscribeToken.Wait()
+	if subscribeToken.Error() != nil {
+		return fmt.Errorf("MQTT Subscribe Error\ntopics: %s\nerror: %s",
+			strings.Join(m.Topics[:], ","), subscribeToken.Error())
+	}
+	s.Subscribed()
 
-func (m *MQTTConsumer) onConnectionLost(c mqtt.Client, err error) {
-	m.acc.AddError(fmt.Errorf("E! MQTT Connection lost\nerror: %s\nMQTT Client will try to reconnect", err.Error()))
-	return
+	select {
+	case <-ctx.Done():
+		return nil
+	case err := <-lost:
+		return fmt.Errorf("MQTT Connection lost\nerror: %s", err.Error())
+	}
 }
 
 // receiver() reads all incoming messages from the consumer, and parses them into
@@ -195,26 +211,24 @@ func (m *MQTTConsumer) receiver() {
 	}
 }
 
-func (m *MQTTConsumer) recvMessage(_ mqtt.Client, msg mqtt.Message) {
-	m.in <- msg
+func (m *MQTTConsumer) recvMessage(msg mqtt.Message) {
+	select {
+	case m.in <- msg:
+	case <-m.done:
+	}
 }
 
 func (m *MQTTConsumer) Stop() {
 	m.Lock()
 	defer m.Unlock()
 
-	if m.connected {
+	m.subscriptions.Stop()
+	if m.done != nil {
 		close(m.done)
-		m.client.Disconnect(200)
-		m.connected = false
 	}
 }
 
 func (m *MQTTConsumer) Gather(acc telegraf.Accumulator) error {
-	if !m.connected {
-		m.connect()
-	}
-
 	return nil
 }
 
@@ -264,11 +278,9 @@ func (m *MQTTConsumer) createOpts() (*mqtt.ClientOptions, error) {
 
 		opts.AddBroker(server)
 	}
-	opts.SetAutoReconnect(true)
+	opts.SetAutoReconnect(false)
 	opts.SetKeepAlive(time.Second * 60)
 	opts.SetCleanSession(!m.PersistentSession)
-	opts.SetOnConnectHandler(m.onConnect)
-	opts.SetConnectionLostHandler(m.onConnectionLost)
 
 	return opts, nil
 }
@@ -22,11 +22,10 @@ const (
 func newTestMQTTConsumer() (*MQTTConsumer, chan mqtt.Message) {
 	in := make(chan mqtt.Message, 100)
 	n := &MQTTConsumer{
-		Topics:    []string{"telegraf"},
-		Servers:   []string{"localhost:1883"},
-		in:        in,
-		done:      make(chan struct{}),
-		connected: true,
+		Topics:  []string{"telegraf"},
+		Servers: []string{"localhost:1883"},
+		in:      in,
+		done:    make(chan struct{}),
 	}
 
 	return n, in
@@ -13,6 +13,7 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/proxy"
 	"github.com/influxdata/telegraf/plugins/inputs"
 
 	gnatsd "github.com/nats-io/gnatsd/server"
@@ -21,6 +22,7 @@ import (
 type Nats struct {
 	Server          string
 	ResponseTimeout internal.Duration
+	proxy.Config
 
 	client *http.Client
 }
@@ -31,6 +33,12 @@ var sampleConfig = `
 
   ## Maximum time to receive response
   # response_timeout = "5s"
+
+  ## Optional proxy, supports http, https and socks5 URLs (telegraf uses the
+  ## agent or system wide proxy settings if it is not set)
+  # http_proxy = "http://localhost:8888"
+  ## Hosts, domains and networks reached without the proxy
+  # no_proxy = ["localhost", ".example.com", "10.0.0.0/8"]
 `
 
 func (n *Nats) SampleConfig() string {
@@ -49,7 +57,11 @@ func (n *Nats) Gather(acc telegraf.Accumulator) error {
 	url.Path = path.Join(url.Path, "varz")
 
 	if n.client == nil {
-		n.client = n.createHTTPClient()
+		client, err := n.createHTTPClient()
+		if err != nil {
+			return err
+		}
+		n.client = client
 	}
 	resp, err := n.client.Get(url.String())
 	if err != nil {
@@ -91,9 +103,13 @@ func (n *Nats) Gather(acc telegraf.Accumulator) error {
 	return nil
 }
 
-func (n *Nats) createHTTPClient() *http.Client {
+func (n *Nats) createHTTPClient() (*http.Client, error) {
+	proxyFunc, err := n.Config.Proxy()
+	if err != nil {
+		return nil, err
+	}
 	transport := &http.Transport{
-		Proxy: http.ProxyFromEnvironment,
+		Proxy: proxyFunc,
 	}
 	timeout := n.ResponseTimeout.Duration
 	if timeout == time.Duration(0) {
@@ -102,7 +118,7 @@ func (n *Nats) createHTTPClient() *http.Client {
 	return &http.Client{
 		Transport: transport,
 		Timeout:   timeout,
-	}
+	}, nil
 }
 
 func init() {
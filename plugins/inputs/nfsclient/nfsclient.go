@@ -0,0 +1,228 @@
+package nfsclient
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/filter"
+	"github.com/influxdata/telegraf/plugins/inputs"
+)
+
+// default host proc path
+const defaultHostProc = "/proc"
+
+// env host proc variable name
+const envProc = "HOST_PROC"
+
+// fields of the bytes line of the mounts
+var bytesFields = []string{
+	"read_bytes",
+	"write_bytes",
+	"direct_read_bytes",
+	"direct_write_bytes",
+	"server_read_bytes",
+	"server_write_bytes",
+	"read_pages",
+	"write_pages",
+}
+
+// fields of the lines of the operations, the errors are reported by the
+// kernels since 4.14 only
+var opFields = []string{
+	"ops",
+	"trans",
+	"timeouts",
+	"bytes_sent",
+	"bytes_recv",
+	"queue_time",
+	"rtt",
+	"execute_time",
+	"errors",
+}
+
+type NFSClient struct {
+	HostProc          string   `toml:"host_proc"`
+	MountPoints       []string `toml:"mount_points"`
+	IncludeOperations []string `toml:"include_operations"`
+
+	mountFilter filter.Filter
+	opFilter    filter.Filter
+}
+
+var sampleConfig = `
+  ## Sets 'proc' directory path
+  ## If not specified, then default is /proc
+  # host_proc = "/proc"
+
+  ## By default, telegraf gathers stats for all NFS mounts.  Setting mount
+  ## points will restrict the stats to the specified mount points, globs
+  ## are supported.
+  # mount_points = ["/mnt/gluster*"]
+
+  ## By default, telegraf gathers the stats of all the operations used at
+  ## least once.  Setting operations will restrict the stats to them.
+  # include_operations = ["READ", "WRITE", "GETATTR", "LOOKUP", "ACCESS"]
+`
+
+func (n *NFSClient) Description() string {
+	return "Read per-mount NFS client statistics from /proc/self/mountstats"
+}
+
+func (n *NFSClient) SampleConfig() string {
+	return sampleConfig
+}
+
+func (n *NFSClient) Gather(acc telegraf.Accumulator) error {
+	if n.HostProc == "" {
+		n.HostProc = defaultHostProc
+		if p := os.Getenv(envProc); p != "" {
+			n.HostProc = p
+		}
+	}
+
+	if n.mountFilter == nil {
+		var err error
+		if n.mountFilter, err = filter.Compile(n.MountPoints); err != nil {
+			return err
+		}
+		if n.opFilter, err = filter.Compile(n.IncludeOperations); err != nil {
+			return err
+		}
+	}
+
+	file, err := os.Open(n.HostProc + "/self/mountstats")
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return n.gatherMountstats(file, acc)
+}
+
+// mount is an NFS mount of mountstats
+type mount struct {
+	tags   map[string]string
+	fields map[string]interface{}
+	ops    map[string]map[string]interface{}
+}
+
+func (n *NFSClient) gatherMountstats(r io.Reader, acc telegraf.Accumulator) error {
+	var current *mount
+	var perOp bool
+
+	flush := func() {
+		if current == nil {
+			return
+		}
+		if len(current.fields) > 0 {
+			acc.AddFields("nfsclient", current.fields, current.tags)
+		}
+		for op, fields := range current.ops {
+			tags := map[string]string{"operation": op}
+			for k, v := range current.tags {
+				tags[k] = v
+			}
+			acc.AddFields("nfsclient_ops", fields, tags)
+		}
+		current = nil
+	}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		cols := strings.Fields(line)
+		if len(cols) == 0 {
+			continue
+		}
+
+		// device server:/export mounted on /mnt with fstype nfs4 statvers=1.1
+		if cols[0] == "device" {
+			flush()
+			perOp = false
+			if len(cols) < 8 || cols[2] != "mounted" || cols[5] != "with" {
+				continue
+			}
+			if !strings.HasPrefix(cols[7], "nfs") {
+				continue
+			}
+			if n.mountFilter != nil && !n.mountFilter.Match(cols[4]) {
+				continue
+			}
+			current = &mount{
+				tags: map[string]string{
+					"server":     cols[1],
+					"mountpoint": cols[4],
+				},
+				fields: make(map[string]interface{}),
+				ops:    make(map[string]map[string]interface{}),
+			}
+			continue
+		}
+		if current == nil {
+			continue
+		}
+
+		switch {
+		case cols[0] == "opts:" && len(cols) == 2:
+			for _, opt := range strings.Split(cols[1], ",") {
+				if strings.HasPrefix(opt, "vers=") {
+					current.tags["version"] = strings.TrimPrefix(opt, "vers=")
+				}
+			}
+		case cols[0] == "bytes:":
+			if err := parseCounters(current.fields, bytesFields, cols[1:]); err != nil {
+				return fmt.Errorf("invalid bytes of %s: %s", current.tags["mountpoint"], err)
+			}
+		case cols[0] == "per-op":
+			perOp = true
+		case perOp && strings.HasSuffix(cols[0], ":") && len(cols) > 8:
+			op := strings.TrimSuffix(cols[0], ":")
+			if n.opFilter != nil && !n.opFilter.Match(op) {
+				continue
+			}
+			fields := make(map[string]interface{})
+			if err := parseCounters(fields, opFields, cols[1:]); err != nil {
+				return fmt.Errorf("invalid %s stats of %s: %s", op, current.tags["mountpoint"], err)
+			}
+			// skip the operations never used, unless they are listed
+			if fields["ops"] == uint64(0) && n.opFilter == nil {
+				continue
+			}
+			if ops, trans := fields["ops"].(uint64), fields["trans"].(uint64); trans > ops {
+				fields["retrans"] = trans - ops
+			} else {
+				fields["retrans"] = uint64(0)
+			}
+			current.ops[op] = fields
+		}
+	}
+	flush()
+	return scanner.Err()
+}
+
+// parseCounters parses the values of the names, the trailing names may be
+// missing from older kernels
+func parseCounters(fields map[string]interface{}, names []string, values []string) error {
+	if len(values) > len(names) {
+		values = values[:len(names)]
+	}
+	for i, value := range values {
+		v, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return err
+		}
+		fields[names[i]] = v
+	}
+	return nil
+}
+
+func init() {
+	inputs.Add("nfsclient", func() telegraf.Input {
+		return &NFSClient{}
+	})
+}
@@ -0,0 +1,153 @@
+package nfsclient
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+const mountstatsContents = `device rootfs mounted on / with fstype rootfs
+device proc mounted on /proc with fstype proc
+device gluster01:/bricks mounted on /mnt/gluster with fstype nfs4 statvers=1.1
+	opts:	rw,vers=4.1,rsize=1048576,wsize=1048576,namlen=255,acregmin=3,acregmax=60,acdirmin=30,acdirmax=60,hard,proto=tcp,timeo=600,retrans=2,sec=sys,clientaddr=10.0.0.5,local_lock=none
+	age:	90210
+	impl_id:	name='',domain='',date='0,0'
+	caps:	caps=0x3ffdf,wtmult=512,dtsize=32768,bsize=0,namlen=255
+	nfsv4:	bm0=0xfdffbfff,bm1=0x40f9be3e,bm2=0x803,acl=0x3,sessions,pnfs=not configured
+	sec:	flavor=1,pseudoflavor=1
+	events:	52472 424435 2 1134 10120 9856 483283 6520 3 202 6342 3654 0 12 4 0 6342 0 0 0 0 0 0 0 0 0 0
+	bytes:	1048576000 524288000 0 0 1048575000 524288000 256001 128000
+	RPC iostats version: 1.0  p/v: 100003/4 (nfs)
+	xprt:	tcp 0 1 2 0 0 42103 42103 0 42106 0 2 0 0
+	per-op statistics
+	        NULL: 1 1 0 44 24 0 0 0 0
+	        READ: 1000 1002 2 160000 1048700000 15 3200 3300 0
+	       WRITE: 500 500 0 524352000 80000 8 2900 2950 1
+	      COMMIT: 0 0 0 0 0 0 0 0 0
+	     GETATTR: 30000 30000 0 4800000 7200000 20 9000 9500 0
+
+device 10.0.0.10:/export mounted on /srv/export with fstype nfs statvers=1.1
+	opts:	rw,vers=3,rsize=131072,wsize=131072,namlen=255,acregmin=3,acregmax=60,acdirmin=30,acdirmax=60,hard,proto=tcp,timeo=600,retrans=2,sec=sys,mountaddr=10.0.0.10,mountvers=3,mountport=20048,mountproto=udp,local_lock=none
+	age:	1234
+	bytes:	4096 0 0 0 4096 0 1 0
+	RPC iostats version: 1.0  p/v: 100003/3 (nfs)
+	xprt:	tcp 0 1 1 0 0 12 12 0 12 0 2 0 0
+	per-op statistics
+	        NULL: 0 0 0 0 0 0 0 0
+	     GETATTR: 10 10 0 1040 1120 0 7 8
+	        READ: 1 1 0 116 4228 0 1 1
+`
+
+func TestGatherMountstats(t *testing.T) {
+	var acc testutil.Accumulator
+	n := &NFSClient{}
+	require.NoError(t, n.gatherMountstats(strings.NewReader(mountstatsContents), &acc))
+
+	tags := map[string]string{
+		"server":     "gluster01:/bricks",
+		"mountpoint": "/mnt/gluster",
+		"version":    "4.1",
+	}
+	acc.AssertContainsTaggedFields(t, "nfsclient",
+		map[string]interface{}{
+			"read_bytes":         uint64(1048576000),
+			"write_bytes":        uint64(524288000),
+			"direct_read_bytes":  uint64(0),
+			"direct_write_bytes": uint64(0),
+			"server_read_bytes":  uint64(1048575000),
+			"server_write_bytes": uint64(524288000),
+			"read_pages":         uint64(256001),
+			"write_pages":        uint64(128000),
+		}, tags)
+
+	tags["operation"] = "READ"
+	acc.AssertContainsTaggedFields(t, "nfsclient_ops",
+		map[string]interface{}{
+			"ops":          uint64(1000),
+			"trans":        uint64(1002),
+			"retrans":      uint64(2),
+			"timeouts":     uint64(2),
+			"bytes_sent":   uint64(160000),
+			"bytes_recv":   uint64(1048700000),
+			"queue_time":   uint64(15),
+			"rtt":          uint64(3200),
+			"execute_time": uint64(3300),
+			"errors":       uint64(0),
+		}, tags)
+
+	// without the errors of the older kernels
+	acc.AssertContainsTaggedFields(t, "nfsclient_ops",
+		map[string]interface{}{
+			"ops":          uint64(10),
+			"trans":        uint64(10),
+			"retrans":      uint64(0),
+			"timeouts":     uint64(0),
+			"bytes_sent":   uint64(1040),
+			"bytes_recv":   uint64(1120),
+			"queue_time":   uint64(0),
+			"rtt":          uint64(7),
+			"execute_time": uint64(8),
+		},
+		map[string]string{
+			"server":     "10.0.0.10:/export",
+			"mountpoint": "/srv/export",
+			"version":    "3",
+			"operation":  "GETATTR",
+		})
+
+	// the bytes and the operations used at least once of the two mounts
+	require.Equal(t, 8, len(acc.Metrics))
+	require.False(t, acc.HasTag("nfsclient", "operation"))
+	for _, m := range acc.Metrics {
+		require.NotEqual(t, "COMMIT", m.Tags["operation"])
+	}
+}
+
+func TestGatherFilters(t *testing.T) {
+	dir, err := ioutil.TempDir("", "nfsclient")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	err = os.MkdirAll(filepath.Join(dir, "self"), 0755)
+	require.NoError(t, err)
+	err = ioutil.WriteFile(filepath.Join(dir, "self", "mountstats"), []byte(mountstatsContents), 0644)
+	require.NoError(t, err)
+
+	var acc testutil.Accumulator
+	n := &NFSClient{
+		HostProc:          dir,
+		MountPoints:       []string{"/mnt/*"},
+		IncludeOperations: []string{"READ", "COMMIT"},
+	}
+	require.NoError(t, acc.GatherError(n.Gather))
+
+	// the bytes of the mount, and both operations even when unused
+	require.Equal(t, 3, len(acc.Metrics))
+	for _, m := range acc.Metrics {
+		require.Equal(t, "/mnt/gluster", m.Tags["mountpoint"])
+	}
+	acc.AssertContainsTaggedFields(t, "nfsclient_ops",
+		map[string]interface{}{
+			"ops":          uint64(0),
+			"trans":        uint64(0),
+			"retrans":      uint64(0),
+			"timeouts":     uint64(0),
+			"bytes_sent":   uint64(0),
+			"bytes_recv":   uint64(0),
+			"queue_time":   uint64(0),
+			"rtt":          uint64(0),
+			"execute_time": uint64(0),
+			"errors":       uint64(0),
+		},
+		map[string]string{
+			"server":     "gluster01:/bricks",
+			"mountpoint": "/mnt/gluster",
+			"version":    "4.1",
+			"operation":  "COMMIT",
+		})
+}
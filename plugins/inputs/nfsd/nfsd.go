@@ -0,0 +1,228 @@
+package nfsd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/inputs"
+)
+
+// default host proc path
+const defaultHostProc = "/proc"
+
+// env host proc variable name
+const envProc = "HOST_PROC"
+
+// fields of the lines of the server counters
+var counterFields = map[string][]string{
+	"rc":  {"reply_cache_hits", "reply_cache_misses", "reply_cache_nocache"},
+	"fh":  {"stale_filehandles"},
+	"io":  {"read_bytes", "write_bytes"},
+	"th":  {"threads", "threads_all_busy"},
+	"net": {"packets", "udp_packets", "tcp_packets", "tcp_connections"},
+	"rpc": {"rpc_calls", "rpc_bad_calls", "rpc_bad_format", "rpc_bad_auth", "rpc_bad_client"},
+}
+
+// names of the operations of the proc lines, by their numbers
+var opNames = map[string][]string{
+	"proc2": {
+		"null", "getattr", "setattr", "root", "lookup", "readlink", "read",
+		"writecache", "write", "create", "remove", "rename", "link",
+		"symlink", "mkdir", "rmdir", "readdir", "fsstat",
+	},
+	"proc3": {
+		"null", "getattr", "setattr", "lookup", "access", "readlink", "read",
+		"write", "create", "mkdir", "symlink", "mknod", "remove", "rmdir",
+		"rename", "link", "readdir", "readdirplus", "fsstat", "fsinfo",
+		"pathconf", "commit",
+	},
+	"proc4": {"null", "compound"},
+	// the operations 0 to 2 are not defined
+	"proc4ops": {
+		"", "", "", "access", "close", "commit", "create", "delegpurge",
+		"delegreturn", "getattr", "getfh", "link", "lock", "lockt", "locku",
+		"lookup", "lookupp", "nverify", "open", "openattr", "open_confirm",
+		"open_downgrade", "putfh", "putpubfh", "putrootfh", "read", "readdir",
+		"readlink", "remove", "rename", "renew", "restorefh", "savefh",
+		"secinfo", "setattr", "setclientid", "setclientid_confirm", "verify",
+		"write", "release_lockowner", "backchannel_ctl",
+		"bind_conn_to_session", "exchange_id", "create_session",
+		"destroy_session", "free_stateid", "get_dir_delegation",
+		"getdeviceinfo", "getdevicelist", "layoutcommit", "layoutget",
+		"layoutreturn", "secinfo_no_name", "sequence", "set_ssv",
+		"test_stateid", "want_delegation", "destroy_clientid",
+		"reclaim_complete", "allocate", "copy", "copy_notify", "deallocate",
+		"io_advise", "layouterror", "layoutstats", "offload_cancel",
+		"offload_status", "read_plus", "seek", "write_same", "clone",
+		"getxattr", "setxattr", "listxattrs", "removexattr",
+	},
+}
+
+// versions of the proc lines, the operations of the compounds of NFSv4 are
+// reported with the version 4ops
+var opVersions = map[string]string{
+	"proc2":    "2",
+	"proc3":    "3",
+	"proc4":    "4",
+	"proc4ops": "4ops",
+}
+
+type Nfsd struct {
+	HostProc string `toml:"host_proc"`
+}
+
+var sampleConfig = `
+  ## Sets 'proc' directory path
+  ## If not specified, then default is /proc
+  # host_proc = "/proc"
+`
+
+func (n *Nfsd) Description() string {
+	return "Read NFS server statistics from /proc/net/rpc/nfsd"
+}
+
+func (n *Nfsd) SampleConfig() string {
+	return sampleConfig
+}
+
+func (n *Nfsd) Gather(acc telegraf.Accumulator) error {
+	if n.HostProc == "" {
+		n.HostProc = defaultHostProc
+		if p := os.Getenv(envProc); p != "" {
+			n.HostProc = p
+		}
+	}
+
+	file, err := os.Open(n.HostProc + "/net/rpc/nfsd")
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if err := gatherServerStats(file, acc); err != nil {
+		return err
+	}
+
+	// the pools are only reported when the nfsd filesystem is mounted
+	pools, err := os.Open(n.HostProc + "/fs/nfsd/pool_stats")
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer pools.Close()
+
+	return gatherPoolStats(pools, acc)
+}
+
+// gatherServerStats adds the counters and the operations of the server of
+// /proc/net/rpc/nfsd.
+func gatherServerStats(r io.Reader, acc telegraf.Accumulator) error {
+	fields := make(map[string]interface{})
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		cols := strings.Fields(scanner.Text())
+		if len(cols) < 2 {
+			continue
+		}
+
+		if names, ok := counterFields[cols[0]]; ok {
+			values := cols[1:]
+			if len(values) > len(names) {
+				values = values[:len(names)]
+			}
+			for i, value := range values {
+				v, err := strconv.ParseUint(value, 10, 64)
+				if err != nil {
+					return fmt.Errorf("invalid %s stats: %s", cols[0], err)
+				}
+				fields[names[i]] = v
+			}
+			continue
+		}
+
+		names, ok := opNames[cols[0]]
+		if !ok {
+			continue
+		}
+		// the first value is the count of the operations of the line
+		ops := make(map[string]interface{})
+		var total uint64
+		for i, value := range cols[2:] {
+			if i >= len(names) || names[i] == "" {
+				continue
+			}
+			v, err := strconv.ParseUint(value, 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid %s stats: %s", cols[0], err)
+			}
+			ops[names[i]] = v
+			total += v
+		}
+		// skip the versions never used, such as the disabled NFSv2
+		if total == 0 {
+			continue
+		}
+		acc.AddFields("nfsd_ops", ops, map[string]string{"version": opVersions[cols[0]]})
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	if len(fields) > 0 {
+		acc.AddFields("nfsd", fields, nil)
+	}
+	return nil
+}
+
+// gatherPoolStats adds the stats of the thread pools of
+// /proc/fs/nfsd/pool_stats, whose columns are named by the header:
+//
+//	# pool packets-arrived sockets-enqueued threads-woken threads-timedout
+//	0 4283925 126 4283799 0
+func gatherPoolStats(r io.Reader, acc telegraf.Accumulator) error {
+	var columns []string
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		cols := strings.Fields(scanner.Text())
+		if len(cols) == 0 {
+			continue
+		}
+		if cols[0] == "#" {
+			columns = cols[1:]
+			continue
+		}
+		if len(columns) == 0 || len(cols) != len(columns) {
+			continue
+		}
+
+		tags := make(map[string]string)
+		fields := make(map[string]interface{})
+		for i, column := range columns {
+			if column == "pool" {
+				tags["pool"] = cols[i]
+				continue
+			}
+			v, err := strconv.ParseUint(cols[i], 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid %s of pool %s: %s", column, cols[0], err)
+			}
+			fields[strings.Replace(column, "-", "_", -1)] = v
+		}
+		acc.AddFields("nfsd_pool", fields, tags)
+	}
+	return scanner.Err()
+}
+
+func init() {
+	inputs.Add("nfsd", func() telegraf.Input {
+		return &Nfsd{}
+	})
+}
@@ -0,0 +1,123 @@
+package nfsd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+const nfsdContents = `rc 0 1425 237261
+fh 3 0 0 0 0
+io 1048576000 524288000
+th 16 42 0.000 0.000 0.000 0.000 0.000 0.000 0.000 0.000 0.000 0.000
+ra 32 0 0 0 0 0 0 0 0 0 0 0
+net 238686 0 238686 129
+rpc 238686 2 1 1 0
+proc2 18 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0
+proc3 22 2 12750 5 3021 1740 0 10240 5120 12 3 0 0 7 2 1 0 5 418 1 2 0 5102
+proc4 2 3 170025
+proc4ops 72 0 0 0 8100 4050 1200 0 0 3 40000 5000 0 0 0 0 6210 0 0 4049 0 0 0 170020 0 1 9800 0 0 0 0 0 0 0 0 1200 0 0 0 4900 0 0 0 2 2 2 0 0 0 0 0 0 0 0 170025 0 0 0 0 2 0 0 0 0 0 0 0 0 0 0 0 0 0
+`
+
+const poolStatsContents = `# pool packets-arrived sockets-enqueued threads-woken threads-timedout
+0 4283925 126 4283799 0
+1 1023 5 1018 0
+`
+
+func TestGatherServerStats(t *testing.T) {
+	var acc testutil.Accumulator
+	require.NoError(t, gatherServerStats(strings.NewReader(nfsdContents), &acc))
+
+	acc.AssertContainsFields(t, "nfsd",
+		map[string]interface{}{
+			"reply_cache_hits":    uint64(0),
+			"reply_cache_misses":  uint64(1425),
+			"reply_cache_nocache": uint64(237261),
+			"stale_filehandles":   uint64(3),
+			"read_bytes":          uint64(1048576000),
+			"write_bytes":         uint64(524288000),
+			"threads":             uint64(16),
+			"threads_all_busy":    uint64(42),
+			"packets":             uint64(238686),
+			"udp_packets":         uint64(0),
+			"tcp_packets":         uint64(238686),
+			"tcp_connections":     uint64(129),
+			"rpc_calls":           uint64(238686),
+			"rpc_bad_calls":       uint64(2),
+			"rpc_bad_format":      uint64(1),
+			"rpc_bad_auth":        uint64(1),
+			"rpc_bad_client":      uint64(0),
+		})
+
+	acc.AssertContainsTaggedFields(t, "nfsd_ops",
+		map[string]interface{}{
+			"null":     uint64(3),
+			"compound": uint64(170025),
+		},
+		map[string]string{"version": "4"})
+
+	require.True(t, acc.HasPoint("nfsd_ops", map[string]string{"version": "3"}, "commit", uint64(5102)))
+	require.True(t, acc.HasPoint("nfsd_ops", map[string]string{"version": "3"}, "getattr", uint64(12750)))
+	require.True(t, acc.HasPoint("nfsd_ops", map[string]string{"version": "4ops"}, "access", uint64(8100)))
+	require.True(t, acc.HasPoint("nfsd_ops", map[string]string{"version": "4ops"}, "putfh", uint64(170020)))
+	require.True(t, acc.HasPoint("nfsd_ops", map[string]string{"version": "4ops"}, "sequence", uint64(170025)))
+
+	// the unused NFSv2 is skipped
+	require.Equal(t, 4, len(acc.Metrics))
+	for _, m := range acc.Metrics {
+		require.NotEqual(t, "2", m.Tags["version"])
+	}
+}
+
+func TestGather(t *testing.T) {
+	dir, err := ioutil.TempDir("", "nfsd")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "net", "rpc"), 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "net", "rpc", "nfsd"), []byte(nfsdContents), 0644))
+
+	// without the nfsd filesystem
+	var acc testutil.Accumulator
+	n := &Nfsd{HostProc: dir}
+	require.NoError(t, acc.GatherError(n.Gather))
+	require.False(t, acc.HasMeasurement("nfsd_pool"))
+
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "fs", "nfsd"), 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "fs", "nfsd", "pool_stats"), []byte(poolStatsContents), 0644))
+
+	acc = testutil.Accumulator{}
+	require.NoError(t, acc.GatherError(n.Gather))
+	require.True(t, acc.HasMeasurement("nfsd"))
+	acc.AssertContainsTaggedFields(t, "nfsd_pool",
+		map[string]interface{}{
+			"packets_arrived":  uint64(4283925),
+			"sockets_enqueued": uint64(126),
+			"threads_woken":    uint64(4283799),
+			"threads_timedout": uint64(0),
+		},
+		map[string]string{"pool": "0"})
+	acc.AssertContainsTaggedFields(t, "nfsd_pool",
+		map[string]interface{}{
+			"packets_arrived":  uint64(1023),
+			"sockets_enqueued": uint64(5),
+			"threads_woken":    uint64(1018),
+			"threads_timedout": uint64(0),
+		},
+		map[string]string{"pool": "1"})
+}
+
+func TestGatherNotLoaded(t *testing.T) {
+	dir, err := ioutil.TempDir("", "nfsd")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	var acc testutil.Accumulator
+	n := &Nfsd{HostProc: dir}
+	require.Error(t, acc.GatherError(n.Gather))
+}
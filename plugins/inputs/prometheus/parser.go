@@ -11,6 +11,7 @@ import (
 	"math"
 	"mime"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/influxdata/telegraf"
@@ -21,9 +22,27 @@ import (
 	"github.com/prometheus/common/expfmt"
 )
 
+// Tags holding the HELP and TYPE metadata of the metric family when
+// metadata is kept, outputs such as prometheus_client use them to restore
+// the exposition metadata.
+const (
+	helpTag = "prometheus_help"
+	typeTag = "prometheus_type"
+)
+
 // Parse returns a slice of Metrics from a text representation of a
 // metrics
 func Parse(buf []byte, header http.Header) ([]telegraf.Metric, error) {
+	return parse(buf, header, false)
+}
+
+// ParseWithMetadata is like Parse but adds the HELP and TYPE of the metric
+// family of each metric as tags.
+func ParseWithMetadata(buf []byte, header http.Header) ([]telegraf.Metric, error) {
+	return parse(buf, header, true)
+}
+
+func parse(buf []byte, header http.Header, metadata bool) ([]telegraf.Metric, error) {
 	var metrics []telegraf.Metric
 	var parser expfmt.TextParser
 	// parse even if the buffer begins with a newline
@@ -61,6 +80,12 @@ func Parse(buf []byte, header http.Header) ([]telegraf.Metric, error) {
 		for _, m := range mf.Metric {
 			// reading tags
 			tags := makeLabels(m)
+			if metadata {
+				if mf.GetHelp() != "" {
+					tags[helpTag] = mf.GetHelp()
+				}
+				tags[typeTag] = strings.ToLower(mf.GetType().String())
+			}
 			// reading fields
 			fields := make(map[string]interface{})
 			if mf.GetType() == dto.MetricType_SUMMARY {
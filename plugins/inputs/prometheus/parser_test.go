@@ -165,3 +165,22 @@ func TestParseValidPrometheus(t *testing.T) {
 		metrics[0].Tags())
 
 }
+
+func TestParseWithMetadata(t *testing.T) {
+	metrics, err := ParseWithMetadata([]byte(validUniqueCounter), http.Header{})
+	assert.NoError(t, err)
+	assert.Len(t, metrics, 1)
+	assert.Equal(t, map[string]string{
+		"prometheus_help": "Counter of failed Token() requests to the alternate token source",
+		"prometheus_type": "counter",
+	}, metrics[0].Tags())
+
+	metrics, err = ParseWithMetadata([]byte(validUniqueSummary), http.Header{})
+	assert.NoError(t, err)
+	assert.Len(t, metrics, 1)
+	assert.Equal(t, map[string]string{
+		"handler":         "prometheus",
+		"prometheus_help": "The HTTP request latencies in microseconds.",
+		"prometheus_type": "summary",
+	}, metrics[0].Tags())
+}
@@ -31,6 +31,9 @@ type Prometheus struct {
 
 	ResponseTimeout internal.Duration `toml:"response_timeout"`
 
+	// Keep the HELP and TYPE metadata as tags
+	MetadataAsTags bool `toml:"metadata_as_tags"`
+
 	tls.ClientConfig
 
 	client *http.Client
@@ -49,6 +52,11 @@ var sampleConfig = `
   ## Specify timeout duration for slower prometheus clients (default is 3s)
   # response_timeout = "3s"
 
+  ## Keep the HELP and TYPE of each metric family as the prometheus_help and
+  ## prometheus_type tags.  The prometheus_client output uses them to restore
+  ## the metadata instead of exposing the tags as labels.
+  # metadata_as_tags = false
+
   ## Optional TLS Config
   # tls_ca = /path/to/cafile
   # tls_cert = /path/to/certfile
@@ -205,7 +213,11 @@ func (p *Prometheus) gatherURL(u URLAndAddress, acc telegraf.Accumulator) error
 		return fmt.Errorf("error reading body: %s", err)
 	}
 
-	metrics, err := Parse(body, resp.Header)
+	parse := Parse
+	if p.MetadataAsTags {
+		parse = ParseWithMetadata
+	}
+	metrics, err := parse(body, resp.Header)
 	if err != nil {
 		return fmt.Errorf("error reading metrics for %s: %s",
 			u.URL, err)
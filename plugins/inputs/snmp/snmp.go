@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"bytes"
 	"fmt"
+	"log"
 	"math"
 	"net"
 	"os/exec"
@@ -35,6 +36,10 @@ const sampleConfig = `
   ## The GETBULK max-repetitions parameter
   max_repetitions = 10
 
+  ## Maximum number of columns of a table walked in parallel per agent, each
+  ## walk uses its own session with the agent.
+  # max_parallel_walks = 1
+
   ## SNMPv3 auth parameters
   #sec_name = "myuser"
   #auth_protocol = "md5"      # Values: "MD5", "SHA", ""
@@ -113,6 +118,9 @@ type Snmp struct {
 	// Parameters for Version 2 & 3
 	MaxRepetitions uint8
 
+	// Maximum number of columns walked in parallel per agent
+	MaxParallelWalks int `toml:"max_parallel_walks"`
+
 	// Parameters for Version 3
 	ContextName string
 	// Values: "noAuthNoPriv", "authNoPriv", "authPriv"
@@ -137,7 +145,9 @@ type Snmp struct {
 	Fields []Field `toml:"field"`
 
 	connectionCache []snmpConnection
-	initialized     bool
+	// the sessions of the agents walking in parallel with their connection
+	sessionCache [][]snmpConnection
+	initialized  bool
 }
 
 func (s *Snmp) init() error {
@@ -146,6 +156,7 @@ func (s *Snmp) init() error {
 	}
 
 	s.connectionCache = make([]snmpConnection, len(s.Agents))
+	s.sessionCache = make([][]snmpConnection, len(s.Agents))
 
 	for i := range s.Tables {
 		if err := s.Tables[i].init(); err != nil {
@@ -364,13 +375,14 @@ func (s *Snmp) Gather(acc telegraf.Accumulator) error {
 				Fields: s.Fields,
 			}
 			topTags := map[string]string{}
-			if err := s.gatherTable(acc, gs, t, topTags, false); err != nil {
+			if err := s.gatherTable(acc, []snmpConnection{gs}, t, topTags, false); err != nil {
 				acc.AddError(Errorf(err, "agent %s", agent))
 			}
 
 			// Now is the real tables.
+			sessions := s.getSessions(i, gs)
 			for _, t := range s.Tables {
-				if err := s.gatherTable(acc, gs, t, topTags, true); err != nil {
+				if err := s.gatherTable(acc, sessions, t, topTags, true); err != nil {
 					acc.AddError(Errorf(err, "agent %s: gathering table %s", agent, t.Name))
 				}
 			}
@@ -381,8 +393,8 @@ func (s *Snmp) Gather(acc telegraf.Accumulator) error {
 	return nil
 }
 
-func (s *Snmp) gatherTable(acc telegraf.Accumulator, gs snmpConnection, t Table, topTags map[string]string, walk bool) error {
-	rt, err := t.Build(gs, walk)
+func (s *Snmp) gatherTable(acc telegraf.Accumulator, sessions []snmpConnection, t Table, topTags map[string]string, walk bool) error {
+	rt, err := t.build(sessions, walk)
 	if err != nil {
 		return err
 	}
@@ -402,7 +414,7 @@ func (s *Snmp) gatherTable(acc telegraf.Accumulator, gs snmpConnection, t Table,
 			}
 		}
 		if _, ok := tr.Tags["agent_host"]; !ok {
-			tr.Tags["agent_host"] = gs.Host()
+			tr.Tags["agent_host"] = sessions[0].Host()
 		}
 		acc.AddFields(rt.Name, tr.Fields, tr.Tags, rt.Time)
 	}
@@ -412,85 +424,56 @@ func (s *Snmp) gatherTable(acc telegraf.Accumulator, gs snmpConnection, t Table,
 
 // Build retrieves all the fields specified in the table and constructs the RTable.
 func (t Table) Build(gs snmpConnection, walk bool) (*RTable, error) {
+	return t.build([]snmpConnection{gs}, walk)
+}
+
+// build retrieves the fields of the table, walking them in parallel with the
+// sessions.  A session is used by a single walk at a time.
+func (t Table) build(sessions []snmpConnection, walk bool) (*RTable, error) {
 	rows := map[string]RTableRow{}
 
-	tagCount := 0
 	for _, f := range t.Fields {
-		if f.IsTag {
-			tagCount++
-		}
-
 		if len(f.Oid) == 0 {
 			return nil, fmt.Errorf("cannot have empty OID on field %s", f.Name)
 		}
-		var oid string
-		if f.Oid[0] == '.' {
-			oid = f.Oid
-		} else {
-			// make sure OID has "." because the BulkWalkAll results do, and the prefix needs to match
-			oid = "." + f.Oid
-		}
-
-		// ifv contains a mapping of table OID index to field value
-		ifv := map[string]interface{}{}
+	}
 
-		if !walk {
-			// This is used when fetching non-table fields. Fields configured a the top
-			// scope of the plugin.
-			// We fetch the fields directly, and add them to ifv as if the index were an
-			// empty string. This results in all the non-table fields sharing the same
-			// index, and being added on the same row.
-			if pkt, err := gs.Get([]string{oid}); err != nil {
-				return nil, Errorf(err, "performing get on field %s", f.Name)
-			} else if pkt != nil && len(pkt.Variables) > 0 && pkt.Variables[0].Type != gosnmp.NoSuchObject && pkt.Variables[0].Type != gosnmp.NoSuchInstance {
-				ent := pkt.Variables[0]
-				fv, err := fieldConvert(f.Conversion, ent.Value)
-				if err != nil {
-					return nil, Errorf(err, "converting %q (OID %s) for field %s", ent.Value, ent.Name, f.Name)
-				}
-				ifv[""] = fv
+	// ifvs contains the mappings of table OID index to field value of the fields
+	ifvs := make([]map[string]interface{}, len(t.Fields))
+	if !walk || len(sessions) == 1 {
+		for i, f := range t.Fields {
+			ifv, err := f.values(sessions[0], walk)
+			if err != nil {
+				return nil, err
 			}
-		} else {
-			err := gs.Walk(oid, func(ent gosnmp.SnmpPDU) error {
-				if len(ent.Name) <= len(oid) || ent.Name[:len(oid)+1] != oid+"." {
-					return NestedError{} // break the walk
-				}
-
-				idx := ent.Name[len(oid):]
-				if f.OidIndexSuffix != "" {
-					if !strings.HasSuffix(idx, f.OidIndexSuffix) {
-						// this entry doesn't match our OidIndexSuffix. skip it
-						return nil
-					}
-					idx = idx[:len(idx)-len(f.OidIndexSuffix)]
-				}
-				if f.OidIndexLength != 0 {
-					i := f.OidIndexLength + 1 // leading separator
-					idx = strings.Map(func(r rune) rune {
-						if r == '.' {
-							i -= 1
-						}
-						if i < 1 {
-							return -1
-						}
-						return r
-					}, idx)
-				}
-
-				fv, err := fieldConvert(f.Conversion, ent.Value)
-				if err != nil {
-					return Errorf(err, "converting %q (OID %s) for field %s", ent.Value, ent.Name, f.Name)
-				}
-				ifv[idx] = fv
-				return nil
-			})
+			ifvs[i] = ifv
+		}
+	} else {
+		pool := make(chan snmpConnection, len(sessions))
+		for _, gs := range sessions {
+			pool <- gs
+		}
+		errs := make([]error, len(t.Fields))
+		var wg sync.WaitGroup
+		for i, f := range t.Fields {
+			wg.Add(1)
+			go func(i int, f Field) {
+				defer wg.Done()
+				gs := <-pool
+				ifvs[i], errs[i] = f.values(gs, walk)
+				pool <- gs
+			}(i, f)
+		}
+		wg.Wait()
+		for _, err := range errs {
 			if err != nil {
-				if _, ok := err.(NestedError); !ok {
-					return nil, Errorf(err, "performing bulk walk for field %s", f.Name)
-				}
+				return nil, err
 			}
 		}
+	}
 
+	for i, f := range t.Fields {
+		ifv := ifvs[i]
 		for idx, v := range ifv {
 			rtr, ok := rows[idx]
 			if !ok {
@@ -531,6 +514,80 @@ func (t Table) Build(gs snmpConnection, walk bool) (*RTable, error) {
 	return &rt, nil
 }
 
+// values retrieves the field, walking it for table fields, and returns the
+// mapping of table OID index to field value.
+func (f Field) values(gs snmpConnection, walk bool) (map[string]interface{}, error) {
+	var oid string
+	if f.Oid[0] == '.' {
+		oid = f.Oid
+	} else {
+		// make sure OID has "." because the BulkWalkAll results do, and the prefix needs to match
+		oid = "." + f.Oid
+	}
+
+	// ifv contains a mapping of table OID index to field value
+	ifv := map[string]interface{}{}
+
+	if !walk {
+		// This is used when fetching non-table fields. Fields configured a the top
+		// scope of the plugin.
+		// We fetch the fields directly, and add them to ifv as if the index were an
+		// empty string. This results in all the non-table fields sharing the same
+		// index, and being added on the same row.
+		if pkt, err := gs.Get([]string{oid}); err != nil {
+			return nil, Errorf(err, "performing get on field %s", f.Name)
+		} else if pkt != nil && len(pkt.Variables) > 0 && pkt.Variables[0].Type != gosnmp.NoSuchObject && pkt.Variables[0].Type != gosnmp.NoSuchInstance {
+			ent := pkt.Variables[0]
+			fv, err := fieldConvert(f.Conversion, ent.Value)
+			if err != nil {
+				return nil, Errorf(err, "converting %q (OID %s) for field %s", ent.Value, ent.Name, f.Name)
+			}
+			ifv[""] = fv
+		}
+		return ifv, nil
+	}
+
+	err := gs.Walk(oid, func(ent gosnmp.SnmpPDU) error {
+		if len(ent.Name) <= len(oid) || ent.Name[:len(oid)+1] != oid+"." {
+			return NestedError{} // break the walk
+		}
+
+		idx := ent.Name[len(oid):]
+		if f.OidIndexSuffix != "" {
+			if !strings.HasSuffix(idx, f.OidIndexSuffix) {
+				// this entry doesn't match our OidIndexSuffix. skip it
+				return nil
+			}
+			idx = idx[:len(idx)-len(f.OidIndexSuffix)]
+		}
+		if f.OidIndexLength != 0 {
+			i := f.OidIndexLength + 1 // leading separator
+			idx = strings.Map(func(r rune) rune {
+				if r == '.' {
+					i -= 1
+				}
+				if i < 1 {
+					return -1
+				}
+				return r
+			}, idx)
+		}
+
+		fv, err := fieldConvert(f.Conversion, ent.Value)
+		if err != nil {
+			return Errorf(err, "converting %q (OID %s) for field %s", ent.Value, ent.Name, f.Name)
+		}
+		ifv[idx] = fv
+		return nil
+	})
+	if err != nil {
+		if _, ok := err.(NestedError); !ok {
+			return nil, Errorf(err, "performing bulk walk for field %s", f.Name)
+		}
+	}
+	return ifv, nil
+}
+
 // snmpConnection is an interface which wraps a *gosnmp.GoSNMP object.
 // We interact through an interface so we can mock it out in tests.
 type snmpConnection interface {
@@ -599,15 +656,42 @@ func (s *Snmp) getConnection(idx int) (snmpConnection, error) {
 		return gs, nil
 	}
 
-	agent := s.Agents[idx]
-
 	gs := gosnmpWrapper{&gosnmp.GoSNMP{}}
 	s.connectionCache[idx] = gs
+	if err := s.setupConnection(gs, s.Agents[idx]); err != nil {
+		return nil, err
+	}
+	return gs, nil
+}
+
+// getSessions returns the sessions of the agent of index idx walking the
+// tables, gs and up to max_parallel_walks - 1 sessions of its own, created
+// once and reused by the next gathers.
+func (s *Snmp) getSessions(idx int, gs snmpConnection) []snmpConnection {
+	sessions := []snmpConnection{gs}
+	if s.MaxParallelWalks <= 1 {
+		return sessions
+	}
+
+	for len(s.sessionCache[idx]) < s.MaxParallelWalks-1 {
+		session := gosnmpWrapper{&gosnmp.GoSNMP{}}
+		if err := s.setupConnection(session, s.Agents[idx]); err != nil {
+			// walk with the sessions set up, the next gather sets up the others
+			log.Printf("D! [inputs.snmp] Unable to set up a session with agent %s: %s", s.Agents[idx], err)
+			break
+		}
+		s.sessionCache[idx] = append(s.sessionCache[idx], session)
+	}
+	return append(sessions, s.sessionCache[idx]...)
+}
+
+// setupConnection configures the connection to the agent and connects it.
+func (s *Snmp) setupConnection(gs gosnmpWrapper, agent string) error {
 
 	host, portStr, err := net.SplitHostPort(agent)
 	if err != nil {
 		if err, ok := err.(*net.AddrError); !ok || err.Err != "missing port in address" {
-			return nil, Errorf(err, "parsing host")
+			return Errorf(err, "parsing host")
 		}
 		host = agent
 		portStr = "161"
@@ -616,7 +700,7 @@ func (s *Snmp) getConnection(idx int) (snmpConnection, error) {
 
 	port, err := strconv.ParseUint(portStr, 10, 16)
 	if err != nil {
-		return nil, Errorf(err, "parsing port")
+		return Errorf(err, "parsing port")
 	}
 	gs.Port = uint16(port)
 
@@ -632,7 +716,7 @@ func (s *Snmp) getConnection(idx int) (snmpConnection, error) {
 	case 1:
 		gs.Version = gosnmp.Version1
 	default:
-		return nil, fmt.Errorf("invalid version")
+		return fmt.Errorf("invalid version")
 	}
 
 	if s.Version < 3 {
@@ -660,7 +744,7 @@ func (s *Snmp) getConnection(idx int) (snmpConnection, error) {
 		case "authpriv":
 			gs.MsgFlags = gosnmp.AuthPriv
 		default:
-			return nil, fmt.Errorf("invalid secLevel")
+			return fmt.Errorf("invalid secLevel")
 		}
 
 		sp.UserName = s.SecName
@@ -673,7 +757,7 @@ func (s *Snmp) getConnection(idx int) (snmpConnection, error) {
 		case "":
 			sp.AuthenticationProtocol = gosnmp.NoAuth
 		default:
-			return nil, fmt.Errorf("invalid authProtocol")
+			return fmt.Errorf("invalid authProtocol")
 		}
 
 		sp.AuthenticationPassphrase = s.AuthPassword
@@ -686,7 +770,7 @@ func (s *Snmp) getConnection(idx int) (snmpConnection, error) {
 		case "":
 			sp.PrivacyProtocol = gosnmp.NoPriv
 		default:
-			return nil, fmt.Errorf("invalid privProtocol")
+			return fmt.Errorf("invalid privProtocol")
 		}
 
 		sp.PrivacyPassphrase = s.PrivPassword
@@ -699,10 +783,10 @@ func (s *Snmp) getConnection(idx int) (snmpConnection, error) {
 	}
 
 	if err := gs.Connect(); err != nil {
-		return nil, Errorf(err, "setting up connection")
+		return Errorf(err, "setting up connection")
 	}
 
-	return gs, nil
+	return nil
 }
 
 // fieldConvert converts from any type according to the conv specification
@@ -513,6 +513,65 @@ func TestTableBuild_walk(t *testing.T) {
 	assert.Contains(t, tb.Rows, rtr4)
 }
 
+// exclusiveSNMPConnection fails the walks using the connection concurrently.
+type exclusiveSNMPConnection struct {
+	*testSNMPConnection
+	mu    sync.Mutex
+	inUse bool
+	walks int
+}
+
+func (esc *exclusiveSNMPConnection) Walk(oid string, wf gosnmp.WalkFunc) error {
+	esc.mu.Lock()
+	if esc.inUse {
+		esc.mu.Unlock()
+		return fmt.Errorf("session used concurrently")
+	}
+	esc.inUse = true
+	esc.walks++
+	esc.mu.Unlock()
+
+	time.Sleep(10 * time.Millisecond)
+	err := esc.testSNMPConnection.Walk(oid, wf)
+
+	esc.mu.Lock()
+	esc.inUse = false
+	esc.mu.Unlock()
+	return err
+}
+
+func TestTableBuild_parallel(t *testing.T) {
+	tbl := Table{
+		Name:       "mytable",
+		IndexAsTag: true,
+		Fields: []Field{
+			{Name: "myfield1", Oid: ".1.0.0.0.1.1", IsTag: true},
+			{Name: "myfield2", Oid: ".1.0.0.0.1.2"},
+			{Name: "myfield3", Oid: ".1.0.0.0.1.3", Conversion: "float"},
+			{Name: "myfield4", Oid: ".1.0.0.0.1.4"},
+		},
+	}
+
+	sessions := []snmpConnection{
+		&exclusiveSNMPConnection{testSNMPConnection: tsc},
+		&exclusiveSNMPConnection{testSNMPConnection: tsc},
+	}
+	tb, err := tbl.build(sessions, true)
+	require.NoError(t, err)
+	expected, err := tbl.Build(tsc, true)
+	require.NoError(t, err)
+
+	assert.Len(t, tb.Rows, len(expected.Rows))
+	for _, row := range expected.Rows {
+		assert.Contains(t, tb.Rows, row)
+	}
+	walks := 0
+	for _, session := range sessions {
+		walks += session.(*exclusiveSNMPConnection).walks
+	}
+	assert.Equal(t, 4, walks)
+}
+
 func TestTableBuild_noWalk(t *testing.T) {
 	tbl := Table{
 		Name: "mytable",
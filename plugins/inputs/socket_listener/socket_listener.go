@@ -16,7 +16,9 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/activation"
 	tlsint "github.com/influxdata/telegraf/internal/tls"
+	"github.com/influxdata/telegraf/internal/vsock"
 	"github.com/influxdata/telegraf/plugins/inputs"
 	"github.com/influxdata/telegraf/plugins/parsers"
 )
@@ -191,6 +193,13 @@ func (sl *SocketListener) SampleConfig() string {
   # service_address = "udp6://:8094"
   # service_address = "unix:///tmp/telegraf.sock"
   # service_address = "unixgram:///tmp/telegraf.sock"
+  ## vsock address of the form "vsock://cid:port", an empty context ID
+  ## accepts connections from any virtual machine.
+  # service_address = "vsock://:8094"
+  ## Socket passed by systemd socket activation, optionally selected by the
+  ## FileDescriptorName of the socket unit.
+  # service_address = "systemd://"
+  # service_address = "systemd://metrics"
 
   ## Maximum number of concurrent connections.
   ## Only applies to stream sockets (e.g. TCP).
@@ -251,26 +260,39 @@ func (sl *SocketListener) Start(acc telegraf.Accumulator) error {
 		os.Remove(spl[1])
 	}
 
+	var (
+		err error
+		l   net.Listener
+		pc  net.PacketConn
+	)
 	switch spl[0] {
 	case "tcp", "tcp4", "tcp6", "unix", "unixpacket":
-		var (
-			err error
-			l   net.Listener
-		)
-
-		tlsCfg, err := sl.ServerConfig.TLSConfig()
-		if err != nil {
-			return nil
+		l, err = net.Listen(spl[0], spl[1])
+	case "vsock":
+		var addr *vsock.Addr
+		if addr, err = vsock.ParseAddr(spl[1]); err == nil {
+			l, err = vsock.Listen(addr)
 		}
+	case "udp", "udp4", "udp6", "ip", "ip4", "ip6", "unixgram":
+		pc, err = net.ListenPacket(spl[0], spl[1])
+	case "systemd":
+		l, pc, err = activatedSocket(spl[1])
+	default:
+		return fmt.Errorf("unknown protocol '%s' in '%s'", spl[0], sl.ServiceAddress)
+	}
+	if err != nil {
+		return err
+	}
 
-		if tlsCfg == nil {
-			l, err = net.Listen(spl[0], spl[1])
-		} else {
-			l, err = tls.Listen(spl[0], spl[1], tlsCfg)
-		}
+	if l != nil {
+		tlsCfg, err := sl.ServerConfig.TLSConfig()
 		if err != nil {
+			l.Close()
 			return err
 		}
+		if tlsCfg != nil {
+			l = tls.NewListener(l, tlsCfg)
+		}
 
 		ssl := &streamSocketListener{
 			Listener:       l,
@@ -280,12 +302,7 @@ func (sl *SocketListener) Start(acc telegraf.Accumulator) error {
 
 		sl.Closer = ssl
 		go ssl.listen()
-	case "udp", "udp4", "udp6", "ip", "ip4", "ip6", "unixgram":
-		pc, err := net.ListenPacket(spl[0], spl[1])
-		if err != nil {
-			return err
-		}
-
+	} else {
 		if sl.ReadBufferSize > 0 {
 			if srb, ok := pc.(setReadBufferer); ok {
 				srb.SetReadBuffer(sl.ReadBufferSize)
@@ -301,8 +318,6 @@ func (sl *SocketListener) Start(acc telegraf.Accumulator) error {
 
 		sl.Closer = psl
 		go psl.listen()
-	default:
-		return fmt.Errorf("unknown protocol '%s' in '%s'", spl[0], sl.ServiceAddress)
 	}
 
 	if spl[0] == "unix" || spl[0] == "unixpacket" || spl[0] == "unixgram" {
@@ -327,6 +342,24 @@ func newSocketListener() *SocketListener {
 	}
 }
 
+// activatedSocket returns the stream or datagram socket of the name passed by
+// systemd.  The socket is duplicated, so that it is left open for the next
+// Start when the listener is stopped.
+func activatedSocket(name string) (net.Listener, net.PacketConn, error) {
+	f, err := activation.File(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	if l, err := net.FileListener(f); err == nil {
+		return l, nil, nil
+	}
+	pc, err := net.FilePacketConn(f)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unsupported socket %s passed by systemd: %s", f.Name(), err)
+	}
+	return nil, pc, nil
+}
+
 type unixCloser struct {
 	path   string
 	closer io.Closer
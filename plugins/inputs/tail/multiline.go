@@ -0,0 +1,97 @@
+// +build !solaris
+
+package tail
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf/internal"
+)
+
+const defaultMultilineTimeout = 5 * time.Second
+
+// Multiline is the configuration of the messages spanning several lines.
+type Multiline struct {
+	// Lines matching StartPattern begin a new message, the other lines
+	// continue the current message
+	StartPattern string `toml:"start_pattern"`
+	// Lines matching ContinuationPattern continue the current message, the
+	// other lines begin a new message
+	ContinuationPattern string `toml:"continuation_pattern"`
+	// The current message is complete when no line is added for Timeout
+	Timeout internal.Duration `toml:"timeout"`
+}
+
+// multiline joins the lines of the messages.
+type multiline struct {
+	start        *regexp.Regexp
+	continuation *regexp.Regexp
+	timeout      time.Duration
+
+	lines []string
+}
+
+// newMultiline returns the joiner of the lines of the configuration, nil
+// when the messages are single lines.
+func (m *Multiline) newMultiline() (*multiline, error) {
+	if m.StartPattern == "" && m.ContinuationPattern == "" {
+		return nil, nil
+	}
+	if m.StartPattern != "" && m.ContinuationPattern != "" {
+		return nil, fmt.Errorf("multiline start_pattern and continuation_pattern are exclusive")
+	}
+
+	ml := &multiline{timeout: m.Timeout.Duration}
+	if ml.timeout <= 0 {
+		ml.timeout = defaultMultilineTimeout
+	}
+
+	var err error
+	if m.StartPattern != "" {
+		if ml.start, err = regexp.Compile(m.StartPattern); err != nil {
+			return nil, fmt.Errorf("invalid multiline start_pattern: %s", err)
+		}
+	} else {
+		if ml.continuation, err = regexp.Compile(m.ContinuationPattern); err != nil {
+			return nil, fmt.Errorf("invalid multiline continuation_pattern: %s", err)
+		}
+	}
+	return ml, nil
+}
+
+// add adds the line and returns the message it completes, if any.
+func (m *multiline) add(line string) (string, bool) {
+	var continues bool
+	if m.start != nil {
+		continues = !m.start.MatchString(line)
+	} else {
+		continues = m.continuation.MatchString(line)
+	}
+
+	// lines continuing no message are messages of their own
+	if continues && len(m.lines) > 0 {
+		m.lines = append(m.lines, line)
+		return "", false
+	}
+	message, ok := m.flush()
+	m.lines = append(m.lines, line)
+	return message, ok
+}
+
+// flush returns the current message, false if there is none.
+func (m *multiline) flush() (string, bool) {
+	if len(m.lines) == 0 {
+		return "", false
+	}
+	message := strings.Join(m.lines, "\n")
+	m.lines = m.lines[:0]
+	return message, true
+}
+
+// pending reports whether a message is being joined.
+func (m *multiline) pending() bool {
+	return len(m.lines) > 0
+}
@@ -0,0 +1,86 @@
+// +build !solaris
+
+package tail
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// offsetStore keeps the offsets of the tailed files in a JSON file, so that
+// tailing resumes from them after a restart.
+type offsetStore struct {
+	path string
+
+	mu      sync.Mutex
+	offsets map[string]int64
+	changed bool
+}
+
+// loadOffsets returns the offsets stored in the file of path, none if the
+// file does not exist yet.
+func loadOffsets(path string) (*offsetStore, error) {
+	s := &offsetStore{path: path, offsets: make(map[string]int64)}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &s.offsets); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *offsetStore) get(file string) (int64, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	offset, ok := s.offsets[file]
+	return offset, ok
+}
+
+func (s *offsetStore) set(file string, offset int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.offsets[file] != offset {
+		s.offsets[file] = offset
+		s.changed = true
+	}
+}
+
+// save writes the offsets if they changed, replacing the file so that it is
+// never left partially written.
+func (s *offsetStore) save() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.changed {
+		return nil
+	}
+
+	data, err := json.Marshal(s.offsets)
+	if err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(s.path), filepath.Base(s.path)+".tmp")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(data)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), s.path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	s.changed = false
+	return nil
+}
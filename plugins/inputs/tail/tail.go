@@ -4,8 +4,10 @@ package tail
 
 import (
 	"fmt"
+	"os"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/influxdata/tail"
 
@@ -24,8 +26,11 @@ type Tail struct {
 	FromBeginning bool
 	Pipe          bool
 	WatchMethod   string
+	OffsetsFile   string    `toml:"offsets_file"`
+	Multiline     Multiline `toml:"multiline"`
 
 	tailers []*tail.Tail
+	offsets *offsetStore
 	parser  parsers.Parser
 	wg      sync.WaitGroup
 	acc     telegraf.Accumulator
@@ -58,11 +63,28 @@ const sampleConfig = `
   ## Method used to watch for file updates.  Can be either "inotify" or "poll".
   # watch_method = "inotify"
 
+  ## File storing the offsets of the files, tailing resumes from them after a
+  ## restart instead of from the end or the beginning of the files.
+  # offsets_file = "/var/lib/telegraf/tail_offsets.json"
+
   ## Data format to consume.
   ## Each data format has its own unique set of configuration options, read
   ## more about them here:
   ## https://github.com/influxdata/telegraf/blob/master/docs/DATA_FORMATS_INPUT.md
   data_format = "influx"
+
+  ## Messages spanning several lines, such as stack traces, are joined with
+  ## either pattern.  The lines are joined with a newline and parsed as a
+  ## single message, once the next message begins or after the timeout.
+  # [inputs.tail.multiline]
+    ## Lines matching the pattern begin a new message, the other lines
+    ## continue the previous one.
+    # start_pattern = '^\[\d{4}-\d{2}-\d{2}'
+    ## Lines matching the pattern continue the previous message, the other
+    ## lines begin a new one.
+    # continuation_pattern = '^\s'
+    ## The message is complete when no line is added for the timeout.
+    # timeout = "5s"
 `
 
 func (t *Tail) SampleConfig() string {
@@ -74,6 +96,11 @@ func (t *Tail) Description() string {
 }
 
 func (t *Tail) Gather(acc telegraf.Accumulator) error {
+	if t.offsets != nil {
+		if err := t.offsets.save(); err != nil {
+			acc.AddError(fmt.Errorf("E! Error saving the offsets to %s: %s", t.OffsetsFile, err))
+		}
+	}
 	return nil
 }
 
@@ -83,12 +110,17 @@ func (t *Tail) Start(acc telegraf.Accumulator) error {
 
 	t.acc = acc
 
-	var seek *tail.SeekInfo
-	if !t.Pipe && !t.FromBeginning {
-		seek = &tail.SeekInfo{
-			Whence: 2,
-			Offset: 0,
+	if _, err := t.Multiline.newMultiline(); err != nil {
+		return err
+	}
+
+	t.offsets = nil
+	if t.OffsetsFile != "" && !t.Pipe {
+		offsets, err := loadOffsets(t.OffsetsFile)
+		if err != nil {
+			return fmt.Errorf("E! Error loading the offsets from %s: %s", t.OffsetsFile, err)
 		}
+		t.offsets = offsets
 	}
 
 	var poll bool
@@ -103,6 +135,7 @@ func (t *Tail) Start(acc telegraf.Accumulator) error {
 			t.acc.AddError(fmt.Errorf("E! Error Glob %s failed to compile, %s", filepath, err))
 		}
 		for file, _ := range g.Match() {
+			seek, offset := t.seek(file)
 			tailer, err := tail.TailFile(file,
 				tail.Config{
 					ReOpen:    true,
@@ -119,7 +152,7 @@ func (t *Tail) Start(acc telegraf.Accumulator) error {
 			}
 			// create a goroutine for each "tailer"
 			t.wg.Add(1)
-			go t.receiver(tailer)
+			go t.receiver(tailer, offset)
 			t.tailers = append(t.tailers, tailer)
 		}
 	}
@@ -127,34 +160,143 @@ func (t *Tail) Start(acc telegraf.Accumulator) error {
 	return nil
 }
 
+// seek returns where to start tailing the file and its offset: the stored
+// offset, unless the file was truncated since, otherwise its end or its
+// beginning with from_beginning.
+func (t *Tail) seek(file string) (*tail.SeekInfo, int64) {
+	if t.Pipe {
+		return nil, 0
+	}
+
+	var size int64
+	if info, err := os.Stat(file); err == nil {
+		size = info.Size()
+	}
+	if t.offsets != nil {
+		if offset, ok := t.offsets.get(file); ok {
+			if offset > size {
+				offset = 0
+			}
+			return &tail.SeekInfo{Whence: 0, Offset: offset}, offset
+		}
+	}
+	if t.FromBeginning {
+		return nil, 0
+	}
+	return &tail.SeekInfo{Whence: 2, Offset: 0}, size
+}
+
 // this is launched as a goroutine to continuously watch a tailed logfile
 // for changes, parse any incoming msgs, and add to the accumulator.
-func (t *Tail) receiver(tailer *tail.Tail) {
+func (t *Tail) receiver(tailer *tail.Tail, offset int64) {
 	defer t.wg.Done()
 
-	var m telegraf.Metric
-	var err error
-	var line *tail.Line
-	for line = range tailer.Lines {
-		if line.Err != nil {
-			t.acc.AddError(fmt.Errorf("E! Error tailing file %s, Error: %s\n",
-				tailer.Filename, err))
-			continue
+	// validated by Start
+	ml, _ := t.Multiline.newMultiline()
+	// offset of the message being joined, the lines before it are parsed
+	var start int64
+	var timer *time.Timer
+	for {
+		var timeout <-chan time.Time
+		if timer != nil {
+			timeout = timer.C
 		}
-		// Fix up files with Windows line endings.
-		text := strings.TrimRight(line.Text, "\r")
-
-		m, err = t.parser.ParseLine(text)
-		if err == nil {
-			t.acc.AddFields(m.Name(), m.Fields(), m.Tags(), m.Time())
-		} else {
-			t.acc.AddError(fmt.Errorf("E! Malformed log line in %s: [%s], Error: %s\n",
-				tailer.Filename, line.Text, err))
+
+		select {
+		case line, ok := <-tailer.Lines:
+			if !ok {
+				if ml != nil {
+					if message, ok := ml.flush(); ok {
+						t.parseMessage(tailer, message)
+					}
+				}
+				t.commit(tailer, offset)
+				if err := tailer.Err(); err != nil {
+					t.acc.AddError(fmt.Errorf("E! Error tailing file %s, Error: %s\n",
+						tailer.Filename, err))
+				}
+				return
+			}
+			if line.Err != nil {
+				t.acc.AddError(fmt.Errorf("E! Error tailing file %s, Error: %s\n",
+					tailer.Filename, line.Err))
+				continue
+			}
+			lineStart := offset
+			offset = t.next(tailer, offset, line.Text)
+			// Fix up files with Windows line endings.
+			text := strings.TrimRight(line.Text, "\r")
+
+			if ml == nil {
+				t.parseLine(tailer, text)
+				t.commit(tailer, offset)
+				continue
+			}
+
+			pending := ml.pending()
+			if message, ok := ml.add(text); ok {
+				t.parseMessage(tailer, message)
+				pending = false
+			}
+			if !pending {
+				start = lineStart
+			}
+			t.commit(tailer, start)
+
+			if timer != nil {
+				timer.Stop()
+			}
+			timer = time.NewTimer(ml.timeout)
+		case <-timeout:
+			timer = nil
+			if message, ok := ml.flush(); ok {
+				t.parseMessage(tailer, message)
+			}
+			t.commit(tailer, offset)
 		}
 	}
-	if err := tailer.Err(); err != nil {
-		t.acc.AddError(fmt.Errorf("E! Error tailing file %s, Error: %s\n",
-			tailer.Filename, err))
+}
+
+// next returns the offset of the line following the line starting at offset.
+// The offset of the tailer is used when it is lower, the file was truncated
+// or rotated.
+func (t *Tail) next(tailer *tail.Tail, offset int64, text string) int64 {
+	if t.offsets == nil {
+		return 0
+	}
+	next := offset + int64(len(text)) + 1
+	if pos, err := tailer.Tell(); err == nil && pos < next {
+		next = pos
+	}
+	return next
+}
+
+// commit records that the lines of the file before offset are parsed.
+func (t *Tail) commit(tailer *tail.Tail, offset int64) {
+	if t.offsets != nil {
+		t.offsets.set(tailer.Filename, offset)
+	}
+}
+
+func (t *Tail) parseLine(tailer *tail.Tail, text string) {
+	m, err := t.parser.ParseLine(text)
+	if err == nil {
+		t.acc.AddFields(m.Name(), m.Fields(), m.Tags(), m.Time())
+	} else {
+		t.acc.AddError(fmt.Errorf("E! Malformed log line in %s: [%s], Error: %s\n",
+			tailer.Filename, text, err))
+	}
+}
+
+func (t *Tail) parseMessage(tailer *tail.Tail, message string) {
+	metrics, err := t.parser.Parse([]byte(message))
+	if err != nil {
+		t.acc.AddError(fmt.Errorf("E! Malformed log message in %s: [%s], Error: %s\n",
+			tailer.Filename, message, err))
+		return
+	}
+	for _, m := range metrics {
+		t.acc.AddFields(m.Name(), m.Fields(), m.Tags(), m.Time())
 	}
 }
 
@@ -170,6 +312,12 @@ func (t *Tail) Stop() {
 		tailer.Cleanup()
 	}
 	t.wg.Wait()
+
+	if t.offsets != nil {
+		if err := t.offsets.save(); err != nil {
+			t.acc.AddError(fmt.Errorf("E! Error saving the offsets to %s: %s", t.OffsetsFile, err))
+		}
+	}
 }
 
 func (t *Tail) SetParser(parser parsers.Parser) {
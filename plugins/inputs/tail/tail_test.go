@@ -3,8 +3,12 @@ package tail
 import (
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"runtime"
 	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf/internal"
 
 	"github.com/influxdata/telegraf/plugins/parsers"
 	"github.com/influxdata/telegraf/testutil"
@@ -141,3 +145,126 @@ func TestTailDosLineendings(t *testing.T) {
 			"usage_idle": float64(200),
 		})
 }
+
+func TestTailMultiline(t *testing.T) {
+	tmpfile, err := ioutil.TempFile("", "")
+	require.NoError(t, err)
+	defer os.Remove(tmpfile.Name())
+	_, err = tmpfile.WriteString("{\n  \"a\": 1,\n  \"b\": 2\n}\n{\n  \"a\": 3\n}\n")
+	require.NoError(t, err)
+
+	tt := NewTail()
+	tt.FromBeginning = true
+	tt.Files = []string{tmpfile.Name()}
+	tt.Multiline = Multiline{
+		StartPattern: `^\{`,
+		Timeout:      internal.Duration{Duration: 100 * time.Millisecond},
+	}
+	p, _ := parsers.NewJSONParser("doc", nil, nil)
+	tt.SetParser(p)
+	defer tt.Stop()
+	defer tmpfile.Close()
+
+	acc := testutil.Accumulator{}
+	require.NoError(t, tt.Start(&acc))
+
+	// the second document is complete after the timeout
+	acc.Wait(2)
+	acc.Lock()
+	defer acc.Unlock()
+	require.Len(t, acc.Metrics, 2)
+	assert.Equal(t, map[string]interface{}{"a": float64(1), "b": float64(2)}, acc.Metrics[0].Fields)
+	assert.Equal(t, map[string]interface{}{"a": float64(3)}, acc.Metrics[1].Fields)
+}
+
+func TestTailMultilineExclusivePatterns(t *testing.T) {
+	tt := NewTail()
+	tt.Multiline = Multiline{
+		StartPattern:        `^\S`,
+		ContinuationPattern: `^\s`,
+	}
+	acc := testutil.Accumulator{}
+	require.Error(t, tt.Start(&acc))
+}
+
+func TestMultilineContinuation(t *testing.T) {
+	m := Multiline{ContinuationPattern: `^\s`}
+	ml, err := m.newMultiline()
+	require.NoError(t, err)
+
+	_, ok := ml.add("panic: runtime error")
+	assert.False(t, ok)
+	_, ok = ml.add("  main.go:10")
+	assert.False(t, ok)
+	message, ok := ml.add("done")
+	assert.True(t, ok)
+	assert.Equal(t, "panic: runtime error\n  main.go:10", message)
+
+	message, ok = ml.flush()
+	assert.True(t, ok)
+	assert.Equal(t, "done", message)
+	assert.False(t, ml.pending())
+}
+
+func TestTailOffsets(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tail")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	tmpfile, err := os.Create(filepath.Join(dir, "metrics.out"))
+	require.NoError(t, err)
+	defer tmpfile.Close()
+	_, err = tmpfile.WriteString("cpu usage_idle=100\n")
+	require.NoError(t, err)
+
+	newTail := func() *Tail {
+		tt := NewTail()
+		tt.FromBeginning = true
+		tt.Files = []string{tmpfile.Name()}
+		tt.OffsetsFile = filepath.Join(dir, "offsets.json")
+		p, _ := parsers.NewInfluxParser()
+		tt.SetParser(p)
+		return tt
+	}
+
+	tt := newTail()
+	acc := testutil.Accumulator{}
+	require.NoError(t, tt.Start(&acc))
+	acc.Wait(1)
+	tt.Stop()
+
+	_, err = tmpfile.WriteString("cpu2 usage_idle=200\n")
+	require.NoError(t, err)
+
+	// the lines parsed before the restart are not parsed again
+	tt = newTail()
+	acc = testutil.Accumulator{}
+	require.NoError(t, tt.Start(&acc))
+	defer tt.Stop()
+	acc.Wait(1)
+	acc.AssertContainsFields(t, "cpu2",
+		map[string]interface{}{
+			"usage_idle": float64(200),
+		})
+	assert.False(t, acc.HasMeasurement("cpu"))
+}
+
+func TestOffsetStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tail")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "offsets.json")
+
+	s, err := loadOffsets(path)
+	require.NoError(t, err)
+	_, ok := s.get("/var/log/a.log")
+	assert.False(t, ok)
+	s.set("/var/log/a.log", 42)
+	require.NoError(t, s.save())
+
+	s, err = loadOffsets(path)
+	require.NoError(t, err)
+	offset, ok := s.get("/var/log/a.log")
+	assert.True(t, ok)
+	assert.Equal(t, int64(42), offset)
+}
@@ -32,7 +32,7 @@ type UdpListener struct {
 	// we now always create 1 max size buffer and then copy only what we need
 	// into the in channel
 	// see https://github.com/influxdata/telegraf/pull/992
-	UDPPacketSize int `toml:"udp_packet_size"`
+	UDPPacketSize int `toml:"udp_packet_size" deprecated:"0.13;the value is ignored"`
 
 	sync.Mutex
 	wg sync.WaitGroup
@@ -1,14 +1,26 @@
 package zfs
 
+import (
+	"github.com/influxdata/telegraf/internal/command"
+)
+
 type Sysctl func(metric string) ([]string, error)
 type Zpool func() ([]string, error)
+type ZpoolStatus func() ([]string, error)
+type Zdataset func() ([]string, error)
 
 type Zfs struct {
-	KstatPath    string
-	KstatMetrics []string
-	PoolMetrics  bool
-	sysctl       Sysctl
-	zpool        Zpool
+	KstatPath      string
+	KstatMetrics   []string
+	PoolMetrics    bool
+	VdevMetrics    bool
+	DatasetMetrics bool
+	sysctl         Sysctl
+	zpool          Zpool
+	zpoolStatus    ZpoolStatus
+	zdataset       Zdataset
+
+	runner command.Runner
 }
 
 var sampleConfig = `
@@ -24,12 +36,24 @@ var sampleConfig = `
   #   "dmu_tx", "fm", "vdev_mirror_stats", "zfetchstats", "zil"]
   ## By default, don't gather zpool stats
   # poolMetrics = false
+
+  ## By default, don't gather the error counters of the vdevs from
+  ## "zpool status"
+  # vdevMetrics = false
+
+  ## By default, don't gather the usage of the datasets from "zfs list"
+  # datasetMetrics = false
 `
 
+// SetRunner sets the runner of the zpool, zfs and sysctl commands.
+func (z *Zfs) SetRunner(runner command.Runner) {
+	z.runner = runner
+}
+
 func (z *Zfs) SampleConfig() string {
 	return sampleConfig
 }
 
 func (z *Zfs) Description() string {
-	return "Read metrics of ZFS from arcstats, zfetchstats, vdev_cache_stats, and pools"
+	return "Read metrics of ZFS from arcstats, zfetchstats, vdev_cache_stats, pools, vdevs and datasets"
 }
@@ -44,7 +44,7 @@ func getTags(pools []poolInfo) map[string]string {
 	return map[string]string{"pools": poolNames}
 }
 
-func gatherPoolStats(pool poolInfo, acc telegraf.Accumulator) error {
+func gatherPoolStats(pool poolInfo, zpool *zpoolList, acc telegraf.Accumulator) error {
 	lines, err := internal.ReadLines(pool.ioFilename)
 	if err != nil {
 		return err
@@ -72,11 +72,47 @@ func gatherPoolStats(pool poolInfo, acc telegraf.Accumulator) error {
 		}
 		fields[keys[i]] = value
 	}
+	if zpool != nil {
+		// the health is a field, the series of the pools are the same
+		// with and without zpool
+		fields["health"] = zpool.tags["health"]
+		for k, v := range zpool.fields {
+			fields[k] = v
+		}
+	}
 	acc.AddFields("zfs_pool", fields, tag)
 
 	return nil
 }
 
+// listPools returns the health, capacity and fragmentation of the pools
+// from "zpool list", by pool name.  The kstat statistics of the pools are
+// gathered without them when zpool fails, or is not installed: zpool is then
+// not run again.
+func (z *Zfs) listPools(acc telegraf.Accumulator) map[string]*zpoolList {
+	zpools := make(map[string]*zpoolList)
+	if z.zpool == nil {
+		return zpools
+	}
+
+	lines, err := z.zpool()
+	if commandNotFound(err) {
+		z.zpool = nil
+		return zpools
+	}
+	if err == nil {
+		var list []zpoolList
+		list, err = parseZpoolList(lines)
+		for i := range list {
+			zpools[list[i].name] = &list[i]
+		}
+	}
+	if err != nil {
+		acc.AddError(err)
+	}
+	return zpools
+}
+
 func (z *Zfs) Gather(acc telegraf.Accumulator) error {
 	kstatMetrics := z.KstatMetrics
 	if len(kstatMetrics) == 0 {
@@ -96,14 +132,27 @@ func (z *Zfs) Gather(acc telegraf.Accumulator) error {
 	tags := getTags(pools)
 
 	if z.PoolMetrics {
+		zpools := z.listPools(acc)
 		for _, pool := range pools {
-			err := gatherPoolStats(pool, acc)
+			err := gatherPoolStats(pool, zpools[pool.name], acc)
 			if err != nil {
 				return err
 			}
 		}
 	}
 
+	if z.VdevMetrics && z.zpoolStatus != nil {
+		if err := z.gatherVdevStats(acc); err != nil {
+			acc.AddError(err)
+		}
+	}
+
+	if z.DatasetMetrics && z.zdataset != nil {
+		if err := z.gatherDatasetStats(acc); err != nil {
+			acc.AddError(err)
+		}
+	}
+
 	fields := make(map[string]interface{})
 	for _, metric := range kstatMetrics {
 		lines, err := internal.ReadLines(kstatPath + "/" + metric)
@@ -133,6 +182,10 @@ func (z *Zfs) Gather(acc telegraf.Accumulator) error {
 
 func init() {
 	inputs.Add("zfs", func() telegraf.Input {
-		return &Zfs{}
+		z := &Zfs{}
+		z.zpool = z.listZpools
+		z.zpoolStatus = z.zpoolStatusLines
+		z.zdataset = z.listDatasets
+		return z
 	})
 }
@@ -5,8 +5,10 @@ package zfs
 import (
 	"io/ioutil"
 	"os"
+	"os/exec"
 	"testing"
 
+	"github.com/influxdata/telegraf/internal/command"
 	"github.com/influxdata/telegraf/testutil"
 	"github.com/stretchr/testify/require"
 )
@@ -284,6 +286,73 @@ func TestZfsPoolMetrics(t *testing.T) {
 	require.NoError(t, err)
 }
 
+// $ zpool list -Hp -o name,size,allocated,free,expandsize,fragmentation,capacity,dedupratio,health,altroot
+var zpoolListContents = []string{
+	"HOME	1992864825344	694165504	1992170659840	-	0	0	1.00x	ONLINE	-",
+}
+
+func mockZpool() ([]string, error) {
+	return zpoolListContents, nil
+}
+
+func TestZfsPoolMetrics_zpool(t *testing.T) {
+	err := os.MkdirAll(testKstatPath+"/HOME", 0755)
+	require.NoError(t, err)
+
+	err = ioutil.WriteFile(testKstatPath+"/HOME/io", []byte(pool_ioContents), 0644)
+	require.NoError(t, err)
+
+	var acc testutil.Accumulator
+
+	z := &Zfs{KstatPath: testKstatPath, KstatMetrics: []string{"arcstats"}, PoolMetrics: true, zpool: mockZpool}
+	err = z.Gather(&acc)
+	require.NoError(t, err)
+
+	//one pool, kstat and zpool metrics
+	tags := map[string]string{
+		"pool": "HOME",
+	}
+	poolMetrics := getPoolMetrics()
+	poolMetrics["health"] = "ONLINE"
+	poolMetrics["size"] = int64(1992864825344)
+	poolMetrics["allocated"] = int64(694165504)
+	poolMetrics["free"] = int64(1992170659840)
+	poolMetrics["fragmentation"] = int64(0)
+	poolMetrics["capacity"] = int64(0)
+	poolMetrics["dedupratio"] = float64(1)
+
+	acc.AssertContainsTaggedFields(t, "zfs_pool", poolMetrics, tags)
+
+	err = os.RemoveAll(os.TempDir() + "/telegraf")
+	require.NoError(t, err)
+}
+
+func TestZfsPoolMetrics_noZpool(t *testing.T) {
+	err := os.MkdirAll(testKstatPath+"/HOME", 0755)
+	require.NoError(t, err)
+
+	err = ioutil.WriteFile(testKstatPath+"/HOME/io", []byte(pool_ioContents), 0644)
+	require.NoError(t, err)
+
+	runs := 0
+	z := &Zfs{KstatPath: testKstatPath, KstatMetrics: []string{"arcstats"}, PoolMetrics: true}
+	z.zpool = func() ([]string, error) {
+		runs++
+		return nil, &command.Error{Command: "zpool list", Err: &exec.Error{Name: "zpool", Err: exec.ErrNotFound}}
+	}
+
+	// the kstat metrics are gathered without error, zpool is tried once
+	for i := 0; i < 2; i++ {
+		var acc testutil.Accumulator
+		require.NoError(t, acc.GatherError(z.Gather))
+		acc.AssertContainsTaggedFields(t, "zfs_pool", getPoolMetrics(), map[string]string{"pool": "HOME"})
+	}
+	require.Equal(t, 1, runs)
+
+	err = os.RemoveAll(os.TempDir() + "/telegraf")
+	require.NoError(t, err)
+}
+
 func TestZfsGeneratesMetrics(t *testing.T) {
 	err := os.MkdirAll(testKstatPath, 0755)
 	require.NoError(t, err)
@@ -0,0 +1,229 @@
+// +build linux freebsd
+
+package zfs
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal/command"
+)
+
+// zpoolListColumns are the columns of "zpool list", listed explicitly because
+// the default columns vary between the ZFS versions.
+const zpoolListColumns = "name,size,allocated,free,expandsize,fragmentation,capacity,dedupratio,health,altroot"
+
+// errorCountUnits are the suffixes of the abbreviated error counters.
+const errorCountUnits = "KMGTPE"
+
+// zpoolList is a pool of the output of "zpool list".
+type zpoolList struct {
+	name   string
+	tags   map[string]string
+	fields map[string]interface{}
+}
+
+func parseZpoolList(lines []string) ([]zpoolList, error) {
+	pools := []zpoolList{}
+	for _, line := range lines {
+		if len(line) == 0 {
+			continue
+		}
+		col := strings.Split(line, "\t")
+		if len(col) < 9 {
+			return nil, fmt.Errorf("Error parsing zpool list: %q", line)
+		}
+
+		tags := map[string]string{"pool": col[0], "health": col[8]}
+		fields := map[string]interface{}{}
+
+		if tags["health"] == "UNAVAIL" {
+
+			fields["size"] = int64(0)
+
+		} else {
+
+			size, err := strconv.ParseInt(col[1], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("Error parsing size: %s", err)
+			}
+			fields["size"] = size
+
+			alloc, err := strconv.ParseInt(col[2], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("Error parsing allocation: %s", err)
+			}
+			fields["allocated"] = alloc
+
+			free, err := strconv.ParseInt(col[3], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("Error parsing free: %s", err)
+			}
+			fields["free"] = free
+
+			frag, err := strconv.ParseInt(strings.TrimSuffix(col[5], "%"), 10, 0)
+			if err != nil { // This might be - for RO devs
+				frag = 0
+			}
+			fields["fragmentation"] = frag
+
+			capval, err := strconv.ParseInt(strings.TrimSuffix(col[6], "%"), 10, 0)
+			if err != nil {
+				return nil, fmt.Errorf("Error parsing capacity: %s", err)
+			}
+			fields["capacity"] = capval
+
+			dedup, err := strconv.ParseFloat(strings.TrimSuffix(col[7], "x"), 32)
+			if err != nil {
+				return nil, fmt.Errorf("Error parsing dedupratio: %s", err)
+			}
+			fields["dedupratio"] = dedup
+		}
+
+		pools = append(pools, zpoolList{name: col[0], tags: tags, fields: fields})
+	}
+	return pools, nil
+}
+
+// gatherVdevStats adds the state and the error counters of the vdevs of the
+// output of "zpool status".
+func (z *Zfs) gatherVdevStats(acc telegraf.Accumulator) error {
+	lines, err := z.zpoolStatus()
+	if err != nil {
+		return err
+	}
+
+	var pool string
+	var config bool
+	for _, line := range lines {
+		col := strings.Fields(line)
+		switch {
+		case len(col) == 0:
+			config = false
+		case col[0] == "pool:" && len(col) == 2:
+			pool = col[1]
+		case col[0] == "NAME" && len(col) >= 5 && col[1] == "STATE":
+			config = true
+		case config && len(col) >= 5:
+			read, err := parseErrorCount(col[2])
+			if err != nil {
+				return fmt.Errorf("Error parsing read errors of %s: %s", col[0], err)
+			}
+			write, err := parseErrorCount(col[3])
+			if err != nil {
+				return fmt.Errorf("Error parsing write errors of %s: %s", col[0], err)
+			}
+			cksum, err := parseErrorCount(col[4])
+			if err != nil {
+				return fmt.Errorf("Error parsing checksum errors of %s: %s", col[0], err)
+			}
+
+			tags := map[string]string{"pool": pool, "vdev": col[0], "state": col[1]}
+			fields := map[string]interface{}{
+				"read_errors":     read,
+				"write_errors":    write,
+				"checksum_errors": cksum,
+			}
+			acc.AddFields("zfs_vdev", fields, tags)
+		}
+	}
+	return nil
+}
+
+// parseErrorCount parses the error counters of "zpool status", abbreviated
+// with a K, M, G, T, P or E suffix when they are large.
+func parseErrorCount(s string) (int64, error) {
+	multiplier := float64(1)
+	if n := len(s); n > 1 {
+		if i := strings.IndexByte(errorCountUnits, s[n-1]); i >= 0 {
+			multiplier = math.Pow(1024, float64(i+1))
+			s = s[:n-1]
+		}
+	}
+	value, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, err
+	}
+	return int64(value * multiplier), nil
+}
+
+// gatherDatasetStats adds the usage of the datasets of the output of
+// "zfs list".
+func (z *Zfs) gatherDatasetStats(acc telegraf.Accumulator) error {
+	lines, err := z.zdataset()
+	if err != nil {
+		return err
+	}
+
+	names := []string{"avail", "used", "usedsnap", "usedds"}
+	for _, line := range lines {
+		if len(line) == 0 {
+			continue
+		}
+		col := strings.Split(line, "\t")
+		if len(col) != len(names)+1 {
+			return fmt.Errorf("Error parsing zfs list: %q", line)
+		}
+
+		fields := map[string]interface{}{}
+		for i, name := range names {
+			// "-" for the properties not applying to the dataset
+			if col[i+1] == "-" {
+				continue
+			}
+			value, err := strconv.ParseInt(col[i+1], 10, 64)
+			if err != nil {
+				return fmt.Errorf("Error parsing %s of %s: %s", name, col[0], err)
+			}
+			fields[name] = value
+		}
+		acc.AddFields("zfs_dataset", fields, map[string]string{"dataset": col[0]})
+	}
+	return nil
+}
+
+// commandNotFound reports whether the command failed because its program is
+// not installed.
+func commandNotFound(err error) bool {
+	if e, ok := err.(*command.Error); ok {
+		err = e.Err
+	}
+	if e, ok := err.(*exec.Error); ok {
+		return e.Err == exec.ErrNotFound
+	}
+	return false
+}
+
+// run runs the command, returning the lines of its output.
+func (z *Zfs) run(name string, args ...string) ([]string, error) {
+	if z.runner == nil {
+		runner, err := (&command.Config{}).Runner()
+		if err != nil {
+			return nil, err
+		}
+		z.runner = runner
+	}
+
+	out, err := z.runner.Run(context.Background(), name, args...)
+	if err != nil {
+		return nil, err
+	}
+	return strings.Split(strings.TrimSpace(string(out)), "\n"), nil
+}
+
+func (z *Zfs) listZpools() ([]string, error) {
+	return z.run("zpool", "list", "-Hp", "-o", zpoolListColumns)
+}
+
+func (z *Zfs) zpoolStatusLines() ([]string, error) {
+	return z.run("zpool", "status")
+}
+
+func (z *Zfs) listDatasets() ([]string, error) {
+	return z.run("zfs", "list", "-Hp", "-t", "filesystem,volume", "-o", "name,avail,used,usedsnap,usedds")
+}
@@ -0,0 +1,110 @@
+// +build linux freebsd
+
+package zfs
+
+import (
+	"testing"
+
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+// $ zpool status
+var zpoolStatusOutput = []string{
+	"  pool: tank",
+	" state: DEGRADED",
+	"status: One or more devices has experienced an unrecoverable error.",
+	"  scan: scrub repaired 0B in 0 days 01:12:09 with 0 errors on Sun Oct  7 01:36:10 2018",
+	"config:",
+	"",
+	"	NAME        STATE     READ WRITE CKSUM",
+	"	tank        DEGRADED     0     0     0",
+	"	  mirror-0  DEGRADED     0     0     0",
+	"	    sda     ONLINE       0     0     0",
+	"	    sdb     FAULTED     12     3  1.5K  too many errors",
+	"	logs",
+	"	  sdc       ONLINE       0     0     0",
+	"	spares",
+	"	  sdd       AVAIL",
+	"",
+	"errors: No known data errors",
+}
+
+func mockZpoolStatus() ([]string, error) {
+	return zpoolStatusOutput, nil
+}
+
+// $ zfs list -Hp -t filesystem,volume -o name,avail,used,usedsnap,usedds
+var zfsListOutput = []string{
+	"tank	960237658112	1161834405888	0	98304",
+	"tank/bricks	960237658112	1161833627648	5083594752	1156750032896",
+	"tank/vol	-	10737418240	0	10737418240",
+}
+
+func mockZdataset() ([]string, error) {
+	return zfsListOutput, nil
+}
+
+func TestGatherVdevStats(t *testing.T) {
+	var acc testutil.Accumulator
+
+	z := &Zfs{zpoolStatus: mockZpoolStatus}
+	err := z.gatherVdevStats(&acc)
+	require.NoError(t, err)
+
+	require.Len(t, acc.Metrics, 5)
+	acc.AssertContainsTaggedFields(t, "zfs_vdev",
+		map[string]interface{}{
+			"read_errors":     int64(0),
+			"write_errors":    int64(0),
+			"checksum_errors": int64(0),
+		},
+		map[string]string{"pool": "tank", "vdev": "mirror-0", "state": "DEGRADED"})
+	acc.AssertContainsTaggedFields(t, "zfs_vdev",
+		map[string]interface{}{
+			"read_errors":     int64(12),
+			"write_errors":    int64(3),
+			"checksum_errors": int64(1536),
+		},
+		map[string]string{"pool": "tank", "vdev": "sdb", "state": "FAULTED"})
+}
+
+func TestGatherDatasetStats(t *testing.T) {
+	var acc testutil.Accumulator
+
+	z := &Zfs{zdataset: mockZdataset}
+	err := z.gatherDatasetStats(&acc)
+	require.NoError(t, err)
+
+	acc.AssertContainsTaggedFields(t, "zfs_dataset",
+		map[string]interface{}{
+			"avail":    int64(960237658112),
+			"used":     int64(1161833627648),
+			"usedsnap": int64(5083594752),
+			"usedds":   int64(1156750032896),
+		},
+		map[string]string{"dataset": "tank/bricks"})
+	acc.AssertContainsTaggedFields(t, "zfs_dataset",
+		map[string]interface{}{
+			"used":     int64(10737418240),
+			"usedsnap": int64(0),
+			"usedds":   int64(10737418240),
+		},
+		map[string]string{"dataset": "tank/vol"})
+}
+
+func TestParseErrorCount(t *testing.T) {
+	for s, expected := range map[string]int64{
+		"0":    0,
+		"42":   42,
+		"1.5K": 1536,
+		"2M":   2097152,
+	} {
+		value, err := parseErrorCount(s)
+		require.NoError(t, err)
+		require.Equal(t, expected, value, s)
+	}
+
+	_, err := parseErrorCount("-")
+	require.Error(t, err)
+}
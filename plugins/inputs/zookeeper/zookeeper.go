@@ -23,7 +23,7 @@ type Zookeeper struct {
 	Timeout internal.Duration
 
 	EnableTLS bool `toml:"enable_tls"`
-	EnableSSL bool `toml:"enable_ssl"` // deprecated in 1.7; use enable_tls
+	EnableSSL bool `toml:"enable_ssl" deprecated:"1.7;use enable_tls"`
 	tlsint.ClientConfig
 
 	initialized bool
@@ -10,6 +10,8 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/proxy"
+	"github.com/influxdata/telegraf/internal/retry"
 	"github.com/influxdata/telegraf/plugins/outputs"
 )
 
@@ -17,6 +19,7 @@ type Amon struct {
 	ServerKey    string
 	AmonInstance string
 	Timeout      internal.Duration
+	proxy.Config
 
 	client *http.Client
 }
@@ -30,6 +33,12 @@ var sampleConfig = `
 
   ## Connection timeout.
   # timeout = "5s"
+
+  ## Optional proxy, supports http, https and socks5 URLs (telegraf uses the
+  ## agent or system wide proxy settings if it is not set)
+  # http_proxy = "http://localhost:8888"
+  ## Hosts, domains and networks reached without the proxy
+  # no_proxy = ["localhost", ".example.com", "10.0.0.0/8"]
 `
 
 type TimeSeries struct {
@@ -47,9 +56,13 @@ func (a *Amon) Connect() error {
 	if a.ServerKey == "" || a.AmonInstance == "" {
 		return fmt.Errorf("serverkey and amon_instance are required fields for amon output")
 	}
+	proxyFunc, err := a.Config.Proxy()
+	if err != nil {
+		return err
+	}
 	a.client = &http.Client{
 		Transport: &http.Transport{
-			Proxy: http.ProxyFromEnvironment,
+			Proxy: proxyFunc,
 		},
 		Timeout: a.Timeout.Duration,
 	}
@@ -99,7 +112,8 @@ func (a *Amon) Write(metrics []telegraf.Metric) error {
 	defer resp.Body.Close()
 
 	if resp.StatusCode < 200 || resp.StatusCode > 209 {
-		return fmt.Errorf("received bad status code, %d\n", resp.StatusCode)
+		return retry.StatusError(resp,
+			fmt.Sprintf("received bad status code, %d\n", resp.StatusCode))
 	}
 
 	return nil
@@ -12,12 +12,15 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/proxy"
+	"github.com/influxdata/telegraf/internal/retry"
 	"github.com/influxdata/telegraf/plugins/outputs"
 )
 
 type Datadog struct {
 	Apikey  string
 	Timeout internal.Duration
+	proxy.Config
 
 	apiUrl string
 	client *http.Client
@@ -29,6 +32,12 @@ var sampleConfig = `
 
   ## Connection timeout.
   # timeout = "5s"
+
+  ## Optional proxy, supports http, https and socks5 URLs (telegraf uses the
+  ## agent or system wide proxy settings if it is not set)
+  # http_proxy = "http://localhost:8888"
+  ## Hosts, domains and networks reached without the proxy
+  # no_proxy = ["localhost", ".example.com", "10.0.0.0/8"]
 `
 
 type TimeSeries struct {
@@ -57,9 +66,13 @@ func (d *Datadog) Connect() error {
 		return fmt.Errorf("apikey is a required field for datadog output")
 	}
 
+	proxyFunc, err := d.Config.Proxy()
+	if err != nil {
+		return err
+	}
 	d.client = &http.Client{
 		Transport: &http.Transport{
-			Proxy: http.ProxyFromEnvironment,
+			Proxy: proxyFunc,
 		},
 		Timeout: d.Timeout.Duration,
 	}
@@ -121,7 +134,8 @@ func (d *Datadog) Write(metrics []telegraf.Metric) error {
 	defer resp.Body.Close()
 
 	if resp.StatusCode < 200 || resp.StatusCode > 209 {
-		return fmt.Errorf("received bad status code, %d\n", resp.StatusCode)
+		return retry.StatusError(resp,
+			fmt.Sprintf("received bad status code, %d\n", resp.StatusCode))
 	}
 
 	return nil
@@ -16,13 +16,15 @@ import (
 )
 
 type Graphite struct {
-	GraphiteTagSupport bool
+	GraphiteTagSupport   bool
+	GraphiteSanitizeMode string
 	// URL is only for backwards compatibility
-	Servers  []string
-	Prefix   string
-	Template string
-	Timeout  int
-	conns    []net.Conn
+	Servers   []string
+	Prefix    string
+	Template  string
+	Templates []string
+	Timeout   int
+	conns     []net.Conn
 	tlsint.ClientConfig
 }
 
@@ -37,9 +39,23 @@ var sampleConfig = `
   ## see https://github.com/influxdata/telegraf/blob/master/docs/DATA_FORMATS_OUTPUT.md
   template = "host.tags.measurement.field"
 
+  ## Templates overriding the template for some measurements, in the form
+  ## "filter template" where filter is a glob matching the measurement name.
+  ## The first matching template is used.
+  # templates = [
+  #   "cpu tags.measurement.field",
+  #   "disk* host.measurement.path.field",
+  # ]
+
   ## Enable Graphite tags support
   # graphite_tag_support = false
 
+  ## Characters replaced in buckets and tags, one of:
+  ##   "strict" - only letters, digits and "-:._=" are kept (legacy)
+  ##   "compatible" - only characters Graphite does not accept are replaced
+  ##   "none" - buckets and tags are not sanitized
+  # graphite_sanitize_mode = "strict"
+
   ## timeout in seconds for the write connection to graphite
   timeout = 2
 
@@ -134,7 +150,13 @@ func checkEOF(conn net.Conn) {
 func (g *Graphite) Write(metrics []telegraf.Metric) error {
 	// Prepare data
 	var batch []byte
-	s, err := serializers.NewGraphiteSerializer(g.Prefix, g.Template, g.GraphiteTagSupport)
+	s, err := serializers.NewGraphiteSerializerConfig(&serializers.Config{
+		Prefix:               g.Prefix,
+		Template:             g.Template,
+		Templates:            g.Templates,
+		GraphiteTagSupport:   g.GraphiteTagSupport,
+		GraphiteSanitizeMode: g.GraphiteSanitizeMode,
+	})
 	if err != nil {
 		return err
 	}
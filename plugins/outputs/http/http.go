@@ -10,6 +10,8 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/proxy"
+	"github.com/influxdata/telegraf/internal/retry"
 	"github.com/influxdata/telegraf/internal/tls"
 	"github.com/influxdata/telegraf/plugins/outputs"
 	"github.com/influxdata/telegraf/plugins/serializers"
@@ -41,6 +43,12 @@ var sampleConfig = `
   ## Use TLS but skip chain & host verification
   # insecure_skip_verify = false
 
+  ## Optional proxy, supports http, https and socks5 URLs (telegraf uses the
+  ## agent or system wide proxy settings if it is not set)
+  # http_proxy = "http://localhost:8888"
+  ## Hosts, domains and networks reached without the proxy
+  # no_proxy = ["localhost", ".example.com", "10.0.0.0/8"]
+
   ## Data format to output.
   ## Each data format has it's own unique set of configuration options, read
   ## more about them here:
@@ -62,6 +70,7 @@ type HTTP struct {
 	Password string            `toml:"password"`
 	Headers  map[string]string `toml:"headers"`
 	tls.ClientConfig
+	proxy.Config
 
 	client     *http.Client
 	serializer serializers.Serializer
@@ -88,11 +97,15 @@ func (h *HTTP) Connect() error {
 	if err != nil {
 		return err
 	}
+	proxyFunc, err := h.Config.Proxy()
+	if err != nil {
+		return err
+	}
 
 	h.client = &http.Client{
 		Transport: &http.Transport{
 			TLSClientConfig: tlsCfg,
-			Proxy:           http.ProxyFromEnvironment,
+			Proxy:           proxyFunc,
 		},
 		Timeout: h.Timeout.Duration,
 	}
@@ -141,7 +154,8 @@ func (h *HTTP) write(reqBody []byte) error {
 	_, err = ioutil.ReadAll(resp.Body)
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return fmt.Errorf("when writing to [%s] received status code: %d", h.URL, resp.StatusCode)
+		return retry.StatusError(resp,
+			fmt.Sprintf("when writing to [%s] received status code: %d", h.URL, resp.StatusCode))
 	}
 
 	return nil
@@ -16,6 +16,8 @@ import (
 	"time"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal/proxy"
+	"github.com/influxdata/telegraf/internal/retry"
 	"github.com/influxdata/telegraf/plugins/serializers/influx"
 )
 
@@ -54,6 +56,9 @@ type APIError struct {
 	Title       string
 	Description string
 	Type        APIErrorType
+
+	// delay asked by the server before the retry of the request
+	retryAfter time.Duration
 }
 
 func (e APIError) Error() string {
@@ -63,6 +68,22 @@ func (e APIError) Error() string {
 	return e.Title
 }
 
+// Permanent returns true when the request was rejected and would be
+// rejected again.
+func (e APIError) Permanent() bool {
+	switch e.StatusCode {
+	case http.StatusBadRequest, http.StatusRequestEntityTooLarge:
+		return true
+	}
+	return false
+}
+
+// RetryAfter returns the delay asked by the server before the retry of the
+// request, 0 if none.
+func (e APIError) RetryAfter() time.Duration {
+	return e.retryAfter
+}
+
 // QueryResponse is the response body from the /query endpoint
 type QueryResponse struct {
 	Results []QueryResult `json:"results"`
@@ -96,6 +117,7 @@ type HTTPConfig struct {
 	Password        string
 	TLSConfig       *tls.Config
 	Proxy           *url.URL
+	NoProxy         []string
 	Headers         map[string]string
 	ContentEncoding string
 	Database        string
@@ -147,11 +169,13 @@ func NewHTTPClient(config *HTTPConfig) (*httpClient, error) {
 		headers[k] = v
 	}
 
-	var proxy func(*http.Request) (*url.URL, error)
+	proxyConfig := proxy.Config{NoProxy: config.NoProxy}
 	if config.Proxy != nil {
-		proxy = http.ProxyURL(config.Proxy)
-	} else {
-		proxy = http.ProxyFromEnvironment
+		proxyConfig.HTTPProxy = config.Proxy.String()
+	}
+	proxyFunc, err := proxyConfig.Proxy()
+	if err != nil {
+		return nil, err
 	}
 
 	serializer := config.Serializer
@@ -176,7 +200,7 @@ func NewHTTPClient(config *HTTPConfig) (*httpClient, error) {
 	switch config.URL.Scheme {
 	case "http", "https":
 		transport = &http.Transport{
-			Proxy:           proxy,
+			Proxy:           proxyFunc,
 			TLSClientConfig: config.TLSConfig,
 		}
 	case "unix":
@@ -334,11 +358,16 @@ func (c *httpClient) Write(ctx context.Context, metrics []telegraf.Metric) error
 		return nil
 	}
 
-	return &APIError{
+	apiError := &APIError{
 		StatusCode:  resp.StatusCode,
 		Title:       resp.Status,
 		Description: desc,
 	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		apiError.retryAfter = retry.ParseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+	}
+	return apiError
 }
 
 func (c *httpClient) makeQueryRequest(query string) (*http.Request, error) {
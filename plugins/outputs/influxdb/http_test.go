@@ -18,6 +18,7 @@ import (
 	"time"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal/retry"
 	"github.com/influxdata/telegraf/metric"
 	"github.com/influxdata/telegraf/plugins/outputs/influxdb"
 	"github.com/stretchr/testify/require"
@@ -422,6 +423,21 @@ func TestHTTP_Write(t *testing.T) {
 				require.Equal(t, expected, err)
 			},
 		},
+		{
+			name: "throttled",
+			config: &influxdb.HTTPConfig{
+				URL:      u,
+				Database: "telegraf",
+			},
+			queryHandlerFunc: func(t *testing.T, w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Retry-After", "30")
+				w.WriteHeader(http.StatusTooManyRequests)
+			},
+			errFunc: func(t *testing.T, err error) {
+				require.Equal(t, retry.Throttled, retry.Classify(err))
+				require.Equal(t, 30*time.Second, retry.RetryAfter(err))
+			},
+		},
 	}
 
 	for _, tt := range tests {
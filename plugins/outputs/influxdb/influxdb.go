@@ -11,6 +11,7 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/retry"
 	"github.com/influxdata/telegraf/internal/tls"
 	"github.com/influxdata/telegraf/plugins/outputs"
 	"github.com/influxdata/telegraf/plugins/serializers/influx"
@@ -32,7 +33,7 @@ type Client interface {
 
 // InfluxDB struct is the primary data structure for the plugin
 type InfluxDB struct {
-	URL                  string   // url deprecated in 0.1.9; use urls
+	URL                  string   `deprecated:"0.1.9;use urls"`
 	URLs                 []string `toml:"urls"`
 	Username             string
 	Password             string
@@ -43,13 +44,14 @@ type InfluxDB struct {
 	Timeout              internal.Duration
 	UDPPayload           int               `toml:"udp_payload"`
 	HTTPProxy            string            `toml:"http_proxy"`
+	NoProxy              []string          `toml:"no_proxy"`
 	HTTPHeaders          map[string]string `toml:"http_headers"`
 	ContentEncoding      string            `toml:"content_encoding"`
 	SkipDatabaseCreation bool              `toml:"skip_database_creation"`
 	InfluxUintSupport    bool              `toml:"influx_uint_support"`
 	tls.ClientConfig
 
-	Precision string // precision deprecated in 1.0; value is ignored
+	Precision string `deprecated:"1.0;the value is ignored"`
 
 	clients []Client
 
@@ -104,9 +106,12 @@ var sampleConfig = `
   ## Use TLS but skip chain & host verification
   # insecure_skip_verify = false
 
-  ## HTTP Proxy override, if unset values the standard proxy environment
-  ## variables are consulted to determine which proxy, if any, should be used.
+  ## HTTP Proxy override, supports http, https and socks5 URLs.  If unset the
+  ## proxy of the agent, or the standard proxy environment variables, are
+  ## consulted to determine which proxy, if any, should be used.
   # http_proxy = "http://corporate.proxy:3128"
+  ## Hosts, domains and networks reached without the proxy
+  # no_proxy = ["localhost", ".example.com", "10.0.0.0/8"]
 
   ## Additional HTTP headers
   # http_headers = {"X-Special-Header" = "Special-Value"}
@@ -195,6 +200,9 @@ func (i *InfluxDB) Write(metrics []telegraf.Metric) error {
 	ctx := context.Background()
 
 	var err error
+	// the metrics are rejected when every server rejects them
+	rejected := true
+	var retryAfter time.Duration
 	p := rand.Perm(len(i.clients))
 	for _, n := range p {
 		client := i.clients[n]
@@ -203,6 +211,17 @@ func (i *InfluxDB) Write(metrics []telegraf.Metric) error {
 			return nil
 		}
 
+		switch retry.Classify(err) {
+		case retry.Permanent:
+		case retry.Throttled:
+			rejected = false
+			if after := retry.RetryAfter(err); after > retryAfter {
+				retryAfter = after
+			}
+		default:
+			rejected = false
+		}
+
 		switch apiError := err.(type) {
 		case *APIError:
 			if !i.SkipDatabaseCreation {
@@ -219,7 +238,14 @@ func (i *InfluxDB) Write(metrics []telegraf.Metric) error {
 		log.Printf("E! [outputs.influxdb]: when writing to [%s]: %v", client.URL(), err)
 	}
 
-	return errors.New("could not write any address")
+	err = errors.New("could not write any address")
+	switch {
+	case rejected && len(i.clients) > 0:
+		return retry.NewPermanent(err)
+	case retryAfter > 0:
+		return retry.NewThrottled(err, retryAfter)
+	}
+	return err
 }
 
 func (i *InfluxDB) udpClient(url *url.URL) (Client, error) {
@@ -251,6 +277,7 @@ func (i *InfluxDB) httpClient(ctx context.Context, url *url.URL, proxy *url.URL)
 		Username:        i.Username,
 		Password:        i.Password,
 		Proxy:           proxy,
+		NoProxy:         i.NoProxy,
 		ContentEncoding: i.ContentEncoding,
 		Headers:         i.HTTPHeaders,
 		Database:        i.Database,
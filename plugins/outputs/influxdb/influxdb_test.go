@@ -8,6 +8,7 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/retry"
 	"github.com/influxdata/telegraf/internal/tls"
 	"github.com/influxdata/telegraf/metric"
 	"github.com/influxdata/telegraf/plugins/outputs/influxdb"
@@ -182,3 +183,43 @@ func TestWriteRecreateDatabaseIfDatabaseNotFound(t *testing.T) {
 	// We only have one URL, so we expect an error
 	require.Error(t, err)
 }
+
+func TestWriteRejected(t *testing.T) {
+	statusCode := http.StatusBadRequest
+	output := influxdb.InfluxDB{
+		URLs:                 []string{"http://localhost:8086"},
+		SkipDatabaseCreation: true,
+
+		CreateHTTPClientF: func(config *influxdb.HTTPConfig) (influxdb.Client, error) {
+			return &MockClient{
+				WriteF: func(ctx context.Context, metrics []telegraf.Metric) error {
+					return &influxdb.APIError{
+						StatusCode: statusCode,
+						Title:      http.StatusText(statusCode),
+					}
+				},
+				URLF: func() string {
+					return "http://localhost:8086"
+				},
+			}, nil
+		},
+	}
+	require.NoError(t, output.Connect())
+
+	m, err := metric.New(
+		"cpu",
+		map[string]string{},
+		map[string]interface{}{
+			"value": 42.0,
+		},
+		time.Unix(0, 0),
+	)
+	require.NoError(t, err)
+
+	err = output.Write([]telegraf.Metric{m})
+	require.Equal(t, retry.Permanent, retry.Classify(err))
+
+	statusCode = http.StatusInternalServerError
+	err = output.Write([]telegraf.Metric{m})
+	require.Equal(t, retry.Temporary, retry.Classify(err))
+}
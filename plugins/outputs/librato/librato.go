@@ -11,6 +11,8 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/proxy"
+	"github.com/influxdata/telegraf/internal/retry"
 	"github.com/influxdata/telegraf/plugins/outputs"
 	"github.com/influxdata/telegraf/plugins/serializers/graphite"
 )
@@ -20,9 +22,10 @@ type Librato struct {
 	APIUser   string `toml:"api_user"`
 	APIToken  string `toml:"api_token"`
 	Debug     bool
-	SourceTag string // Deprecated, keeping for backward-compatibility
+	SourceTag string `deprecated:"1.0;use template"` // keeping for backward-compatibility
 	Timeout   internal.Duration
 	Template  string
+	proxy.Config
 
 	APIUrl string
 	client *http.Client
@@ -47,6 +50,11 @@ var sampleConfig = `
   ## This template is used in librato's source (not metric's name)
   template = "host"
 
+  ## Optional proxy, supports http, https and socks5 URLs (telegraf uses the
+  ## agent or system wide proxy settings if it is not set)
+  # http_proxy = "http://localhost:8888"
+  ## Hosts, domains and networks reached without the proxy
+  # no_proxy = ["localhost", ".example.com", "10.0.0.0/8"]
 `
 
 // LMetrics is the default struct for Librato's API fromat
@@ -79,9 +87,13 @@ func (l *Librato) Connect() error {
 		return fmt.Errorf(
 			"api_user and api_token are required fields for librato output")
 	}
+	proxyFunc, err := l.Config.Proxy()
+	if err != nil {
+		return err
+	}
 	l.client = &http.Client{
 		Transport: &http.Transport{
-			Proxy: http.ProxyFromEnvironment,
+			Proxy: proxyFunc,
 		},
 		Timeout: l.Timeout.Duration,
 	}
@@ -159,10 +171,10 @@ func (l *Librato) Write(metrics []telegraf.Metric) error {
 				log.Printf("D! Couldn't get response! (%v)\n", err)
 			}
 			if resp.StatusCode != 200 {
-				return fmt.Errorf(
+				return retry.StatusError(resp, fmt.Sprintf(
 					"received bad status code, %d\n %s",
 					resp.StatusCode,
-					string(htmlData))
+					string(htmlData)))
 			}
 			log.Printf("D! Librato response: %v\n", string(htmlData))
 		}
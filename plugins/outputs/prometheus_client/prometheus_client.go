@@ -23,6 +23,15 @@ import (
 
 var invalidNameCharRE = regexp.MustCompile(`[^a-zA-Z0-9_]`)
 
+// Tags added by the prometheus input with metadata_as_tags, they are used as
+// metadata of the metric family instead of labels.
+const (
+	helpTag = "prometheus_help"
+	typeTag = "prometheus_type"
+)
+
+const defaultHelp = "Telegraf collected metric"
+
 // SampleID uniquely identifies a Sample
 type SampleID string
 
@@ -50,6 +59,8 @@ type MetricFamily struct {
 	TelegrafValueType telegraf.ValueType
 	// LabelSet is the label counts for all Samples.
 	LabelSet map[string]int
+	// Help is the HELP text of the metric family.
+	Help string
 }
 
 type PrometheusClient struct {
@@ -234,7 +245,11 @@ func (p *PrometheusClient) Collect(ch chan<- prometheus.Metric) {
 				labelNames = append(labelNames, k)
 			}
 		}
-		desc := prometheus.NewDesc(name, "Telegraf collected metric", labelNames, nil)
+		help := family.Help
+		if help == "" {
+			help = defaultHelp
+		}
+		desc := prometheus.NewDesc(name, help, labelNames, nil)
 
 		for _, sample := range family.Samples {
 			// Get labels for this sample; unset labels will be set to the
@@ -311,6 +326,9 @@ func (p *PrometheusClient) addMetricFamily(point telegraf.Metric, sample *Sample
 		}
 		p.fam[mname] = fam
 	}
+	if help, ok := point.GetTag(helpTag); ok {
+		fam.Help = help
+	}
 
 	addSample(fam, sample, sampleID)
 }
@@ -323,6 +341,8 @@ func (p *PrometheusClient) Write(metrics []telegraf.Metric) error {
 
 	for _, point := range metrics {
 		tags := point.Tags()
+		delete(tags, helpTag)
+		delete(tags, typeTag)
 		sampleID := CreateSampleID(tags)
 
 		labels := make(map[string]string)
@@ -691,3 +691,30 @@ func setupPrometheus() (*PrometheusClient, *prometheus_input.Prometheus, error)
 
 	return pTesting, p, nil
 }
+
+func TestWrite_Metadata(t *testing.T) {
+	p1, err := metric.New(
+		"foo",
+		map[string]string{
+			"host":            "localhost",
+			"prometheus_help": "Number of foos",
+			"prometheus_type": "counter",
+		},
+		map[string]interface{}{"counter": 1.0},
+		time.Now(),
+		telegraf.Counter)
+	require.NoError(t, err)
+
+	client := NewClient()
+	err = client.Write([]telegraf.Metric{p1})
+	require.NoError(t, err)
+
+	fam, ok := client.fam["foo"]
+	require.True(t, ok)
+	require.Equal(t, "Number of foos", fam.Help)
+	require.Equal(t, map[string]int{"host": 1}, fam.LabelSet)
+
+	sample, ok := fam.Samples[CreateSampleID(map[string]string{"host": "localhost"})]
+	require.True(t, ok)
+	require.Equal(t, map[string]string{"host": "localhost"}, sample.Labels)
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
 	tlsint "github.com/influxdata/telegraf/internal/tls"
+	"github.com/influxdata/telegraf/internal/vsock"
 	"github.com/influxdata/telegraf/plugins/outputs"
 	"github.com/influxdata/telegraf/plugins/serializers"
 )
@@ -42,8 +43,11 @@ func (sw *SocketWriter) SampleConfig() string {
   # address = "udp6://127.0.0.1:8094"
   # address = "unix:///tmp/telegraf.sock"
   # address = "unixgram:///tmp/telegraf.sock"
+  ## vsock address of the form "vsock://cid:port", "host" is the context ID
+  ## of the host of the virtual machine.
+  # address = "vsock://host:8094"
 
-  ## Optional TLS Config
+  ## Optional TLS Config, not used with vsock
   # tls_ca = "/etc/telegraf/ca.pem"
   # tls_cert = "/etc/telegraf/cert.pem"
   # tls_key = "/etc/telegraf/key.pem"
@@ -80,7 +84,10 @@ func (sw *SocketWriter) Connect() error {
 	}
 
 	var c net.Conn
-	if tlsCfg == nil {
+	if spl[0] == "vsock" {
+		// no host name to verify the certificate of
+		c, err = dialVsock(spl[1])
+	} else if tlsCfg == nil {
 		c, err = net.Dial(spl[0], spl[1])
 	} else {
 		c, err = tls.Dial(spl[0], spl[1], tlsCfg)
@@ -114,6 +121,14 @@ func (sw *SocketWriter) setKeepAlive(c net.Conn) error {
 	return tcpc.SetKeepAlivePeriod(sw.KeepAlivePeriod.Duration)
 }
 
+func dialVsock(address string) (net.Conn, error) {
+	addr, err := vsock.ParseAddr(address)
+	if err != nil {
+		return nil, err
+	}
+	return vsock.Dial(addr)
+}
+
 // Write writes the given metrics to the destination.
 // If an error is encountered, it is up to the caller to retry the same write again later.
 // Not parallel safe.
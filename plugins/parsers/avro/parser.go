@@ -0,0 +1,187 @@
+package avro
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/metric"
+)
+
+// magicByte starts every message of the Confluent wire format, it is
+// followed by the big endian schema id and the Avro encoded record.
+const magicByte = 0
+
+// Parser decodes Avro records into metrics using either a fixed schema or
+// the schema registry referenced by messages in the Confluent wire format.
+// Nested records are flattened into fields joined by FieldSeparator.
+type Parser struct {
+	MetricName       string
+	SchemaRegistry   string
+	RegistryUsername string
+	RegistryPassword string
+	Schema           string
+	Measurement      string
+	Tags             []string
+	Fields           []string
+	Timestamp        string
+	TimestampFormat  string
+	FieldSeparator   string
+	DefaultTags      map[string]string
+
+	registry *schemaRegistry
+	schema   *schema
+}
+
+// Init parses the fixed schema or sets up the schema registry client, it
+// must be called before parsing.
+func (p *Parser) Init() error {
+	if p.Schema == "" && p.SchemaRegistry == "" {
+		return fmt.Errorf("one of avro_schema or avro_schema_registry is required")
+	}
+	if p.Schema != "" {
+		s, err := parseSchema(p.Schema)
+		if err != nil {
+			return err
+		}
+		p.schema = s
+	}
+	if p.SchemaRegistry != "" {
+		p.registry = newSchemaRegistry(p.SchemaRegistry, p.RegistryUsername, p.RegistryPassword)
+	}
+	if p.FieldSeparator == "" {
+		p.FieldSeparator = "_"
+	}
+	return nil
+}
+
+func (p *Parser) Parse(buf []byte) ([]telegraf.Metric, error) {
+	if len(buf) == 0 {
+		return make([]telegraf.Metric, 0), nil
+	}
+
+	s := p.schema
+	if p.registry != nil && len(buf) >= 5 && buf[0] == magicByte {
+		var err error
+		s, err = p.registry.get(int32(binary.BigEndian.Uint32(buf[1:5])))
+		if err != nil {
+			return nil, err
+		}
+		buf = buf[5:]
+	}
+	if s == nil {
+		return nil, fmt.Errorf("message is not in the schema registry wire format")
+	}
+
+	d := &decoder{buf: buf}
+	v, err := d.decode(s)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode avro record: %s", err)
+	}
+	rec, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("schema of type %s is not a record", s.typ)
+	}
+
+	values := make(map[string]interface{})
+	p.flatten("", rec, values)
+
+	m, err := p.newMetric(values)
+	if err != nil {
+		return nil, err
+	}
+	return []telegraf.Metric{m}, nil
+}
+
+func (p *Parser) newMetric(values map[string]interface{}) (telegraf.Metric, error) {
+	name := p.MetricName
+	if p.Measurement != "" {
+		if v, ok := values[p.Measurement]; ok {
+			name = fmt.Sprint(v)
+			delete(values, p.Measurement)
+		}
+	}
+
+	t := time.Now().UTC()
+	if p.Timestamp != "" {
+		if v, ok := values[p.Timestamp]; ok {
+			ts := fmt.Sprint(v)
+			if f, ok := v.(float64); ok {
+				ts = strconv.FormatFloat(f, 'f', -1, 64)
+			}
+			var err error
+			t, err = internal.ParseTimestamp(ts, p.TimestampFormat)
+			if err != nil {
+				return nil, err
+			}
+			delete(values, p.Timestamp)
+		}
+	}
+
+	tags := make(map[string]string)
+	for k, v := range p.DefaultTags {
+		tags[k] = v
+	}
+	for _, k := range p.Tags {
+		if v, ok := values[k]; ok {
+			tags[k] = fmt.Sprint(v)
+			delete(values, k)
+		}
+	}
+
+	fields := values
+	if len(p.Fields) > 0 {
+		fields = make(map[string]interface{}, len(p.Fields))
+		for _, k := range p.Fields {
+			if v, ok := values[k]; ok {
+				fields[k] = v
+			}
+		}
+	}
+
+	return metric.New(name, tags, fields, t)
+}
+
+// flatten adds the values of v to values, the keys of nested records, maps
+// and arrays are joined to their parent key.
+func (p *Parser) flatten(key string, v interface{}, values map[string]interface{}) {
+	join := func(k string) string {
+		if key == "" {
+			return k
+		}
+		return key + p.FieldSeparator + k
+	}
+
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, nested := range v {
+			p.flatten(join(k), nested, values)
+		}
+	case []interface{}:
+		for i, nested := range v {
+			p.flatten(join(strconv.Itoa(i)), nested, values)
+		}
+	case int64, float64, bool, string:
+		values[key] = v
+	}
+}
+
+func (p *Parser) ParseLine(line string) (telegraf.Metric, error) {
+	metrics, err := p.Parse([]byte(line))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(metrics) < 1 {
+		return nil, fmt.Errorf("Can not parse the line: %s, for data format: avro ", line)
+	}
+
+	return metrics[0], nil
+}
+
+func (p *Parser) SetDefaultTags(tags map[string]string) {
+	p.DefaultTags = tags
+}
@@ -0,0 +1,278 @@
+package avro
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSchema = `
+{
+  "type": "record",
+  "name": "Reading",
+  "namespace": "sensors",
+  "fields": [
+    {"name": "host", "type": "string"},
+    {"name": "measurement", "type": "string"},
+    {"name": "time", "type": {"type": "long", "logicalType": "timestamp-millis"}},
+    {"name": "temperature", "type": "double"},
+    {"name": "humidity", "type": ["null", "float"]},
+    {"name": "count", "type": "int"},
+    {"name": "active", "type": "boolean"},
+    {"name": "state", "type": {"type": "enum", "name": "State", "symbols": ["OK", "FAILED"]}},
+    {"name": "location", "type": {
+      "type": "record",
+      "name": "Location",
+      "fields": [
+        {"name": "room", "type": "string"},
+        {"name": "floor", "type": "int"}
+      ]
+    }},
+    {"name": "samples", "type": {"type": "array", "items": "long"}},
+    {"name": "labels", "type": {"type": "map", "values": "string"}},
+    {"name": "raw", "type": "bytes"},
+    {"name": "previous", "type": ["null", "Location"]}
+  ]
+}`
+
+type encoder struct {
+	buf []byte
+}
+
+func (e *encoder) long(v int64) *encoder {
+	x := uint64(v<<1) ^ uint64(v>>63)
+	for x >= 0x80 {
+		e.buf = append(e.buf, byte(x)|0x80)
+		x >>= 7
+	}
+	e.buf = append(e.buf, byte(x))
+	return e
+}
+
+func (e *encoder) str(s string) *encoder {
+	e.long(int64(len(s)))
+	e.buf = append(e.buf, s...)
+	return e
+}
+
+func (e *encoder) double(f float64) *encoder {
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], math.Float64bits(f))
+	e.buf = append(e.buf, b[:]...)
+	return e
+}
+
+func (e *encoder) float(f float32) *encoder {
+	var b [4]byte
+	binary.LittleEndian.PutUint32(b[:], math.Float32bits(f))
+	e.buf = append(e.buf, b[:]...)
+	return e
+}
+
+func (e *encoder) boolean(v bool) *encoder {
+	if v {
+		e.buf = append(e.buf, 1)
+	} else {
+		e.buf = append(e.buf, 0)
+	}
+	return e
+}
+
+func testRecord() []byte {
+	e := &encoder{}
+	e.str("server01").str("environment")
+	e.long(1500000000123)
+	e.double(21.5)
+	e.long(1).float(0.5)
+	e.long(7)
+	e.boolean(true)
+	e.long(1)
+	e.str("lab").long(3)
+	// an array written as a sized block followed by a plain block
+	e.long(-2).long(2).long(4).long(5).long(1).long(6).long(0)
+	e.long(1).str("rack").str("r1").long(0)
+	e.str("\x00\x01")
+	e.long(0)
+	return e.buf
+}
+
+func TestParseSchema(t *testing.T) {
+	p := &Parser{
+		MetricName:      "avro",
+		Schema:          testSchema,
+		Measurement:     "measurement",
+		Tags:            []string{"host", "location_room"},
+		Timestamp:       "time",
+		TimestampFormat: "unix_ms",
+	}
+	require.NoError(t, p.Init())
+
+	metrics, err := p.Parse(testRecord())
+	require.NoError(t, err)
+	require.Len(t, metrics, 1)
+
+	m := metrics[0]
+	assert.Equal(t, "environment", m.Name())
+	assert.Equal(t, map[string]string{
+		"host":          "server01",
+		"location_room": "lab",
+	}, m.Tags())
+	assert.Equal(t, map[string]interface{}{
+		"temperature":    21.5,
+		"humidity":       0.5,
+		"count":          int64(7),
+		"active":         true,
+		"state":          "FAILED",
+		"location_floor": int64(3),
+		"samples_0":      int64(4),
+		"samples_1":      int64(5),
+		"samples_2":      int64(6),
+		"labels_rack":    "r1",
+	}, m.Fields())
+	assert.Equal(t, time.Unix(1500000000, 123000000).UTC(), m.Time())
+}
+
+func TestParseFieldSelection(t *testing.T) {
+	p := &Parser{
+		MetricName:     "avro",
+		Schema:         testSchema,
+		Fields:         []string{"temperature", "location.floor"},
+		FieldSeparator: ".",
+	}
+	require.NoError(t, p.Init())
+	p.SetDefaultTags(map[string]string{"source": "kafka"})
+
+	m, err := p.ParseLine(string(testRecord()))
+	require.NoError(t, err)
+	assert.Equal(t, "avro", m.Name())
+	assert.Equal(t, map[string]string{"source": "kafka"}, m.Tags())
+	assert.Equal(t, map[string]interface{}{
+		"temperature":    21.5,
+		"location.floor": int64(3),
+	}, m.Fields())
+}
+
+func TestParseSchemaRegistry(t *testing.T) {
+	requests := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		username, password, ok := r.BasicAuth()
+		if !ok || username != "user" || password != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Path != "/schemas/ids/42" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"schema": testSchema})
+	}))
+	defer ts.Close()
+
+	p := &Parser{
+		MetricName:       "avro",
+		SchemaRegistry:   ts.URL,
+		RegistryUsername: "user",
+		RegistryPassword: "secret",
+	}
+	require.NoError(t, p.Init())
+
+	msg := append([]byte{magicByte, 0, 0, 0, 42}, testRecord()...)
+	for i := 0; i < 3; i++ {
+		metrics, err := p.Parse(msg)
+		require.NoError(t, err)
+		require.Len(t, metrics, 1)
+		assert.Equal(t, "server01", metrics[0].Fields()["host"])
+	}
+	// the schema is only fetched once
+	assert.Equal(t, 1, requests)
+
+	_, err := p.Parse(append([]byte{magicByte, 0, 0, 0, 7}, testRecord()...))
+	assert.Error(t, err)
+
+	_, err = p.Parse(testRecord())
+	assert.Error(t, err)
+}
+
+func TestSchemaRegistryFailures(t *testing.T) {
+	requests := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests < 3 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"schema": testSchema})
+	}))
+	defer ts.Close()
+
+	p := &Parser{MetricName: "avro", SchemaRegistry: ts.URL}
+	require.NoError(t, p.Init())
+
+	msg := append([]byte{magicByte, 0, 0, 0, 42}, testRecord()...)
+	for i := 0; i < 3; i++ {
+		_, err := p.Parse(msg)
+		assert.Error(t, err)
+	}
+	// the failure is cached until the retry delay expires
+	assert.Equal(t, 1, requests)
+	assert.Equal(t, minRetryDelay, p.registry.failures[42].delay)
+
+	p.registry.failures[42].retry = time.Now()
+	_, err := p.Parse(msg)
+	assert.Error(t, err)
+	assert.Equal(t, 2, requests)
+	assert.Equal(t, 2*minRetryDelay, p.registry.failures[42].delay)
+
+	p.registry.failures[42].retry = time.Now()
+	metrics, err := p.Parse(msg)
+	require.NoError(t, err)
+	require.Len(t, metrics, 1)
+	assert.Equal(t, 3, requests)
+	assert.Len(t, p.registry.failures, 0)
+}
+
+func TestParseBlockCount(t *testing.T) {
+	p := &Parser{
+		MetricName: "avro",
+		Schema: `{"type": "record", "name": "Reading", "fields": [
+			{"name": "samples", "type": {"type": "array", "items": "long"}}
+		]}`,
+	}
+	require.NoError(t, p.Init())
+
+	// a count far larger than the message, and the most negative count
+	for _, count := range []int64{1 << 40, math.MinInt64} {
+		e := &encoder{}
+		e.long(count).long(8).long(1).long(0)
+		_, err := p.Parse(e.buf)
+		assert.Error(t, err)
+	}
+}
+
+func TestParseTruncated(t *testing.T) {
+	p := &Parser{MetricName: "avro", Schema: testSchema}
+	require.NoError(t, p.Init())
+
+	buf := testRecord()
+	_, err := p.Parse(buf[:len(buf)-4])
+	assert.Error(t, err)
+}
+
+func TestInvalidSchema(t *testing.T) {
+	p := &Parser{MetricName: "avro", Schema: `{"type": "record", "fields": []}`}
+	assert.Error(t, p.Init())
+
+	p = &Parser{MetricName: "avro", Schema: `{"type": "unknown"}`}
+	assert.Error(t, p.Init())
+
+	p = &Parser{MetricName: "avro"}
+	assert.Error(t, p.Init())
+}
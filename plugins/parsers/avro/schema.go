@@ -0,0 +1,317 @@
+package avro
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+)
+
+// schema is a parsed Avro schema, only the parts needed to decode the binary
+// encoding are kept.
+type schema struct {
+	typ     string
+	name    string
+	fields  []recordField
+	symbols []string
+	items   *schema
+	union   []*schema
+	size    int
+}
+
+type recordField struct {
+	name   string
+	schema *schema
+}
+
+// parseSchema parses the JSON representation of an Avro schema.
+func parseSchema(text string) (*schema, error) {
+	var v interface{}
+	if err := json.Unmarshal([]byte(text), &v); err != nil {
+		return nil, fmt.Errorf("invalid schema: %s", err)
+	}
+	return newSchemaParser().parse(v, "")
+}
+
+type schemaParser struct {
+	named map[string]*schema
+}
+
+func newSchemaParser() *schemaParser {
+	return &schemaParser{named: make(map[string]*schema)}
+}
+
+func (sp *schemaParser) parse(v interface{}, namespace string) (*schema, error) {
+	switch v := v.(type) {
+	case string:
+		return sp.parseName(v, namespace)
+	case []interface{}:
+		s := &schema{typ: "union"}
+		for _, branch := range v {
+			b, err := sp.parse(branch, namespace)
+			if err != nil {
+				return nil, err
+			}
+			s.union = append(s.union, b)
+		}
+		return s, nil
+	case map[string]interface{}:
+		return sp.parseComplex(v, namespace)
+	default:
+		return nil, fmt.Errorf("invalid schema: unexpected %v", v)
+	}
+}
+
+func (sp *schemaParser) parseName(name string, namespace string) (*schema, error) {
+	switch name {
+	case "null", "boolean", "int", "long", "float", "double", "bytes", "string":
+		return &schema{typ: name}, nil
+	}
+	if s, ok := sp.named[fullName(name, namespace)]; ok {
+		return s, nil
+	}
+	if s, ok := sp.named[name]; ok {
+		return s, nil
+	}
+	return nil, fmt.Errorf("invalid schema: unknown type %q", name)
+}
+
+func (sp *schemaParser) parseComplex(v map[string]interface{}, namespace string) (*schema, error) {
+	typ, ok := v["type"].(string)
+	if !ok {
+		// the type itself is a schema, ie {"type": {"type": "array", ...}}
+		return sp.parse(v["type"], namespace)
+	}
+
+	switch typ {
+	case "record", "error", "enum", "fixed":
+		name, _ := v["name"].(string)
+		if name == "" {
+			return nil, fmt.Errorf("invalid schema: %s without name", typ)
+		}
+		if ns, ok := v["namespace"].(string); ok {
+			namespace = ns
+		}
+		if i := strings.LastIndex(name, "."); i >= 0 {
+			namespace = name[:i]
+		}
+		s := &schema{typ: typ, name: fullName(name, namespace)}
+		sp.named[s.name] = s
+		return s, sp.parseNamed(s, v, namespace)
+	case "array":
+		items, err := sp.parse(v["items"], namespace)
+		if err != nil {
+			return nil, err
+		}
+		return &schema{typ: "array", items: items}, nil
+	case "map":
+		values, err := sp.parse(v["values"], namespace)
+		if err != nil {
+			return nil, err
+		}
+		return &schema{typ: "map", items: values}, nil
+	default:
+		// primitive types, possibly annotated with a logical type
+		return sp.parseName(typ, namespace)
+	}
+}
+
+func (sp *schemaParser) parseNamed(s *schema, v map[string]interface{}, namespace string) error {
+	switch s.typ {
+	case "record", "error":
+		s.typ = "record"
+		fields, _ := v["fields"].([]interface{})
+		for _, f := range fields {
+			fm, ok := f.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("invalid schema: field of %s is not an object", s.name)
+			}
+			name, _ := fm["name"].(string)
+			fs, err := sp.parse(fm["type"], namespace)
+			if err != nil {
+				return err
+			}
+			s.fields = append(s.fields, recordField{name: name, schema: fs})
+		}
+	case "enum":
+		symbols, _ := v["symbols"].([]interface{})
+		for _, sym := range symbols {
+			str, _ := sym.(string)
+			s.symbols = append(s.symbols, str)
+		}
+	case "fixed":
+		size, _ := v["size"].(float64)
+		s.size = int(size)
+	}
+	return nil
+}
+
+func fullName(name, namespace string) string {
+	if namespace == "" || strings.Contains(name, ".") {
+		return name
+	}
+	return namespace + "." + name
+}
+
+// decoder reads values of the Avro binary encoding from buf.
+type decoder struct {
+	buf []byte
+	pos int
+}
+
+// decode returns the next value of schema s. Records and maps are returned
+// as map[string]interface{}, arrays as []interface{}, enums as their symbol
+// and bytes and fixed values as []byte.
+func (d *decoder) decode(s *schema) (interface{}, error) {
+	switch s.typ {
+	case "null":
+		return nil, nil
+	case "boolean":
+		if d.pos >= len(d.buf) {
+			return nil, io.ErrUnexpectedEOF
+		}
+		d.pos++
+		return d.buf[d.pos-1] != 0, nil
+	case "int", "long":
+		return d.long()
+	case "float":
+		if len(d.buf)-d.pos < 4 {
+			return nil, io.ErrUnexpectedEOF
+		}
+		bits := binary.LittleEndian.Uint32(d.buf[d.pos:])
+		d.pos += 4
+		return float64(math.Float32frombits(bits)), nil
+	case "double":
+		if len(d.buf)-d.pos < 8 {
+			return nil, io.ErrUnexpectedEOF
+		}
+		bits := binary.LittleEndian.Uint64(d.buf[d.pos:])
+		d.pos += 8
+		return math.Float64frombits(bits), nil
+	case "bytes":
+		return d.bytes()
+	case "string":
+		b, err := d.bytes()
+		return string(b), err
+	case "fixed":
+		return d.next(s.size)
+	case "enum":
+		i, err := d.long()
+		if err != nil {
+			return nil, err
+		}
+		if i < 0 || int(i) >= len(s.symbols) {
+			return nil, fmt.Errorf("enum %s: invalid index %d", s.name, i)
+		}
+		return s.symbols[i], nil
+	case "union":
+		i, err := d.long()
+		if err != nil {
+			return nil, err
+		}
+		if i < 0 || int(i) >= len(s.union) {
+			return nil, fmt.Errorf("invalid union index %d", i)
+		}
+		return d.decode(s.union[i])
+	case "record":
+		rec := make(map[string]interface{}, len(s.fields))
+		for _, f := range s.fields {
+			v, err := d.decode(f.schema)
+			if err != nil {
+				return nil, fmt.Errorf("field %s.%s: %s", s.name, f.name, err)
+			}
+			rec[f.name] = v
+		}
+		return rec, nil
+	case "array":
+		var values []interface{}
+		err := d.blocks(func() error {
+			v, err := d.decode(s.items)
+			values = append(values, v)
+			return err
+		})
+		return values, err
+	case "map":
+		values := make(map[string]interface{})
+		err := d.blocks(func() error {
+			k, err := d.bytes()
+			if err != nil {
+				return err
+			}
+			v, err := d.decode(s.items)
+			values[string(k)] = v
+			return err
+		})
+		return values, err
+	default:
+		return nil, fmt.Errorf("unsupported type %s", s.typ)
+	}
+}
+
+// blocks calls item for every item of a block encoded array or map.
+func (d *decoder) blocks(item func() error) error {
+	for {
+		n, err := d.long()
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return nil
+		}
+		if n < 0 {
+			// a negative count is followed by the size of the block
+			n = -n
+			if _, err := d.long(); err != nil {
+				return err
+			}
+		}
+		// every item takes at least a byte, except for nulls, which keeps
+		// corrupt counts from looping or allocating without bounds
+		if n < 0 || n > int64(len(d.buf)-d.pos) {
+			return fmt.Errorf("block of %d items exceeds the %d remaining bytes", n, len(d.buf)-d.pos)
+		}
+		for i := int64(0); i < n; i++ {
+			if err := item(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (d *decoder) long() (int64, error) {
+	var x uint64
+	for shift := uint(0); shift < 64; shift += 7 {
+		if d.pos >= len(d.buf) {
+			return 0, io.ErrUnexpectedEOF
+		}
+		b := d.buf[d.pos]
+		d.pos++
+		x |= uint64(b&0x7f) << shift
+		if b < 0x80 {
+			return int64(x>>1) ^ -int64(x&1), nil
+		}
+	}
+	return 0, fmt.Errorf("varint overflows a 64-bit integer")
+}
+
+func (d *decoder) bytes() ([]byte, error) {
+	n, err := d.long()
+	if err != nil {
+		return nil, err
+	}
+	if n < 0 {
+		return nil, fmt.Errorf("negative length %d", n)
+	}
+	return d.next(int(n))
+}
+
+func (d *decoder) next(n int) ([]byte, error) {
+	if len(d.buf)-d.pos < n {
+		return nil, io.ErrUnexpectedEOF
+	}
+	b := d.buf[d.pos : d.pos+n]
+	d.pos += n
+	return b, nil
+}
@@ -0,0 +1,113 @@
+package avro
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// delays before fetching again a schema that could not be fetched, the
+	// delay doubles on every failure
+	minRetryDelay = time.Second
+	maxRetryDelay = 5 * time.Minute
+)
+
+// schemaRegistry fetches schemas by id from a Confluent schema registry and
+// caches them, the schema of an id never changes.  Failures are cached too,
+// so that messages of an unknown id do not query the registry every time.
+type schemaRegistry struct {
+	url      string
+	username string
+	password string
+	client   *http.Client
+
+	mu       sync.Mutex
+	cache    map[int32]*schema
+	failures map[int32]*failure
+}
+
+// failure is the last error fetching a schema, it is returned until retry.
+type failure struct {
+	err   error
+	retry time.Time
+	delay time.Duration
+}
+
+func newSchemaRegistry(url, username, password string) *schemaRegistry {
+	return &schemaRegistry{
+		url:      strings.TrimSuffix(url, "/"),
+		username: username,
+		password: password,
+		client:   &http.Client{Timeout: 10 * time.Second},
+		cache:    make(map[int32]*schema),
+		failures: make(map[int32]*failure),
+	}
+}
+
+func (r *schemaRegistry) get(id int32) (*schema, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if s, ok := r.cache[id]; ok {
+		return s, nil
+	}
+	f, ok := r.failures[id]
+	if ok && time.Now().Before(f.retry) {
+		return nil, f.err
+	}
+
+	s, err := r.fetch(id)
+	if err != nil {
+		delay := minRetryDelay
+		if ok {
+			delay = f.delay * 2
+			if delay > maxRetryDelay {
+				delay = maxRetryDelay
+			}
+		}
+		r.failures[id] = &failure{err: err, retry: time.Now().Add(delay), delay: delay}
+		return nil, err
+	}
+	delete(r.failures, id)
+	r.cache[id] = s
+	return s, nil
+}
+
+func (r *schemaRegistry) fetch(id int32) (*schema, error) {
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/schemas/ids/%d", r.url, id), nil)
+	if err != nil {
+		return nil, err
+	}
+	if r.username != "" || r.password != "" {
+		req.SetBasicAuth(r.username, r.password)
+	}
+	req.Header.Set("Accept", "application/vnd.schemaregistry.v1+json")
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("unable to fetch schema %d: %s", id, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unable to fetch schema %d: received status code %d (%s)",
+			id, resp.StatusCode, http.StatusText(resp.StatusCode))
+	}
+
+	var body struct {
+		Schema string `json:"schema"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("unable to decode schema %d: %s", id, err)
+	}
+
+	s, err := parseSchema(body.Schema)
+	if err != nil {
+		return nil, fmt.Errorf("schema %d: %s", id, err)
+	}
+	return s, nil
+}
@@ -36,19 +36,29 @@ func NewCollectdParser(
 	popts := network.ParseOpts{}
 
 	switch securityLevel {
-	case "none":
+	case "", "none":
 		popts.SecurityLevel = network.None
 	case "sign":
 		popts.SecurityLevel = network.Sign
 	case "encrypt":
 		popts.SecurityLevel = network.Encrypt
 	default:
-		popts.SecurityLevel = network.None
+		return nil, fmt.Errorf("invalid collectd security level %q, must be one of none, sign or encrypt",
+			securityLevel)
 	}
 
 	if authFile == "" {
 		authFile = DefaultAuthFile
 	}
+	// The auth file is read when the first signed or encrypted part arrives,
+	// fail early if it is required but can not be read.
+	if popts.SecurityLevel != network.None {
+		f, err := os.Open(authFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read collectd auth file: %s", err)
+		}
+		f.Close()
+	}
 	popts.PasswordLookup = network.NewAuthFile(authFile)
 
 	for _, path := range typesDB {
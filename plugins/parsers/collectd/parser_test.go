@@ -2,6 +2,9 @@ package collectd
 
 import (
 	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"collectd.org/api"
@@ -260,6 +263,51 @@ func TestParse_EncryptSecurityLevel(t *testing.T) {
 	require.NotNil(t, err)
 }
 
+func TestNewCollectdParser_SecurityLevel(t *testing.T) {
+	_, err := NewCollectdParser("", "encrypted", []string{})
+	require.NotNil(t, err)
+
+	_, err = NewCollectdParser("/nonexistent/auth_file", "sign", []string{})
+	require.NotNil(t, err)
+
+	// the auth file is only required with a security level
+	_, err = NewCollectdParser("/nonexistent/auth_file", "none", []string{})
+	require.Nil(t, err)
+}
+
+func TestParse_AuthFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "collectd")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	authFile := filepath.Join(dir, "auth_file")
+	err = ioutil.WriteFile(authFile, []byte("# comment\nuser0: bar\nuser1: baz\n"), 0600)
+	require.Nil(t, err)
+
+	parser, err := NewCollectdParser(authFile, "encrypt", []string{})
+	require.Nil(t, err)
+
+	buf, err := writeValueList(singleMetric.vl)
+	require.Nil(t, err)
+	buf.Encrypt("user1", "baz")
+	bytes, err := buf.Bytes()
+	require.Nil(t, err)
+
+	metrics, err := parser.Parse(bytes)
+	require.Nil(t, err)
+	assertEqualMetrics(t, singleMetric.expected, metrics)
+
+	// Unknown user
+	buf, err = writeValueList(singleMetric.vl)
+	require.Nil(t, err)
+	buf.Encrypt("user2", "baz")
+	bytes, err = buf.Bytes()
+	require.Nil(t, err)
+
+	_, err = parser.Parse(bytes)
+	require.NotNil(t, err)
+}
+
 func TestParseLine(t *testing.T) {
 	buf, err := writeValueList(singleMetric.vl)
 	require.Nil(t, err)
@@ -0,0 +1,318 @@
+package csv
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/metric"
+)
+
+// Parser converts CSV documents into metrics, one metric per row. Column
+// names are either configured or read from the first HeaderRowCount rows,
+// with the cells of multiple header rows joined by an underscore.
+type Parser struct {
+	MetricName        string
+	HeaderRowCount    int
+	SkipRows          int
+	SkipColumns       int
+	Delimiter         string
+	Comment           string
+	TrimSpace         bool
+	ColumnNames       []string
+	ColumnTypes       map[string]string
+	TagColumns        []string
+	MeasurementColumn string
+	TimestampColumn   string
+	TimestampFormat   string
+	SkipIf            map[string]string
+	DefaultTags       map[string]string
+
+	skipIf map[string]*regexp.Regexp
+}
+
+// Compile validates the column types and compiles the row-skip expressions,
+// it must be called before parsing.
+func (p *Parser) Compile() error {
+	if p.HeaderRowCount == 0 && len(p.ColumnNames) == 0 {
+		return fmt.Errorf("one of csv_header_row_count or csv_column_names is required")
+	}
+	if len([]rune(p.Delimiter)) > 1 {
+		return fmt.Errorf("csv_delimiter must be a single character, got %q", p.Delimiter)
+	}
+	if len([]rune(p.Comment)) > 1 {
+		return fmt.Errorf("csv_comment must be a single character, got %q", p.Comment)
+	}
+	for column, typ := range p.ColumnTypes {
+		switch typ {
+		case "int", "float", "bool", "string":
+		default:
+			return fmt.Errorf("invalid type %q for column %q", typ, column)
+		}
+	}
+
+	p.skipIf = make(map[string]*regexp.Regexp, len(p.SkipIf))
+	for column, expr := range p.SkipIf {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return fmt.Errorf("invalid skip expression for column %q: %s", column, err)
+		}
+		p.skipIf[column] = re
+	}
+	return nil
+}
+
+func (p *Parser) Parse(buf []byte) ([]telegraf.Metric, error) {
+	metrics := make([]telegraf.Metric, 0)
+	err := p.ParseStream(bytes.NewReader(buf), func(m telegraf.Metric) error {
+		metrics = append(metrics, m)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return metrics, nil
+}
+
+// ParseStream reads the rows of r one by one and passes each resulting
+// metric to fn, so that large documents never have to be held in memory.
+// Parsing stops at the first error returned by fn.
+func (p *Parser) ParseStream(r io.Reader, fn func(telegraf.Metric) error) error {
+	reader := p.newReader(r)
+
+	for i := 0; i < p.SkipRows; i++ {
+		if _, err := reader.Read(); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+	}
+
+	columns := p.ColumnNames
+	if p.HeaderRowCount > 0 {
+		header := make([][]string, 0, p.HeaderRowCount)
+		for i := 0; i < p.HeaderRowCount; i++ {
+			row, err := reader.Read()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			header = append(header, row)
+		}
+		if len(p.ColumnNames) == 0 {
+			columns = joinHeader(header, p.SkipColumns)
+		}
+	}
+
+	now := time.Now().UTC()
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		m, err := p.parseRow(columns, row, now)
+		if err != nil {
+			return err
+		}
+		if m == nil {
+			continue
+		}
+		if err := fn(m); err != nil {
+			return err
+		}
+	}
+}
+
+func (p *Parser) newReader(r io.Reader) *csv.Reader {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = p.TrimSpace
+	if p.Delimiter != "" {
+		reader.Comma = []rune(p.Delimiter)[0]
+	}
+	if p.Comment != "" {
+		reader.Comment = []rune(p.Comment)[0]
+	}
+	return reader
+}
+
+// joinHeader merges the header rows into a single column name per column.
+// Empty cells of all but the last header row continue the name of the
+// column to their left, as spreadsheet exports leave merged cells empty.
+func joinHeader(header [][]string, skip int) []string {
+	width := 0
+	for _, row := range header {
+		if len(row) > width {
+			width = len(row)
+		}
+	}
+
+	columns := make([]string, 0, width)
+	for col := skip; col < width; col++ {
+		var parts []string
+		for i, row := range header {
+			var cell string
+			if col < len(row) {
+				cell = strings.TrimSpace(row[col])
+			}
+			if cell == "" && i < len(header)-1 {
+				for c := col - 1; c >= skip && c < len(row); c-- {
+					if cell = strings.TrimSpace(row[c]); cell != "" {
+						break
+					}
+				}
+			}
+			if cell != "" {
+				parts = append(parts, cell)
+			}
+		}
+		columns = append(columns, strings.Join(parts, "_"))
+	}
+	return columns
+}
+
+func (p *Parser) parseRow(columns []string, row []string, now time.Time) (telegraf.Metric, error) {
+	if len(row) <= p.SkipColumns {
+		return nil, nil
+	}
+	row = row[p.SkipColumns:]
+
+	values := make(map[string]string, len(columns))
+	for i, column := range columns {
+		if i >= len(row) || column == "" {
+			continue
+		}
+		v := row[i]
+		if p.TrimSpace {
+			v = strings.TrimSpace(v)
+		}
+		values[column] = v
+	}
+
+	for column, re := range p.skipIf {
+		if v, ok := values[column]; ok && re.MatchString(v) {
+			return nil, nil
+		}
+	}
+
+	name := p.MetricName
+	if p.MeasurementColumn != "" {
+		if v := values[p.MeasurementColumn]; v != "" {
+			name = v
+		}
+		delete(values, p.MeasurementColumn)
+	}
+
+	t := now
+	if p.TimestampColumn != "" {
+		if v := values[p.TimestampColumn]; v != "" {
+			var err error
+			t, err = internal.ParseTimestamp(v, p.TimestampFormat)
+			if err != nil {
+				return nil, err
+			}
+		}
+		delete(values, p.TimestampColumn)
+	}
+
+	tags := make(map[string]string)
+	for k, v := range p.DefaultTags {
+		tags[k] = v
+	}
+	for _, column := range p.TagColumns {
+		if v := values[column]; v != "" {
+			tags[column] = v
+		}
+		delete(values, column)
+	}
+
+	fields := make(map[string]interface{}, len(values))
+	for column, v := range values {
+		if v == "" {
+			continue
+		}
+		value, err := convert(v, p.ColumnTypes[column])
+		if err != nil {
+			return nil, fmt.Errorf("unable to convert column %q: %s", column, err)
+		}
+		fields[column] = value
+	}
+
+	if len(fields) == 0 {
+		return nil, nil
+	}
+	return metric.New(name, tags, fields, t)
+}
+
+// ParseLine parses a single row, the column names must be configured as
+// there is no header to read them from.
+func (p *Parser) ParseLine(line string) (telegraf.Metric, error) {
+	if len(p.ColumnNames) == 0 {
+		return nil, fmt.Errorf("csv_column_names is required to parse single lines")
+	}
+
+	row, err := p.newReader(strings.NewReader(line)).Read()
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := p.parseRow(p.ColumnNames, row, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+	if m == nil {
+		return nil, fmt.Errorf("Can not parse the line: %s, for data format: csv ", line)
+	}
+	return m, nil
+}
+
+func (p *Parser) SetDefaultTags(tags map[string]string) {
+	p.DefaultTags = tags
+}
+
+// convert returns the value as the given type, or as the most specific type
+// when no type is given.
+func convert(v string, typ string) (interface{}, error) {
+	switch typ {
+	case "":
+		if i, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return i, nil
+		}
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			return f, nil
+		}
+		if b, err := strconv.ParseBool(v); err == nil {
+			return b, nil
+		}
+		return v, nil
+	case "int":
+		if i, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return i, nil
+		}
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return nil, err
+		}
+		return int64(f), nil
+	case "float":
+		return strconv.ParseFloat(v, 64)
+	case "bool":
+		return strconv.ParseBool(v)
+	default:
+		return v, nil
+	}
+}
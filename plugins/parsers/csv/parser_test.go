@@ -0,0 +1,182 @@
+package csv
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseHeader(t *testing.T) {
+	p := &Parser{
+		MetricName:      "csv",
+		HeaderRowCount:  1,
+		TagColumns:      []string{"host"},
+		TimestampColumn: "time",
+	}
+	require.NoError(t, p.Compile())
+
+	metrics, err := p.Parse([]byte("host,time,value,ok,state\nserver01,1500000000,42,true,up\nserver02,1500000001,1.5,false,down\n"))
+	require.NoError(t, err)
+	require.Len(t, metrics, 2)
+
+	assert.Equal(t, "csv", metrics[0].Name())
+	assert.Equal(t, map[string]string{"host": "server01"}, metrics[0].Tags())
+	assert.Equal(t, map[string]interface{}{
+		"value": int64(42),
+		"ok":    true,
+		"state": "up",
+	}, metrics[0].Fields())
+	assert.Equal(t, time.Unix(1500000000, 0).UTC(), metrics[0].Time())
+
+	assert.Equal(t, map[string]interface{}{
+		"value": 1.5,
+		"ok":    false,
+		"state": "down",
+	}, metrics[1].Fields())
+}
+
+func TestParseMultiRowHeader(t *testing.T) {
+	p := &Parser{
+		MetricName:     "report",
+		HeaderRowCount: 2,
+		SkipRows:       1,
+		SkipColumns:    1,
+		TagColumns:     []string{"host"},
+	}
+	require.NoError(t, p.Compile())
+
+	doc := `Exported report
+#,,cpu,,disk
+1,host,user,system,used
+2,server01,10,5,70
+`
+	metrics, err := p.Parse([]byte(doc))
+	require.NoError(t, err)
+	require.Len(t, metrics, 1)
+
+	assert.Equal(t, map[string]string{"host": "server01"}, metrics[0].Tags())
+	assert.Equal(t, map[string]interface{}{
+		"cpu_user":   int64(10),
+		"cpu_system": int64(5),
+		"disk_used":  int64(70),
+	}, metrics[0].Fields())
+}
+
+func TestParseColumnTypes(t *testing.T) {
+	p := &Parser{
+		MetricName:  "csv",
+		ColumnNames: []string{"id", "value", "count", "flag"},
+		ColumnTypes: map[string]string{
+			"id":    "string",
+			"value": "float",
+			"count": "int",
+			"flag":  "bool",
+		},
+		Delimiter: ";",
+		TrimSpace: true,
+	}
+	require.NoError(t, p.Compile())
+
+	m, err := p.ParseLine("0042; 3; 7.9; 1")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"id":    "0042",
+		"value": 3.0,
+		"count": int64(7),
+		"flag":  true,
+	}, m.Fields())
+
+	_, err = p.ParseLine("1;a;2;true")
+	assert.Error(t, err)
+}
+
+func TestParseSkipIf(t *testing.T) {
+	p := &Parser{
+		MetricName:        "csv",
+		HeaderRowCount:    1,
+		Comment:           "#",
+		MeasurementColumn: "name",
+		SkipIf: map[string]string{
+			"name": "^(Total|Subtotal)$",
+		},
+	}
+	require.NoError(t, p.Compile())
+
+	doc := `name,value
+# comment
+disk,1
+Subtotal,1
+mem,2
+Total,3
+`
+	metrics, err := p.Parse([]byte(doc))
+	require.NoError(t, err)
+	require.Len(t, metrics, 2)
+	assert.Equal(t, "disk", metrics[0].Name())
+	assert.Equal(t, "mem", metrics[1].Name())
+	assert.Equal(t, map[string]interface{}{"value": int64(2)}, metrics[1].Fields())
+}
+
+func TestParseStream(t *testing.T) {
+	p := &Parser{
+		MetricName:     "csv",
+		HeaderRowCount: 1,
+	}
+	require.NoError(t, p.Compile())
+
+	doc := "value\n" + strings.Repeat("1\n", 1000)
+
+	var count int
+	err := p.ParseStream(strings.NewReader(doc), func(m telegraf.Metric) error {
+		count++
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1000, count)
+
+	// parsing stops when the callback fails
+	count = 0
+	stop := errors.New("stop")
+	err = p.ParseStream(strings.NewReader(doc), func(m telegraf.Metric) error {
+		count++
+		if count == 10 {
+			return stop
+		}
+		return nil
+	})
+	assert.Equal(t, stop, err)
+	assert.Equal(t, 10, count)
+}
+
+func TestParseDefaultTags(t *testing.T) {
+	p := &Parser{
+		MetricName:     "csv",
+		HeaderRowCount: 1,
+	}
+	require.NoError(t, p.Compile())
+	p.SetDefaultTags(map[string]string{"source": "report"})
+
+	metrics, err := p.Parse([]byte("value\n1\n"))
+	require.NoError(t, err)
+	require.Len(t, metrics, 1)
+	assert.Equal(t, map[string]string{"source": "report"}, metrics[0].Tags())
+}
+
+func TestCompileErrors(t *testing.T) {
+	p := &Parser{MetricName: "csv"}
+	assert.Error(t, p.Compile())
+
+	p = &Parser{MetricName: "csv", HeaderRowCount: 1, Delimiter: ";;"}
+	assert.Error(t, p.Compile())
+
+	p = &Parser{MetricName: "csv", HeaderRowCount: 1, ColumnTypes: map[string]string{"a": "uint"}}
+	assert.Error(t, p.Compile())
+
+	p = &Parser{MetricName: "csv", HeaderRowCount: 1, SkipIf: map[string]string{"a": "("}}
+	assert.Error(t, p.Compile())
+}
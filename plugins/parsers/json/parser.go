@@ -175,7 +175,7 @@ func (f *JSONFlattener) FullFlattenJSON(
 func isarray(buf []byte) bool {
 	ia := bytes.IndexByte(buf, '[')
 	ib := bytes.IndexByte(buf, '{')
-	if ia > -1 && ia < ib {
+	if ia > -1 && (ib == -1 || ia < ib) {
 		return true
 	} else {
 		return false
@@ -349,6 +349,11 @@ func TestParseValidJSONArray(t *testing.T) {
 		"b_c": float64(8),
 	}, metrics[1].Fields())
 	assert.Equal(t, map[string]string{}, metrics[1].Tags())
+
+	// Empty array, such as the last page of a paginated API
+	metrics, err = parser.Parse([]byte("[]\n"))
+	assert.NoError(t, err)
+	assert.Len(t, metrics, 0)
 }
 
 func TestParseArrayWithTagKeys(t *testing.T) {
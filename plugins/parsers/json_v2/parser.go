@@ -0,0 +1,213 @@
+package json_v2
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/tidwall/gjson"
+)
+
+var (
+	utf8BOM = []byte("\xef\xbb\xbf")
+)
+
+// Parser converts JSON documents into metrics using GJSON path queries. The
+// object path selects the objects to turn into metrics, nested arrays are
+// expanded so that every object becomes one metric, and all other paths are
+// relative to the selected object.
+type Parser struct {
+	MetricName      string
+	ObjectPath      string
+	MeasurementPath string
+	TimestampPath   string
+	TimestampFormat string
+	Tags            map[string]string
+	Fields          map[string]string
+	FieldTypes      map[string]string
+	DefaultTags     map[string]string
+}
+
+func (p *Parser) Parse(buf []byte) ([]telegraf.Metric, error) {
+	buf = bytes.TrimSpace(buf)
+	buf = bytes.TrimPrefix(buf, utf8BOM)
+	if len(buf) == 0 {
+		return make([]telegraf.Metric, 0), nil
+	}
+
+	var v interface{}
+	if err := json.Unmarshal(buf, &v); err != nil {
+		return nil, fmt.Errorf("unable to parse out as JSON, %s", err)
+	}
+
+	root := gjson.ParseBytes(buf)
+	if p.ObjectPath != "" {
+		root = root.Get(p.ObjectPath)
+	}
+
+	now := time.Now().UTC()
+	metrics := make([]telegraf.Metric, 0)
+	for _, obj := range expand(root) {
+		m, err := p.parseObject(obj, now)
+		if err != nil {
+			return nil, err
+		}
+		if m != nil {
+			metrics = append(metrics, m)
+		}
+	}
+	return metrics, nil
+}
+
+func (p *Parser) parseObject(obj gjson.Result, now time.Time) (telegraf.Metric, error) {
+	name := p.MetricName
+	if p.MeasurementPath != "" {
+		if r := obj.Get(p.MeasurementPath); r.Exists() && r.String() != "" {
+			name = r.String()
+		}
+	}
+
+	t := now
+	if p.TimestampPath != "" {
+		if r := obj.Get(p.TimestampPath); r.Exists() {
+			// the raw numbers keep the precision of the timestamps in ns
+			ts := r.String()
+			if r.Type == gjson.Number {
+				ts = r.Raw
+			}
+			var err error
+			t, err = internal.ParseTimestamp(ts, p.TimestampFormat)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	tags := make(map[string]string)
+	for k, v := range p.DefaultTags {
+		tags[k] = v
+	}
+	for k, path := range p.Tags {
+		if r := obj.Get(path); r.Exists() && r.String() != "" {
+			tags[k] = r.String()
+		}
+	}
+
+	fields := make(map[string]interface{})
+	for k, path := range p.Fields {
+		r := obj.Get(path)
+		if !r.Exists() || r.Type == gjson.Null {
+			continue
+		}
+		v, err := convert(r, p.FieldTypes[k])
+		if err != nil {
+			return nil, fmt.Errorf("unable to convert field %q: %s", k, err)
+		}
+		if v != nil {
+			fields[k] = v
+		}
+	}
+
+	if len(fields) == 0 {
+		return nil, nil
+	}
+	return metric.New(name, tags, fields, t)
+}
+
+func (p *Parser) ParseLine(line string) (telegraf.Metric, error) {
+	metrics, err := p.Parse([]byte(line + "\n"))
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(metrics) < 1 {
+		return nil, fmt.Errorf("Can not parse the line: %s, for data format: json_v2 ", line)
+	}
+
+	return metrics[0], nil
+}
+
+func (p *Parser) SetDefaultTags(tags map[string]string) {
+	p.DefaultTags = tags
+}
+
+// expand returns the objects contained in r, descending into nested arrays.
+func expand(r gjson.Result) []gjson.Result {
+	if !isArray(r) {
+		if isObject(r) {
+			return []gjson.Result{r}
+		}
+		return nil
+	}
+
+	var objs []gjson.Result
+	for _, elem := range r.Array() {
+		objs = append(objs, expand(elem)...)
+	}
+	return objs
+}
+
+func isArray(r gjson.Result) bool {
+	return r.Type == gjson.JSON && len(r.Raw) > 0 && r.Raw[0] == '['
+}
+
+func isObject(r gjson.Result) bool {
+	return r.Type == gjson.JSON && len(r.Raw) > 0 && r.Raw[0] == '{'
+}
+
+// convert returns the value of r as the given type, or using the JSON type
+// when no type is given. Objects and arrays are not valid field values.
+func convert(r gjson.Result, typ string) (interface{}, error) {
+	if isObject(r) || isArray(r) {
+		return nil, nil
+	}
+
+	switch typ {
+	case "":
+		switch r.Type {
+		case gjson.Number:
+			return r.Float(), nil
+		case gjson.True, gjson.False:
+			return r.Bool(), nil
+		default:
+			return r.String(), nil
+		}
+	case "int":
+		if r.Type == gjson.String {
+			if i, err := strconv.ParseInt(r.Str, 10, 64); err == nil {
+				return i, nil
+			}
+			f, err := strconv.ParseFloat(r.Str, 64)
+			if err != nil {
+				return nil, err
+			}
+			return int64(f), nil
+		}
+		return r.Int(), nil
+	case "uint":
+		if r.Type == gjson.String {
+			return strconv.ParseUint(r.Str, 10, 64)
+		}
+		return r.Uint(), nil
+	case "float":
+		if r.Type == gjson.String {
+			return strconv.ParseFloat(r.Str, 64)
+		}
+		return r.Float(), nil
+	case "bool":
+		if r.Type == gjson.String {
+			return strconv.ParseBool(r.Str)
+		}
+		return r.Bool(), nil
+	case "string":
+		return r.String(), nil
+	default:
+		return nil, fmt.Errorf("unknown type %q", typ)
+	}
+}
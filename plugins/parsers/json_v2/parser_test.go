@@ -0,0 +1,189 @@
+package json_v2
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const volumes = `
+{
+  "cluster": "prod",
+  "time": 1525168800,
+  "volumes": [
+    {
+      "name": "gv0",
+      "status": "Started",
+      "bricks": [
+        {"host": "node1", "path": "/data/b1", "online": true, "port": "49152", "size": {"total": 100, "free": 40.5}},
+        {"host": "node2", "path": "/data/b1", "online": false, "port": null, "size": {"total": 100, "free": 99}}
+      ]
+    },
+    {
+      "name": "gv1",
+      "status": "Stopped",
+      "bricks": [
+        {"host": "node3", "path": "/data/b2", "online": true, "port": "49153", "size": {"total": 50, "free": 1}}
+      ]
+    }
+  ]
+}`
+
+func TestParseNestedArrays(t *testing.T) {
+	p := &Parser{
+		MetricName: "gluster",
+		ObjectPath: "volumes.#.bricks",
+		Tags: map[string]string{
+			"host": "host",
+			"path": "path",
+		},
+		Fields: map[string]string{
+			"online":     "online",
+			"port":       "port",
+			"size_total": "size.total",
+			"size_free":  "size.free",
+		},
+		FieldTypes: map[string]string{
+			"port":       "int",
+			"size_total": "int",
+		},
+	}
+
+	metrics, err := p.Parse([]byte(volumes))
+	require.NoError(t, err)
+	require.Len(t, metrics, 3)
+
+	assert.Equal(t, "gluster", metrics[0].Name())
+	assert.Equal(t, map[string]string{"host": "node1", "path": "/data/b1"}, metrics[0].Tags())
+	assert.Equal(t, map[string]interface{}{
+		"online":     true,
+		"port":       int64(49152),
+		"size_total": int64(100),
+		"size_free":  float64(40.5),
+	}, metrics[0].Fields())
+
+	// null values are skipped
+	assert.Equal(t, map[string]interface{}{
+		"online":     false,
+		"size_total": int64(100),
+		"size_free":  float64(99),
+	}, metrics[1].Fields())
+
+	assert.Equal(t, map[string]string{"host": "node3", "path": "/data/b2"}, metrics[2].Tags())
+}
+
+func TestParseMeasurementAndTimestamp(t *testing.T) {
+	p := &Parser{
+		MetricName:      "json",
+		MeasurementPath: "cluster",
+		TimestampPath:   "time",
+		Fields: map[string]string{
+			"volumes": "volumes.#",
+			"first":   "volumes.0.name",
+		},
+		DefaultTags: map[string]string{"source": "test"},
+	}
+
+	metrics, err := p.Parse([]byte(volumes))
+	require.NoError(t, err)
+	require.Len(t, metrics, 1)
+
+	assert.Equal(t, "prod", metrics[0].Name())
+	assert.Equal(t, time.Unix(1525168800, 0).UTC(), metrics[0].Time())
+	assert.Equal(t, map[string]string{"source": "test"}, metrics[0].Tags())
+	assert.Equal(t, map[string]interface{}{
+		"volumes": float64(2),
+		"first":   "gv0",
+	}, metrics[0].Fields())
+}
+
+func TestParseTimestampLayout(t *testing.T) {
+	p := &Parser{
+		MetricName:      "json",
+		TimestampPath:   "ts",
+		TimestampFormat: time.RFC3339,
+		Fields:          map[string]string{"value": "value"},
+		FieldTypes:      map[string]string{"value": "float"},
+	}
+
+	m, err := p.ParseLine(`{"ts": "2018-05-01T10:00:00Z", "value": "1.5"}`)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2018, 5, 1, 10, 0, 0, 0, time.UTC), m.Time())
+	assert.Equal(t, map[string]interface{}{"value": float64(1.5)}, m.Fields())
+}
+
+func TestParseTimestampNanoseconds(t *testing.T) {
+	p := &Parser{
+		MetricName:      "json",
+		TimestampPath:   "ts",
+		TimestampFormat: "unix_ns",
+		Fields:          map[string]string{"value": "value"},
+	}
+
+	m, err := p.ParseLine(`{"ts": 1536869008123456789, "value": 1}`)
+	require.NoError(t, err)
+	assert.Equal(t, time.Unix(1536869008, 123456789).UTC(), m.Time())
+}
+
+func TestParseTypeCoercion(t *testing.T) {
+	p := &Parser{
+		MetricName: "json",
+		Fields: map[string]string{
+			"i": "a",
+			"u": "b",
+			"b": "c",
+			"s": "d",
+		},
+		FieldTypes: map[string]string{
+			"i": "int",
+			"u": "uint",
+			"b": "bool",
+			"s": "string",
+		},
+	}
+
+	m, err := p.ParseLine(`{"a": 1.9, "b": "42", "c": "true", "d": 5}`)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"i": int64(1),
+		"u": uint64(42),
+		"b": true,
+		"s": "5",
+	}, m.Fields())
+}
+
+func TestParseErrors(t *testing.T) {
+	p := &Parser{
+		MetricName: "json",
+		Fields:     map[string]string{"value": "value"},
+		FieldTypes: map[string]string{"value": "int"},
+	}
+
+	_, err := p.Parse([]byte(`{"value": "abc"}`))
+	assert.Error(t, err)
+
+	_, err = p.Parse([]byte(`{"value": `))
+	assert.Error(t, err)
+
+	p.FieldTypes["value"] = "complex"
+	_, err = p.Parse([]byte(`{"value": 1}`))
+	assert.Error(t, err)
+}
+
+func TestParseEmpty(t *testing.T) {
+	p := &Parser{
+		MetricName: "json",
+		ObjectPath: "missing",
+		Fields:     map[string]string{"value": "value"},
+	}
+
+	metrics, err := p.Parse([]byte(`{"value": 1}`))
+	require.NoError(t, err)
+	assert.Len(t, metrics, 0)
+
+	metrics, err = p.Parse([]byte(``))
+	require.NoError(t, err)
+	assert.Len(t, metrics, 0)
+}
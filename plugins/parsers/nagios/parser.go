@@ -1,15 +1,28 @@
 package nagios
 
 import (
+	"errors"
+	"fmt"
+	"os/exec"
 	"regexp"
 	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/metric"
 )
 
+// Service states of the Nagios plugin API, as returned in the exit code of
+// a check.
+var states = map[string]int{
+	"OK":       0,
+	"WARNING":  1,
+	"CRITICAL": 2,
+	"UNKNOWN":  3,
+}
+
 type NagiosParser struct {
 	MetricName  string
 	DefaultTags map[string]string
@@ -17,12 +30,22 @@ type NagiosParser struct {
 
 // Got from Alignak
 // https://github.com/Alignak-monitoring/alignak/blob/develop/alignak/misc/perfdata.py
-var perfSplitRegExp, _ = regexp.Compile(`([^=]+=\S+)`)
-var nagiosRegExp, _ = regexp.Compile(`^([^=]+)=([\d\.\-\+eE]+)([\w\/%]*);?([\d\.\-\+eE:~@]+)?;?([\d\.\-\+eE:~@]+)?;?([\d\.\-\+eE]+)?;?([\d\.\-\+eE]+)?;?\s*`)
+var perfSplitRegExp = regexp.MustCompile(`('(?:[^']|'')+'|[^\s'=]+)=(\S+)`)
+var nagiosRegExp = regexp.MustCompile(`^([\d\.\-\+eE]+)([\w\/%]*)$`)
+
+// stateRegExp matches the status at the start of the service output, ie
+// "PING OK - Packet loss = 0%" or "WARNING: load average 5.1"
+var stateRegExp = regexp.MustCompile(`^(?:[^\s:]+\s+)?(OK|WARNING|CRITICAL|UNKNOWN)\b`)
 
 func (p *NagiosParser) ParseLine(line string) (telegraf.Metric, error) {
 	metrics, err := p.Parse([]byte(line))
-	return metrics[0], err
+	if err != nil {
+		return nil, err
+	}
+	if len(metrics) == 0 {
+		return nil, errors.New("no metric in line")
+	}
+	return metrics[0], nil
 }
 
 func (p *NagiosParser) SetDefaultTags(tags map[string]string) {
@@ -31,92 +54,202 @@ func (p *NagiosParser) SetDefaultTags(tags map[string]string) {
 
 //> rta,host=absol,unit=ms critical=6000,min=0,value=0.332,warning=4000 1456374625003628099
 //> pl,host=absol,unit=% critical=90,min=0,value=0,warning=80 1456374625003693967
+//> nagios_state,host=absol service_output="PING OK - Packet loss = 0%",state=0i 1456374625003693967
 
 func (p *NagiosParser) Parse(buf []byte) ([]telegraf.Metric, error) {
 	metrics := make([]telegraf.Metric, 0)
+	now := time.Now().UTC()
+
 	// Convert to string
-	out := string(buf)
+	out := strings.TrimRight(string(buf), "\n")
+	if out == "" {
+		return metrics, nil
+	}
 	// Prepare output for splitting
 	// Delete escaped pipes
 	out = strings.Replace(out, `\|`, "___PROTECT_PIPE___", -1)
-	// Split lines and get the first one
+	restore := func(s string) string {
+		return strings.Replace(s, "___PROTECT_PIPE___", `\|`, -1)
+	}
+
+	// The first line holds the service output and perfdata, the following
+	// lines the long service output and, after a pipe, more perfdata
 	lines := strings.Split(out, "\n")
-	// Split output and perfdatas
-	data_splitted := strings.Split(lines[0], "|")
-	if len(data_splitted) <= 1 {
-		// No pipe == no perf data
-		return nil, nil
+	var serviceOutput, longOutput string
+	var perfdatas []string
+
+	firstLine := strings.SplitN(lines[0], "|", 2)
+	serviceOutput = strings.TrimSpace(restore(firstLine[0]))
+	if len(firstLine) == 2 {
+		perfdatas = append(perfdatas, restore(firstLine[1]))
 	}
-	// Get perfdatas
-	perfdatas := data_splitted[1]
-	// Add escaped pipes
-	perfdatas = strings.Replace(perfdatas, "___PROTECT_PIPE___", `\|`, -1)
-	// Split perfs
-	unParsedPerfs := perfSplitRegExp.FindAllSubmatch([]byte(perfdatas), -1)
-	// Iterate on all perfs
-	for _, unParsedPerfs := range unParsedPerfs {
-		// Get metrics
-		// Trim perf
-		trimedPerf := strings.Trim(string(unParsedPerfs[0]), " ")
-		// Parse perf
-		perf := nagiosRegExp.FindAllSubmatch([]byte(trimedPerf), -1)
-		// Bad string
-		if len(perf) == 0 {
-			continue
-		}
-		if len(perf[0]) <= 2 {
-			continue
+	if len(lines) > 1 {
+		rest := strings.SplitN(strings.Join(lines[1:], "\n"), "|", 2)
+		longOutput = strings.TrimSpace(restore(rest[0]))
+		if len(rest) == 2 {
+			perfdatas = append(perfdatas, restore(rest[1]))
 		}
-		if perf[0][1] == nil || perf[0][2] == nil {
-			continue
-		}
-		fieldName := string(perf[0][1])
-		tags := make(map[string]string)
-		if perf[0][3] != nil {
-			str := string(perf[0][3])
-			if str != "" {
-				tags["unit"] = str
+	}
+
+	for _, perfdata := range perfdatas {
+		for _, perf := range perfSplitRegExp.FindAllStringSubmatch(perfdata, -1) {
+			m, err := p.parsePerf(perf[1], perf[2], now)
+			if err != nil {
+				return nil, err
+			}
+			if m != nil {
+				metrics = append(metrics, m)
 			}
 		}
-		fields := make(map[string]interface{})
-		f, err := strconv.ParseFloat(string(perf[0][2]), 64)
-		if err == nil {
-			fields["value"] = f
+	}
+
+	fields := map[string]interface{}{
+		"service_output": serviceOutput,
+	}
+	if longOutput != "" {
+		fields["long_service_output"] = longOutput
+	}
+	if match := stateRegExp.FindStringSubmatch(serviceOutput); match != nil {
+		fields["state"] = states[match[1]]
+	}
+	state, err := metric.New("nagios_state", p.tags(nil), fields, now)
+	if err != nil {
+		return nil, err
+	}
+	metrics = append(metrics, state)
+
+	return metrics, nil
+}
+
+// parsePerf parses a single perfdata token, 'label'=value[UOM];[warn];[crit];[min];[max]
+func (p *NagiosParser) parsePerf(label, data string, now time.Time) (telegraf.Metric, error) {
+	if strings.HasPrefix(label, "'") {
+		label = strings.Replace(label[1:len(label)-1], "''", "'", -1)
+	}
+
+	parts := strings.Split(data, ";")
+	value := nagiosRegExp.FindStringSubmatch(parts[0])
+	// Bad string or unknown value "U"
+	if value == nil {
+		return nil, nil
+	}
+	f, err := strconv.ParseFloat(value[1], 64)
+	if err != nil {
+		return nil, nil
+	}
+
+	tags := make(map[string]string)
+	if value[2] != "" {
+		tags["unit"] = value[2]
+	}
+	fields := map[string]interface{}{
+		"value": f,
+	}
+
+	if len(parts) > 1 {
+		addThreshold(fields, "warning", parts[1])
+	}
+	if len(parts) > 2 {
+		addThreshold(fields, "critical", parts[2])
+	}
+	if len(parts) > 3 {
+		if f, err := strconv.ParseFloat(parts[3], 64); err == nil {
+			fields["min"] = f
 		}
-		// TODO should we set empty field
-		// if metric if there is no data ?
-		if perf[0][4] != nil {
-			f, err := strconv.ParseFloat(string(perf[0][4]), 64)
-			if err == nil {
-				fields["warning"] = f
-			}
+	}
+	if len(parts) > 4 {
+		if f, err := strconv.ParseFloat(parts[4], 64); err == nil {
+			fields["max"] = f
 		}
-		if perf[0][5] != nil {
-			f, err := strconv.ParseFloat(string(perf[0][5]), 64)
-			if err == nil {
-				fields["critical"] = f
-			}
+	}
+
+	return metric.New(label, p.tags(tags), fields, now)
+}
+
+// addThreshold adds the threshold range to fields.  A plain number is added
+// as is, other ranges as the bounds outside of which, or with a leading "@"
+// inside of which, the check alerts:
+//
+//	10:     name_lt=10
+//	~:10    name_gt=10
+//	10:20   name_lt=10,name_gt=20
+//	@10:20  name_ge=10,name_le=20
+func addThreshold(fields map[string]interface{}, name string, threshold string) {
+	if threshold == "" {
+		return
+	}
+	if f, err := strconv.ParseFloat(threshold, 64); err == nil {
+		fields[name] = f
+		return
+	}
+
+	inside := strings.HasPrefix(threshold, "@")
+	threshold = strings.TrimPrefix(threshold, "@")
+
+	start, end := "0", threshold
+	if i := strings.Index(threshold, ":"); i >= 0 {
+		start, end = threshold[:i], threshold[i+1:]
+	}
+
+	lower, upper := "_lt", "_gt"
+	if inside {
+		lower, upper = "_ge", "_le"
+	}
+	if start != "~" {
+		if f, err := strconv.ParseFloat(start, 64); err == nil {
+			fields[name+lower] = f
 		}
-		if perf[0][6] != nil {
-			f, err := strconv.ParseFloat(string(perf[0][6]), 64)
-			if err == nil {
-				fields["min"] = f
-			}
+	}
+	if end != "" {
+		if f, err := strconv.ParseFloat(end, 64); err == nil {
+			fields[name+upper] = f
 		}
-		if perf[0][7] != nil {
-			f, err := strconv.ParseFloat(string(perf[0][7]), 64)
-			if err == nil {
-				fields["max"] = f
-			}
+	}
+}
+
+func (p *NagiosParser) tags(tags map[string]string) map[string]string {
+	if tags == nil {
+		tags = make(map[string]string)
+	}
+	for k, v := range p.DefaultTags {
+		if _, ok := tags[k]; !ok {
+			tags[k] = v
 		}
-		// Create metric
-		metric, err := metric.New(fieldName, tags, fields, time.Now().UTC())
-		if err != nil {
-			return nil, err
+	}
+	return tags
+}
+
+// TryAddState sets the state of the nagios_state metric to the exit code of
+// the check, which takes precedence over the state found in the output.
+// When the check did not exit, ie it timed out, the state is UNKNOWN and the
+// error is returned along with the metrics.
+func TryAddState(runErr error, metrics []telegraf.Metric) ([]telegraf.Metric, error) {
+	state, stateErr := exitState(runErr)
+
+	for _, m := range metrics {
+		if m.Name() == "nagios_state" {
+			m.RemoveField("state")
+			m.AddField("state", state)
+			return metrics, stateErr
 		}
-		// Add Metric
-		metrics = append(metrics, metric)
 	}
 
-	return metrics, nil
+	m, err := metric.New("nagios_state", nil, map[string]interface{}{"state": state}, time.Now().UTC())
+	if err != nil {
+		return metrics, err
+	}
+	return append(metrics, m), stateErr
+}
+
+// exitState returns the state of the exit code of the check.
+func exitState(runErr error) (int, error) {
+	if runErr == nil {
+		return states["OK"], nil
+	}
+	if exiterr, ok := runErr.(*exec.ExitError); ok {
+		if status, ok := exiterr.Sys().(syscall.WaitStatus); ok {
+			return status.ExitStatus(), nil
+		}
+	}
+	return states["UNKNOWN"], fmt.Errorf("unable to get nagios plugin exit code: %s", runErr)
 }
@@ -1,6 +1,8 @@
 package nagios
 
 import (
+	"errors"
+	"os/exec"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -24,7 +26,7 @@ func TestParseValidOutput(t *testing.T) {
 	// Output1
 	metrics, err := parser.Parse([]byte(validOutput1))
 	require.NoError(t, err)
-	assert.Len(t, metrics, 2)
+	assert.Len(t, metrics, 3)
 	// rta
 	assert.Equal(t, "rta", metrics[0].Name())
 	assert.Equal(t, map[string]interface{}{
@@ -44,11 +46,18 @@ func TestParseValidOutput(t *testing.T) {
 		"max":      float64(100),
 	}, metrics[1].Fields())
 	assert.Equal(t, map[string]string{"unit": "%"}, metrics[1].Tags())
+	// state
+	assert.Equal(t, "nagios_state", metrics[2].Name())
+	assert.Equal(t, map[string]interface{}{
+		"service_output":      "PING OK - Packet loss = 0%, RTA = 0.30 ms",
+		"long_service_output": "This is a long output\nwith three lines",
+		"state":               int64(0),
+	}, metrics[2].Fields())
 
 	// Output2
 	metrics, err = parser.Parse([]byte(validOutput2))
 	require.NoError(t, err)
-	assert.Len(t, metrics, 1)
+	assert.Len(t, metrics, 2)
 	// time
 	assert.Equal(t, "time", metrics[0].Name())
 	assert.Equal(t, map[string]interface{}{
@@ -61,7 +70,7 @@ func TestParseValidOutput(t *testing.T) {
 	// Output3
 	metrics, err = parser.Parse([]byte(validOutput3))
 	require.NoError(t, err)
-	assert.Len(t, metrics, 1)
+	assert.Len(t, metrics, 2)
 	// time
 	assert.Equal(t, "time", metrics[0].Name())
 	assert.Equal(t, map[string]interface{}{
@@ -75,14 +84,83 @@ func TestParseInvalidOutput(t *testing.T) {
 		MetricName: "nagios_test",
 	}
 
-	// invalidOutput3
+	// invalidOutput3, only the state
 	metrics, err := parser.Parse([]byte(invalidOutput3))
 	require.NoError(t, err)
-	assert.Len(t, metrics, 0)
+	assert.Len(t, metrics, 1)
+	assert.Equal(t, "nagios_state", metrics[0].Name())
 
 	// invalidOutput4
 	metrics, err = parser.Parse([]byte(invalidOutput4))
 	require.NoError(t, err)
-	assert.Len(t, metrics, 0)
+	assert.Len(t, metrics, 1)
+	assert.Equal(t, "nagios_state", metrics[0].Name())
+
+}
+
+func TestParseThresholds(t *testing.T) {
+	parser := NagiosParser{}
+
+	out := "DISK WARNING - free space: / 3326 MB|'/ free'=3326MB;@0:4000;~:2000;0;16000 'it''s'=1;10:;5:8\n" +
+		"long output|used=12%;80;90"
+	metrics, err := parser.Parse([]byte(out))
+	require.NoError(t, err)
+	require.Len(t, metrics, 4)
+
+	assert.Equal(t, "/ free", metrics[0].Name())
+	assert.Equal(t, map[string]interface{}{
+		"value":       float64(3326),
+		"warning_ge":  float64(0),
+		"warning_le":  float64(4000),
+		"critical_gt": float64(2000),
+		"min":         float64(0),
+		"max":         float64(16000),
+	}, metrics[0].Fields())
+	assert.Equal(t, map[string]string{"unit": "MB"}, metrics[0].Tags())
+
+	assert.Equal(t, "it's", metrics[1].Name())
+	assert.Equal(t, map[string]interface{}{
+		"value":       float64(1),
+		"warning_lt":  float64(10),
+		"critical_lt": float64(5),
+		"critical_gt": float64(8),
+	}, metrics[1].Fields())
+
+	// perfdata of the long output
+	assert.Equal(t, "used", metrics[2].Name())
+
+	assert.Equal(t, map[string]interface{}{
+		"service_output":      "DISK WARNING - free space: / 3326 MB",
+		"long_service_output": "long output",
+		"state":               int64(1),
+	}, metrics[3].Fields())
+}
+
+func TestTryAddState(t *testing.T) {
+	parser := NagiosParser{}
+	metrics, err := parser.Parse([]byte("CHECK OK - fine|x=1"))
+	require.NoError(t, err)
+
+	err = exec.Command("sh", "-c", "exit 2").Run()
+	require.Error(t, err)
+
+	metrics, err = TryAddState(err, metrics)
+	require.NoError(t, err)
+	require.Len(t, metrics, 2)
+	state, ok := metrics[1].GetField("state")
+	require.True(t, ok)
+	assert.Equal(t, int64(2), state)
+	assert.Len(t, metrics[1].FieldList(), 2)
+
+	// a state is added when the output has none
+	metrics, err = TryAddState(nil, nil)
+	require.NoError(t, err)
+	require.Len(t, metrics, 1)
+	assert.Equal(t, map[string]interface{}{"state": int64(0)}, metrics[0].Fields())
 
+	// the state is unknown when the check did not exit
+	metrics, err = TryAddState(errors.New("timeout"), nil)
+	assert.Error(t, err)
+	require.Len(t, metrics, 1)
+	assert.Equal(t, map[string]interface{}{"state": int64(3)}, metrics[0].Fields())
 }
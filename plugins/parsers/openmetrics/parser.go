@@ -0,0 +1,525 @@
+package openmetrics
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+)
+
+// suffixes lists the sample name suffixes allowed for each metric type.
+var suffixes = map[string][]string{
+	"counter":        {"_total", "_created"},
+	"gauge":          {""},
+	"unknown":        {""},
+	"histogram":      {"_bucket", "_count", "_sum", "_created"},
+	"gaugehistogram": {"_bucket", "_gcount", "_gsum"},
+	"summary":        {"", "_count", "_sum", "_created"},
+	"info":           {"_info"},
+	"stateset":       {""},
+}
+
+// Parser parses the OpenMetrics text exposition format. Samples of a metric
+// family sharing the same labels are combined into one metric named after the
+// family, using the same fields as the prometheus input, and exemplars are
+// optionally added as separate metrics.
+type Parser struct {
+	Exemplars   bool
+	DefaultTags map[string]string
+}
+
+type family struct {
+	name string
+	typ  string
+	help string
+	unit string
+
+	// samples were seen, metadata must precede them
+	started bool
+}
+
+type series struct {
+	name   string
+	tags   map[string]string
+	fields map[string]interface{}
+	typ    string
+	time   time.Time
+}
+
+type sample struct {
+	name      string
+	labels    map[string]string
+	value     float64
+	timestamp *time.Time
+	exemplar  *exemplar
+}
+
+type exemplar struct {
+	labels    map[string]string
+	value     float64
+	timestamp *time.Time
+}
+
+func (p *Parser) Parse(buf []byte) ([]telegraf.Metric, error) {
+	text := string(buf)
+	if !strings.HasSuffix(text, "\n") {
+		return nil, fmt.Errorf("missing newline after # EOF")
+	}
+	lines := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+	if len(lines) == 0 || lines[len(lines)-1] != "# EOF" {
+		return nil, fmt.Errorf("missing # EOF at the end of the exposition")
+	}
+	lines = lines[:len(lines)-1]
+
+	now := time.Now()
+	var (
+		cur       *family
+		seen      = make(map[string]bool)
+		order     []string
+		all       = make(map[string]*series)
+		exemplars []telegraf.Metric
+	)
+
+	for n, line := range lines {
+		lineErr := func(format string, args ...interface{}) error {
+			return fmt.Errorf("line %d: %s", n+1, fmt.Sprintf(format, args...))
+		}
+
+		if line == "" {
+			return nil, lineErr("empty line")
+		}
+
+		if strings.HasPrefix(line, "#") {
+			parts := strings.SplitN(line, " ", 4)
+			if len(parts) < 3 || parts[0] != "#" {
+				return nil, lineErr("invalid metadata %q", line)
+			}
+			name := parts[2]
+			if cur == nil || cur.name != name {
+				if seen[name] {
+					return nil, lineErr("metric family %s is not contiguous", name)
+				}
+				if !validName(name) {
+					return nil, lineErr("invalid metric name %q", name)
+				}
+				cur = &family{name: name, typ: "unknown"}
+				seen[name] = true
+			}
+			if cur.started {
+				return nil, lineErr("metadata for %s after its samples", name)
+			}
+
+			var value string
+			if len(parts) == 4 {
+				value = parts[3]
+			}
+			switch parts[1] {
+			case "TYPE":
+				if _, ok := suffixes[value]; !ok {
+					return nil, lineErr("invalid type %q", value)
+				}
+				cur.typ = value
+			case "HELP":
+				cur.help = unescape(value)
+			case "UNIT":
+				if value != "" && !strings.HasSuffix(name, "_"+value) {
+					return nil, lineErr("metric name %s does not end with its unit %s", name, value)
+				}
+				cur.unit = value
+			default:
+				return nil, lineErr("invalid metadata %q", line)
+			}
+			continue
+		}
+
+		s, err := parseSample(line)
+		if err != nil {
+			return nil, lineErr("%s", err)
+		}
+
+		suffix, ok := matchFamily(cur, s.name)
+		if !ok {
+			// samples without metadata are a family of unknown type
+			if seen[s.name] {
+				return nil, lineErr("metric family %s is not contiguous", s.name)
+			}
+			cur = &family{name: s.name, typ: "unknown"}
+			seen[s.name] = true
+			suffix = ""
+		}
+		cur.started = true
+
+		if s.exemplar != nil && suffix != "_total" && suffix != "_bucket" {
+			return nil, lineErr("exemplars are only allowed on counters and histogram buckets")
+		}
+
+		tags := make(map[string]string, len(s.labels))
+		for k, v := range s.labels {
+			tags[k] = v
+		}
+		field, err := fieldName(cur, suffix, tags)
+		if err != nil {
+			return nil, lineErr("%s", err)
+		}
+
+		key := cur.name + "\x00" + labelKey(tags)
+		m, ok := all[key]
+		if !ok {
+			m = &series{
+				name:   cur.name,
+				tags:   tags,
+				fields: make(map[string]interface{}),
+				typ:    cur.typ,
+				time:   now,
+			}
+			all[key] = m
+			order = append(order, key)
+		}
+		if s.timestamp != nil {
+			m.time = *s.timestamp
+		}
+		if !math.IsNaN(s.value) {
+			m.fields[field] = s.value
+		}
+
+		if s.exemplar != nil && p.Exemplars {
+			e, err := p.exemplarMetric(cur.name, s, m.time)
+			if err != nil {
+				return nil, err
+			}
+			exemplars = append(exemplars, e)
+		}
+	}
+
+	metrics := make([]telegraf.Metric, 0, len(order)+len(exemplars))
+	for _, key := range order {
+		s := all[key]
+		if len(s.fields) == 0 {
+			continue
+		}
+		tags := make(map[string]string, len(s.tags)+len(p.DefaultTags))
+		for k, v := range p.DefaultTags {
+			tags[k] = v
+		}
+		for k, v := range s.tags {
+			tags[k] = v
+		}
+		m, err := metric.New(s.name, tags, s.fields, s.time, valueType(s.typ))
+		if err != nil {
+			return nil, err
+		}
+		metrics = append(metrics, m)
+	}
+	return append(metrics, exemplars...), nil
+}
+
+func (p *Parser) exemplarMetric(name string, s *sample, t time.Time) (telegraf.Metric, error) {
+	tags := make(map[string]string)
+	for k, v := range p.DefaultTags {
+		tags[k] = v
+	}
+	for k, v := range s.labels {
+		tags[k] = v
+	}
+	for k, v := range s.exemplar.labels {
+		tags[k] = v
+	}
+	if s.exemplar.timestamp != nil {
+		t = *s.exemplar.timestamp
+	}
+	return metric.New(name+"_exemplar", tags, map[string]interface{}{"value": s.exemplar.value}, t)
+}
+
+// ParseLine is not supported, an OpenMetrics exposition is only valid as a
+// whole.
+func (p *Parser) ParseLine(line string) (telegraf.Metric, error) {
+	return nil, fmt.Errorf("openmetrics: parsing single lines is not supported")
+}
+
+func (p *Parser) SetDefaultTags(tags map[string]string) {
+	p.DefaultTags = tags
+}
+
+// matchFamily returns the suffix of name if it is a sample of f.
+func matchFamily(f *family, name string) (string, bool) {
+	if f == nil || !strings.HasPrefix(name, f.name) {
+		return "", false
+	}
+	suffix := name[len(f.name):]
+	for _, s := range suffixes[f.typ] {
+		if s == suffix {
+			return suffix, true
+		}
+	}
+	return "", false
+}
+
+// fieldName returns the field of the sample and removes the labels that are
+// part of the field name from tags.
+func fieldName(f *family, suffix string, tags map[string]string) (string, error) {
+	switch suffix {
+	case "_created":
+		return "created", nil
+	case "_count", "_gcount":
+		return "count", nil
+	case "_sum", "_gsum":
+		return "sum", nil
+	case "_total":
+		return "counter", nil
+	case "_info":
+		return "info", nil
+	case "_bucket":
+		le, ok := tags["le"]
+		if !ok {
+			return "", fmt.Errorf("bucket of %s without le label", f.name)
+		}
+		bound, err := parseFloat(le)
+		if err != nil {
+			return "", fmt.Errorf("invalid le label %q", le)
+		}
+		delete(tags, "le")
+		return fmt.Sprint(bound), nil
+	}
+
+	switch f.typ {
+	case "gauge":
+		return "gauge", nil
+	case "summary":
+		q, ok := tags["quantile"]
+		if !ok {
+			return "", fmt.Errorf("summary %s without quantile label", f.name)
+		}
+		quantile, err := parseFloat(q)
+		if err != nil {
+			return "", fmt.Errorf("invalid quantile label %q", q)
+		}
+		delete(tags, "quantile")
+		return fmt.Sprint(quantile), nil
+	case "stateset":
+		state, ok := tags[f.name]
+		if !ok {
+			return "", fmt.Errorf("stateset %s without %s label", f.name, f.name)
+		}
+		delete(tags, f.name)
+		return state, nil
+	default:
+		return "value", nil
+	}
+}
+
+func valueType(typ string) telegraf.ValueType {
+	switch typ {
+	case "counter":
+		return telegraf.Counter
+	case "gauge":
+		return telegraf.Gauge
+	case "summary":
+		return telegraf.Summary
+	case "histogram", "gaugehistogram":
+		return telegraf.Histogram
+	default:
+		return telegraf.Untyped
+	}
+}
+
+// parseSample parses a sample line:
+//
+//	name{label="value",...} value [timestamp] [# {label="value"} value [timestamp]]
+func parseSample(line string) (*sample, error) {
+	s := &sample{labels: map[string]string{}}
+
+	i := strings.IndexAny(line, "{ ")
+	if i <= 0 {
+		return nil, fmt.Errorf("invalid sample %q", line)
+	}
+	s.name = line[:i]
+	if !validName(s.name) {
+		return nil, fmt.Errorf("invalid metric name %q", s.name)
+	}
+	rest := line[i:]
+
+	if strings.HasPrefix(rest, "{") {
+		var err error
+		s.labels, rest, err = parseLabels(rest)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var exemplarText string
+	if i := strings.Index(rest, " # "); i >= 0 {
+		exemplarText = rest[i+3:]
+		rest = rest[:i]
+	}
+
+	if !strings.HasPrefix(rest, " ") {
+		return nil, fmt.Errorf("missing value in %q", line)
+	}
+	parts := strings.Split(rest[1:], " ")
+	if len(parts) > 2 {
+		return nil, fmt.Errorf("unexpected text in %q", line)
+	}
+
+	var err error
+	if s.value, err = parseFloat(parts[0]); err != nil {
+		return nil, fmt.Errorf("invalid value %q", parts[0])
+	}
+	if len(parts) == 2 {
+		if s.timestamp, err = parseTimestamp(parts[1]); err != nil {
+			return nil, err
+		}
+	}
+
+	if exemplarText != "" {
+		if s.exemplar, err = parseExemplar(exemplarText); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+func parseExemplar(text string) (*exemplar, error) {
+	if !strings.HasPrefix(text, "{") {
+		return nil, fmt.Errorf("invalid exemplar %q", text)
+	}
+	labels, rest, err := parseLabels(text)
+	if err != nil {
+		return nil, err
+	}
+
+	parts := strings.Split(strings.TrimPrefix(rest, " "), " ")
+	if len(parts) < 1 || len(parts) > 2 || !strings.HasPrefix(rest, " ") {
+		return nil, fmt.Errorf("invalid exemplar %q", text)
+	}
+
+	e := &exemplar{labels: labels}
+	if e.value, err = parseFloat(parts[0]); err != nil {
+		return nil, fmt.Errorf("invalid exemplar value %q", parts[0])
+	}
+	if len(parts) == 2 {
+		if e.timestamp, err = parseTimestamp(parts[1]); err != nil {
+			return nil, err
+		}
+	}
+	return e, nil
+}
+
+// parseLabels parses a label set starting with "{" and returns the text
+// following it.
+func parseLabels(text string) (map[string]string, string, error) {
+	labels := make(map[string]string)
+	i := 1
+	for {
+		if i >= len(text) {
+			return nil, "", fmt.Errorf("unterminated label set")
+		}
+		if text[i] == '}' {
+			return labels, text[i+1:], nil
+		}
+
+		eq := strings.IndexByte(text[i:], '=')
+		if eq <= 0 {
+			return nil, "", fmt.Errorf("invalid label set %q", text)
+		}
+		name := text[i : i+eq]
+		if !validLabelName(name) {
+			return nil, "", fmt.Errorf("invalid label name %q", name)
+		}
+		if _, ok := labels[name]; ok {
+			return nil, "", fmt.Errorf("duplicate label %q", name)
+		}
+		i += eq + 1
+
+		if i >= len(text) || text[i] != '"' {
+			return nil, "", fmt.Errorf("label %s: value must be quoted", name)
+		}
+		i++
+		var value []byte
+		for {
+			if i >= len(text) {
+				return nil, "", fmt.Errorf("label %s: unterminated value", name)
+			}
+			c := text[i]
+			if c == '"' {
+				i++
+				break
+			}
+			if c == '\\' && i+1 < len(text) {
+				i++
+				switch text[i] {
+				case 'n':
+					c = '\n'
+				case '"', '\\':
+					c = text[i]
+				default:
+					return nil, "", fmt.Errorf("label %s: invalid escape \\%c", name, text[i])
+				}
+			}
+			value = append(value, c)
+			i++
+		}
+		labels[name] = string(value)
+
+		if i < len(text) && text[i] == ',' {
+			i++
+		}
+	}
+}
+
+func parseFloat(s string) (float64, error) {
+	switch s {
+	case "+Inf":
+		return math.Inf(1), nil
+	case "-Inf":
+		return math.Inf(-1), nil
+	case "NaN":
+		return math.NaN(), nil
+	}
+	return strconv.ParseFloat(s, 64)
+}
+
+// parseTimestamp parses a timestamp in seconds since the epoch.
+func parseTimestamp(s string) (*time.Time, error) {
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timestamp %q", s)
+	}
+	sec, frac := math.Modf(f)
+	t := time.Unix(int64(sec), int64(frac*1e9)).UTC()
+	return &t, nil
+}
+
+func unescape(s string) string {
+	r := strings.NewReplacer(`\\`, `\`, `\n`, "\n", `\"`, `"`)
+	return r.Replace(s)
+}
+
+func labelKey(tags map[string]string) string {
+	pairs := make([]string, 0, len(tags))
+	for k, v := range tags {
+		pairs = append(pairs, k+"\x00"+v)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, "\x00")
+}
+
+func validName(name string) bool {
+	for i, c := range name {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c == '_', c == ':':
+		case c >= '0' && c <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return name != ""
+}
+
+func validLabelName(name string) bool {
+	return validName(name) && !strings.Contains(name, ":")
+}
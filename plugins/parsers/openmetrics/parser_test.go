@@ -0,0 +1,161 @@
+package openmetrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const exposition = `# TYPE acme_http_requests counter
+# HELP acme_http_requests Number of requests.
+acme_http_requests_total{code="200"} 17 1520879607.789 # {trace_id="KOO5S4vxi0o"} 0.67 1520879607.7
+acme_http_requests_created{code="200"} 1520430000.123
+# TYPE temperature_celsius gauge
+# UNIT temperature_celsius celsius
+temperature_celsius{room="lab"} 21.5
+# TYPE rpc_duration_seconds histogram
+rpc_duration_seconds_bucket{le="0.5"} 10 # {trace_id="abc"} 0.31
+rpc_duration_seconds_bucket{le="+Inf"} 12
+rpc_duration_seconds_count 12
+rpc_duration_seconds_sum 4.2
+# TYPE gc_seconds summary
+gc_seconds{quantile="0.99"} 0.02
+gc_seconds_count 3
+gc_seconds_sum 0.04
+# TYPE build info
+build_info{version="1.7.0",revision="abc"} 1
+# TYPE power_supply stateset
+power_supply{power_supply="on",unit="1"} 1
+power_supply{power_supply="off",unit="1"} 0
+untyped_sample{path="/a \"b\""} 3
+# EOF
+`
+
+func find(metrics []telegraf.Metric, name string) telegraf.Metric {
+	for _, m := range metrics {
+		if m.Name() == name {
+			return m
+		}
+	}
+	return nil
+}
+
+func TestParse(t *testing.T) {
+	p := &Parser{}
+	metrics, err := p.Parse([]byte(exposition))
+	require.NoError(t, err)
+	require.Len(t, metrics, 7)
+
+	m := find(metrics, "acme_http_requests")
+	require.NotNil(t, m)
+	assert.Equal(t, telegraf.Counter, m.Type())
+	assert.Equal(t, map[string]string{"code": "200"}, m.Tags())
+	assert.Equal(t, map[string]interface{}{
+		"counter": 17.0,
+		"created": 1520430000.123,
+	}, m.Fields())
+	assert.Equal(t, time.Unix(1520879607, 789000000).UTC(), m.Time().Round(time.Millisecond))
+
+	m = find(metrics, "temperature_celsius")
+	require.NotNil(t, m)
+	assert.Equal(t, telegraf.Gauge, m.Type())
+	assert.Equal(t, map[string]interface{}{"gauge": 21.5}, m.Fields())
+
+	m = find(metrics, "rpc_duration_seconds")
+	require.NotNil(t, m)
+	assert.Equal(t, telegraf.Histogram, m.Type())
+	assert.Equal(t, map[string]string{}, m.Tags())
+	assert.Equal(t, map[string]interface{}{
+		"0.5":   10.0,
+		"+Inf":  12.0,
+		"count": 12.0,
+		"sum":   4.2,
+	}, m.Fields())
+
+	m = find(metrics, "gc_seconds")
+	require.NotNil(t, m)
+	assert.Equal(t, telegraf.Summary, m.Type())
+	assert.Equal(t, map[string]interface{}{
+		"0.99":  0.02,
+		"count": 3.0,
+		"sum":   0.04,
+	}, m.Fields())
+
+	m = find(metrics, "build")
+	require.NotNil(t, m)
+	assert.Equal(t, map[string]string{"version": "1.7.0", "revision": "abc"}, m.Tags())
+	assert.Equal(t, map[string]interface{}{"info": 1.0}, m.Fields())
+
+	m = find(metrics, "power_supply")
+	require.NotNil(t, m)
+	assert.Equal(t, map[string]string{"unit": "1"}, m.Tags())
+	assert.Equal(t, map[string]interface{}{"on": 1.0, "off": 0.0}, m.Fields())
+
+	m = find(metrics, "untyped_sample")
+	require.NotNil(t, m)
+	assert.Equal(t, telegraf.Untyped, m.Type())
+	assert.Equal(t, map[string]string{"path": `/a "b"`}, m.Tags())
+	assert.Equal(t, map[string]interface{}{"value": 3.0}, m.Fields())
+
+	assert.Nil(t, find(metrics, "acme_http_requests_exemplar"))
+}
+
+func TestParseExemplars(t *testing.T) {
+	p := &Parser{Exemplars: true}
+	p.SetDefaultTags(map[string]string{"source": "app"})
+	metrics, err := p.Parse([]byte(exposition))
+	require.NoError(t, err)
+	require.Len(t, metrics, 9)
+
+	m := find(metrics, "acme_http_requests_exemplar")
+	require.NotNil(t, m)
+	assert.Equal(t, map[string]string{
+		"source":   "app",
+		"code":     "200",
+		"trace_id": "KOO5S4vxi0o",
+	}, m.Tags())
+	assert.Equal(t, map[string]interface{}{"value": 0.67}, m.Fields())
+	assert.Equal(t, time.Unix(1520879607, 700000000).UTC(), m.Time().Round(time.Millisecond))
+
+	m = find(metrics, "rpc_duration_seconds_exemplar")
+	require.NotNil(t, m)
+	assert.Equal(t, map[string]string{
+		"source":   "app",
+		"le":       "0.5",
+		"trace_id": "abc",
+	}, m.Tags())
+	assert.Equal(t, map[string]interface{}{"value": 0.31}, m.Fields())
+}
+
+func TestParseStrict(t *testing.T) {
+	invalid := map[string]string{
+		"missing eof":            "a 1\n",
+		"text after eof":         "a 1\n# EOF\na 2\n",
+		"missing newline":        "a 1\n# EOF",
+		"empty line":             "a 1\n\n# EOF\n",
+		"invalid type":           "# TYPE a foo\na 1\n# EOF\n",
+		"counter without total":  "# TYPE a counter\na 1\n# EOF\n",
+		"not contiguous":         "# TYPE a gauge\na 1\n# TYPE b gauge\nb 1\n# TYPE a gauge\n# EOF\n",
+		"metadata after samples": "# TYPE a gauge\na 1\n# HELP a help\n# EOF\n",
+		"bucket without le":      "# TYPE a histogram\na_bucket 1\n# EOF\n",
+		"gauge exemplar":         "# TYPE a gauge\na 1 # {trace_id=\"x\"} 1\n# EOF\n",
+		"invalid value":          "a one\n# EOF\n",
+		"unquoted label":         "a{b=c} 1\n# EOF\n",
+		"unit mismatch":          "# TYPE a gauge\n# UNIT a seconds\na 1\n# EOF\n",
+		"stateset without state": "# TYPE a stateset\na{b=\"c\"} 1\n# EOF\n",
+	}
+	for name, text := range invalid {
+		p := &Parser{}
+		_, err := p.Parse([]byte(text))
+		assert.Error(t, err, name)
+	}
+}
+
+func TestParseLine(t *testing.T) {
+	p := &Parser{}
+	_, err := p.ParseLine("a 1")
+	assert.Error(t, err)
+}
@@ -0,0 +1,464 @@
+package protobuf
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+)
+
+// wire types of the protobuf encoding
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireStart   = 3
+	wireEnd     = 4
+	wireFixed32 = 5
+)
+
+// Parser decodes protobuf messages of MessageType into metrics. The message
+// layout is taken from .proto files or descriptor sets loaded at runtime, nested messages are
+// flattened into fields joined by underscores, and repeated fields are
+// suffixed with their index.
+type Parser struct {
+	MetricName     string
+	MessageType    string
+	TagKeys        []string
+	TimestampField string
+	DefaultTags    map[string]string
+
+	messages map[string]*descriptor.DescriptorProto
+	enums    map[string]map[int32]string
+}
+
+// NewParser loads the .proto files and the descriptor sets, as produced by
+// `protoc --include_imports --descriptor_set_out`, and checks that the
+// message type is defined in them.  The imports of the .proto files are
+// searched in the import paths.
+func NewParser(files []string, importPaths []string, messageType string) (*Parser, error) {
+	p := &Parser{
+		MessageType: strings.TrimPrefix(messageType, "."),
+		messages:    make(map[string]*descriptor.DescriptorProto),
+		enums:       make(map[string]map[int32]string),
+	}
+
+	var protoFiles []string
+	for _, file := range files {
+		if strings.HasSuffix(file, ".proto") {
+			protoFiles = append(protoFiles, file)
+			continue
+		}
+		buf, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read descriptor set: %s", err)
+		}
+		if err := p.AddDescriptorSet(buf); err != nil {
+			return nil, fmt.Errorf("unable to load descriptor set %s: %s", file, err)
+		}
+	}
+
+	if len(protoFiles) > 0 {
+		fds, err := loadProtoFiles(protoFiles, importPaths)
+		if err != nil {
+			return nil, fmt.Errorf("unable to load proto files: %s", err)
+		}
+		p.addFiles(fds)
+	}
+
+	if _, ok := p.messages[p.MessageType]; !ok {
+		return nil, fmt.Errorf("message type %q not found in descriptor files", messageType)
+	}
+	return p, nil
+}
+
+// AddDescriptorSet registers the messages and enums of a serialized
+// FileDescriptorSet.
+func (p *Parser) AddDescriptorSet(buf []byte) error {
+	set := &descriptor.FileDescriptorSet{}
+	if err := proto.Unmarshal(buf, set); err != nil {
+		return err
+	}
+	p.addFiles(set.GetFile())
+	return nil
+}
+
+func (p *Parser) addFiles(files []*descriptor.FileDescriptorProto) {
+	for _, file := range files {
+		prefix := file.GetPackage()
+		for _, msg := range file.GetMessageType() {
+			p.addMessage(prefix, msg)
+		}
+		for _, enum := range file.GetEnumType() {
+			p.addEnum(prefix, enum)
+		}
+	}
+}
+
+func (p *Parser) addMessage(prefix string, msg *descriptor.DescriptorProto) {
+	name := join(prefix, msg.GetName())
+	p.messages[name] = msg
+	for _, nested := range msg.GetNestedType() {
+		p.addMessage(name, nested)
+	}
+	for _, enum := range msg.GetEnumType() {
+		p.addEnum(name, enum)
+	}
+}
+
+func (p *Parser) addEnum(prefix string, enum *descriptor.EnumDescriptorProto) {
+	values := make(map[int32]string)
+	for _, v := range enum.GetValue() {
+		values[v.GetNumber()] = v.GetName()
+	}
+	p.enums[join(prefix, enum.GetName())] = values
+}
+
+func join(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
+
+func (p *Parser) Parse(buf []byte) ([]telegraf.Metric, error) {
+	if len(buf) == 0 {
+		return make([]telegraf.Metric, 0), nil
+	}
+
+	fields := make(map[string]interface{})
+	if err := p.decode(buf, p.messages[p.MessageType], "", fields); err != nil {
+		return nil, fmt.Errorf("unable to decode %s: %s", p.MessageType, err)
+	}
+
+	tags := make(map[string]string)
+	for k, v := range p.DefaultTags {
+		tags[k] = v
+	}
+	for _, key := range p.TagKeys {
+		if v, ok := fields[key]; ok {
+			tags[key] = fmt.Sprint(v)
+			delete(fields, key)
+		}
+	}
+
+	t := time.Now().UTC()
+	if p.TimestampField != "" {
+		if ts, ok := p.timestamp(fields); ok {
+			t = ts
+		}
+	}
+
+	m, err := metric.New(p.MetricName, tags, fields, t)
+	if err != nil {
+		return nil, err
+	}
+	return []telegraf.Metric{m}, nil
+}
+
+// timestamp extracts the time of the metric from either a numeric field
+// holding unix seconds or a google.protobuf.Timestamp message, and removes
+// it from the fields.
+func (p *Parser) timestamp(fields map[string]interface{}) (time.Time, bool) {
+	key := p.TimestampField
+	if v, ok := fields[key]; ok {
+		delete(fields, key)
+		switch v := v.(type) {
+		case int64:
+			return time.Unix(v, 0).UTC(), true
+		case uint64:
+			return time.Unix(int64(v), 0).UTC(), true
+		case float64:
+			sec, frac := math.Modf(v)
+			return time.Unix(int64(sec), int64(frac*1e9)).UTC(), true
+		}
+		return time.Time{}, false
+	}
+
+	sec, sok := fields[key+"_seconds"].(int64)
+	nsec, _ := fields[key+"_nanos"].(int64)
+	if !sok {
+		return time.Time{}, false
+	}
+	delete(fields, key+"_seconds")
+	delete(fields, key+"_nanos")
+	return time.Unix(sec, nsec).UTC(), true
+}
+
+func (p *Parser) ParseLine(line string) (telegraf.Metric, error) {
+	metrics, err := p.Parse([]byte(line))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(metrics) < 1 {
+		return nil, fmt.Errorf("Can not parse the line: %s, for data format: protobuf ", line)
+	}
+
+	return metrics[0], nil
+}
+
+func (p *Parser) SetDefaultTags(tags map[string]string) {
+	p.DefaultTags = tags
+}
+
+// decode adds the fields of the encoded message buf to fields, nested
+// messages are decoded recursively with their field name as prefix.
+func (p *Parser) decode(
+	buf []byte,
+	msg *descriptor.DescriptorProto,
+	prefix string,
+	fields map[string]interface{},
+) error {
+	byNumber := make(map[int32]*descriptor.FieldDescriptorProto)
+	for _, f := range msg.GetField() {
+		byNumber[f.GetNumber()] = f
+	}
+	counts := make(map[int32]int)
+
+	r := &reader{buf: buf}
+	for !r.done() {
+		key, err := r.varint()
+		if err != nil {
+			return err
+		}
+		number := int32(key >> 3)
+		wire := int(key & 7)
+
+		f, ok := byNumber[number]
+		if !ok {
+			if err := r.skip(wire); err != nil {
+				return err
+			}
+			continue
+		}
+
+		name := prefix + f.GetName()
+		repeated := f.GetLabel() == descriptor.FieldDescriptorProto_LABEL_REPEATED
+
+		// packed repeated scalars are a single length-delimited value
+		if wire == wireBytes && repeated && isPackable(f.GetType()) {
+			packed, err := r.bytes()
+			if err != nil {
+				return err
+			}
+			pr := &reader{buf: packed}
+			for !pr.done() {
+				v, err := p.decodeScalar(pr, f, scalarWireType(f.GetType()))
+				if err != nil {
+					return err
+				}
+				fields[name+"_"+strconv.Itoa(counts[number])] = v
+				counts[number]++
+			}
+			continue
+		}
+
+		if repeated {
+			name += "_" + strconv.Itoa(counts[number])
+			counts[number]++
+		}
+
+		if f.GetType() == descriptor.FieldDescriptorProto_TYPE_MESSAGE {
+			raw, err := r.bytes()
+			if err != nil {
+				return err
+			}
+			nested, ok := p.messages[strings.TrimPrefix(f.GetTypeName(), ".")]
+			if !ok {
+				return fmt.Errorf("unknown message type %s", f.GetTypeName())
+			}
+			if err := p.decode(raw, nested, name+"_", fields); err != nil {
+				return err
+			}
+			continue
+		}
+
+		v, err := p.decodeScalar(r, f, wire)
+		if err != nil {
+			return err
+		}
+		if v != nil {
+			fields[name] = v
+		}
+	}
+	return nil
+}
+
+func (p *Parser) decodeScalar(
+	r *reader,
+	f *descriptor.FieldDescriptorProto,
+	wire int,
+) (interface{}, error) {
+	if wire != scalarWireType(f.GetType()) {
+		return nil, fmt.Errorf("field %s: unexpected wire type %d", f.GetName(), wire)
+	}
+
+	switch f.GetType() {
+	case descriptor.FieldDescriptorProto_TYPE_DOUBLE:
+		x, err := r.fixed64()
+		return math.Float64frombits(x), err
+	case descriptor.FieldDescriptorProto_TYPE_FLOAT:
+		x, err := r.fixed32()
+		return float64(math.Float32frombits(x)), err
+	case descriptor.FieldDescriptorProto_TYPE_INT64:
+		x, err := r.varint()
+		return int64(x), err
+	case descriptor.FieldDescriptorProto_TYPE_INT32:
+		x, err := r.varint()
+		return int64(int32(x)), err
+	case descriptor.FieldDescriptorProto_TYPE_UINT64,
+		descriptor.FieldDescriptorProto_TYPE_UINT32:
+		return r.varint()
+	case descriptor.FieldDescriptorProto_TYPE_SINT64,
+		descriptor.FieldDescriptorProto_TYPE_SINT32:
+		x, err := r.varint()
+		return int64(x>>1) ^ -int64(x&1), err
+	case descriptor.FieldDescriptorProto_TYPE_FIXED64:
+		return r.fixed64()
+	case descriptor.FieldDescriptorProto_TYPE_FIXED32:
+		x, err := r.fixed32()
+		return uint64(x), err
+	case descriptor.FieldDescriptorProto_TYPE_SFIXED64:
+		x, err := r.fixed64()
+		return int64(x), err
+	case descriptor.FieldDescriptorProto_TYPE_SFIXED32:
+		x, err := r.fixed32()
+		return int64(int32(x)), err
+	case descriptor.FieldDescriptorProto_TYPE_BOOL:
+		x, err := r.varint()
+		return x != 0, err
+	case descriptor.FieldDescriptorProto_TYPE_ENUM:
+		x, err := r.varint()
+		if err != nil {
+			return nil, err
+		}
+		if name, ok := p.enums[strings.TrimPrefix(f.GetTypeName(), ".")][int32(x)]; ok {
+			return name, nil
+		}
+		return int64(int32(x)), nil
+	case descriptor.FieldDescriptorProto_TYPE_STRING:
+		b, err := r.bytes()
+		return string(b), err
+	default:
+		// bytes and groups can't be represented as fields
+		return nil, r.skip(wire)
+	}
+}
+
+func scalarWireType(t descriptor.FieldDescriptorProto_Type) int {
+	switch t {
+	case descriptor.FieldDescriptorProto_TYPE_DOUBLE,
+		descriptor.FieldDescriptorProto_TYPE_FIXED64,
+		descriptor.FieldDescriptorProto_TYPE_SFIXED64:
+		return wireFixed64
+	case descriptor.FieldDescriptorProto_TYPE_FLOAT,
+		descriptor.FieldDescriptorProto_TYPE_FIXED32,
+		descriptor.FieldDescriptorProto_TYPE_SFIXED32:
+		return wireFixed32
+	case descriptor.FieldDescriptorProto_TYPE_STRING,
+		descriptor.FieldDescriptorProto_TYPE_BYTES,
+		descriptor.FieldDescriptorProto_TYPE_MESSAGE:
+		return wireBytes
+	case descriptor.FieldDescriptorProto_TYPE_GROUP:
+		return wireStart
+	default:
+		return wireVarint
+	}
+}
+
+func isPackable(t descriptor.FieldDescriptorProto_Type) bool {
+	w := scalarWireType(t)
+	return w == wireVarint || w == wireFixed32 || w == wireFixed64
+}
+
+// reader reads the primitive values of the protobuf wire format from buf.
+type reader struct {
+	buf []byte
+	pos int
+}
+
+func (r *reader) done() bool {
+	return r.pos >= len(r.buf)
+}
+
+func (r *reader) varint() (uint64, error) {
+	x, n := proto.DecodeVarint(r.buf[r.pos:])
+	if n == 0 {
+		return 0, io.ErrUnexpectedEOF
+	}
+	r.pos += n
+	return x, nil
+}
+
+func (r *reader) fixed64() (uint64, error) {
+	if len(r.buf)-r.pos < 8 {
+		return 0, io.ErrUnexpectedEOF
+	}
+	x := binary.LittleEndian.Uint64(r.buf[r.pos:])
+	r.pos += 8
+	return x, nil
+}
+
+func (r *reader) fixed32() (uint32, error) {
+	if len(r.buf)-r.pos < 4 {
+		return 0, io.ErrUnexpectedEOF
+	}
+	x := binary.LittleEndian.Uint32(r.buf[r.pos:])
+	r.pos += 4
+	return x, nil
+}
+
+func (r *reader) bytes() ([]byte, error) {
+	n, err := r.varint()
+	if err != nil {
+		return nil, err
+	}
+	if uint64(len(r.buf)-r.pos) < n {
+		return nil, io.ErrUnexpectedEOF
+	}
+	b := r.buf[r.pos : r.pos+int(n)]
+	r.pos += int(n)
+	return b, nil
+}
+
+// skip discards a value of the given wire type.
+func (r *reader) skip(wire int) error {
+	var err error
+	switch wire {
+	case wireVarint:
+		_, err = r.varint()
+	case wireFixed64:
+		_, err = r.fixed64()
+	case wireFixed32:
+		_, err = r.fixed32()
+	case wireBytes:
+		_, err = r.bytes()
+	case wireStart:
+		for {
+			key, err := r.varint()
+			if err != nil {
+				return err
+			}
+			if int(key&7) == wireEnd {
+				return nil
+			}
+			if err := r.skip(int(key & 7)); err != nil {
+				return err
+			}
+		}
+	default:
+		err = fmt.Errorf("unknown wire type %d", wire)
+	}
+	return err
+}
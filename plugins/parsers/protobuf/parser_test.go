@@ -0,0 +1,371 @@
+package protobuf
+
+import (
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func field(
+	name string,
+	number int32,
+	typ descriptor.FieldDescriptorProto_Type,
+	label descriptor.FieldDescriptorProto_Label,
+	typeName string,
+) *descriptor.FieldDescriptorProto {
+	f := &descriptor.FieldDescriptorProto{
+		Name:   proto.String(name),
+		Number: proto.Int32(number),
+		Type:   typ.Enum(),
+		Label:  label.Enum(),
+	}
+	if typeName != "" {
+		f.TypeName = proto.String(typeName)
+	}
+	return f
+}
+
+const (
+	optional = descriptor.FieldDescriptorProto_LABEL_OPTIONAL
+	repeated = descriptor.FieldDescriptorProto_LABEL_REPEATED
+)
+
+// testDescriptorSet describes:
+//
+//	package sensors;
+//	message Reading {
+//	  enum State { UNKNOWN = 0; OK = 1; FAILED = 2; }
+//	  message Location { string room = 1; int32 floor = 2; }
+//	  string host = 1;
+//	  double temperature = 2;
+//	  sint64 offset = 3;
+//	  uint32 count = 4;
+//	  bool active = 5;
+//	  State state = 6;
+//	  Location location = 7;
+//	  repeated int32 samples = 8;
+//	  int64 time = 9;
+//	  float ratio = 10;
+//	}
+func testDescriptorSet(t *testing.T) []byte {
+	reading := &descriptor.DescriptorProto{
+		Name: proto.String("Reading"),
+		EnumType: []*descriptor.EnumDescriptorProto{
+			{
+				Name: proto.String("State"),
+				Value: []*descriptor.EnumValueDescriptorProto{
+					{Name: proto.String("UNKNOWN"), Number: proto.Int32(0)},
+					{Name: proto.String("OK"), Number: proto.Int32(1)},
+					{Name: proto.String("FAILED"), Number: proto.Int32(2)},
+				},
+			},
+		},
+		NestedType: []*descriptor.DescriptorProto{
+			{
+				Name: proto.String("Location"),
+				Field: []*descriptor.FieldDescriptorProto{
+					field("room", 1, descriptor.FieldDescriptorProto_TYPE_STRING, optional, ""),
+					field("floor", 2, descriptor.FieldDescriptorProto_TYPE_INT32, optional, ""),
+				},
+			},
+		},
+		Field: []*descriptor.FieldDescriptorProto{
+			field("host", 1, descriptor.FieldDescriptorProto_TYPE_STRING, optional, ""),
+			field("temperature", 2, descriptor.FieldDescriptorProto_TYPE_DOUBLE, optional, ""),
+			field("offset", 3, descriptor.FieldDescriptorProto_TYPE_SINT64, optional, ""),
+			field("count", 4, descriptor.FieldDescriptorProto_TYPE_UINT32, optional, ""),
+			field("active", 5, descriptor.FieldDescriptorProto_TYPE_BOOL, optional, ""),
+			field("state", 6, descriptor.FieldDescriptorProto_TYPE_ENUM, optional, ".sensors.Reading.State"),
+			field("location", 7, descriptor.FieldDescriptorProto_TYPE_MESSAGE, optional, ".sensors.Reading.Location"),
+			field("samples", 8, descriptor.FieldDescriptorProto_TYPE_INT32, repeated, ""),
+			field("time", 9, descriptor.FieldDescriptorProto_TYPE_INT64, optional, ""),
+			field("ratio", 10, descriptor.FieldDescriptorProto_TYPE_FLOAT, optional, ""),
+		},
+	}
+
+	set := &descriptor.FileDescriptorSet{
+		File: []*descriptor.FileDescriptorProto{
+			{
+				Name:        proto.String("sensors.proto"),
+				Package:     proto.String("sensors"),
+				MessageType: []*descriptor.DescriptorProto{reading},
+			},
+		},
+	}
+	buf, err := proto.Marshal(set)
+	require.NoError(t, err)
+	return buf
+}
+
+func key(number int, wire int) uint64 {
+	return uint64(number<<3 | wire)
+}
+
+func testMessage(t *testing.T) []byte {
+	location := proto.NewBuffer(nil)
+	require.NoError(t, location.EncodeVarint(key(1, wireBytes)))
+	require.NoError(t, location.EncodeStringBytes("lab"))
+	require.NoError(t, location.EncodeVarint(key(2, wireVarint)))
+	require.NoError(t, location.EncodeVarint(3))
+
+	packed := proto.NewBuffer(nil)
+	for _, v := range []uint64{4, 5} {
+		require.NoError(t, packed.EncodeVarint(v))
+	}
+
+	offset := int64(-42)
+
+	b := proto.NewBuffer(nil)
+	require.NoError(t, b.EncodeVarint(key(1, wireBytes)))
+	require.NoError(t, b.EncodeStringBytes("server01"))
+	require.NoError(t, b.EncodeVarint(key(2, wireFixed64)))
+	require.NoError(t, b.EncodeFixed64(math.Float64bits(21.5)))
+	require.NoError(t, b.EncodeVarint(key(3, wireVarint)))
+	require.NoError(t, b.EncodeZigzag64(uint64(offset)))
+	require.NoError(t, b.EncodeVarint(key(4, wireVarint)))
+	require.NoError(t, b.EncodeVarint(7))
+	require.NoError(t, b.EncodeVarint(key(5, wireVarint)))
+	require.NoError(t, b.EncodeVarint(1))
+	require.NoError(t, b.EncodeVarint(key(6, wireVarint)))
+	require.NoError(t, b.EncodeVarint(2))
+	require.NoError(t, b.EncodeVarint(key(7, wireBytes)))
+	require.NoError(t, b.EncodeRawBytes(location.Bytes()))
+	require.NoError(t, b.EncodeVarint(key(8, wireBytes)))
+	require.NoError(t, b.EncodeRawBytes(packed.Bytes()))
+	// unpacked elements of a repeated field are appended
+	require.NoError(t, b.EncodeVarint(key(8, wireVarint)))
+	require.NoError(t, b.EncodeVarint(6))
+	require.NoError(t, b.EncodeVarint(key(9, wireVarint)))
+	require.NoError(t, b.EncodeVarint(1500000000))
+	require.NoError(t, b.EncodeVarint(key(10, wireFixed32)))
+	require.NoError(t, b.EncodeFixed32(uint64(math.Float32bits(0.5))))
+	// unknown fields are skipped
+	require.NoError(t, b.EncodeVarint(key(15, wireBytes)))
+	require.NoError(t, b.EncodeStringBytes("ignored"))
+	return b.Bytes()
+}
+
+func newTestParser(t *testing.T) *Parser {
+	f, err := ioutil.TempFile("", "protobuf")
+	require.NoError(t, err)
+	defer os.Remove(f.Name())
+	_, err = f.Write(testDescriptorSet(t))
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	p, err := NewParser([]string{f.Name()}, nil, "sensors.Reading")
+	require.NoError(t, err)
+	p.MetricName = "sensor"
+	return p
+}
+
+func TestParse(t *testing.T) {
+	p := newTestParser(t)
+	p.TagKeys = []string{"host", "location_room"}
+	p.TimestampField = "time"
+
+	metrics, err := p.Parse(testMessage(t))
+	require.NoError(t, err)
+	require.Len(t, metrics, 1)
+
+	m := metrics[0]
+	assert.Equal(t, "sensor", m.Name())
+	assert.Equal(t, map[string]string{
+		"host":          "server01",
+		"location_room": "lab",
+	}, m.Tags())
+	assert.Equal(t, map[string]interface{}{
+		"temperature":    21.5,
+		"offset":         int64(-42),
+		"count":          uint64(7),
+		"active":         true,
+		"state":          "FAILED",
+		"location_floor": int64(3),
+		"samples_0":      int64(4),
+		"samples_1":      int64(5),
+		"samples_2":      int64(6),
+		"ratio":          0.5,
+	}, m.Fields())
+	assert.Equal(t, time.Unix(1500000000, 0).UTC(), m.Time())
+}
+
+func TestParseDefaultTags(t *testing.T) {
+	p := newTestParser(t)
+	p.SetDefaultTags(map[string]string{"source": "kafka"})
+
+	m, err := p.ParseLine(string(testMessage(t)))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"source": "kafka"}, m.Tags())
+	assert.Equal(t, "server01", m.Fields()["host"])
+	assert.Equal(t, int64(1500000000), m.Fields()["time"])
+}
+
+func TestParseTruncated(t *testing.T) {
+	p := newTestParser(t)
+
+	buf := testMessage(t)
+	_, err := p.Parse(buf[:len(buf)-3])
+	assert.Error(t, err)
+}
+
+func TestParseEmpty(t *testing.T) {
+	p := newTestParser(t)
+
+	metrics, err := p.Parse(nil)
+	require.NoError(t, err)
+	assert.Len(t, metrics, 0)
+}
+
+func TestUnknownMessageType(t *testing.T) {
+	f, err := ioutil.TempFile("", "protobuf")
+	require.NoError(t, err)
+	defer os.Remove(f.Name())
+	_, err = f.Write(testDescriptorSet(t))
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	_, err = NewParser([]string{f.Name()}, nil, "sensors.Missing")
+	assert.Error(t, err)
+}
+
+// sensorsProto describes the same layout as testDescriptorSet, the state
+// enum being imported from another package.
+const sensorsProto = `
+// readings of the sensors
+syntax = "proto3";
+
+package sensors;
+
+import "common/state.proto";
+import "google/protobuf/timestamp.proto";
+
+option go_package = "sensors";
+
+message Reading {
+  message Location {
+    string room = 1;
+    int32 floor = 2 [deprecated = true];
+  }
+  string host = 1;
+  double temperature = 2;
+  sint64 offset = 3;
+  uint32 count = 4;
+  bool active = 5;
+  common.State state = 6;
+  Location location = 7;
+  repeated int32 samples = 8 [packed = true];
+  int64 time = 9;
+  float ratio = 10;
+  map<string, Location> neighbours = 11;
+  oneof source {
+    string probe = 12;
+    google.protobuf.Timestamp calibrated = 13;
+  }
+  reserved 14, 20 to 30;
+}
+`
+
+const stateProto = `
+syntax = "proto3";
+
+package common;
+
+/* state of a sensor */
+enum State {
+  UNKNOWN = 0;
+  OK = 1;
+  FAILED = 2;
+}
+`
+
+func writeProto(t *testing.T, path string, content string) {
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, ioutil.WriteFile(path, []byte(content), 0644))
+}
+
+func TestParseProtoFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "protobuf")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	writeProto(t, filepath.Join(dir, "sensors", "sensors.proto"), sensorsProto)
+	writeProto(t, filepath.Join(dir, "include", "common", "state.proto"), stateProto)
+
+	p, err := NewParser(
+		[]string{filepath.Join(dir, "sensors", "sensors.proto")},
+		[]string{filepath.Join(dir, "include")},
+		"sensors.Reading")
+	require.NoError(t, err)
+	p.MetricName = "sensor"
+	p.TagKeys = []string{"host", "location_room"}
+	p.TimestampField = "time"
+
+	metrics, err := p.Parse(testMessage(t))
+	require.NoError(t, err)
+	require.Len(t, metrics, 1)
+
+	m := metrics[0]
+	assert.Equal(t, map[string]string{
+		"host":          "server01",
+		"location_room": "lab",
+	}, m.Tags())
+	assert.Equal(t, map[string]interface{}{
+		"temperature":    21.5,
+		"offset":         int64(-42),
+		"count":          uint64(7),
+		"active":         true,
+		"state":          "FAILED",
+		"location_floor": int64(3),
+		"samples_0":      int64(4),
+		"samples_1":      int64(5),
+		"samples_2":      int64(6),
+		"ratio":          0.5,
+	}, m.Fields())
+	assert.Equal(t, time.Unix(1500000000, 0).UTC(), m.Time())
+}
+
+func TestProtoFileErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{
+			name:    "missing import",
+			content: `syntax = "proto3"; import "missing.proto"; message Reading { int32 a = 1; }`,
+		},
+		{
+			name:    "unknown type",
+			content: `syntax = "proto3"; message Reading { Missing a = 1; }`,
+		},
+		{
+			name:    "syntax error",
+			content: `syntax = "proto3"; message Reading { int32 a = ; }`,
+		},
+		{
+			name:    "unterminated message",
+			content: `syntax = "proto3"; message Reading { int32 a = 1;`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "protobuf")
+			require.NoError(t, err)
+			defer os.RemoveAll(dir)
+
+			path := filepath.Join(dir, "reading.proto")
+			writeProto(t, path, tt.content)
+
+			_, err = NewParser([]string{path}, nil, "Reading")
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,850 @@
+package protobuf
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+// wellKnownTypes are the sources of the well-known types, reduced to their
+// messages, so that they can be imported without being installed.
+var wellKnownTypes = map[string]string{
+	"google/protobuf/any.proto": `syntax = "proto3"; package google.protobuf;
+		message Any { string type_url = 1; bytes value = 2; }`,
+	"google/protobuf/duration.proto": `syntax = "proto3"; package google.protobuf;
+		message Duration { int64 seconds = 1; int32 nanos = 2; }`,
+	"google/protobuf/empty.proto": `syntax = "proto3"; package google.protobuf;
+		message Empty {}`,
+	"google/protobuf/field_mask.proto": `syntax = "proto3"; package google.protobuf;
+		message FieldMask { repeated string paths = 1; }`,
+	"google/protobuf/struct.proto": `syntax = "proto3"; package google.protobuf;
+		message Struct { map<string, Value> fields = 1; }
+		message Value {
+		  oneof kind {
+		    NullValue null_value = 1; double number_value = 2; string string_value = 3;
+		    bool bool_value = 4; Struct struct_value = 5; ListValue list_value = 6;
+		  }
+		}
+		enum NullValue { NULL_VALUE = 0; }
+		message ListValue { repeated Value values = 1; }`,
+	"google/protobuf/timestamp.proto": `syntax = "proto3"; package google.protobuf;
+		message Timestamp { int64 seconds = 1; int32 nanos = 2; }`,
+	"google/protobuf/wrappers.proto": `syntax = "proto3"; package google.protobuf;
+		message DoubleValue { double value = 1; }
+		message FloatValue { float value = 1; }
+		message Int64Value { int64 value = 1; }
+		message UInt64Value { uint64 value = 1; }
+		message Int32Value { int32 value = 1; }
+		message UInt32Value { uint32 value = 1; }
+		message BoolValue { bool value = 1; }
+		message StringValue { string value = 1; }
+		message BytesValue { bytes value = 1; }`,
+}
+
+var scalarTypes = map[string]descriptor.FieldDescriptorProto_Type{
+	"double":   descriptor.FieldDescriptorProto_TYPE_DOUBLE,
+	"float":    descriptor.FieldDescriptorProto_TYPE_FLOAT,
+	"int64":    descriptor.FieldDescriptorProto_TYPE_INT64,
+	"uint64":   descriptor.FieldDescriptorProto_TYPE_UINT64,
+	"int32":    descriptor.FieldDescriptorProto_TYPE_INT32,
+	"fixed64":  descriptor.FieldDescriptorProto_TYPE_FIXED64,
+	"fixed32":  descriptor.FieldDescriptorProto_TYPE_FIXED32,
+	"bool":     descriptor.FieldDescriptorProto_TYPE_BOOL,
+	"string":   descriptor.FieldDescriptorProto_TYPE_STRING,
+	"bytes":    descriptor.FieldDescriptorProto_TYPE_BYTES,
+	"uint32":   descriptor.FieldDescriptorProto_TYPE_UINT32,
+	"sfixed32": descriptor.FieldDescriptorProto_TYPE_SFIXED32,
+	"sfixed64": descriptor.FieldDescriptorProto_TYPE_SFIXED64,
+	"sint32":   descriptor.FieldDescriptorProto_TYPE_SINT32,
+	"sint64":   descriptor.FieldDescriptorProto_TYPE_SINT64,
+}
+
+// protoLoader parses .proto files, and the files they import, into file
+// descriptors like the ones of the descriptor sets written by protoc.
+type protoLoader struct {
+	importPaths []string
+
+	files   []*descriptor.FileDescriptorProto
+	loaded  map[string]bool
+	loading map[string]bool
+}
+
+// loadProtoFiles parses the .proto files, their imports are searched in the
+// import paths, then in the directory of the configured file, and the
+// well-known types are built in.
+func loadProtoFiles(paths []string, importPaths []string) ([]*descriptor.FileDescriptorProto, error) {
+	l := &protoLoader{
+		importPaths: importPaths,
+		loaded:      make(map[string]bool),
+		loading:     make(map[string]bool),
+	}
+	for _, path := range paths {
+		src, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read proto file: %s", err)
+		}
+		if err := l.load(path, filepath.Dir(path), string(src)); err != nil {
+			return nil, err
+		}
+	}
+	if err := resolveTypes(l.files); err != nil {
+		return nil, err
+	}
+	return l.files, nil
+}
+
+func (l *protoLoader) load(name string, dir string, src string) error {
+	l.loading[name] = true
+	defer delete(l.loading, name)
+
+	toks, err := tokenize(name, src)
+	if err != nil {
+		return err
+	}
+	pp := &protoParser{file: name, toks: toks}
+	fd, err := pp.parseFile()
+	if err != nil {
+		return err
+	}
+	fd.Name = proto.String(name)
+
+	for _, dep := range fd.GetDependency() {
+		if err := l.loadImport(name, dir, dep); err != nil {
+			return err
+		}
+	}
+	l.files = append(l.files, fd)
+	return nil
+}
+
+func (l *protoLoader) loadImport(from string, dir string, name string) error {
+	if l.loading[name] {
+		return fmt.Errorf("%s: import cycle with %s", from, name)
+	}
+	if l.loaded[name] {
+		return nil
+	}
+	l.loaded[name] = true
+
+	dirs := append(append([]string{}, l.importPaths...), dir)
+	for _, path := range dirs {
+		src, err := ioutil.ReadFile(filepath.Join(path, name))
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return fmt.Errorf("%s: unable to read import: %s", from, err)
+		}
+		return l.load(name, dir, string(src))
+	}
+	if src, ok := wellKnownTypes[name]; ok {
+		return l.load(name, dir, src)
+	}
+	return fmt.Errorf("%s: import %q not found in %s", from, name, strings.Join(dirs, ", "))
+}
+
+// token is a word, a number, a string literal or a symbol of a .proto file.
+type token struct {
+	text string
+	line int
+	str  bool
+}
+
+// tokenize splits the source into tokens, dropping the comments.
+func tokenize(file string, src string) ([]token, error) {
+	var toks []token
+	line := 1
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == '\n':
+			line++
+			i++
+		case c == ' ' || c == '\t' || c == '\r' || c == '\f' || c == '\v':
+			i++
+		case strings.HasPrefix(src[i:], "//"):
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+		case strings.HasPrefix(src[i:], "/*"):
+			end := strings.Index(src[i+2:], "*/")
+			if end < 0 {
+				return nil, fmt.Errorf("%s:%d: unterminated comment", file, line)
+			}
+			line += strings.Count(src[i:i+2+end], "\n")
+			i += end + 4
+		case isLetter(c) || (c == '.' && i+1 < len(src) && isLetter(src[i+1])):
+			start := i
+			for i++; i < len(src) && (isLetter(src[i]) || isDigit(src[i]) || src[i] == '.'); i++ {
+			}
+			toks = append(toks, token{text: src[start:i], line: line})
+		case isDigit(c) || (c == '.' && i+1 < len(src) && isDigit(src[i+1])):
+			start := i
+			for i++; i < len(src); i++ {
+				if isLetter(src[i]) || isDigit(src[i]) || src[i] == '.' {
+					continue
+				}
+				// signed exponents of the floats
+				if (src[i] == '+' || src[i] == '-') && (src[i-1] == 'e' || src[i-1] == 'E') &&
+					!strings.HasPrefix(src[start:], "0x") && !strings.HasPrefix(src[start:], "0X") {
+					continue
+				}
+				break
+			}
+			toks = append(toks, token{text: src[start:i], line: line})
+		case c == '"' || c == '\'':
+			var b bytes.Buffer
+			for i++; ; i++ {
+				if i >= len(src) || src[i] == '\n' {
+					return nil, fmt.Errorf("%s:%d: unterminated string", file, line)
+				}
+				if src[i] == c {
+					i++
+					break
+				}
+				if src[i] == '\\' && i+1 < len(src) {
+					i++
+				}
+				b.WriteByte(src[i])
+			}
+			toks = append(toks, token{text: b.String(), line: line, str: true})
+		default:
+			toks = append(toks, token{text: string(c), line: line})
+			i++
+		}
+	}
+	return toks, nil
+}
+
+func isLetter(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// protoParser parses the tokens of a .proto file.  The options, services
+// and extensions are skipped, as they don't change the decoding of the
+// messages.
+type protoParser struct {
+	file string
+	toks []token
+	pos  int
+}
+
+func (p *protoParser) done() bool {
+	return p.pos >= len(p.toks)
+}
+
+func (p *protoParser) peek() token {
+	if p.done() {
+		return token{}
+	}
+	return p.toks[p.pos]
+}
+
+func (p *protoParser) next() (token, error) {
+	if p.done() {
+		return token{}, p.errorf("unexpected end of file")
+	}
+	tok := p.toks[p.pos]
+	p.pos++
+	return tok, nil
+}
+
+func (p *protoParser) errorf(format string, args ...interface{}) error {
+	line := 0
+	if len(p.toks) > 0 {
+		line = p.toks[len(p.toks)-1].line
+		if !p.done() {
+			line = p.toks[p.pos].line
+		}
+	}
+	return fmt.Errorf("%s:%d: %s", p.file, line, fmt.Sprintf(format, args...))
+}
+
+func (p *protoParser) expect(text string) error {
+	tok, err := p.next()
+	if err != nil {
+		return err
+	}
+	if tok.str || tok.text != text {
+		p.pos--
+		return p.errorf("expected %q, found %q", text, tok.text)
+	}
+	return nil
+}
+
+func (p *protoParser) ident() (string, error) {
+	tok, err := p.next()
+	if err != nil {
+		return "", err
+	}
+	if tok.str || !(isLetter(tok.text[0]) || tok.text[0] == '.') {
+		p.pos--
+		return "", p.errorf("expected a name, found %q", tok.text)
+	}
+	return tok.text, nil
+}
+
+func (p *protoParser) str() (string, error) {
+	tok, err := p.next()
+	if err != nil {
+		return "", err
+	}
+	if !tok.str {
+		p.pos--
+		return "", p.errorf("expected a string, found %q", tok.text)
+	}
+	return tok.text, nil
+}
+
+func (p *protoParser) number() (int32, error) {
+	tok, err := p.next()
+	if err != nil {
+		return 0, err
+	}
+	sign := ""
+	if !tok.str && tok.text == "-" {
+		sign = "-"
+		if tok, err = p.next(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := strconv.ParseInt(sign+tok.text, 0, 32)
+	if err != nil || tok.str {
+		p.pos--
+		return 0, p.errorf("expected a number, found %q", tok.text)
+	}
+	return int32(n), nil
+}
+
+// skipStatement skips the tokens up to the end of the statement, including
+// the aggregate values of the options.
+func (p *protoParser) skipStatement() error {
+	depth := 0
+	for {
+		tok, err := p.next()
+		if err != nil {
+			return err
+		}
+		if tok.str {
+			continue
+		}
+		switch tok.text {
+		case "{", "[", "(":
+			depth++
+		case "}", "]", ")":
+			depth--
+		case ";":
+			if depth == 0 {
+				return nil
+			}
+		}
+	}
+}
+
+// skipBlock skips the tokens up to the end of the next block.
+func (p *protoParser) skipBlock() error {
+	depth := 0
+	for {
+		tok, err := p.next()
+		if err != nil {
+			return err
+		}
+		if tok.str {
+			continue
+		}
+		switch tok.text {
+		case "{":
+			depth++
+		case "}":
+			depth--
+			if depth == 0 {
+				return nil
+			}
+		}
+	}
+}
+
+// skipOptions skips the [...] options of a field or an enum value.
+func (p *protoParser) skipOptions() error {
+	if tok := p.peek(); tok.str || tok.text != "[" {
+		return nil
+	}
+	depth := 0
+	for {
+		tok, err := p.next()
+		if err != nil {
+			return err
+		}
+		if tok.str {
+			continue
+		}
+		switch tok.text {
+		case "[", "{":
+			depth++
+		case "]", "}":
+			depth--
+			if depth == 0 {
+				return nil
+			}
+		}
+	}
+}
+
+func (p *protoParser) parseFile() (*descriptor.FileDescriptorProto, error) {
+	fd := &descriptor.FileDescriptorProto{}
+	for !p.done() {
+		tok, _ := p.next()
+		if tok.str {
+			return nil, p.errorf("unexpected string %q", tok.text)
+		}
+		switch tok.text {
+		case ";":
+		case "syntax", "edition":
+			if err := p.expect("="); err != nil {
+				return nil, err
+			}
+			syntax, err := p.str()
+			if err != nil {
+				return nil, err
+			}
+			fd.Syntax = proto.String(syntax)
+			if err := p.expect(";"); err != nil {
+				return nil, err
+			}
+		case "package":
+			pkg, err := p.ident()
+			if err != nil {
+				return nil, err
+			}
+			fd.Package = proto.String(pkg)
+			if err := p.expect(";"); err != nil {
+				return nil, err
+			}
+		case "import":
+			if next := p.peek(); !next.str && (next.text == "public" || next.text == "weak") {
+				p.pos++
+			}
+			dep, err := p.str()
+			if err != nil {
+				return nil, err
+			}
+			fd.Dependency = append(fd.Dependency, dep)
+			if err := p.expect(";"); err != nil {
+				return nil, err
+			}
+		case "option":
+			if err := p.skipStatement(); err != nil {
+				return nil, err
+			}
+		case "message":
+			msg, err := p.parseMessage()
+			if err != nil {
+				return nil, err
+			}
+			fd.MessageType = append(fd.MessageType, msg)
+		case "enum":
+			enum, err := p.parseEnum()
+			if err != nil {
+				return nil, err
+			}
+			fd.EnumType = append(fd.EnumType, enum)
+		case "service", "extend":
+			if err := p.skipBlock(); err != nil {
+				return nil, err
+			}
+		default:
+			p.pos--
+			return nil, p.errorf("unexpected %q", tok.text)
+		}
+	}
+	return fd, nil
+}
+
+func (p *protoParser) parseMessage() (*descriptor.DescriptorProto, error) {
+	name, err := p.ident()
+	if err != nil {
+		return nil, err
+	}
+	msg := &descriptor.DescriptorProto{Name: proto.String(name)}
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	if err := p.parseMessageBody(msg); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+// parseMessageBody parses the definitions of the message up to its closing
+// brace.
+func (p *protoParser) parseMessageBody(msg *descriptor.DescriptorProto) error {
+	for {
+		tok, err := p.next()
+		if err != nil {
+			return err
+		}
+		if tok.str {
+			return p.errorf("unexpected string %q", tok.text)
+		}
+		switch tok.text {
+		case "}":
+			return nil
+		case ";":
+		case "message":
+			nested, err := p.parseMessage()
+			if err != nil {
+				return err
+			}
+			msg.NestedType = append(msg.NestedType, nested)
+		case "enum":
+			enum, err := p.parseEnum()
+			if err != nil {
+				return err
+			}
+			msg.EnumType = append(msg.EnumType, enum)
+		case "option", "reserved", "extensions":
+			if err := p.skipStatement(); err != nil {
+				return err
+			}
+		case "extend":
+			if err := p.skipBlock(); err != nil {
+				return err
+			}
+		case "oneof":
+			if err := p.parseOneof(msg); err != nil {
+				return err
+			}
+		default:
+			p.pos--
+			if err := p.parseField(msg, nil); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (p *protoParser) parseOneof(msg *descriptor.DescriptorProto) error {
+	name, err := p.ident()
+	if err != nil {
+		return err
+	}
+	index := proto.Int32(int32(len(msg.OneofDecl)))
+	msg.OneofDecl = append(msg.OneofDecl, &descriptor.OneofDescriptorProto{Name: proto.String(name)})
+	if err := p.expect("{"); err != nil {
+		return err
+	}
+	for {
+		tok := p.peek()
+		switch {
+		case !tok.str && tok.text == "}":
+			p.pos++
+			return nil
+		case !tok.str && tok.text == ";":
+			p.pos++
+		case !tok.str && tok.text == "option":
+			if err := p.skipStatement(); err != nil {
+				return err
+			}
+		default:
+			if err := p.parseField(msg, index); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// parseField parses a field, a map field or a group of the message.
+func (p *protoParser) parseField(msg *descriptor.DescriptorProto, oneof *int32) error {
+	label := descriptor.FieldDescriptorProto_LABEL_OPTIONAL
+	typ, err := p.ident()
+	if err != nil {
+		return err
+	}
+	switch typ {
+	case "repeated":
+		label = descriptor.FieldDescriptorProto_LABEL_REPEATED
+		typ, err = p.ident()
+	case "required":
+		label = descriptor.FieldDescriptorProto_LABEL_REQUIRED
+		typ, err = p.ident()
+	case "optional":
+		typ, err = p.ident()
+	}
+	if err != nil {
+		return err
+	}
+
+	f := &descriptor.FieldDescriptorProto{Label: label.Enum(), OneofIndex: oneof}
+	var entry, group *descriptor.DescriptorProto
+	switch {
+	case typ == "map" && p.peek().text == "<":
+		if entry, err = p.parseMapEntry(); err != nil {
+			return err
+		}
+		f.Label = descriptor.FieldDescriptorProto_LABEL_REPEATED.Enum()
+		f.Type = descriptor.FieldDescriptorProto_TYPE_MESSAGE.Enum()
+	case typ == "group":
+		f.Type = descriptor.FieldDescriptorProto_TYPE_GROUP.Enum()
+	default:
+		if t, ok := scalarTypes[typ]; ok {
+			f.Type = t.Enum()
+		} else {
+			f.TypeName = proto.String(typ)
+		}
+	}
+
+	name, err := p.ident()
+	if err != nil {
+		return err
+	}
+	f.Name = proto.String(name)
+	if err := p.expect("="); err != nil {
+		return err
+	}
+	if f.Number, err = p.numberPtr(); err != nil {
+		return err
+	}
+	if err := p.skipOptions(); err != nil {
+		return err
+	}
+
+	switch {
+	case entry != nil:
+		entry.Name = proto.String(mapEntryName(name))
+		f.TypeName = entry.Name
+		msg.NestedType = append(msg.NestedType, entry)
+	case f.GetType() == descriptor.FieldDescriptorProto_TYPE_GROUP:
+		// the groups are named after their type, the field is in lower case
+		group = &descriptor.DescriptorProto{Name: proto.String(name)}
+		f.Name = proto.String(strings.ToLower(name))
+		f.TypeName = group.Name
+		if err := p.expect("{"); err != nil {
+			return err
+		}
+		if err := p.parseMessageBody(group); err != nil {
+			return err
+		}
+		msg.NestedType = append(msg.NestedType, group)
+		msg.Field = append(msg.Field, f)
+		return nil
+	}
+	msg.Field = append(msg.Field, f)
+	return p.expect(";")
+}
+
+func (p *protoParser) numberPtr() (*int32, error) {
+	n, err := p.number()
+	if err != nil {
+		return nil, err
+	}
+	return proto.Int32(n), nil
+}
+
+// parseMapEntry parses the <key, value> types of a map into the entry
+// message protoc generates for maps.
+func (p *protoParser) parseMapEntry() (*descriptor.DescriptorProto, error) {
+	if err := p.expect("<"); err != nil {
+		return nil, err
+	}
+	keyType, err := p.ident()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(","); err != nil {
+		return nil, err
+	}
+	valueType, err := p.ident()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(">"); err != nil {
+		return nil, err
+	}
+
+	key, ok := scalarTypes[keyType]
+	if !ok {
+		return nil, p.errorf("invalid map key type %q", keyType)
+	}
+	value := &descriptor.FieldDescriptorProto{
+		Name:   proto.String("value"),
+		Number: proto.Int32(2),
+		Label:  descriptor.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+	}
+	if t, ok := scalarTypes[valueType]; ok {
+		value.Type = t.Enum()
+	} else {
+		value.TypeName = proto.String(valueType)
+	}
+	return &descriptor.DescriptorProto{
+		Field: []*descriptor.FieldDescriptorProto{
+			{
+				Name:   proto.String("key"),
+				Number: proto.Int32(1),
+				Label:  descriptor.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+				Type:   key.Enum(),
+			},
+			value,
+		},
+		Options: &descriptor.MessageOptions{MapEntry: proto.Bool(true)},
+	}, nil
+}
+
+// mapEntryName returns the name of the entry message of a map field, the
+// field name in camel case suffixed with Entry.
+func mapEntryName(field string) string {
+	var b bytes.Buffer
+	upper := true
+	for _, c := range field {
+		if c == '_' {
+			upper = true
+			continue
+		}
+		if upper {
+			b.WriteString(strings.ToUpper(string(c)))
+			upper = false
+		} else {
+			b.WriteRune(c)
+		}
+	}
+	return b.String() + "Entry"
+}
+
+func (p *protoParser) parseEnum() (*descriptor.EnumDescriptorProto, error) {
+	name, err := p.ident()
+	if err != nil {
+		return nil, err
+	}
+	enum := &descriptor.EnumDescriptorProto{Name: proto.String(name)}
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	for {
+		tok, err := p.next()
+		if err != nil {
+			return nil, err
+		}
+		if tok.str {
+			return nil, p.errorf("unexpected string %q", tok.text)
+		}
+		switch tok.text {
+		case "}":
+			return enum, nil
+		case ";":
+		case "option", "reserved":
+			if err := p.skipStatement(); err != nil {
+				return nil, err
+			}
+		default:
+			p.pos--
+			value, err := p.ident()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect("="); err != nil {
+				return nil, err
+			}
+			number, err := p.numberPtr()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.skipOptions(); err != nil {
+				return nil, err
+			}
+			if err := p.expect(";"); err != nil {
+				return nil, err
+			}
+			enum.Value = append(enum.Value, &descriptor.EnumValueDescriptorProto{
+				Name:   proto.String(value),
+				Number: number,
+			})
+		}
+	}
+}
+
+// resolveTypes resolves the type names of the fields to the fully qualified
+// names of the messages and enums, searching the scopes from the innermost
+// one outwards like protoc.
+func resolveTypes(files []*descriptor.FileDescriptorProto) error {
+	enums := make(map[string]bool)
+	var collect func(prefix string, msgs []*descriptor.DescriptorProto, en []*descriptor.EnumDescriptorProto)
+	collect = func(prefix string, msgs []*descriptor.DescriptorProto, en []*descriptor.EnumDescriptorProto) {
+		for _, e := range en {
+			enums[join(prefix, e.GetName())] = true
+		}
+		for _, m := range msgs {
+			name := join(prefix, m.GetName())
+			enums[name] = false
+			collect(name, m.GetNestedType(), m.GetEnumType())
+		}
+	}
+	for _, fd := range files {
+		collect(fd.GetPackage(), fd.GetMessageType(), fd.GetEnumType())
+	}
+
+	var resolve func(file string, scope string, msgs []*descriptor.DescriptorProto) error
+	resolve = func(file string, scope string, msgs []*descriptor.DescriptorProto) error {
+		for _, m := range msgs {
+			name := join(scope, m.GetName())
+			for _, f := range m.GetField() {
+				if f.TypeName == nil {
+					continue
+				}
+				full, ok := lookupType(enums, name, f.GetTypeName())
+				if !ok {
+					return fmt.Errorf("%s: unknown type %s of field %s.%s", file, f.GetTypeName(), name, f.GetName())
+				}
+				f.TypeName = proto.String("." + full)
+				switch {
+				case f.GetType() == descriptor.FieldDescriptorProto_TYPE_GROUP:
+				case enums[full]:
+					f.Type = descriptor.FieldDescriptorProto_TYPE_ENUM.Enum()
+				default:
+					f.Type = descriptor.FieldDescriptorProto_TYPE_MESSAGE.Enum()
+				}
+			}
+			if err := resolve(file, name, m.GetNestedType()); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for _, fd := range files {
+		if err := resolve(fd.GetName(), fd.GetPackage(), fd.GetMessageType()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// lookupType returns the fully qualified name of the type referenced from
+// the scope.
+func lookupType(types map[string]bool, scope string, name string) (string, bool) {
+	if strings.HasPrefix(name, ".") {
+		_, ok := types[name[1:]]
+		return name[1:], ok
+	}
+	for {
+		full := join(scope, name)
+		if _, ok := types[full]; ok {
+			return full, true
+		}
+		if scope == "" {
+			return "", false
+		}
+		if i := strings.LastIndexByte(scope, '.'); i >= 0 {
+			scope = scope[:i]
+		} else {
+			scope = ""
+		}
+	}
+}
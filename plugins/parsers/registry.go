@@ -2,16 +2,24 @@ package parsers
 
 import (
 	"fmt"
+	"io"
 
 	"github.com/influxdata/telegraf"
 
+	"github.com/influxdata/telegraf/plugins/parsers/avro"
 	"github.com/influxdata/telegraf/plugins/parsers/collectd"
+	"github.com/influxdata/telegraf/plugins/parsers/csv"
 	"github.com/influxdata/telegraf/plugins/parsers/dropwizard"
 	"github.com/influxdata/telegraf/plugins/parsers/graphite"
 	"github.com/influxdata/telegraf/plugins/parsers/influx"
 	"github.com/influxdata/telegraf/plugins/parsers/json"
+	"github.com/influxdata/telegraf/plugins/parsers/json_v2"
 	"github.com/influxdata/telegraf/plugins/parsers/nagios"
+	"github.com/influxdata/telegraf/plugins/parsers/openmetrics"
+	"github.com/influxdata/telegraf/plugins/parsers/protobuf"
+	"github.com/influxdata/telegraf/plugins/parsers/syslog"
 	"github.com/influxdata/telegraf/plugins/parsers/value"
+	"github.com/influxdata/telegraf/plugins/parsers/xml"
 )
 
 // ParserInput is an interface for input plugins that are able to parse
@@ -43,10 +51,21 @@ type Parser interface {
 	SetDefaultTags(tags map[string]string)
 }
 
+// StreamParser is implemented by parsers able to parse a reader row by row,
+// without holding the whole document in memory.
+type StreamParser interface {
+	Parser
+
+	// ParseStream parses r and calls fn for every metric, parsing stops at
+	// the first error returned by fn.
+	ParseStream(r io.Reader, fn func(telegraf.Metric) error) error
+}
+
 // Config is a struct that covers the data types needed for all parser types,
 // and can be used to instantiate _any_ of the parsers.
 type Config struct {
-	// Dataformat can be one of: json, influx, graphite, value, nagios
+	// Dataformat can be one of: json, json_v2, influx, graphite, value, nagios, xml,
+	// protobuf, avro, csv, openmetrics, syslog
 	DataFormat string
 
 	// Separator only applied to Graphite data.
@@ -87,6 +106,107 @@ type Config struct {
 	// an optional map containing tag names as keys and json paths to retrieve the tag values from as values
 	// used if TagsPath is empty or doesn't return any tags
 	DropwizardTagPathsMap map[string]string
+
+	// an optional gjson path selecting the objects to convert into metrics,
+	// nested arrays are expanded into one metric per object
+	JSONV2ObjectPath string
+	// optional gjson paths to the measurement name and the time of the metric
+	JSONV2MeasurementPath string
+	JSONV2TimestampPath   string
+	// format of the time, one of unix, unix_ms, unix_us, unix_ns or a Go
+	// time layout; defaults to unix
+	JSONV2TimestampFormat string
+	// maps of tag and field names to the gjson path returning their values
+	JSONV2Tags   map[string]string
+	JSONV2Fields map[string]string
+	// map of field names to the type they are converted to, one of int,
+	// uint, float, bool or string
+	JSONV2FieldTypes map[string]string
+
+	// XPath selecting the nodes to convert into metrics, defaults to the
+	// whole document
+	XMLMetricSelection string
+	// an optional XPath returning the measurement name
+	XMLMetricName string
+	// an optional XPath returning the metric time
+	XMLTimestamp string
+	// format of the time, one of unix, unix_ms, unix_us, unix_ns or a Go
+	// time layout; defaults to unix
+	XMLTimestampFormat string
+	// maps of tag and field names to the XPath returning their values
+	XMLTags      map[string]string
+	XMLFields    map[string]string
+	XMLFieldsInt map[string]string
+	// an optional XPath selecting leaf nodes which are added as fields
+	// named after the node
+	XMLFieldSelection string
+
+	// .proto files, or descriptor sets as written by protoc
+	// --descriptor_set_out, defining the message type to decode
+	ProtobufDescriptorFiles []string
+	// directories searched for the imports of the .proto files
+	ProtobufImportPaths []string
+	// fully qualified name of the message type, ie "pkg.Message"
+	ProtobufMessageType string
+	// flattened field names to add as tags instead of fields
+	ProtobufTagKeys []string
+	// an optional field holding the metric time, either in unix seconds or
+	// as a google.protobuf.Timestamp
+	ProtobufTimestampField string
+
+	// URL of the schema registry resolving the schema ids of messages in the
+	// Confluent wire format, and the credentials used to access it
+	AvroSchemaRegistry         string
+	AvroSchemaRegistryUsername string
+	AvroSchemaRegistryPassword string
+	// a fixed schema used instead of the schema registry
+	AvroSchema string
+	// optional record fields holding the measurement name and the metric time
+	AvroMeasurement     string
+	AvroTimestamp       string
+	AvroTimestampFormat string
+	// record fields to use as tags and fields, all fields which are not tags
+	// are used if AvroFields is empty
+	AvroTags   []string
+	AvroFields []string
+	// separator joining the names of nested fields, defaults to "_"
+	AvroFieldSeparator string
+
+	// number of header rows to read the column names from, the cells of
+	// multiple rows are joined by an underscore
+	CSVHeaderRowCount int
+	// number of rows to skip before the header, and of columns to skip at
+	// the start of each row
+	CSVSkipRows    int
+	CSVSkipColumns int
+	// single characters separating the columns and starting comment lines
+	CSVDelimiter string
+	CSVComment   string
+	CSVTrimSpace bool
+	// column names used instead of the header
+	CSVColumnNames []string
+	// map of column names to the type they are converted to, one of int,
+	// float, bool or string
+	CSVColumnTypes map[string]string
+	CSVTagColumns  []string
+	// optional columns holding the measurement name and the metric time
+	CSVMeasurementColumn string
+	CSVTimestampColumn   string
+	CSVTimestampFormat   string
+	// map of column names to a regular expression, rows with a matching
+	// value are skipped
+	CSVSkipIf map[string]string
+
+	// add the exemplars of OpenMetrics counters and histogram buckets as
+	// separate metrics
+	OpenMetricsExemplars bool
+
+	// one of auto, rfc5424 or rfc3164
+	SyslogFormat string
+	// timezone and year of RFC 3164 timestamps, which have neither, the
+	// year is inferred from the current time if 0
+	SyslogTimezone string
+	SyslogYear     int
 }
 
 // NewParser returns a Parser interface based on the given config.
@@ -97,6 +217,8 @@ func NewParser(config *Config) (Parser, error) {
 	case "json":
 		parser, err = NewJSONParser(config.MetricName,
 			config.TagKeys, config.DefaultTags)
+	case "json_v2":
+		parser, err = NewJSONV2Parser(config)
 	case "value":
 		parser, err = NewValueParser(config.MetricName,
 			config.DataType, config.DefaultTags)
@@ -120,6 +242,18 @@ func NewParser(config *Config) (Parser, error) {
 			config.DefaultTags,
 			config.Separator,
 			config.Templates)
+	case "xml":
+		parser, err = NewXMLParser(config)
+	case "protobuf":
+		parser, err = NewProtobufParser(config)
+	case "avro":
+		parser, err = NewAvroParser(config)
+	case "csv":
+		parser, err = NewCSVParser(config)
+	case "openmetrics":
+		parser, err = NewOpenMetricsParser(config)
+	case "syslog":
+		parser, err = NewSyslogParser(config)
 	default:
 		err = fmt.Errorf("Invalid data format: %s", config.DataFormat)
 	}
@@ -139,6 +273,28 @@ func NewJSONParser(
 	return parser, nil
 }
 
+func NewJSONV2Parser(config *Config) (Parser, error) {
+	for field, typ := range config.JSONV2FieldTypes {
+		switch typ {
+		case "int", "uint", "float", "bool", "string":
+		default:
+			return nil, fmt.Errorf("invalid type %q for field %q", typ, field)
+		}
+	}
+
+	return &json_v2.Parser{
+		MetricName:      config.MetricName,
+		ObjectPath:      config.JSONV2ObjectPath,
+		MeasurementPath: config.JSONV2MeasurementPath,
+		TimestampPath:   config.JSONV2TimestampPath,
+		TimestampFormat: config.JSONV2TimestampFormat,
+		Tags:            config.JSONV2Tags,
+		Fields:          config.JSONV2Fields,
+		FieldTypes:      config.JSONV2FieldTypes,
+		DefaultTags:     config.DefaultTags,
+	}, nil
+}
+
 func NewNagiosParser() (Parser, error) {
 	return &nagios.NagiosParser{}, nil
 }
@@ -200,3 +356,101 @@ func NewDropwizardParser(
 	}
 	return parser, err
 }
+
+func NewXMLParser(config *Config) (Parser, error) {
+	parser := &xml.Parser{
+		MetricName:      config.MetricName,
+		MetricSelection: config.XMLMetricSelection,
+		MetricNameQuery: config.XMLMetricName,
+		Timestamp:       config.XMLTimestamp,
+		TimestampFormat: config.XMLTimestampFormat,
+		Tags:            config.XMLTags,
+		Fields:          config.XMLFields,
+		FieldsInt:       config.XMLFieldsInt,
+		FieldSelection:  config.XMLFieldSelection,
+		DefaultTags:     config.DefaultTags,
+	}
+	if err := parser.Compile(); err != nil {
+		return nil, err
+	}
+	return parser, nil
+}
+
+func NewProtobufParser(config *Config) (Parser, error) {
+	parser, err := protobuf.NewParser(config.ProtobufDescriptorFiles,
+		config.ProtobufImportPaths, config.ProtobufMessageType)
+	if err != nil {
+		return nil, err
+	}
+	parser.MetricName = config.MetricName
+	parser.TagKeys = config.ProtobufTagKeys
+	parser.TimestampField = config.ProtobufTimestampField
+	parser.DefaultTags = config.DefaultTags
+	return parser, nil
+}
+
+func NewAvroParser(config *Config) (Parser, error) {
+	parser := &avro.Parser{
+		MetricName:       config.MetricName,
+		SchemaRegistry:   config.AvroSchemaRegistry,
+		RegistryUsername: config.AvroSchemaRegistryUsername,
+		RegistryPassword: config.AvroSchemaRegistryPassword,
+		Schema:           config.AvroSchema,
+		Measurement:      config.AvroMeasurement,
+		Tags:             config.AvroTags,
+		Fields:           config.AvroFields,
+		Timestamp:        config.AvroTimestamp,
+		TimestampFormat:  config.AvroTimestampFormat,
+		FieldSeparator:   config.AvroFieldSeparator,
+		DefaultTags:      config.DefaultTags,
+	}
+	if err := parser.Init(); err != nil {
+		return nil, err
+	}
+	return parser, nil
+}
+
+func NewCSVParser(config *Config) (Parser, error) {
+	parser := &csv.Parser{
+		MetricName:        config.MetricName,
+		HeaderRowCount:    config.CSVHeaderRowCount,
+		SkipRows:          config.CSVSkipRows,
+		SkipColumns:       config.CSVSkipColumns,
+		Delimiter:         config.CSVDelimiter,
+		Comment:           config.CSVComment,
+		TrimSpace:         config.CSVTrimSpace,
+		ColumnNames:       config.CSVColumnNames,
+		ColumnTypes:       config.CSVColumnTypes,
+		TagColumns:        config.CSVTagColumns,
+		MeasurementColumn: config.CSVMeasurementColumn,
+		TimestampColumn:   config.CSVTimestampColumn,
+		TimestampFormat:   config.CSVTimestampFormat,
+		SkipIf:            config.CSVSkipIf,
+		DefaultTags:       config.DefaultTags,
+	}
+	if err := parser.Compile(); err != nil {
+		return nil, err
+	}
+	return parser, nil
+}
+
+func NewOpenMetricsParser(config *Config) (Parser, error) {
+	return &openmetrics.Parser{
+		Exemplars:   config.OpenMetricsExemplars,
+		DefaultTags: config.DefaultTags,
+	}, nil
+}
+
+func NewSyslogParser(config *Config) (Parser, error) {
+	parser := &syslog.Parser{
+		MetricName:  config.MetricName,
+		Format:      config.SyslogFormat,
+		Timezone:    config.SyslogTimezone,
+		Year:        config.SyslogYear,
+		DefaultTags: config.DefaultTags,
+	}
+	if err := parser.Init(); err != nil {
+		return nil, err
+	}
+	return parser, nil
+}
@@ -0,0 +1,342 @@
+package syslog
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+)
+
+var facilities = []string{
+	"kern", "user", "mail", "daemon", "auth", "syslog", "lpr", "news",
+	"uucp", "cron", "authpriv", "ftp", "ntp", "security", "console",
+	"solaris-cron", "local0", "local1", "local2", "local3", "local4",
+	"local5", "local6", "local7",
+}
+
+var severities = []string{
+	"emerg", "alert", "crit", "err", "warning", "notice", "info", "debug",
+}
+
+// Layouts of the RFC 3164 timestamp, some devices add the year or use the
+// RFC 3339 timestamps of RFC 5424.
+var bsdLayouts = []string{
+	time.StampMicro,
+	"Jan _2 2006 15:04:05",
+	time.Stamp,
+	time.RFC3339Nano,
+}
+
+// Parser parses syslog messages in the RFC 5424 format and in the legacy BSD
+// format of RFC 3164, one message per line.  The RFC 3164 timestamp has no
+// year and no timezone, they are taken from Year and Timezone.
+type Parser struct {
+	MetricName string
+	// one of auto, rfc5424 or rfc3164
+	Format string
+	// location of RFC 3164 timestamps, defaults to the local time
+	Timezone string
+	// year of RFC 3164 timestamps, the year is inferred from the current
+	// time if 0
+	Year        int
+	DefaultTags map[string]string
+
+	location *time.Location
+	timeFunc func() time.Time
+}
+
+// Init validates the format and loads the timezone, it must be called before
+// parsing.
+func (p *Parser) Init() error {
+	switch p.Format {
+	case "":
+		p.Format = "auto"
+	case "auto", "rfc5424", "rfc3164":
+	default:
+		return fmt.Errorf("invalid syslog format %q, must be one of auto, rfc5424 or rfc3164", p.Format)
+	}
+
+	p.location = time.Local
+	if p.Timezone != "" {
+		loc, err := time.LoadLocation(p.Timezone)
+		if err != nil {
+			return fmt.Errorf("invalid syslog timezone %q: %s", p.Timezone, err)
+		}
+		p.location = loc
+	}
+	if p.timeFunc == nil {
+		p.timeFunc = time.Now
+	}
+	return nil
+}
+
+func (p *Parser) Parse(buf []byte) ([]telegraf.Metric, error) {
+	metrics := make([]telegraf.Metric, 0)
+	for _, line := range strings.Split(string(buf), "\n") {
+		line = strings.TrimRight(line, "\r")
+		if line == "" {
+			continue
+		}
+		m, err := p.ParseLine(line)
+		if err != nil {
+			return nil, err
+		}
+		metrics = append(metrics, m)
+	}
+	return metrics, nil
+}
+
+func (p *Parser) ParseLine(line string) (telegraf.Metric, error) {
+	pri, rest, err := parsePriority(line)
+	if err != nil {
+		return nil, err
+	}
+
+	tags := make(map[string]string)
+	for k, v := range p.DefaultTags {
+		tags[k] = v
+	}
+	fields := make(map[string]interface{})
+
+	facility, severity := pri/8, pri%8
+	fields["facility_code"] = facility
+	fields["severity_code"] = severity
+	if facility < len(facilities) {
+		tags["facility"] = facilities[facility]
+	}
+	tags["severity"] = severities[severity]
+
+	format := p.Format
+	if format == "auto" {
+		format = "rfc3164"
+		if len(rest) > 1 && rest[0] >= '1' && rest[0] <= '9' && rest[1] == ' ' {
+			format = "rfc5424"
+		}
+	}
+
+	var t time.Time
+	if format == "rfc5424" {
+		t, err = p.parseRFC5424(rest, tags, fields)
+	} else {
+		t, err = p.parseRFC3164(rest, tags, fields)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse %s message %q: %s", format, line, err)
+	}
+
+	return metric.New(p.MetricName, tags, fields, t)
+}
+
+func (p *Parser) SetDefaultTags(tags map[string]string) {
+	p.DefaultTags = tags
+}
+
+func parsePriority(line string) (int, string, error) {
+	end := strings.IndexByte(line, '>')
+	if !strings.HasPrefix(line, "<") || end < 2 || end > 4 {
+		return 0, "", fmt.Errorf("missing priority in syslog message %q", line)
+	}
+	pri, err := strconv.Atoi(line[1:end])
+	if err != nil || pri > 191 {
+		return 0, "", fmt.Errorf("invalid priority in syslog message %q", line)
+	}
+	return pri, line[end+1:], nil
+}
+
+// parseRFC5424 parses the message following the priority:
+//
+//	VERSION TIMESTAMP HOSTNAME APP-NAME PROCID MSGID STRUCTURED-DATA [MSG]
+func (p *Parser) parseRFC5424(msg string, tags map[string]string, fields map[string]interface{}) (time.Time, error) {
+	parts := strings.SplitN(msg, " ", 7)
+	if len(parts) < 7 {
+		return time.Time{}, fmt.Errorf("missing header fields")
+	}
+
+	version, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid version %q", parts[0])
+	}
+	fields["version"] = version
+
+	t := p.timeFunc()
+	if parts[1] != "-" {
+		t, err = time.Parse(time.RFC3339Nano, parts[1])
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid timestamp %q", parts[1])
+		}
+	}
+
+	if parts[2] != "-" {
+		tags["hostname"] = parts[2]
+	}
+	if parts[3] != "-" {
+		tags["appname"] = parts[3]
+	}
+	if parts[4] != "-" {
+		fields["procid"] = parts[4]
+	}
+	if parts[5] != "-" {
+		fields["msgid"] = parts[5]
+	}
+
+	rest := parts[6]
+	if strings.HasPrefix(rest, "-") {
+		rest = rest[1:]
+	} else {
+		rest, err = parseStructuredData(rest, fields)
+		if err != nil {
+			return time.Time{}, err
+		}
+	}
+
+	if strings.HasPrefix(rest, " ") {
+		message := strings.TrimPrefix(rest[1:], "\xef\xbb\xbf")
+		if message != "" {
+			fields["message"] = message
+		}
+	} else if rest != "" {
+		return time.Time{}, fmt.Errorf("invalid structured data")
+	}
+	return t, nil
+}
+
+// parseStructuredData adds the parameters of the structured data elements,
+// ie [id param="value"], as fields named id_param and returns the rest of
+// the message.
+func parseStructuredData(s string, fields map[string]interface{}) (string, error) {
+	for strings.HasPrefix(s, "[") {
+		end := strings.IndexAny(s, " ]")
+		if end < 2 {
+			return "", fmt.Errorf("invalid structured data element")
+		}
+		id := s[1:end]
+		s = s[end:]
+
+		for strings.HasPrefix(s, " ") {
+			s = s[1:]
+			eq := strings.Index(s, `="`)
+			if eq < 1 {
+				return "", fmt.Errorf("invalid structured data parameter in %s", id)
+			}
+			name := s[:eq]
+			s = s[eq+2:]
+
+			var value []byte
+			i := 0
+			for ; i < len(s) && s[i] != '"'; i++ {
+				if s[i] == '\\' && i+1 < len(s) && strings.IndexByte(`"\]`, s[i+1]) >= 0 {
+					i++
+				}
+				value = append(value, s[i])
+			}
+			if i == len(s) {
+				return "", fmt.Errorf("unterminated structured data parameter %s", name)
+			}
+			fields[id+"_"+name] = string(value)
+			s = s[i+1:]
+		}
+
+		if !strings.HasPrefix(s, "]") {
+			return "", fmt.Errorf("unterminated structured data element %s", id)
+		}
+		s = s[1:]
+	}
+	return s, nil
+}
+
+// parseRFC3164 parses the message following the priority:
+//
+//	TIMESTAMP [HOSTNAME] TAG[PID]: MSG
+func (p *Parser) parseRFC3164(msg string, tags map[string]string, fields map[string]interface{}) (time.Time, error) {
+	t, rest, err := p.parseBSDTimestamp(msg)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	// The hostname is optional, the first token is the tag if it ends with
+	// a colon or the pid
+	if i := strings.IndexByte(rest, ' '); i > 0 && !isTag(rest[:i]) {
+		tags["hostname"] = rest[:i]
+		rest = rest[i+1:]
+	}
+
+	if i := strings.IndexAny(rest, ":[ "); i > 0 && i <= 48 && rest[i] != ' ' {
+		tags["appname"] = rest[:i]
+		rest = rest[i:]
+		if strings.HasPrefix(rest, "[") {
+			if end := strings.IndexByte(rest, ']'); end > 0 {
+				fields["procid"] = rest[1:end]
+				rest = rest[end+1:]
+			}
+		}
+		rest = strings.TrimPrefix(strings.TrimPrefix(rest, ":"), " ")
+	}
+
+	if rest != "" {
+		fields["message"] = rest
+	}
+	return t, nil
+}
+
+func isTag(token string) bool {
+	return strings.HasSuffix(token, ":") || strings.HasSuffix(token, "]")
+}
+
+// parseBSDTimestamp parses the timestamp at the start of msg and returns the
+// rest of the message.
+func (p *Parser) parseBSDTimestamp(msg string) (time.Time, string, error) {
+	for _, layout := range bsdLayouts {
+		var n int
+		if layout == time.RFC3339Nano {
+			n = strings.IndexByte(msg, ' ')
+			if n < 0 {
+				n = len(msg)
+			}
+		} else {
+			n = len(layout)
+			if n > len(msg) {
+				continue
+			}
+		}
+
+		ts := msg[:n]
+		if layout == time.RFC3339Nano {
+			t, err := time.Parse(layout, ts)
+			if err != nil {
+				continue
+			}
+			return t, strings.TrimPrefix(msg[n:], " "), nil
+		}
+
+		t, err := time.ParseInLocation(layout, ts, p.location)
+		if err != nil {
+			continue
+		}
+		if !strings.Contains(layout, "2006") {
+			t = p.withYear(t)
+		}
+		return t, strings.TrimPrefix(msg[n:], " "), nil
+	}
+	return time.Time{}, "", fmt.Errorf("invalid timestamp")
+}
+
+// withYear sets the year of a timestamp parsed without one.  Without a
+// configured year the current year is used, unless the timestamp would be
+// more than a day in the future, as happens with messages sent just before
+// the new year, in which case it is from the previous year.
+func (p *Parser) withYear(t time.Time) time.Time {
+	year := p.Year
+	now := p.timeFunc().In(p.location)
+	if year == 0 {
+		year = now.Year()
+	}
+
+	t = time.Date(year, t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), p.location)
+	if p.Year == 0 && t.After(now.Add(24*time.Hour)) {
+		t = t.AddDate(-1, 0, 0)
+	}
+	return t
+}
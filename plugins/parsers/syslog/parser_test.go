@@ -0,0 +1,145 @@
+package syslog
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newParser(t *testing.T, format string) *Parser {
+	p := &Parser{
+		MetricName: "syslog",
+		Format:     format,
+		Timezone:   "UTC",
+		timeFunc: func() time.Time {
+			return time.Date(2018, time.March, 10, 12, 0, 0, 0, time.UTC)
+		},
+	}
+	require.NoError(t, p.Init())
+	return p
+}
+
+func TestParseRFC5424(t *testing.T) {
+	p := newParser(t, "rfc5424")
+
+	m, err := p.ParseLine(`<165>1 2003-10-11T22:14:15.003Z mymachine.example.com evntslog 1234 ID47 [exampleSDID@32473 iut="3" eventSource="Application \"A\""][origin ip="192.0.2.1"] ` + "\xef\xbb\xbf" + `An application event`)
+	require.NoError(t, err)
+	assert.Equal(t, "syslog", m.Name())
+	assert.Equal(t, map[string]string{
+		"facility": "local4",
+		"severity": "notice",
+		"hostname": "mymachine.example.com",
+		"appname":  "evntslog",
+	}, m.Tags())
+	assert.Equal(t, map[string]interface{}{
+		"facility_code":                 int64(20),
+		"severity_code":                 int64(5),
+		"version":                       int64(1),
+		"procid":                        "1234",
+		"msgid":                         "ID47",
+		"exampleSDID@32473_iut":         "3",
+		"exampleSDID@32473_eventSource": `Application "A"`,
+		"origin_ip":                     "192.0.2.1",
+		"message":                       "An application event",
+	}, m.Fields())
+	assert.Equal(t, time.Date(2003, time.October, 11, 22, 14, 15, 3000000, time.UTC), m.Time().UTC())
+
+	m, err = p.ParseLine(`<34>1 - - - - - -`)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"facility": "auth", "severity": "crit"}, m.Tags())
+	assert.Equal(t, map[string]interface{}{
+		"facility_code": int64(4),
+		"severity_code": int64(2),
+		"version":       int64(1),
+	}, m.Fields())
+	assert.Equal(t, p.timeFunc(), m.Time())
+}
+
+func TestParseRFC3164(t *testing.T) {
+	p := newParser(t, "rfc3164")
+
+	m, err := p.ParseLine(`<34>Oct 11 22:14:15 mymachine su[230]: 'su root' failed for lonvick on /dev/pts/8`)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"facility": "auth",
+		"severity": "crit",
+		"hostname": "mymachine",
+		"appname":  "su",
+	}, m.Tags())
+	assert.Equal(t, map[string]interface{}{
+		"facility_code": int64(4),
+		"severity_code": int64(2),
+		"procid":        "230",
+		"message":       "'su root' failed for lonvick on /dev/pts/8",
+	}, m.Fields())
+	// a timestamp too far in the future is from the previous year
+	assert.Equal(t, time.Date(2017, time.October, 11, 22, 14, 15, 0, time.UTC), m.Time().UTC())
+
+	// without a hostname
+	m, err = p.ParseLine(`<13>Mar  9 08:00:00 kernel: link up`)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"facility": "user",
+		"severity": "notice",
+		"appname":  "kernel",
+	}, m.Tags())
+	assert.Equal(t, "link up", m.Fields()["message"])
+	assert.Equal(t, time.Date(2018, time.March, 9, 8, 0, 0, 0, time.UTC), m.Time().UTC())
+
+	// without a tag, with the year
+	m, err = p.ParseLine(`<13>Mar  9 2016 08:00:00 switch01 port 3 down`)
+	require.NoError(t, err)
+	assert.Equal(t, "switch01", m.Tags()["hostname"])
+	assert.Equal(t, "port 3 down", m.Fields()["message"])
+	assert.Equal(t, time.Date(2016, time.March, 9, 8, 0, 0, 0, time.UTC), m.Time().UTC())
+
+	_, err = p.ParseLine(`<13>yesterday switch01 port 3 down`)
+	assert.Error(t, err)
+}
+
+func TestParseTimezoneAndYear(t *testing.T) {
+	p := &Parser{
+		MetricName: "syslog",
+		Timezone:   "America/New_York",
+		Year:       2015,
+	}
+	require.NoError(t, p.Init())
+
+	m, err := p.ParseLine(`<13>Dec 31 23:00:00 host app: message`)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2016, time.January, 1, 4, 0, 0, 0, time.UTC), m.Time().UTC())
+}
+
+func TestParseAuto(t *testing.T) {
+	p := newParser(t, "")
+	p.SetDefaultTags(map[string]string{"source": "udp"})
+
+	metrics, err := p.Parse([]byte("<34>1 2003-10-11T22:14:15.003Z host app - - - hello\r\n\n<34>Oct 11 22:14:15 host app: hello\n"))
+	require.NoError(t, err)
+	require.Len(t, metrics, 2)
+	assert.Equal(t, int64(1), metrics[0].Fields()["version"])
+	assert.Equal(t, "hello", metrics[0].Fields()["message"])
+	assert.Equal(t, "udp", metrics[0].Tags()["source"])
+	_, ok := metrics[1].Fields()["version"]
+	assert.False(t, ok)
+	assert.Equal(t, "hello", metrics[1].Fields()["message"])
+}
+
+func TestParseInvalid(t *testing.T) {
+	p := newParser(t, "auto")
+	for _, line := range []string{
+		"no priority",
+		"<192>Oct 11 22:14:15 host app: hello",
+		"<34>1 2003-10-11 host app - - - hello",
+		"<34>1 - host app - - [id a=b] hello",
+		"<34>1 - host app - - [id a=\"b\" hello",
+	} {
+		_, err := p.ParseLine(line)
+		assert.Error(t, err, line)
+	}
+
+	assert.Error(t, (&Parser{Format: "rfc3339"}).Init())
+	assert.Error(t, (&Parser{Timezone: "Mars/Olympus"}).Init())
+}
@@ -0,0 +1,296 @@
+package xml
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/antchfx/xmlquery"
+	"github.com/antchfx/xpath"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/metric"
+)
+
+// Parser parses XML documents into metrics using XPath expressions. Every
+// node matched by MetricSelection becomes one metric, and all other
+// expressions are evaluated relative to that node.
+type Parser struct {
+	MetricName      string
+	MetricSelection string
+	MetricNameQuery string
+	Timestamp       string
+	TimestampFormat string
+	Tags            map[string]string
+	Fields          map[string]string
+	FieldsInt       map[string]string
+	FieldSelection  string
+	DefaultTags     map[string]string
+
+	selection      *xpath.Expr
+	nameQuery      *xpath.Expr
+	timestamp      *xpath.Expr
+	tags           map[string]*xpath.Expr
+	fields         map[string]*xpath.Expr
+	fieldsInt      map[string]*xpath.Expr
+	fieldSelection *xpath.Expr
+}
+
+// Compile compiles the configured XPath expressions, it must be called
+// before parsing.
+func (p *Parser) Compile() error {
+	var err error
+
+	selection := p.MetricSelection
+	if selection == "" {
+		selection = "/"
+	}
+	if p.selection, err = compile("metric selection", selection); err != nil {
+		return err
+	}
+	if p.MetricNameQuery != "" {
+		if p.nameQuery, err = compile("metric name", p.MetricNameQuery); err != nil {
+			return err
+		}
+	}
+	if p.Timestamp != "" {
+		if p.timestamp, err = compile("timestamp", p.Timestamp); err != nil {
+			return err
+		}
+	}
+	if p.FieldSelection != "" {
+		if p.fieldSelection, err = compile("field selection", p.FieldSelection); err != nil {
+			return err
+		}
+	}
+	if p.tags, err = compileAll("tag", p.Tags); err != nil {
+		return err
+	}
+	if p.fields, err = compileAll("field", p.Fields); err != nil {
+		return err
+	}
+	if p.fieldsInt, err = compileAll("field", p.FieldsInt); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (p *Parser) Parse(buf []byte) ([]telegraf.Metric, error) {
+	buf = bytes.TrimSpace(buf)
+	if len(buf) == 0 {
+		return []telegraf.Metric{}, nil
+	}
+
+	doc, err := xmlquery.Parse(bytes.NewReader(buf))
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse XML: %s", err)
+	}
+
+	now := time.Now()
+	metrics := make([]telegraf.Metric, 0)
+	iter := p.selection.Select(xmlquery.CreateXPathNavigator(doc))
+	for iter.MoveNext() {
+		node := iter.Current().(*xmlquery.NodeNavigator).Current()
+		m, err := p.parseNode(node, now)
+		if err != nil {
+			return nil, err
+		}
+		if m != nil {
+			metrics = append(metrics, m)
+		}
+	}
+	return metrics, nil
+}
+
+func (p *Parser) parseNode(node *xmlquery.Node, now time.Time) (telegraf.Metric, error) {
+	name := p.MetricName
+	if p.nameQuery != nil {
+		if v := toString(evaluate(p.nameQuery, node)); v != "" {
+			name = v
+		}
+	}
+
+	t := now
+	if p.timestamp != nil {
+		if s := toString(evaluate(p.timestamp, node)); s != "" {
+			var err error
+			t, err = internal.ParseTimestamp(s, p.TimestampFormat)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	tags := make(map[string]string)
+	for k, v := range p.DefaultTags {
+		tags[k] = v
+	}
+	for k, expr := range p.tags {
+		if v := toString(evaluate(expr, node)); v != "" {
+			tags[k] = v
+		}
+	}
+
+	fields := make(map[string]interface{})
+	if p.fieldSelection != nil {
+		iter := p.fieldSelection.Select(xmlquery.CreateXPathNavigator(node))
+		for iter.MoveNext() {
+			nav := iter.Current().(*xmlquery.NodeNavigator)
+			if hasChildElements(nav.Current()) {
+				continue
+			}
+			if v := nav.Value(); v != "" {
+				fields[nav.LocalName()] = autoType(v)
+			}
+		}
+	}
+	for k, expr := range p.fields {
+		switch v := evaluate(expr, node).(type) {
+		case float64:
+			if !math.IsNaN(v) {
+				fields[k] = v
+			}
+		case bool:
+			fields[k] = v
+		case string:
+			if v != "" {
+				fields[k] = autoType(v)
+			}
+		}
+	}
+	for k, expr := range p.fieldsInt {
+		// values such as "N/A" skip the field rather than the document
+		v, err := toInt(evaluate(expr, node))
+		if err != nil {
+			log.Printf("W! [parsers.xml] Skipping field %q: unable to convert to integer: %s", k, err)
+			continue
+		}
+		if v != nil {
+			fields[k] = *v
+		}
+	}
+
+	if len(fields) == 0 {
+		return nil, nil
+	}
+	return metric.New(name, tags, fields, t)
+}
+
+func (p *Parser) ParseLine(line string) (telegraf.Metric, error) {
+	metrics, err := p.Parse([]byte(line))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(metrics) < 1 {
+		return nil, fmt.Errorf("Can not parse the line: %s, for data format: xml ", line)
+	}
+
+	return metrics[0], nil
+}
+
+func (p *Parser) SetDefaultTags(tags map[string]string) {
+	p.DefaultTags = tags
+}
+
+func compile(what string, expr string) (*xpath.Expr, error) {
+	e, err := xpath.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid XPath for %s %q: %s", what, expr, err)
+	}
+	return e, nil
+}
+
+func compileAll(what string, exprs map[string]string) (map[string]*xpath.Expr, error) {
+	compiled := make(map[string]*xpath.Expr, len(exprs))
+	for k, v := range exprs {
+		e, err := compile(what+" "+k, v)
+		if err != nil {
+			return nil, err
+		}
+		compiled[k] = e
+	}
+	return compiled, nil
+}
+
+// evaluate returns the result of expr relative to node. Node-sets are
+// reduced to the text of their first node.
+func evaluate(expr *xpath.Expr, node *xmlquery.Node) interface{} {
+	v := expr.Evaluate(xmlquery.CreateXPathNavigator(node))
+	if iter, ok := v.(*xpath.NodeIterator); ok {
+		if !iter.MoveNext() {
+			return ""
+		}
+		return strings.TrimSpace(iter.Current().Value())
+	}
+	return v
+}
+
+func hasChildElements(node *xmlquery.Node) bool {
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type == xmlquery.ElementNode {
+			return true
+		}
+	}
+	return false
+}
+
+func toString(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	return ""
+}
+
+func toInt(v interface{}) (*int64, error) {
+	var i int64
+	switch v := v.(type) {
+	case float64:
+		if math.IsNaN(v) {
+			return nil, nil
+		}
+		i = int64(v)
+	case bool:
+		if v {
+			i = 1
+		}
+	case string:
+		if v == "" {
+			return nil, nil
+		}
+		var err error
+		i, err = strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			f, ferr := strconv.ParseFloat(v, 64)
+			if ferr != nil {
+				return nil, err
+			}
+			i = int64(f)
+		}
+	}
+	return &i, nil
+}
+
+// autoType converts the text of a node to the most specific field type.
+func autoType(s string) interface{} {
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return i
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return f
+	}
+	if s == "true" || s == "false" {
+		return s == "true"
+	}
+	return s
+}
@@ -0,0 +1,189 @@
+package xml
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const volumeStatus = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<cliOutput>
+  <opRet>0</opRet>
+  <volStatus>
+    <volumes>
+      <volume>
+        <volName>gv0</volName>
+        <node>
+          <hostname>node1</hostname>
+          <path>/data/brick1</path>
+          <status>1</status>
+          <port>49152</port>
+          <pid>1234</pid>
+          <sizeTotal>10725883904</sizeTotal>
+          <sizeFree>10690813952</sizeFree>
+          <fsName>xfs</fsName>
+        </node>
+        <node>
+          <hostname>node2</hostname>
+          <path>/data/brick1</path>
+          <status>0</status>
+          <port>N/A</port>
+          <pid>-1</pid>
+          <sizeTotal>10725883904</sizeTotal>
+          <sizeFree>1.5e9</sizeFree>
+          <fsName>xfs</fsName>
+        </node>
+      </volume>
+    </volumes>
+  </volStatus>
+</cliOutput>`
+
+func TestParseSelection(t *testing.T) {
+	p := &Parser{
+		MetricName:      "glusterfs",
+		MetricSelection: "//volume/node",
+		Tags: map[string]string{
+			"volume": "../volName",
+			"host":   "hostname",
+			"path":   "path",
+		},
+		Fields: map[string]string{
+			"online":    "status = 1",
+			"size_free": "number(sizeFree)",
+			"fs":        "fsName",
+		},
+		FieldsInt: map[string]string{
+			"size_total": "sizeTotal",
+			"pid":        "pid",
+			"port":       "port",
+		},
+	}
+	require.NoError(t, p.Compile())
+
+	metrics, err := p.Parse([]byte(volumeStatus))
+	require.NoError(t, err)
+	require.Len(t, metrics, 2)
+
+	assert.Equal(t, "glusterfs", metrics[0].Name())
+	assert.Equal(t, map[string]string{
+		"volume": "gv0",
+		"host":   "node1",
+		"path":   "/data/brick1",
+	}, metrics[0].Tags())
+	assert.Equal(t, map[string]interface{}{
+		"online":     true,
+		"size_free":  float64(10690813952),
+		"fs":         "xfs",
+		"size_total": int64(10725883904),
+		"pid":        int64(1234),
+		"port":       int64(49152),
+	}, metrics[0].Fields())
+
+	// the N/A port of the offline brick is skipped
+	assert.Equal(t, map[string]interface{}{
+		"online":     false,
+		"size_free":  float64(1.5e9),
+		"fs":         "xfs",
+		"size_total": int64(10725883904),
+		"pid":        int64(-1),
+	}, metrics[1].Fields())
+}
+
+func TestParseFieldSelection(t *testing.T) {
+	p := &Parser{
+		MetricName:      "glusterfs",
+		MetricSelection: "//volume/node",
+		FieldSelection:  "*[not(self::hostname) and not(self::path)]",
+		DefaultTags:     map[string]string{"source": "test"},
+	}
+	require.NoError(t, p.Compile())
+
+	metrics, err := p.Parse([]byte(volumeStatus))
+	require.NoError(t, err)
+	require.Len(t, metrics, 2)
+
+	assert.Equal(t, map[string]string{"source": "test"}, metrics[1].Tags())
+	assert.Equal(t, map[string]interface{}{
+		"status":    int64(0),
+		"port":      "N/A",
+		"pid":       int64(-1),
+		"sizeTotal": int64(10725883904),
+		"sizeFree":  float64(1.5e9),
+		"fsName":    "xfs",
+	}, metrics[1].Fields())
+}
+
+func TestParseNameAndTimestamp(t *testing.T) {
+	doc := `<stats>
+  <sample name="cpu" time="2018-05-01T10:00:00Z"><value>1.5</value></sample>
+  <sample name="mem" time="2018-05-01T10:00:10Z"><value>2</value></sample>
+</stats>`
+	p := &Parser{
+		MetricName:      "xml",
+		MetricSelection: "/stats/sample",
+		MetricNameQuery: "@name",
+		Timestamp:       "@time",
+		TimestampFormat: time.RFC3339,
+		Fields:          map[string]string{"value": "value"},
+	}
+	require.NoError(t, p.Compile())
+
+	metrics, err := p.Parse([]byte(doc))
+	require.NoError(t, err)
+	require.Len(t, metrics, 2)
+
+	assert.Equal(t, "cpu", metrics[0].Name())
+	assert.Equal(t, time.Date(2018, 5, 1, 10, 0, 0, 0, time.UTC), metrics[0].Time())
+	assert.Equal(t, map[string]interface{}{"value": float64(1.5)}, metrics[0].Fields())
+	assert.Equal(t, "mem", metrics[1].Name())
+	assert.Equal(t, map[string]interface{}{"value": int64(2)}, metrics[1].Fields())
+}
+
+func TestParseUnixTimestamp(t *testing.T) {
+	p := &Parser{
+		MetricName:      "xml",
+		Timestamp:       "/doc/@ts",
+		TimestampFormat: "unix_ms",
+		Fields:          map[string]string{"value": "number(/doc)"},
+	}
+	require.NoError(t, p.Compile())
+
+	m, err := p.ParseLine(`<doc ts="1525168800000">42</doc>`)
+	require.NoError(t, err)
+	assert.Equal(t, time.Unix(1525168800, 0).UTC(), m.Time())
+	assert.Equal(t, map[string]interface{}{"value": float64(42)}, m.Fields())
+}
+
+func TestParseNoFields(t *testing.T) {
+	p := &Parser{
+		MetricName: "xml",
+		Fields:     map[string]string{"value": "/doc/missing"},
+	}
+	require.NoError(t, p.Compile())
+
+	metrics, err := p.Parse([]byte(`<doc><present>1</present></doc>`))
+	require.NoError(t, err)
+	assert.Len(t, metrics, 0)
+}
+
+func TestParseInvalid(t *testing.T) {
+	p := &Parser{MetricName: "xml"}
+	require.NoError(t, p.Compile())
+
+	_, err := p.Parse([]byte(`<doc><unclosed></doc>`))
+	assert.Error(t, err)
+
+	p = &Parser{MetricName: "xml", Tags: map[string]string{"bad": "//["}}
+	assert.Error(t, p.Compile())
+
+	p = &Parser{
+		MetricName: "xml",
+		FieldsInt:  map[string]string{"value": "/doc"},
+	}
+	require.NoError(t, p.Compile())
+	metrics, err := p.Parse([]byte(`<doc>abc</doc>`))
+	assert.NoError(t, err)
+	assert.Len(t, metrics, 0)
+}
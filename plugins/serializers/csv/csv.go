@@ -0,0 +1,275 @@
+package csv
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/influxdata/telegraf"
+)
+
+type columnKind int
+
+const (
+	timestampColumn columnKind = iota
+	measurementColumn
+	tagColumn
+	fieldColumn
+)
+
+type column struct {
+	kind columnKind
+	key  string
+}
+
+func (c column) String() string {
+	switch c.kind {
+	case timestampColumn:
+		return "timestamp"
+	case measurementColumn:
+		return "measurement"
+	default:
+		return c.key
+	}
+}
+
+// Serializer writes metrics as CSV rows.  The columns are given as a list of
+// "timestamp", "measurement", "tag.<key>" and "field.<key>", without columns
+// the timestamp, measurement, tags and fields of the metrics are written,
+// tags and fields in sorted order.
+type Serializer struct {
+	columns         []column
+	delimiter       rune
+	timestampFormat string
+	header          bool
+	quoteAll        bool
+
+	// columns of the last header written by Serialize
+	headerColumns []column
+}
+
+func NewSerializer(columns []string, delimiter string, timestampFormat string) (*Serializer, error) {
+	s := &Serializer{
+		delimiter:       ',',
+		timestampFormat: timestampFormat,
+	}
+
+	if delimiter != "" {
+		r, size := utf8.DecodeRuneInString(delimiter)
+		if size != len(delimiter) || r == '"' || r == '\r' || r == '\n' {
+			return nil, fmt.Errorf("invalid csv delimiter %q", delimiter)
+		}
+		s.delimiter = r
+	}
+
+	switch timestampFormat {
+	case "", "unix", "unix_ms", "unix_us", "unix_ns":
+	default:
+		// a layout without any element is formatted as itself
+		if time.Unix(0, 0).Format(timestampFormat) == timestampFormat {
+			return nil, fmt.Errorf("invalid csv timestamp format %q", timestampFormat)
+		}
+	}
+
+	for _, name := range columns {
+		switch {
+		case name == "timestamp":
+			s.columns = append(s.columns, column{kind: timestampColumn})
+		case name == "measurement":
+			s.columns = append(s.columns, column{kind: measurementColumn})
+		case strings.HasPrefix(name, "tag.") && len(name) > len("tag."):
+			s.columns = append(s.columns, column{kind: tagColumn, key: name[len("tag."):]})
+		case strings.HasPrefix(name, "field.") && len(name) > len("field."):
+			s.columns = append(s.columns, column{kind: fieldColumn, key: name[len("field."):]})
+		default:
+			return nil, fmt.Errorf("invalid csv column %q", name)
+		}
+	}
+	return s, nil
+}
+
+// SetHeader enables writing a header with the column names.  Serialize
+// writes the header before the first metric, and again when the columns of
+// a metric differ from the last header, SerializeBatch before every batch.
+func (s *Serializer) SetHeader(header bool) {
+	s.header = header
+}
+
+// SetQuoteAll enables quoting all values, otherwise values are only quoted
+// when needed.
+func (s *Serializer) SetQuoteAll(quoteAll bool) {
+	s.quoteAll = quoteAll
+}
+
+func (s *Serializer) Serialize(m telegraf.Metric) ([]byte, error) {
+	columns := s.columnsOf([]telegraf.Metric{m})
+
+	var buf bytes.Buffer
+	if s.header && !sameColumns(columns, s.headerColumns) {
+		s.writeHeader(&buf, columns)
+		s.headerColumns = columns
+	}
+	s.writeRow(&buf, columns, m)
+	return buf.Bytes(), nil
+}
+
+func (s *Serializer) SerializeBatch(metrics []telegraf.Metric) ([]byte, error) {
+	columns := s.columnsOf(metrics)
+
+	var buf bytes.Buffer
+	if s.header {
+		s.writeHeader(&buf, columns)
+	}
+	for _, m := range metrics {
+		s.writeRow(&buf, columns, m)
+	}
+	return buf.Bytes(), nil
+}
+
+// columnsOf returns the configured columns, or the columns of all tags and
+// fields of the metrics.
+func (s *Serializer) columnsOf(metrics []telegraf.Metric) []column {
+	if len(s.columns) > 0 {
+		return s.columns
+	}
+
+	tags := make(map[string]bool)
+	fields := make(map[string]bool)
+	for _, m := range metrics {
+		for _, tag := range m.TagList() {
+			tags[tag.Key] = true
+		}
+		for _, field := range m.FieldList() {
+			fields[field.Key] = true
+		}
+	}
+
+	columns := []column{{kind: timestampColumn}, {kind: measurementColumn}}
+	for _, key := range sortedKeys(tags) {
+		columns = append(columns, column{kind: tagColumn, key: key})
+	}
+	for _, key := range sortedKeys(fields) {
+		columns = append(columns, column{kind: fieldColumn, key: key})
+	}
+	return columns
+}
+
+func sameColumns(a, b []column) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (s *Serializer) writeHeader(buf *bytes.Buffer, columns []column) {
+	values := make([]string, 0, len(columns))
+	for _, c := range columns {
+		values = append(values, c.String())
+	}
+	s.writeValues(buf, values)
+}
+
+// writeRow writes the row of the metric, metrics having none of the field
+// columns are skipped.
+func (s *Serializer) writeRow(buf *bytes.Buffer, columns []column, m telegraf.Metric) {
+	values := make([]string, 0, len(columns))
+	hasFields, hasValue := false, false
+	for _, c := range columns {
+		var value string
+		switch c.kind {
+		case timestampColumn:
+			value = s.formatTimestamp(m.Time())
+		case measurementColumn:
+			value = m.Name()
+		case tagColumn:
+			value, _ = m.GetTag(c.key)
+		case fieldColumn:
+			hasFields = true
+			if v, ok := m.GetField(c.key); ok {
+				value = formatValue(v)
+				hasValue = true
+			}
+		}
+		values = append(values, value)
+	}
+	if hasFields && !hasValue {
+		return
+	}
+	s.writeValues(buf, values)
+}
+
+func (s *Serializer) writeValues(buf *bytes.Buffer, values []string) {
+	for i, v := range values {
+		if i > 0 {
+			buf.WriteRune(s.delimiter)
+		}
+		if !s.quoteAll && !s.needsQuotes(v) {
+			buf.WriteString(v)
+			continue
+		}
+		buf.WriteByte('"')
+		buf.WriteString(strings.Replace(v, `"`, `""`, -1))
+		buf.WriteByte('"')
+	}
+	buf.WriteByte('\n')
+}
+
+func (s *Serializer) needsQuotes(v string) bool {
+	if v == "" {
+		return false
+	}
+	if v[0] == ' ' || v[0] == '\t' {
+		return true
+	}
+	return strings.ContainsRune(v, s.delimiter) || strings.ContainsAny(v, "\"\r\n")
+}
+
+func (s *Serializer) formatTimestamp(t time.Time) string {
+	switch s.timestampFormat {
+	case "", "unix":
+		return strconv.FormatInt(t.Unix(), 10)
+	case "unix_ms":
+		return strconv.FormatInt(t.UnixNano()/int64(time.Millisecond), 10)
+	case "unix_us":
+		return strconv.FormatInt(t.UnixNano()/int64(time.Microsecond), 10)
+	case "unix_ns":
+		return strconv.FormatInt(t.UnixNano(), 10)
+	default:
+		return t.UTC().Format(s.timestampFormat)
+	}
+}
+
+func formatValue(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case uint64:
+		return strconv.FormatUint(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
@@ -0,0 +1,125 @@
+package csv
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+)
+
+func MustMetric(v telegraf.Metric, err error) telegraf.Metric {
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+var now = time.Unix(1500000000, 0)
+
+func testMetrics() []telegraf.Metric {
+	return []telegraf.Metric{
+		MustMetric(metric.New(
+			"cpu",
+			map[string]string{"host": "server01", "cpu": "cpu0"},
+			map[string]interface{}{"usage_idle": 91.5, "state": "ok"},
+			now,
+		)),
+		MustMetric(metric.New(
+			"mem",
+			map[string]string{"host": "server02"},
+			map[string]interface{}{"free": uint64(42)},
+			now.Add(time.Second),
+		)),
+	}
+}
+
+func TestSerializeBatch(t *testing.T) {
+	s, err := NewSerializer(nil, "", "")
+	require.NoError(t, err)
+	s.SetHeader(true)
+
+	buf, err := s.SerializeBatch(testMetrics())
+	require.NoError(t, err)
+	require.Equal(t, ""+
+		"timestamp,measurement,cpu,host,free,state,usage_idle\n"+
+		"1500000000,cpu,cpu0,server01,,ok,91.5\n"+
+		"1500000001,mem,,server02,42,,\n",
+		string(buf))
+}
+
+func TestSerializeColumns(t *testing.T) {
+	s, err := NewSerializer([]string{"tag.host", "field.usage_idle", "timestamp"}, ";", "2006-01-02T15:04:05Z07:00")
+	require.NoError(t, err)
+	s.SetHeader(true)
+
+	metrics := testMetrics()
+	var out []byte
+	for _, m := range metrics {
+		buf, err := s.Serialize(m)
+		require.NoError(t, err)
+		out = append(out, buf...)
+	}
+
+	// the header is written once, the mem metric has none of the fields
+	require.Equal(t, ""+
+		"host;usage_idle;timestamp\n"+
+		"server01;91.5;2017-07-14T02:40:00Z\n",
+		string(out))
+}
+
+func TestSerializeHeaderChange(t *testing.T) {
+	s, err := NewSerializer(nil, "", "")
+	require.NoError(t, err)
+	s.SetHeader(true)
+
+	metrics := append(testMetrics(), testMetrics()[1])
+	var out []byte
+	for _, m := range metrics {
+		buf, err := s.Serialize(m)
+		require.NoError(t, err)
+		out = append(out, buf...)
+	}
+
+	// the header is written again when the columns change
+	require.Equal(t, ""+
+		"timestamp,measurement,cpu,host,state,usage_idle\n"+
+		"1500000000,cpu,cpu0,server01,ok,91.5\n"+
+		"timestamp,measurement,host,free\n"+
+		"1500000001,mem,server02,42\n"+
+		"1500000001,mem,server02,42\n",
+		string(out))
+}
+
+func TestSerializeQuoting(t *testing.T) {
+	m := MustMetric(metric.New(
+		"log",
+		map[string]string{"source": "a,b"},
+		map[string]interface{}{"message": `say "hi"`, "count": int64(2), "line": " indented"},
+		now,
+	))
+
+	s, err := NewSerializer([]string{"tag.source", "field.message", "field.count", "field.line"}, "", "unix_ms")
+	require.NoError(t, err)
+	buf, err := s.Serialize(m)
+	require.NoError(t, err)
+	require.Equal(t, `"a,b","say ""hi""",2," indented"`+"\n", string(buf))
+
+	s.SetQuoteAll(true)
+	buf, err = s.Serialize(m)
+	require.NoError(t, err)
+	require.Equal(t, `"a,b","say ""hi""","2"," indented"`+"\n", string(buf))
+}
+
+func TestNewSerializerErrors(t *testing.T) {
+	_, err := NewSerializer([]string{"host"}, "", "")
+	require.Error(t, err)
+
+	_, err = NewSerializer(nil, "::", "")
+	require.Error(t, err)
+
+	_, err = NewSerializer(nil, "", "seconds")
+	require.Error(t, err)
+}
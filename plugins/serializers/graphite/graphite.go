@@ -10,6 +10,7 @@ import (
 	"strings"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/filter"
 )
 
 const DEFAULT_TEMPLATE = "host.tags.measurement.field"
@@ -27,12 +28,71 @@ var (
 	)
 
 	fieldDeleter = strings.NewReplacer(".FIELDNAME", "", "FIELDNAME.", "")
+
+	// characters not allowed by the compatible sanitize mode, see
+	// http://graphite.readthedocs.io/en/latest/tags.html
+	compatiblePathChars     = regexp.MustCompile(`[\s;]`)
+	compatibleTagKeyChars   = regexp.MustCompile(`[\s;!^=]`)
+	compatibleTagValueChars = regexp.MustCompile(`[\s;]`)
+)
+
+// SanitizeMode selects the characters replaced in bucket names and tags.
+type SanitizeMode int
+
+const (
+	// StrictSanitize replaces all characters but letters, digits and
+	// "-:._=", this is the legacy behavior
+	StrictSanitize SanitizeMode = iota
+	// CompatibleSanitize only replaces the characters Graphite does not
+	// accept: whitespace, ";" and, in tag keys, "!^="
+	CompatibleSanitize
+	// NoSanitize leaves bucket names and tags as they are
+	NoSanitize
 )
 
 type GraphiteSerializer struct {
-	Prefix     string
-	Template   string
-	TagSupport bool
+	Prefix       string
+	Template     string
+	TagSupport   bool
+	SanitizeMode SanitizeMode
+
+	templates []measurementTemplate
+}
+
+// measurementTemplate is a template used for the measurements matching the
+// filter.
+type measurementTemplate struct {
+	filter   filter.Filter
+	template string
+}
+
+// SetTemplates sets templates overriding the Template for some measurements.
+// Each template has the form "filter template", where filter is a glob
+// matching the measurement name, the first matching template is used.
+func (s *GraphiteSerializer) SetTemplates(templates []string) error {
+	s.templates = s.templates[:0]
+	for _, t := range templates {
+		parts := strings.Fields(t)
+		if len(parts) != 2 {
+			return fmt.Errorf("invalid graphite template %q, expected \"filter template\"", t)
+		}
+		f, err := filter.Compile([]string{parts[0]})
+		if err != nil {
+			return fmt.Errorf("invalid graphite template filter %q: %s", parts[0], err)
+		}
+		s.templates = append(s.templates, measurementTemplate{filter: f, template: parts[1]})
+	}
+	return nil
+}
+
+// template returns the template of the measurement.
+func (s *GraphiteSerializer) template(measurement string) string {
+	for _, t := range s.templates {
+		if t.filter.Match(measurement) {
+			return t.template
+		}
+	}
+	return s.Template
 }
 
 func (s *GraphiteSerializer) Serialize(metric telegraf.Metric) ([]byte, error) {
@@ -48,7 +108,7 @@ func (s *GraphiteSerializer) Serialize(metric telegraf.Metric) ([]byte, error) {
 			if fieldValue == "" {
 				continue
 			}
-			bucket := SerializeBucketNameWithTags(metric.Name(), metric.Tags(), s.Prefix, fieldName)
+			bucket := serializeBucketNameWithTags(metric.Name(), metric.Tags(), s.Prefix, fieldName, s.SanitizeMode)
 			metricString := fmt.Sprintf("%s %s %d\n",
 				// insert "field" section of template
 				bucket,
@@ -59,7 +119,7 @@ func (s *GraphiteSerializer) Serialize(metric telegraf.Metric) ([]byte, error) {
 			out = append(out, point...)
 		}
 	default:
-		bucket := SerializeBucketName(metric.Name(), metric.Tags(), s.Template, s.Prefix)
+		bucket := SerializeBucketName(metric.Name(), metric.Tags(), s.template(metric.Name()), s.Prefix)
 		if bucket == "" {
 			return out, nil
 		}
@@ -71,7 +131,7 @@ func (s *GraphiteSerializer) Serialize(metric telegraf.Metric) ([]byte, error) {
 			}
 			metricString := fmt.Sprintf("%s %s %d\n",
 				// insert "field" section of template
-				sanitize(InsertField(bucket, fieldName)),
+				sanitizePath(InsertField(bucket, fieldName), s.SanitizeMode),
 				fieldValue,
 				timestamp)
 			point := []byte(metricString)
@@ -193,6 +253,16 @@ func SerializeBucketNameWithTags(
 	tags map[string]string,
 	prefix string,
 	field string,
+) string {
+	return serializeBucketNameWithTags(measurement, tags, prefix, field, StrictSanitize)
+}
+
+func serializeBucketNameWithTags(
+	measurement string,
+	tags map[string]string,
+	prefix string,
+	field string,
+	mode SanitizeMode,
 ) string {
 	var out string
 	var tagsCopy []string
@@ -200,7 +270,20 @@ func SerializeBucketNameWithTags(
 		if k == "name" {
 			k = "_name"
 		}
-		tagsCopy = append(tagsCopy, sanitize(k+"="+v))
+		switch mode {
+		case StrictSanitize:
+			tagsCopy = append(tagsCopy, sanitize(k+"="+v))
+		case CompatibleSanitize:
+			k = compatibleTagKeyChars.ReplaceAllLiteralString(k, "_")
+			// tag values must not start with "~"
+			v = strings.TrimLeft(compatibleTagValueChars.ReplaceAllLiteralString(v, "_"), "~")
+			if v == "" {
+				continue
+			}
+			tagsCopy = append(tagsCopy, k+"="+v)
+		default:
+			tagsCopy = append(tagsCopy, k+"="+v)
+		}
 	}
 	sort.Strings(tagsCopy)
 
@@ -214,7 +297,7 @@ func SerializeBucketNameWithTags(
 		out += "." + field
 	}
 
-	out = sanitize(out)
+	out = sanitizePath(out, mode)
 
 	if len(tagsCopy) > 0 {
 		out += ";" + strings.Join(tagsCopy, ";")
@@ -253,6 +336,17 @@ func buildTags(tags map[string]string) string {
 	return tag_str
 }
 
+func sanitizePath(value string, mode SanitizeMode) string {
+	switch mode {
+	case StrictSanitize:
+		return sanitize(value)
+	case CompatibleSanitize:
+		return compatiblePathChars.ReplaceAllLiteralString(value, "_")
+	default:
+		return value
+	}
+}
+
 func sanitize(value string) string {
 	// Apply special hypenation rules to preserve backwards compatibility
 	value = hypenChars.Replace(value)
@@ -952,3 +952,97 @@ func TestSerializeBatchWithTagsSupport(t *testing.T) {
 		})
 	}
 }
+
+func TestSerializeTemplates(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	s := GraphiteSerializer{
+		Template: "host.measurement.field",
+	}
+	require.NoError(t, s.SetTemplates([]string{
+		"cpu tags.measurement.field",
+		"disk* measurement.path.field",
+	}))
+
+	tests := []struct {
+		name     string
+		tags     map[string]string
+		expected string
+	}{
+		{"cpu", defaultTags, "cpu0.us-west-2.localhost.cpu.used 1 1234567890\n"},
+		{"diskio", map[string]string{"host": "localhost", "path": "sda"}, "diskio.sda.used 1 1234567890\n"},
+		{"mem", defaultTags, "localhost.mem.used 1 1234567890\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := metric.New(tt.name, tt.tags, map[string]interface{}{"used": int64(1)}, now)
+			require.NoError(t, err)
+			actual, err := s.Serialize(m)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, string(actual))
+		})
+	}
+
+	require.Error(t, s.SetTemplates([]string{"cpu"}))
+}
+
+func TestSerializeSanitizeMode(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	tags := map[string]string{
+		"path":   "/var/lib",
+		"mode":   "~r w;x",
+		"a!b=c":  "d",
+		"unset":  "~",
+		"status": "ok",
+	}
+	tests := []struct {
+		name       string
+		mode       SanitizeMode
+		tagSupport bool
+		expected   string
+	}{
+		{
+			"strict",
+			StrictSanitize,
+			false,
+			"d._r_w_x.-var-lib.ok._.disk-host.used 1 1234567890\n",
+		},
+		{
+			"strict tags",
+			StrictSanitize,
+			true,
+			"disk-host.used;a_b=c=d;mode=_r_w_x;path=-var-lib;status=ok;unset=_ 1 1234567890\n",
+		},
+		{
+			"compatible",
+			CompatibleSanitize,
+			false,
+			"d.~r_w_x./var/lib.ok.~.disk@host.used 1 1234567890\n",
+		},
+		{
+			"compatible tags",
+			CompatibleSanitize,
+			true,
+			"disk@host.used;a_b_c=d;mode=r_w_x;path=/var/lib;status=ok 1 1234567890\n",
+		},
+		{
+			"none tags",
+			NoSanitize,
+			true,
+			"disk@host.used;a!b=c=d;mode=~r w;x;path=/var/lib;status=ok;unset=~ 1 1234567890\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := metric.New("disk@host", tags, map[string]interface{}{"used": int64(1)}, now)
+			require.NoError(t, err)
+			s := GraphiteSerializer{
+				Template:     "tags.measurement.field",
+				TagSupport:   tt.tagSupport,
+				SanitizeMode: tt.mode,
+			}
+			actual, err := s.Serialize(m)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, string(actual))
+		})
+	}
+}
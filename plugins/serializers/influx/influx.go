@@ -8,6 +8,7 @@ import (
 	"math"
 	"sort"
 	"strconv"
+	"time"
 
 	"github.com/influxdata/telegraf"
 )
@@ -21,6 +22,13 @@ const (
 	SortFields
 )
 
+type TagSortOrder int
+
+const (
+	NoSortTags TagSortOrder = iota
+	SortTags
+)
+
 type FieldTypeSupport int
 
 const (
@@ -56,12 +64,19 @@ type Serializer struct {
 	maxLineBytes     int
 	bytesWritten     int
 	fieldSortOrder   FieldSortOrder
+	tagSortOrder     TagSortOrder
 	fieldTypeSupport FieldTypeSupport
+	precision        time.Duration
 
 	buf    bytes.Buffer
 	header []byte
 	footer []byte
 	pair   []byte
+	tags   []*telegraf.Tag
+	fields []*telegraf.Field
+
+	// average size of the metrics of the last batch
+	metricBytes int
 }
 
 func NewSerializer() *Serializer {
@@ -83,10 +98,23 @@ func (s *Serializer) SetFieldSortOrder(order FieldSortOrder) {
 	s.fieldSortOrder = order
 }
 
+// SetTagSortOrder sets the order of the tags, sorting them by key produces
+// the canonical form of line protocol even if a metric does not hold its
+// tags in order.
+func (s *Serializer) SetTagSortOrder(order TagSortOrder) {
+	s.tagSortOrder = order
+}
+
 func (s *Serializer) SetFieldTypeSupport(typeSupport FieldTypeSupport) {
 	s.fieldTypeSupport = typeSupport
 }
 
+// SetTimestampPrecision truncates timestamps to a multiple of precision,
+// they are still written in nanoseconds.
+func (s *Serializer) SetTimestampPrecision(precision time.Duration) {
+	s.precision = precision
+}
+
 // Serialize writes the telegraf.Metric to a byte slice.  May produce multiple
 // lines of output if longer than maximum line length.  Lines are terminated
 // with a newline (LF) char.
@@ -103,13 +131,19 @@ func (s *Serializer) Serialize(m telegraf.Metric) ([]byte, error) {
 }
 
 func (s *Serializer) SerializeBatch(metrics []telegraf.Metric) ([]byte, error) {
+	// Size the batch from the previous one rather than growing it metric by
+	// metric
 	var batch bytes.Buffer
+	batch.Grow(s.metricBytes * len(metrics))
 	for _, m := range metrics {
 		_, err := s.Write(&batch, m)
 		if err != nil {
 			return nil, err
 		}
 	}
+	if len(metrics) > 0 {
+		s.metricBytes = batch.Len() / len(metrics)
+	}
 	return batch.Bytes(), nil
 }
 
@@ -140,7 +174,16 @@ func (s *Serializer) buildHeader(m telegraf.Metric) error {
 
 	s.header = append(s.header, name...)
 
-	for _, tag := range m.TagList() {
+	tags := m.TagList()
+	if s.tagSortOrder == SortTags {
+		s.tags = append(s.tags[:0], tags...)
+		sort.Slice(s.tags, func(i, j int) bool {
+			return s.tags[i].Key < s.tags[j].Key
+		})
+		tags = s.tags
+	}
+
+	for _, tag := range tags {
 		key := escape(tag.Key)
 		value := escape(tag.Value)
 
@@ -163,7 +206,11 @@ func (s *Serializer) buildHeader(m telegraf.Metric) error {
 func (s *Serializer) buildFooter(m telegraf.Metric) {
 	s.footer = s.footer[:0]
 	s.footer = append(s.footer, ' ')
-	s.footer = strconv.AppendInt(s.footer, m.Time().UnixNano(), 10)
+	ts := m.Time().UnixNano()
+	if s.precision > 0 {
+		ts -= ts % int64(s.precision)
+	}
+	s.footer = strconv.AppendInt(s.footer, ts, 10)
 	s.footer = append(s.footer, '\n')
 }
 
@@ -197,15 +244,20 @@ func (s *Serializer) writeMetric(w io.Writer, m telegraf.Metric) error {
 
 	s.buildFooter(m)
 
+	// The field list may be shared with copies of the metric, so it is
+	// sorted on a copy
+	fields := m.FieldList()
 	if s.fieldSortOrder == SortFields {
-		sort.Slice(m.FieldList(), func(i, j int) bool {
-			return m.FieldList()[i].Key < m.FieldList()[j].Key
+		s.fields = append(s.fields[:0], fields...)
+		sort.Slice(s.fields, func(i, j int) bool {
+			return s.fields[i].Key < s.fields[j].Key
 		})
+		fields = s.fields
 	}
 
 	pairsLen := 0
 	firstField := true
-	for _, field := range m.FieldList() {
+	for _, field := range fields {
 		err = s.buildFieldPair(field.Key, field.Value)
 		if err != nil {
 			log.Printf(
@@ -468,3 +468,71 @@ func TestSerialize_SerializeBatch(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, []byte("cpu value=42 0\ncpu value=42 0\n"), output)
 }
+
+// unsortedTags is a metric holding its tags in reverse order.
+type unsortedTags struct {
+	telegraf.Metric
+}
+
+func (m *unsortedTags) TagList() []*telegraf.Tag {
+	tags := m.Metric.TagList()
+	reversed := make([]*telegraf.Tag, 0, len(tags))
+	for i := len(tags) - 1; i >= 0; i-- {
+		reversed = append(reversed, tags[i])
+	}
+	return reversed
+}
+
+func TestSerialize_SortTags(t *testing.T) {
+	m := &unsortedTags{MustMetric(
+		metric.New(
+			"cpu",
+			map[string]string{"a": "1", "b": "2", "c": "3"},
+			map[string]interface{}{
+				"value": 42.0,
+			},
+			time.Unix(0, 0),
+		),
+	)}
+
+	serializer := NewSerializer()
+	output, err := serializer.Serialize(m)
+	require.NoError(t, err)
+	require.Equal(t, "cpu,c=3,b=2,a=1 value=42 0\n", string(output))
+
+	serializer.SetTagSortOrder(SortTags)
+	output, err = serializer.Serialize(m)
+	require.NoError(t, err)
+	require.Equal(t, "cpu,a=1,b=2,c=3 value=42 0\n", string(output))
+
+	// the tags of the metric are left as is
+	require.Equal(t, "c", m.TagList()[0].Key)
+}
+
+func TestSerialize_TimestampPrecision(t *testing.T) {
+	m := MustMetric(
+		metric.New(
+			"cpu",
+			map[string]string{},
+			map[string]interface{}{
+				"value": 42.0,
+			},
+			time.Unix(1500000000, 123456789),
+		),
+	)
+
+	serializer := NewSerializer()
+	output, err := serializer.Serialize(m)
+	require.NoError(t, err)
+	require.Equal(t, "cpu value=42 1500000000123456789\n", string(output))
+
+	serializer.SetTimestampPrecision(time.Millisecond)
+	output, err = serializer.Serialize(m)
+	require.NoError(t, err)
+	require.Equal(t, "cpu value=42 1500000000123000000\n", string(output))
+
+	serializer.SetTimestampPrecision(time.Second)
+	output, err = serializer.Serialize(m)
+	require.NoError(t, err)
+	require.Equal(t, "cpu value=42 1500000000000000000\n", string(output))
+}
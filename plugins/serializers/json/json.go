@@ -1,23 +1,99 @@
 package json
 
 import (
+	"bytes"
 	"encoding/json"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/influxdata/telegraf"
 )
 
+type BatchFormat int
+
+const (
+	// BatchObject wraps the metrics in an object, {"metrics": [...]}
+	BatchObject BatchFormat = iota
+	// BatchArray writes the metrics as a single array
+	BatchArray
+	// BatchNDJSON writes one metric per line, newline delimited JSON
+	BatchNDJSON
+)
+
+// Layout holds the keys of the name, tags, fields and timestamp in the
+// document of a metric.  Keys containing a dot are paths of nested objects,
+// ie "metric.labels" creates {"metric": {"labels": {...}}}.
+type Layout struct {
+	NameKey      string
+	TagsKey      string
+	FieldsKey    string
+	TimestampKey string
+}
+
+var DefaultLayout = Layout{
+	NameKey:      "name",
+	TagsKey:      "tags",
+	FieldsKey:    "fields",
+	TimestampKey: "timestamp",
+}
+
 type serializer struct {
 	TimestampUnits time.Duration
+
+	// key paths of the name, tags, fields and timestamp
+	namePath      []string
+	tagsPath      []string
+	fieldsPath    []string
+	timestampPath []string
+	batchFormat   BatchFormat
 }
 
 func NewSerializer(timestampUnits time.Duration) (*serializer, error) {
 	s := &serializer{
 		TimestampUnits: truncateDuration(timestampUnits),
 	}
+	if err := s.SetLayout(DefaultLayout); err != nil {
+		return nil, err
+	}
 	return s, nil
 }
 
+// SetLayout sets the keys of the metric document, empty keys use the key of
+// the default layout.  Keys must not be the path of another key.
+func (s *serializer) SetLayout(layout Layout) error {
+	keys := [4]string{layout.NameKey, layout.TagsKey, layout.FieldsKey, layout.TimestampKey}
+	defaults := [4]string{DefaultLayout.NameKey, DefaultLayout.TagsKey, DefaultLayout.FieldsKey, DefaultLayout.TimestampKey}
+
+	for i := range keys {
+		if keys[i] == "" {
+			keys[i] = defaults[i]
+		}
+		for _, part := range strings.Split(keys[i], ".") {
+			if part == "" {
+				return fmt.Errorf("invalid json key %q", keys[i])
+			}
+		}
+	}
+	for i := range keys {
+		for j := range keys {
+			if i != j && (keys[i] == keys[j] || strings.HasPrefix(keys[j], keys[i]+".")) {
+				return fmt.Errorf("json key %q conflicts with json key %q", keys[i], keys[j])
+			}
+		}
+	}
+
+	s.namePath = strings.Split(keys[0], ".")
+	s.tagsPath = strings.Split(keys[1], ".")
+	s.fieldsPath = strings.Split(keys[2], ".")
+	s.timestampPath = strings.Split(keys[3], ".")
+	return nil
+}
+
+func (s *serializer) SetBatchFormat(format BatchFormat) {
+	s.batchFormat = format
+}
+
 func (s *serializer) Serialize(metric telegraf.Metric) ([]byte, error) {
 	m := s.createObject(metric)
 	serialized, err := json.Marshal(m)
@@ -30,14 +106,29 @@ func (s *serializer) Serialize(metric telegraf.Metric) ([]byte, error) {
 }
 
 func (s *serializer) SerializeBatch(metrics []telegraf.Metric) ([]byte, error) {
+	if s.batchFormat == BatchNDJSON {
+		var buf bytes.Buffer
+		for _, metric := range metrics {
+			serialized, err := s.Serialize(metric)
+			if err != nil {
+				return []byte{}, err
+			}
+			buf.Write(serialized)
+		}
+		return buf.Bytes(), nil
+	}
+
 	objects := make([]interface{}, 0, len(metrics))
 	for _, metric := range metrics {
 		m := s.createObject(metric)
 		objects = append(objects, m)
 	}
 
-	obj := map[string]interface{}{
-		"metrics": objects,
+	var obj interface{} = objects
+	if s.batchFormat == BatchObject {
+		obj = map[string]interface{}{
+			"metrics": objects,
+		}
 	}
 
 	serialized, err := json.Marshal(obj)
@@ -49,13 +140,26 @@ func (s *serializer) SerializeBatch(metrics []telegraf.Metric) ([]byte, error) {
 
 func (s *serializer) createObject(metric telegraf.Metric) map[string]interface{} {
 	m := make(map[string]interface{}, 4)
-	m["tags"] = metric.Tags()
-	m["fields"] = metric.Fields()
-	m["name"] = metric.Name()
-	m["timestamp"] = metric.Time().UnixNano() / int64(s.TimestampUnits)
+	set(m, s.namePath, metric.Name())
+	set(m, s.tagsPath, metric.Tags())
+	set(m, s.fieldsPath, metric.Fields())
+	set(m, s.timestampPath, metric.Time().UnixNano()/int64(s.TimestampUnits))
 	return m
 }
 
+// set sets the value at the path in m, creating the nested objects.
+func set(m map[string]interface{}, path []string, value interface{}) {
+	for _, key := range path[:len(path)-1] {
+		nested, ok := m[key].(map[string]interface{})
+		if !ok {
+			nested = make(map[string]interface{})
+			m[key] = nested
+		}
+		m = nested
+	}
+	m[path[len(path)-1]] = value
+}
+
 func truncateDuration(units time.Duration) time.Duration {
 	// Default precision is 1s
 	if units <= 0 {
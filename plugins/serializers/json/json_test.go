@@ -193,3 +193,65 @@ func TestSerializeBatch(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, []byte(`{"metrics":[{"fields":{"value":42},"name":"cpu","tags":{},"timestamp":0},{"fields":{"value":42},"name":"cpu","tags":{},"timestamp":0}]}`), buf)
 }
+
+func TestSerializeLayout(t *testing.T) {
+	m := MustMetric(
+		metric.New(
+			"cpu",
+			map[string]string{"host": "server01"},
+			map[string]interface{}{
+				"value": 42.0,
+			},
+			time.Unix(0, 0),
+		),
+	)
+
+	s, _ := NewSerializer(0)
+	err := s.SetLayout(Layout{
+		NameKey:      "metric.name",
+		TagsKey:      "metric.labels",
+		FieldsKey:    "values",
+		TimestampKey: "time",
+	})
+	require.NoError(t, err)
+
+	buf, err := s.Serialize(m)
+	require.NoError(t, err)
+	require.Equal(t, `{"metric":{"labels":{"host":"server01"},"name":"cpu"},"time":0,"values":{"value":42}}`+"\n", string(buf))
+
+	// unset keys use the default layout
+	err = s.SetLayout(Layout{FieldsKey: "data.fields"})
+	require.NoError(t, err)
+	buf, err = s.Serialize(m)
+	require.NoError(t, err)
+	require.Equal(t, `{"data":{"fields":{"value":42}},"name":"cpu","tags":{"host":"server01"},"timestamp":0}`+"\n", string(buf))
+
+	require.Error(t, s.SetLayout(Layout{NameKey: "metric", TagsKey: "metric.tags"}))
+	require.Error(t, s.SetLayout(Layout{NameKey: "tags"}))
+	require.Error(t, s.SetLayout(Layout{NameKey: "metric..name"}))
+}
+
+func TestSerializeBatchFormat(t *testing.T) {
+	m := MustMetric(
+		metric.New(
+			"cpu",
+			map[string]string{},
+			map[string]interface{}{
+				"value": 42.0,
+			},
+			time.Unix(0, 0),
+		),
+	)
+	metrics := []telegraf.Metric{m, m}
+
+	s, _ := NewSerializer(0)
+	s.SetBatchFormat(BatchArray)
+	buf, err := s.SerializeBatch(metrics)
+	require.NoError(t, err)
+	require.Equal(t, `[{"fields":{"value":42},"name":"cpu","tags":{},"timestamp":0},{"fields":{"value":42},"name":"cpu","tags":{},"timestamp":0}]`, string(buf))
+
+	s.SetBatchFormat(BatchNDJSON)
+	buf, err = s.SerializeBatch(metrics)
+	require.NoError(t, err)
+	require.Equal(t, `{"fields":{"value":42},"name":"cpu","tags":{},"timestamp":0}`+"\n"+`{"fields":{"value":42},"name":"cpu","tags":{},"timestamp":0}`+"\n", string(buf))
+}
@@ -0,0 +1,487 @@
+package parquet
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/golang/snappy"
+
+	"github.com/influxdata/telegraf"
+)
+
+const magic = "PAR1"
+
+// Physical types, repetitions, converted types, encodings and codecs of the
+// parquet format
+const (
+	typeBoolean   = 0
+	typeInt64     = 2
+	typeDouble    = 5
+	typeByteArray = 6
+
+	required = 0
+	optional = 1
+
+	convertedUTF8            = 0
+	convertedTimestampMicros = 10
+	convertedUint64          = 14
+
+	encodingPlain = 0
+	encodingRLE   = 3
+
+	pageData = 0
+)
+
+type Compression int
+
+const (
+	Uncompressed Compression = iota
+	Snappy
+	Gzip
+)
+
+const DefaultRowGroupSize = 10000
+
+// Serializer writes a batch of metrics as a parquet file.  The schema is
+// inferred from the metrics: a measurement and a time column, followed by
+// a column for every tag and every field.  The metrics of each measurement
+// are written to row groups of their own, columns of other measurements are
+// null in them.
+type Serializer struct {
+	RowGroupSize int
+	Compression  Compression
+}
+
+func NewSerializer(rowGroupSize int, compression Compression) *Serializer {
+	if rowGroupSize <= 0 {
+		rowGroupSize = DefaultRowGroupSize
+	}
+	return &Serializer{
+		RowGroupSize: rowGroupSize,
+		Compression:  compression,
+	}
+}
+
+type column struct {
+	name      string
+	typ       int32
+	converted int32
+	optional  bool
+	tag       bool
+	// key of the tag or field
+	key string
+}
+
+// Serialize fails, the files of metrics serialized one by one would be
+// written one after the other, which is not a valid parquet file.  Outputs
+// must use SerializeBatch.
+func (s *Serializer) Serialize(m telegraf.Metric) ([]byte, error) {
+	return nil, fmt.Errorf("parquet: metrics can only be serialized in batches, by outputs such as http")
+}
+
+func (s *Serializer) SerializeBatch(metrics []telegraf.Metric) ([]byte, error) {
+	columns := inferSchema(metrics)
+
+	// keep the metrics of each measurement together, in order
+	var names []string
+	byName := make(map[string][]telegraf.Metric)
+	for _, m := range metrics {
+		if _, ok := byName[m.Name()]; !ok {
+			names = append(names, m.Name())
+		}
+		byName[m.Name()] = append(byName[m.Name()], m)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(magic)
+
+	var groups []rowGroup
+	for _, name := range names {
+		ms := byName[name]
+		for start := 0; start < len(ms); start += s.RowGroupSize {
+			end := start + s.RowGroupSize
+			if end > len(ms) {
+				end = len(ms)
+			}
+			group, err := s.writeRowGroup(&buf, columns, ms[start:end])
+			if err != nil {
+				return nil, err
+			}
+			groups = append(groups, group)
+		}
+	}
+
+	footer := s.fileMetaData(columns, groups, int64(len(metrics)))
+	buf.Write(footer)
+	var length [4]byte
+	binary.LittleEndian.PutUint32(length[:], uint32(len(footer)))
+	buf.Write(length[:])
+	buf.WriteString(magic)
+	return buf.Bytes(), nil
+}
+
+// inferSchema returns the columns of the metrics.  A field with values of
+// different types is a double column if all of them are numbers, otherwise
+// a string column.
+func inferSchema(metrics []telegraf.Metric) []*column {
+	tags := make(map[string]bool)
+	fields := make(map[string]int32)
+	unsigned := make(map[string]bool)
+	for _, m := range metrics {
+		for _, tag := range m.TagList() {
+			tags[tag.Key] = true
+		}
+		for _, field := range m.FieldList() {
+			typ, isUint := fieldType(field.Value)
+			if typ < 0 {
+				continue
+			}
+			prev, ok := fields[field.Key]
+			switch {
+			case !ok:
+				fields[field.Key] = typ
+				unsigned[field.Key] = isUint
+			case prev == typ:
+				unsigned[field.Key] = unsigned[field.Key] && isUint
+			case isNumeric(prev) && isNumeric(typ):
+				fields[field.Key] = typeDouble
+			default:
+				fields[field.Key] = typeByteArray
+			}
+		}
+	}
+
+	columns := []*column{
+		{name: "measurement", typ: typeByteArray, converted: convertedUTF8},
+		{name: "time", typ: typeInt64, converted: convertedTimestampMicros},
+	}
+	names := map[string]bool{"measurement": true, "time": true}
+
+	for _, key := range sortedKeys(tags) {
+		name := key
+		for names[name] {
+			name += "_tag"
+		}
+		names[name] = true
+		columns = append(columns, &column{
+			name: name, key: key, typ: typeByteArray, converted: convertedUTF8,
+			optional: true, tag: true,
+		})
+	}
+
+	fieldKeys := make(map[string]bool, len(fields))
+	for key := range fields {
+		fieldKeys[key] = true
+	}
+	for _, key := range sortedKeys(fieldKeys) {
+		name := key
+		for names[name] {
+			name += "_field"
+		}
+		names[name] = true
+		c := &column{name: name, key: key, typ: fields[key], converted: -1, optional: true}
+		switch {
+		case c.typ == typeByteArray:
+			c.converted = convertedUTF8
+		case c.typ == typeInt64 && unsigned[key]:
+			c.converted = convertedUint64
+		}
+		columns = append(columns, c)
+	}
+	return columns
+}
+
+func fieldType(v interface{}) (int32, bool) {
+	switch v.(type) {
+	case int64:
+		return typeInt64, false
+	case uint64:
+		return typeInt64, true
+	case float64:
+		return typeDouble, false
+	case bool:
+		return typeBoolean, false
+	case string:
+		return typeByteArray, false
+	default:
+		return -1, false
+	}
+}
+
+func isNumeric(typ int32) bool {
+	return typ == typeInt64 || typ == typeDouble
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+type columnChunk struct {
+	offset           int64
+	numValues        int64
+	uncompressedSize int64
+	compressedSize   int64
+}
+
+type rowGroup struct {
+	numRows int64
+	size    int64
+	chunks  []columnChunk
+}
+
+// writeRowGroup writes every column of the metrics as a single data page.
+func (s *Serializer) writeRowGroup(buf *bytes.Buffer, columns []*column, metrics []telegraf.Metric) (rowGroup, error) {
+	group := rowGroup{numRows: int64(len(metrics))}
+	for _, c := range columns {
+		page := s.encodeColumn(c, metrics)
+		compressed, err := s.compress(page)
+		if err != nil {
+			return group, err
+		}
+
+		header := pageHeader(len(page), len(compressed), len(metrics))
+		chunk := columnChunk{
+			offset:           int64(buf.Len()),
+			numValues:        int64(len(metrics)),
+			uncompressedSize: int64(len(header) + len(page)),
+			compressedSize:   int64(len(header) + len(compressed)),
+		}
+		buf.Write(header)
+		buf.Write(compressed)
+
+		group.size += chunk.uncompressedSize
+		group.chunks = append(group.chunks, chunk)
+	}
+	return group, nil
+}
+
+// encodeColumn returns the definition levels of optional columns followed by
+// the plain encoded values that are not null.
+func (s *Serializer) encodeColumn(c *column, metrics []telegraf.Metric) []byte {
+	var values bytes.Buffer
+	levels := make([]bool, 0, len(metrics))
+	var bools []bool
+
+	for _, m := range metrics {
+		var v interface{}
+		switch {
+		case c.name == "measurement":
+			v = m.Name()
+		case c.name == "time":
+			v = m.Time().UnixNano() / 1000
+		case c.tag:
+			if tv, ok := m.GetTag(c.key); ok {
+				v = tv
+			}
+		default:
+			if fv, ok := m.GetField(c.key); ok {
+				v = fv
+			}
+		}
+
+		v, ok := convert(v, c.typ)
+		levels = append(levels, ok)
+		if !ok {
+			continue
+		}
+
+		switch v := v.(type) {
+		case int64:
+			binary.Write(&values, binary.LittleEndian, v)
+		case float64:
+			binary.Write(&values, binary.LittleEndian, math.Float64bits(v))
+		case string:
+			binary.Write(&values, binary.LittleEndian, uint32(len(v)))
+			values.WriteString(v)
+		case bool:
+			bools = append(bools, v)
+		}
+	}
+
+	if c.typ == typeBoolean {
+		packed := make([]byte, (len(bools)+7)/8)
+		for i, b := range bools {
+			if b {
+				packed[i/8] |= 1 << uint(i%8)
+			}
+		}
+		values.Write(packed)
+	}
+
+	if !c.optional {
+		return values.Bytes()
+	}
+
+	encoded := encodeLevels(levels)
+	page := make([]byte, 4, 4+len(encoded)+values.Len())
+	binary.LittleEndian.PutUint32(page, uint32(len(encoded)))
+	page = append(page, encoded...)
+	return append(page, values.Bytes()...)
+}
+
+// convert returns the value as the type of the column, and false for nulls.
+func convert(v interface{}, typ int32) (interface{}, bool) {
+	switch typ {
+	case typeInt64:
+		switch v := v.(type) {
+		case int64:
+			return v, true
+		case uint64:
+			return int64(v), true
+		}
+	case typeDouble:
+		switch v := v.(type) {
+		case int64:
+			return float64(v), true
+		case uint64:
+			return float64(v), true
+		case float64:
+			return v, true
+		}
+	case typeBoolean:
+		if v, ok := v.(bool); ok {
+			return v, true
+		}
+	case typeByteArray:
+		switch v := v.(type) {
+		case string:
+			return v, true
+		case int64:
+			return strconv.FormatInt(v, 10), true
+		case uint64:
+			return strconv.FormatUint(v, 10), true
+		case float64:
+			return strconv.FormatFloat(v, 'f', -1, 64), true
+		case bool:
+			return strconv.FormatBool(v), true
+		}
+	}
+	return nil, false
+}
+
+// encodeLevels encodes definition levels of bit width 1 as runs of the
+// RLE/bit-packing hybrid encoding.
+func encodeLevels(levels []bool) []byte {
+	var buf []byte
+	for i := 0; i < len(levels); {
+		j := i
+		for j < len(levels) && levels[j] == levels[i] {
+			j++
+		}
+		var header [binary.MaxVarintLen64]byte
+		n := binary.PutUvarint(header[:], uint64(j-i)<<1)
+		buf = append(buf, header[:n]...)
+		if levels[i] {
+			buf = append(buf, 1)
+		} else {
+			buf = append(buf, 0)
+		}
+		i = j
+	}
+	return buf
+}
+
+func (s *Serializer) compress(page []byte) ([]byte, error) {
+	switch s.Compression {
+	case Snappy:
+		return snappy.Encode(nil, page), nil
+	case Gzip:
+		var buf bytes.Buffer
+		w := gzip.NewWriter(&buf)
+		if _, err := w.Write(page); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	case Uncompressed:
+		return page, nil
+	default:
+		return nil, fmt.Errorf("unknown compression %d", s.Compression)
+	}
+}
+
+func pageHeader(uncompressed, compressed, numValues int) []byte {
+	w := &compactWriter{}
+	w.beginStruct()
+	w.i32(1, pageData)
+	w.i32(2, int32(uncompressed))
+	w.i32(3, int32(compressed))
+	w.structField(5, func() {
+		w.i32(1, int32(numValues))
+		w.i32(2, encodingPlain)
+		w.i32(3, encodingRLE)
+		w.i32(4, encodingRLE)
+	})
+	w.endStruct()
+	return w.buf.Bytes()
+}
+
+// codec returns the compression codec of the column chunks, the values of
+// Compression are the ones of the parquet format.
+func (s *Serializer) codec() int32 {
+	return int32(s.Compression)
+}
+
+func (s *Serializer) fileMetaData(columns []*column, groups []rowGroup, numRows int64) []byte {
+	w := &compactWriter{}
+	w.beginStruct()
+	w.i32(1, 1)
+	w.structList(2, len(columns)+1, func(i int) {
+		if i == 0 {
+			w.str(4, "schema")
+			w.i32(5, int32(len(columns)))
+			return
+		}
+		c := columns[i-1]
+		w.i32(1, c.typ)
+		if c.optional {
+			w.i32(3, optional)
+		} else {
+			w.i32(3, required)
+		}
+		w.str(4, c.name)
+		if c.converted >= 0 {
+			w.i32(6, c.converted)
+		}
+	})
+	w.i64(3, numRows)
+	w.structList(4, len(groups), func(i int) {
+		group := groups[i]
+		w.structList(1, len(group.chunks), func(j int) {
+			chunk := group.chunks[j]
+			c := columns[j]
+			w.i64(2, chunk.offset)
+			w.structField(3, func() {
+				w.i32(1, c.typ)
+				if c.optional {
+					w.i32List(2, []int32{encodingPlain, encodingRLE})
+				} else {
+					w.i32List(2, []int32{encodingPlain})
+				}
+				w.strList(3, []string{c.name})
+				w.i32(4, s.codec())
+				w.i64(5, chunk.numValues)
+				w.i64(6, chunk.uncompressedSize)
+				w.i64(7, chunk.compressedSize)
+				w.i64(9, chunk.offset)
+			})
+		})
+		w.i64(2, group.size)
+		w.i64(3, group.numRows)
+	})
+	w.str(6, "telegraf")
+	w.endStruct()
+	return w.buf.Bytes()
+}
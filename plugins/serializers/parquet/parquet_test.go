@@ -0,0 +1,213 @@
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/golang/snappy"
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+)
+
+func MustMetric(v telegraf.Metric, err error) telegraf.Metric {
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+// compactReader decodes thrift compact structs into maps of field id to
+// value, lists are decoded as []interface{}.
+type compactReader struct {
+	buf []byte
+}
+
+func (r *compactReader) varint() uint64 {
+	v, n := binary.Uvarint(r.buf)
+	r.buf = r.buf[n:]
+	return v
+}
+
+func (r *compactReader) zigzag() int64 {
+	v := r.varint()
+	return int64(v>>1) ^ -int64(v&1)
+}
+
+func (r *compactReader) value(typ byte) interface{} {
+	switch typ {
+	case compactBoolTrue:
+		return true
+	case compactBoolFalse:
+		return false
+	case compactI32, compactI64:
+		return r.zigzag()
+	case compactBinary:
+		l := r.varint()
+		v := string(r.buf[:l])
+		r.buf = r.buf[l:]
+		return v
+	case compactList:
+		header := r.buf[0]
+		r.buf = r.buf[1:]
+		size := int(header >> 4)
+		if size == 15 {
+			size = int(r.varint())
+		}
+		list := make([]interface{}, size)
+		for i := range list {
+			list[i] = r.value(header & 0x0f)
+		}
+		return list
+	case compactStruct:
+		return r.readStruct()
+	}
+	panic("unexpected type")
+}
+
+func (r *compactReader) readStruct() map[int16]interface{} {
+	s := make(map[int16]interface{})
+	var id int16
+	for {
+		header := r.buf[0]
+		r.buf = r.buf[1:]
+		if header == 0 {
+			return s
+		}
+		if delta := int16(header >> 4); delta != 0 {
+			id += delta
+		} else {
+			id = int16(r.zigzag())
+		}
+		s[id] = r.value(header & 0x0f)
+	}
+}
+
+func footer(t *testing.T, buf []byte) map[int16]interface{} {
+	require.Equal(t, magic, string(buf[:4]))
+	require.Equal(t, magic, string(buf[len(buf)-4:]))
+	length := binary.LittleEndian.Uint32(buf[len(buf)-8:])
+	r := &compactReader{buf[len(buf)-8-int(length) : len(buf)-8]}
+	return r.readStruct()
+}
+
+func schemaNames(meta map[int16]interface{}) []string {
+	var names []string
+	for _, e := range meta[2].([]interface{}) {
+		names = append(names, e.(map[int16]interface{})[4].(string))
+	}
+	return names
+}
+
+// page returns the uncompressed data of the column chunk.
+func page(t *testing.T, buf []byte, chunk map[int16]interface{}) []byte {
+	meta := chunk[3].(map[int16]interface{})
+	r := &compactReader{buf[meta[9].(int64):]}
+	header := r.readStruct()
+	data := r.buf[:header[3].(int64)]
+	if meta[4].(int64) == int64(Snappy) {
+		var err error
+		data, err = snappy.Decode(nil, data)
+		require.NoError(t, err)
+	}
+	require.Len(t, data, int(header[2].(int64)))
+	return data
+}
+
+func TestSerializeBatch(t *testing.T) {
+	now := time.Unix(1500000000, 0)
+	metrics := []telegraf.Metric{
+		MustMetric(metric.New(
+			"cpu",
+			map[string]string{"host": "server01"},
+			map[string]interface{}{"usage_idle": 91.5, "count": int64(1)},
+			now,
+		)),
+		MustMetric(metric.New(
+			"cpu",
+			map[string]string{"host": "server02"},
+			map[string]interface{}{"usage_idle": 42.0, "count": 2.5},
+			now,
+		)),
+		MustMetric(metric.New(
+			"mem",
+			map[string]string{"host": "server01"},
+			map[string]interface{}{"host": "x", "free": uint64(3)},
+			now,
+		)),
+	}
+
+	s := NewSerializer(0, Uncompressed)
+	buf, err := s.SerializeBatch(metrics)
+	require.NoError(t, err)
+
+	meta := footer(t, buf)
+	require.Equal(t, int64(3), meta[3])
+	require.Equal(t, []string{
+		"schema", "measurement", "time", "host", "count", "free", "host_field", "usage_idle",
+	}, schemaNames(meta))
+
+	groups := meta[4].([]interface{})
+	require.Len(t, groups, 2)
+	chunks := groups[0].(map[int16]interface{})[1].([]interface{})
+	require.Len(t, chunks, 7)
+
+	// count is a double column, the integer is converted
+	data := page(t, buf, chunks[3].(map[int16]interface{}))
+	var values [2]float64
+	require.NoError(t, binary.Read(bytes.NewReader(data[4+binary.LittleEndian.Uint32(data):]), binary.LittleEndian, &values))
+	require.Equal(t, [2]float64{1, 2.5}, values)
+}
+
+func TestSerializeRowGroupSize(t *testing.T) {
+	now := time.Unix(1500000000, 0)
+	var metrics []telegraf.Metric
+	for i := 0; i < 5; i++ {
+		metrics = append(metrics, MustMetric(metric.New(
+			"cpu",
+			map[string]string{},
+			map[string]interface{}{"value": int64(i)},
+			now.Add(time.Duration(i)*time.Second),
+		)))
+	}
+
+	s := NewSerializer(2, Snappy)
+	buf, err := s.SerializeBatch(metrics)
+	require.NoError(t, err)
+
+	meta := footer(t, buf)
+	groups := meta[4].([]interface{})
+	require.Len(t, groups, 3)
+
+	var rows []int64
+	for _, g := range groups {
+		rows = append(rows, g.(map[int16]interface{})[3].(int64))
+	}
+	require.Equal(t, []int64{2, 2, 1}, rows)
+
+	// the time column is required and has no definition levels
+	chunks := groups[2].(map[int16]interface{})[1].([]interface{})
+	data := page(t, buf, chunks[1].(map[int16]interface{}))
+	require.Equal(t, now.Add(4*time.Second).UnixNano()/1000, int64(binary.LittleEndian.Uint64(data)))
+}
+
+func TestSerializeDefinitionLevels(t *testing.T) {
+	levels := []bool{true, true, false, true}
+	require.Equal(t, []byte{4, 1, 2, 0, 2, 1}, encodeLevels(levels))
+}
+
+func TestSerializeSingleMetric(t *testing.T) {
+	m := MustMetric(metric.New(
+		"cpu",
+		map[string]string{},
+		map[string]interface{}{"usage_idle": 91.5},
+		time.Unix(1500000000, 0),
+	))
+
+	s := NewSerializer(0, Uncompressed)
+	_, err := s.Serialize(m)
+	require.Error(t, err)
+}
@@ -0,0 +1,114 @@
+package parquet
+
+import (
+	"bytes"
+)
+
+// Types of the thrift compact protocol
+const (
+	compactBoolTrue  = 1
+	compactBoolFalse = 2
+	compactI32       = 5
+	compactI64       = 6
+	compactBinary    = 8
+	compactList      = 9
+	compactStruct    = 12
+)
+
+// compactWriter writes structs in the thrift compact protocol, the encoding
+// of the parquet metadata.
+type compactWriter struct {
+	buf     bytes.Buffer
+	lastIDs []int16
+	lastID  int16
+}
+
+func (w *compactWriter) varint(v uint64) {
+	for v >= 0x80 {
+		w.buf.WriteByte(byte(v) | 0x80)
+		v >>= 7
+	}
+	w.buf.WriteByte(byte(v))
+}
+
+func (w *compactWriter) zigzag(v int64) {
+	w.varint(uint64(v<<1) ^ uint64(v>>63))
+}
+
+func (w *compactWriter) fieldHeader(id int16, typ byte) {
+	if delta := id - w.lastID; delta > 0 && delta <= 15 {
+		w.buf.WriteByte(byte(delta)<<4 | typ)
+	} else {
+		w.buf.WriteByte(typ)
+		w.zigzag(int64(id))
+	}
+	w.lastID = id
+}
+
+func (w *compactWriter) beginStruct() {
+	w.lastIDs = append(w.lastIDs, w.lastID)
+	w.lastID = 0
+}
+
+func (w *compactWriter) endStruct() {
+	w.buf.WriteByte(0)
+	w.lastID = w.lastIDs[len(w.lastIDs)-1]
+	w.lastIDs = w.lastIDs[:len(w.lastIDs)-1]
+}
+
+func (w *compactWriter) i32(id int16, v int32) {
+	w.fieldHeader(id, compactI32)
+	w.zigzag(int64(v))
+}
+
+func (w *compactWriter) i64(id int16, v int64) {
+	w.fieldHeader(id, compactI64)
+	w.zigzag(v)
+}
+
+func (w *compactWriter) str(id int16, v string) {
+	w.fieldHeader(id, compactBinary)
+	w.varint(uint64(len(v)))
+	w.buf.WriteString(v)
+}
+
+func (w *compactWriter) structField(id int16, fn func()) {
+	w.fieldHeader(id, compactStruct)
+	w.beginStruct()
+	fn()
+	w.endStruct()
+}
+
+func (w *compactWriter) listHeader(id int16, typ byte, size int) {
+	w.fieldHeader(id, compactList)
+	if size < 15 {
+		w.buf.WriteByte(byte(size)<<4 | typ)
+	} else {
+		w.buf.WriteByte(0xf0 | typ)
+		w.varint(uint64(size))
+	}
+}
+
+func (w *compactWriter) structList(id int16, size int, fn func(i int)) {
+	w.listHeader(id, compactStruct, size)
+	for i := 0; i < size; i++ {
+		w.beginStruct()
+		fn(i)
+		w.endStruct()
+	}
+}
+
+func (w *compactWriter) i32List(id int16, values []int32) {
+	w.listHeader(id, compactI32, len(values))
+	for _, v := range values {
+		w.zigzag(int64(v))
+	}
+}
+
+func (w *compactWriter) strList(id int16, values []string) {
+	w.listHeader(id, compactBinary, len(values))
+	for _, v := range values {
+		w.varint(uint64(len(v)))
+		w.buf.WriteString(v)
+	}
+}
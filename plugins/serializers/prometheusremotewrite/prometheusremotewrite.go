@@ -0,0 +1,223 @@
+package prometheusremotewrite
+
+import (
+	"bytes"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/golang/snappy"
+
+	"github.com/influxdata/telegraf"
+)
+
+var invalidNameCharRE = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
+// Tags added by the prometheus input with metadata_as_tags, they are sent as
+// metadata of the metric family instead of labels.
+const (
+	helpTag = "prometheus_help"
+	typeTag = "prometheus_type"
+)
+
+// metricTypes are the values of the MetricType enum of the metadata
+var metricTypes = map[string]int{
+	"untyped":   0,
+	"counter":   1,
+	"gauge":     2,
+	"histogram": 3,
+	"summary":   5,
+}
+
+// Serializer writes metrics as a snappy compressed Prometheus remote write
+// WriteRequest.  Fields are named like in the prometheus_client output:
+// histograms and summaries are split into their buckets or quantiles, and
+// the count and sum, other fields become a series named measurement_field.
+type Serializer struct{}
+
+func NewSerializer() *Serializer {
+	return &Serializer{}
+}
+
+// Serialize writes the metric as a WriteRequest of its own.  It only suits
+// outputs sending every metric as a message of its own, outputs writing the
+// metrics one after the other, such as the file output, would concatenate
+// requests that cannot be decoded.  Outputs sending batches should use
+// SerializeBatch instead.
+func (s *Serializer) Serialize(m telegraf.Metric) ([]byte, error) {
+	return s.SerializeBatch([]telegraf.Metric{m})
+}
+
+func (s *Serializer) SerializeBatch(metrics []telegraf.Metric) ([]byte, error) {
+	series := make(map[string]*timeSeries)
+	metadata := make(map[string]*metricMetadata)
+	for _, m := range metrics {
+		addMetric(series, m)
+		addMetadata(metadata, m)
+	}
+
+	keys := make([]string, 0, len(series))
+	for key := range series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	req := make([]*timeSeries, 0, len(keys))
+	for _, key := range keys {
+		ts := series[key]
+		// the samples of a series must be in time order
+		sort.SliceStable(ts.samples, func(i, j int) bool {
+			return ts.samples[i].timestamp < ts.samples[j].timestamp
+		})
+		req = append(req, ts)
+	}
+
+	families := make([]string, 0, len(metadata))
+	for family := range metadata {
+		families = append(families, family)
+	}
+	sort.Strings(families)
+
+	meta := make([]*metricMetadata, 0, len(families))
+	for _, family := range families {
+		meta = append(meta, metadata[family])
+	}
+	return snappy.Encode(nil, marshalWriteRequest(req, meta)), nil
+}
+
+// addMetadata adds the HELP and TYPE of the family of the metric kept by the
+// prometheus input.
+func addMetadata(metadata map[string]*metricMetadata, m telegraf.Metric) {
+	help, hasHelp := m.GetTag(helpTag)
+	typ, hasType := m.GetTag(typeTag)
+	if !hasHelp && !hasType {
+		return
+	}
+
+	family := sanitize(m.Name())
+	if _, ok := metadata[family]; ok {
+		return
+	}
+	metadata[family] = &metricMetadata{
+		typ:    metricTypes[typ],
+		family: family,
+		help:   help,
+	}
+}
+
+func addMetric(series map[string]*timeSeries, m telegraf.Metric) {
+	labels := make([]label, 0, len(m.TagList()))
+	for _, tag := range m.TagList() {
+		if tag.Key == helpTag || tag.Key == typeTag {
+			continue
+		}
+		labels = append(labels, label{sanitize(tag.Key), tag.Value})
+	}
+
+	name := sanitize(m.Name())
+	timestamp := m.Time().UnixNano() / 1e6
+
+	add := func(name string, value float64, extra ...label) {
+		ls := make([]label, 0, len(labels)+len(extra)+1)
+		ls = append(ls, label{"__name__", name})
+		ls = append(ls, extra...)
+		// tags whose sanitized names collide with another label are
+		// dropped, the tags are sorted so the first one is kept
+		for _, l := range labels {
+			if !hasLabel(ls, l.name) {
+				ls = append(ls, l)
+			}
+		}
+		sort.Slice(ls, func(i, j int) bool { return ls[i].name < ls[j].name })
+
+		key := seriesKey(ls)
+		ts, ok := series[key]
+		if !ok {
+			ts = &timeSeries{labels: ls}
+			series[key] = ts
+		}
+		ts.samples = append(ts.samples, sample{value, timestamp})
+	}
+
+	for _, field := range m.FieldList() {
+		value, ok := floatValue(field.Value)
+		if !ok {
+			continue
+		}
+
+		switch m.Type() {
+		case telegraf.Histogram, telegraf.Summary:
+			switch field.Key {
+			case "sum":
+				add(name+"_sum", value)
+			case "count":
+				add(name+"_count", value)
+			default:
+				bound, err := strconv.ParseFloat(field.Key, 64)
+				if err != nil {
+					continue
+				}
+				if m.Type() == telegraf.Histogram {
+					add(name+"_bucket", value, label{"le", formatFloat(bound)})
+				} else {
+					add(name, value, label{"quantile", formatFloat(bound)})
+				}
+			}
+			continue
+		}
+
+		switch {
+		case m.Type() == telegraf.Counter && field.Key == "counter",
+			m.Type() == telegraf.Gauge && field.Key == "gauge",
+			field.Key == "value":
+			add(name, value)
+		default:
+			add(sanitize(m.Name()+"_"+field.Key), value)
+		}
+	}
+}
+
+func hasLabel(labels []label, name string) bool {
+	for _, l := range labels {
+		if l.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func floatValue(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case int64:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case float64:
+		return v, true
+	default:
+		return 0, false
+	}
+}
+
+func formatFloat(f float64) string {
+	if math.IsInf(f, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+func seriesKey(labels []label) string {
+	var buf bytes.Buffer
+	for _, l := range labels {
+		buf.WriteString(l.name)
+		buf.WriteByte(0)
+		buf.WriteString(l.value)
+		buf.WriteByte(0)
+	}
+	return buf.String()
+}
+
+func sanitize(value string) string {
+	return invalidNameCharRE.ReplaceAllString(value, "_")
+}
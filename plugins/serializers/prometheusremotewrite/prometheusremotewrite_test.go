@@ -0,0 +1,285 @@
+package prometheusremotewrite
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/snappy"
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+)
+
+func MustMetric(v telegraf.Metric, err error) telegraf.Metric {
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+// fields splits a protobuf message into its fields, the values of varint
+// and fixed64 fields are returned as the raw value.
+func fields(t *testing.T, buf []byte) [][2]interface{} {
+	var out [][2]interface{}
+	for len(buf) > 0 {
+		tag, n := proto.DecodeVarint(buf)
+		require.NotZero(t, n)
+		buf = buf[n:]
+		switch tag & 7 {
+		case wireVarint:
+			v, n := proto.DecodeVarint(buf)
+			require.NotZero(t, n)
+			out = append(out, [2]interface{}{int(tag >> 3), v})
+			buf = buf[n:]
+		case wireFixed64:
+			out = append(out, [2]interface{}{int(tag >> 3), binary.LittleEndian.Uint64(buf)})
+			buf = buf[8:]
+		case wireBytes:
+			l, n := proto.DecodeVarint(buf)
+			require.NotZero(t, n)
+			buf = buf[n:]
+			out = append(out, [2]interface{}{int(tag >> 3), buf[:l]})
+			buf = buf[l:]
+		default:
+			t.Fatalf("unexpected wire type %d", tag&7)
+		}
+	}
+	return out
+}
+
+// decode returns the series of a WriteRequest as strings in the text
+// exposition format.
+func decode(t *testing.T, buf []byte) []string {
+	raw, err := snappy.Decode(nil, buf)
+	require.NoError(t, err)
+
+	var series []string
+	for _, f := range fields(t, raw) {
+		if f[0] == 3 {
+			continue
+		}
+		require.Equal(t, 1, f[0])
+		var name, labels string
+		var samples []string
+		for _, ts := range fields(t, f[1].([]byte)) {
+			msg := fields(t, ts[1].([]byte))
+			switch ts[0] {
+			case 1:
+				k, v := string(msg[0][1].([]byte)), string(msg[1][1].([]byte))
+				if k == "__name__" {
+					name = v
+				} else {
+					labels += fmt.Sprintf("%s=%q,", k, v)
+				}
+			case 2:
+				value := math.Float64frombits(msg[0][1].(uint64))
+				samples = append(samples, fmt.Sprintf("%v %d", value, int64(msg[1][1].(uint64))))
+			}
+		}
+		for _, s := range samples {
+			if labels != "" {
+				series = append(series, fmt.Sprintf("%s{%s} %s", name, labels[:len(labels)-1], s))
+			} else {
+				series = append(series, fmt.Sprintf("%s %s", name, s))
+			}
+		}
+	}
+	return series
+}
+
+// decodeMetadata returns the metadata of a WriteRequest as the type, the
+// family name and the help of every family.
+func decodeMetadata(t *testing.T, buf []byte) []string {
+	raw, err := snappy.Decode(nil, buf)
+	require.NoError(t, err)
+
+	var metadata []string
+	for _, f := range fields(t, raw) {
+		if f[0] != 3 {
+			continue
+		}
+		var typ uint64
+		var family, help string
+		for _, mf := range fields(t, f[1].([]byte)) {
+			switch mf[0] {
+			case 1:
+				typ = mf[1].(uint64)
+			case 2:
+				family = string(mf[1].([]byte))
+			case 4:
+				help = string(mf[1].([]byte))
+			}
+		}
+		metadata = append(metadata, fmt.Sprintf("%d %s %q", typ, family, help))
+	}
+	return metadata
+}
+
+func TestSerializeBatch(t *testing.T) {
+	now := time.Unix(1500000000, 0)
+	metrics := []telegraf.Metric{
+		MustMetric(metric.New(
+			"cpu",
+			map[string]string{"host": "server01", "cpu": "cpu0"},
+			map[string]interface{}{
+				"usage_idle": 91.5,
+				"state":      "ok",
+			},
+			now,
+		)),
+		MustMetric(metric.New(
+			"http_requests",
+			map[string]string{"code": "200", "prometheus_type": "counter"},
+			map[string]interface{}{"counter": int64(17)},
+			now,
+			telegraf.Counter,
+		)),
+		MustMetric(metric.New(
+			"temperature",
+			map[string]string{},
+			map[string]interface{}{"value": uint64(22)},
+			now.Add(2*time.Second),
+		)),
+		MustMetric(metric.New(
+			"temperature",
+			map[string]string{},
+			map[string]interface{}{"value": uint64(21)},
+			now.Add(time.Second),
+		)),
+	}
+
+	s := NewSerializer()
+	buf, err := s.SerializeBatch(metrics)
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		`cpu_usage_idle{cpu="cpu0",host="server01"} 91.5 1500000000000`,
+		`http_requests{code="200"} 17 1500000000000`,
+		`temperature 21 1500000001000`,
+		`temperature 22 1500000002000`,
+	}, decode(t, buf))
+}
+
+func TestSerializeHistogram(t *testing.T) {
+	now := time.Unix(1500000000, 0)
+	m := MustMetric(metric.New(
+		"rpc.duration",
+		map[string]string{"service": "api"},
+		map[string]interface{}{
+			"0.5":   10.0,
+			"+Inf":  12.0,
+			"count": 12.0,
+			"sum":   4.2,
+		},
+		now,
+		telegraf.Histogram,
+	))
+
+	s := NewSerializer()
+	buf, err := s.Serialize(m)
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		`rpc_duration_bucket{le="+Inf",service="api"} 12 1500000000000`,
+		`rpc_duration_bucket{le="0.5",service="api"} 10 1500000000000`,
+		`rpc_duration_count{service="api"} 12 1500000000000`,
+		`rpc_duration_sum{service="api"} 4.2 1500000000000`,
+	}, decode(t, buf))
+}
+
+func TestSerializeSummary(t *testing.T) {
+	now := time.Unix(1500000000, 0)
+	m := MustMetric(metric.New(
+		"gc",
+		map[string]string{},
+		map[string]interface{}{
+			"0.99":  0.02,
+			"count": int64(3),
+			"sum":   0.04,
+		},
+		now,
+		telegraf.Summary,
+	))
+
+	s := NewSerializer()
+	buf, err := s.Serialize(m)
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		`gc{quantile="0.99"} 0.02 1500000000000`,
+		`gc_count 3 1500000000000`,
+		`gc_sum 0.04 1500000000000`,
+	}, decode(t, buf))
+}
+
+func TestSerializeLabelCollision(t *testing.T) {
+	m := MustMetric(metric.New(
+		"disk",
+		map[string]string{
+			"mount.point": "/",
+			"mount_point": "/var",
+			"__name__":    "other",
+		},
+		map[string]interface{}{"value": 42.0},
+		time.Unix(1500000000, 0),
+	))
+
+	s := NewSerializer()
+	buf, err := s.Serialize(m)
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		`disk{mount_point="/"} 42 1500000000000`,
+	}, decode(t, buf))
+}
+
+func TestSerializeMetadata(t *testing.T) {
+	now := time.Unix(1500000000, 0)
+	metrics := []telegraf.Metric{
+		MustMetric(metric.New(
+			"http_requests",
+			map[string]string{
+				"code":            "200",
+				"prometheus_help": "Number of HTTP requests.",
+				"prometheus_type": "counter",
+			},
+			map[string]interface{}{"counter": int64(17)},
+			now,
+			telegraf.Counter,
+		)),
+		MustMetric(metric.New(
+			"http_requests",
+			map[string]string{
+				"code":            "500",
+				"prometheus_help": "Number of HTTP requests.",
+				"prometheus_type": "counter",
+			},
+			map[string]interface{}{"counter": int64(2)},
+			now,
+			telegraf.Counter,
+		)),
+		MustMetric(metric.New(
+			"rpc_duration",
+			map[string]string{"prometheus_type": "histogram"},
+			map[string]interface{}{"+Inf": 1.0, "count": 1.0, "sum": 0.2},
+			now,
+			telegraf.Histogram,
+		)),
+		MustMetric(metric.New(
+			"cpu",
+			map[string]string{},
+			map[string]interface{}{"usage_idle": 91.5},
+			now,
+		)),
+	}
+
+	s := NewSerializer()
+	buf, err := s.SerializeBatch(metrics)
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		`1 http_requests "Number of HTTP requests."`,
+		`3 rpc_duration ""`,
+	}, decodeMetadata(t, buf))
+	require.Len(t, decode(t, buf), 6)
+}
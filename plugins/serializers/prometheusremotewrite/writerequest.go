@@ -0,0 +1,103 @@
+package prometheusremotewrite
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// The protobuf messages of the remote write protocol:
+//
+//	message WriteRequest {
+//	  repeated TimeSeries timeseries = 1;
+//	  repeated MetricMetadata metadata = 3;
+//	}
+//	message TimeSeries { repeated Label labels = 1; repeated Sample samples = 2; }
+//	message Label { string name = 1; string value = 2; }
+//	message Sample { double value = 1; int64 timestamp = 2; }
+//	message MetricMetadata {
+//	  MetricType type = 1;
+//	  string metric_family_name = 2;
+//	  string help = 4;
+//	}
+
+type label struct {
+	name  string
+	value string
+}
+
+type sample struct {
+	value     float64
+	timestamp int64
+}
+
+type timeSeries struct {
+	labels  []label
+	samples []sample
+}
+
+type metricMetadata struct {
+	typ    int
+	family string
+	help   string
+}
+
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+)
+
+func appendVarint(buf []byte, v uint64) []byte {
+	for v >= 0x80 {
+		buf = append(buf, byte(v)|0x80)
+		v >>= 7
+	}
+	return append(buf, byte(v))
+}
+
+func appendTag(buf []byte, field int, wireType int) []byte {
+	return appendVarint(buf, uint64(field<<3|wireType))
+}
+
+func appendBytes(buf []byte, field int, b []byte) []byte {
+	buf = appendTag(buf, field, wireBytes)
+	buf = appendVarint(buf, uint64(len(b)))
+	return append(buf, b...)
+}
+
+func marshalWriteRequest(series []*timeSeries, metadata []*metricMetadata) []byte {
+	var buf, ts, msg []byte
+	for _, s := range series {
+		ts = ts[:0]
+		for _, l := range s.labels {
+			msg = msg[:0]
+			msg = appendBytes(msg, 1, []byte(l.name))
+			msg = appendBytes(msg, 2, []byte(l.value))
+			ts = appendBytes(ts, 1, msg)
+		}
+		for _, sm := range s.samples {
+			msg = msg[:0]
+			msg = appendTag(msg, 1, wireFixed64)
+			var b [8]byte
+			binary.LittleEndian.PutUint64(b[:], math.Float64bits(sm.value))
+			msg = append(msg, b[:]...)
+			msg = appendTag(msg, 2, wireVarint)
+			msg = appendVarint(msg, uint64(sm.timestamp))
+			ts = appendBytes(ts, 2, msg)
+		}
+		buf = appendBytes(buf, 1, ts)
+	}
+	for _, m := range metadata {
+		msg = msg[:0]
+		if m.typ != 0 {
+			msg = appendTag(msg, 1, wireVarint)
+			msg = appendVarint(msg, uint64(m.typ))
+		}
+		msg = appendBytes(msg, 2, []byte(m.family))
+		if m.help != "" {
+			msg = appendBytes(msg, 4, []byte(m.help))
+		}
+		buf = appendBytes(buf, 3, msg)
+	}
+	return buf
+}
@@ -6,9 +6,14 @@ import (
 
 	"github.com/influxdata/telegraf"
 
+	"github.com/influxdata/telegraf/plugins/serializers/csv"
 	"github.com/influxdata/telegraf/plugins/serializers/graphite"
 	"github.com/influxdata/telegraf/plugins/serializers/influx"
 	"github.com/influxdata/telegraf/plugins/serializers/json"
+	"github.com/influxdata/telegraf/plugins/serializers/parquet"
+	"github.com/influxdata/telegraf/plugins/serializers/prometheusremotewrite"
+	"github.com/influxdata/telegraf/plugins/serializers/splunkmetric"
+	"github.com/influxdata/telegraf/plugins/serializers/template"
 )
 
 // SerializerOutput is an interface for output plugins that are able to
@@ -35,12 +40,17 @@ type Serializer interface {
 // Config is a struct that covers the data types needed for all serializer types,
 // and can be used to instantiate _any_ of the serializers.
 type Config struct {
-	// Dataformat can be one of: influx, graphite, or json
+	// Dataformat can be one of: influx, graphite, json, prometheusremotewrite,
+	// parquet, csv, splunkmetric or template
 	DataFormat string
 
 	// Support tags in graphite protocol
 	GraphiteTagSupport bool
 
+	// Characters replaced in graphite buckets and tags, one of strict,
+	// compatible or none
+	GraphiteSanitizeMode string
+
 	// Maximum line length in bytes; influx format only
 	InfluxMaxLineBytes int
 
@@ -48,18 +58,77 @@ type Config struct {
 	// than unsorted fields; influx format only
 	InfluxSortFields bool
 
+	// Sort tag keys, producing canonical line protocol; influx format only
+	InfluxSortTags bool
+
 	// Support unsigned integer output; influx format only
 	InfluxUintSupport bool
 
+	// Truncate timestamps to a multiple of this duration; influx format only
+	InfluxTimestampPrecision time.Duration
+
 	// Prefix to add to all measurements, only supports Graphite
 	Prefix string
 
-	// Template for converting telegraf metrics into Graphite
-	// only supports Graphite
+	// Template for converting telegraf metrics into Graphite, or the Go
+	// template of a metric; only supports Graphite and template
 	Template string
 
+	// Go template of a batch of metrics; template format only
+	BatchTemplate string
+
+	// Templates overriding Template for the measurements matching their
+	// filter, in the form "filter template"; only supports Graphite
+	Templates []string
+
 	// Timestamp units to use for JSON formatted output
 	TimestampUnits time.Duration
+
+	// Keys of the name, tags, fields and timestamp in the JSON document of a
+	// metric, keys containing a dot are paths of nested objects
+	JSONNameKey      string
+	JSONTagsKey      string
+	JSONFieldsKey    string
+	JSONTimestampKey string
+
+	// Format of a batch of JSON metrics, one of object, array or ndjson
+	JSONBatchFormat string
+
+	// Compression of the column chunks, one of snappy, gzip or none; parquet
+	// format only
+	ParquetCompression string
+
+	// Maximum number of rows in a row group; parquet format only
+	ParquetRowGroupSize int
+
+	// Columns of the CSV rows, "timestamp", "measurement", "tag.<key>" or
+	// "field.<key>"; csv format only
+	CSVColumns []string
+
+	// Write a header with the column names; csv format only
+	CSVHeader bool
+
+	// Delimiter of the values, a single character; csv format only
+	CSVDelimiter string
+
+	// Quote all values instead of only the values needing it; csv format only
+	CSVQuoteAll bool
+
+	// Format of the timestamp, unix, unix_ms, unix_us, unix_ns or a Go time
+	// layout; csv format only
+	CSVTimestampFormat string
+
+	// Write events in the format of the HTTP Event Collector; splunkmetric
+	// format only
+	SplunkmetricHecRouting bool
+
+	// Write all fields of a metric as measures of a single event; splunkmetric
+	// format only
+	SplunkmetricMultiMetric bool
+
+	// Tags written as dimensions; splunkmetric format only
+	SplunkmetricDimensionsInclude []string
+	SplunkmetricDimensionsExclude []string
 }
 
 // NewSerializer a Serializer interface based on the given config.
@@ -70,9 +139,19 @@ func NewSerializer(config *Config) (Serializer, error) {
 	case "influx":
 		serializer, err = NewInfluxSerializerConfig(config)
 	case "graphite":
-		serializer, err = NewGraphiteSerializer(config.Prefix, config.Template, config.GraphiteTagSupport)
+		serializer, err = NewGraphiteSerializerConfig(config)
 	case "json":
-		serializer, err = NewJsonSerializer(config.TimestampUnits)
+		serializer, err = NewJsonSerializerConfig(config)
+	case "prometheusremotewrite":
+		serializer, err = NewPrometheusRemoteWriteSerializer()
+	case "parquet":
+		serializer, err = NewParquetSerializer(config)
+	case "csv":
+		serializer, err = NewCSVSerializer(config)
+	case "splunkmetric":
+		serializer, err = NewSplunkmetricSerializer(config)
+	case "template":
+		serializer, err = NewTemplateSerializer(config)
 	default:
 		err = fmt.Errorf("Invalid data format: %s", config.DataFormat)
 	}
@@ -83,12 +162,47 @@ func NewJsonSerializer(timestampUnits time.Duration) (Serializer, error) {
 	return json.NewSerializer(timestampUnits)
 }
 
+func NewJsonSerializerConfig(config *Config) (Serializer, error) {
+	var batchFormat json.BatchFormat
+	switch config.JSONBatchFormat {
+	case "", "object":
+		batchFormat = json.BatchObject
+	case "array":
+		batchFormat = json.BatchArray
+	case "ndjson":
+		batchFormat = json.BatchNDJSON
+	default:
+		return nil, fmt.Errorf("Invalid json_batch_format: %s", config.JSONBatchFormat)
+	}
+
+	s, err := json.NewSerializer(config.TimestampUnits)
+	if err != nil {
+		return nil, err
+	}
+	err = s.SetLayout(json.Layout{
+		NameKey:      config.JSONNameKey,
+		TagsKey:      config.JSONTagsKey,
+		FieldsKey:    config.JSONFieldsKey,
+		TimestampKey: config.JSONTimestampKey,
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.SetBatchFormat(batchFormat)
+	return s, nil
+}
+
 func NewInfluxSerializerConfig(config *Config) (Serializer, error) {
 	var sort influx.FieldSortOrder
 	if config.InfluxSortFields {
 		sort = influx.SortFields
 	}
 
+	var tagSort influx.TagSortOrder
+	if config.InfluxSortTags {
+		tagSort = influx.SortTags
+	}
+
 	var typeSupport influx.FieldTypeSupport
 	if config.InfluxUintSupport {
 		typeSupport = typeSupport + influx.UintSupport
@@ -97,10 +211,16 @@ func NewInfluxSerializerConfig(config *Config) (Serializer, error) {
 	s := influx.NewSerializer()
 	s.SetMaxLineBytes(config.InfluxMaxLineBytes)
 	s.SetFieldSortOrder(sort)
+	s.SetTagSortOrder(tagSort)
 	s.SetFieldTypeSupport(typeSupport)
+	s.SetTimestampPrecision(config.InfluxTimestampPrecision)
 	return s, nil
 }
 
+func NewPrometheusRemoteWriteSerializer() (Serializer, error) {
+	return prometheusremotewrite.NewSerializer(), nil
+}
+
 func NewInfluxSerializer() (Serializer, error) {
 	return influx.NewSerializer(), nil
 }
@@ -112,3 +232,70 @@ func NewGraphiteSerializer(prefix, template string, tag_support bool) (Serialize
 		TagSupport: tag_support,
 	}, nil
 }
+
+func NewGraphiteSerializerConfig(config *Config) (Serializer, error) {
+	var mode graphite.SanitizeMode
+	switch config.GraphiteSanitizeMode {
+	case "", "strict":
+		mode = graphite.StrictSanitize
+	case "compatible":
+		mode = graphite.CompatibleSanitize
+	case "none":
+		mode = graphite.NoSanitize
+	default:
+		return nil, fmt.Errorf("Invalid graphite_sanitize_mode: %s", config.GraphiteSanitizeMode)
+	}
+
+	s := &graphite.GraphiteSerializer{
+		Prefix:       config.Prefix,
+		Template:     config.Template,
+		TagSupport:   config.GraphiteTagSupport,
+		SanitizeMode: mode,
+	}
+	if err := s.SetTemplates(config.Templates); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func NewParquetSerializer(config *Config) (Serializer, error) {
+	var compression parquet.Compression
+	switch config.ParquetCompression {
+	case "", "snappy":
+		compression = parquet.Snappy
+	case "gzip":
+		compression = parquet.Gzip
+	case "none":
+		compression = parquet.Uncompressed
+	default:
+		return nil, fmt.Errorf("Invalid parquet_compression: %s", config.ParquetCompression)
+	}
+	return parquet.NewSerializer(config.ParquetRowGroupSize, compression), nil
+}
+
+func NewCSVSerializer(config *Config) (Serializer, error) {
+	s, err := csv.NewSerializer(config.CSVColumns, config.CSVDelimiter, config.CSVTimestampFormat)
+	if err != nil {
+		return nil, err
+	}
+	s.SetHeader(config.CSVHeader)
+	s.SetQuoteAll(config.CSVQuoteAll)
+	return s, nil
+}
+
+func NewSplunkmetricSerializer(config *Config) (Serializer, error) {
+	s := splunkmetric.NewSerializer(config.SplunkmetricHecRouting, config.SplunkmetricMultiMetric)
+	err := s.SetDimensions(config.SplunkmetricDimensionsInclude, config.SplunkmetricDimensionsExclude)
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func NewTemplateSerializer(config *Config) (Serializer, error) {
+	s, err := template.NewSerializer(config.Template, config.BatchTemplate)
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
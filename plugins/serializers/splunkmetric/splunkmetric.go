@@ -0,0 +1,154 @@
+package splunkmetric
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/filter"
+)
+
+type serializer struct {
+	HecRouting  bool
+	MultiMetric bool
+
+	dimensions filter.Filter
+}
+
+// NewSerializer returns a serializer writing metrics as Splunk metric events.
+// With HEC routing the events have the format of the HTTP Event Collector,
+// otherwise the format of a metrics index file.  With multi metric all
+// fields of a metric are measures of a single event, as supported by Splunk
+// 8 and later, otherwise each field is an event of its own.
+func NewSerializer(hecRouting, multiMetric bool) *serializer {
+	return &serializer{
+		HecRouting:  hecRouting,
+		MultiMetric: multiMetric,
+	}
+}
+
+// SetDimensions sets the tags written as dimensions, tags not matching the
+// include filters or matching the exclude filters are dropped.
+func (s *serializer) SetDimensions(include, exclude []string) error {
+	f, err := filter.NewIncludeExcludeFilter(include, exclude)
+	if err != nil {
+		return err
+	}
+	s.dimensions = f
+	return nil
+}
+
+func (s *serializer) Serialize(metric telegraf.Metric) ([]byte, error) {
+	var buf bytes.Buffer
+	for _, event := range s.createEvents(metric) {
+		serialized, err := json.Marshal(event)
+		if err != nil {
+			return []byte{}, err
+		}
+		buf.Write(serialized)
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes(), nil
+}
+
+func (s *serializer) SerializeBatch(metrics []telegraf.Metric) ([]byte, error) {
+	var buf bytes.Buffer
+	for _, metric := range metrics {
+		serialized, err := s.Serialize(metric)
+		if err != nil {
+			return []byte{}, err
+		}
+		buf.Write(serialized)
+	}
+	return buf.Bytes(), nil
+}
+
+// createEvents returns the events of the metric, fields without a numeric
+// value are skipped.
+func (s *serializer) createEvents(metric telegraf.Metric) []map[string]interface{} {
+	var events []map[string]interface{}
+	var multi map[string]interface{}
+	for _, field := range metric.FieldList() {
+		value, ok := floatValue(field.Value)
+		if !ok {
+			continue
+		}
+
+		name := metric.Name() + "." + field.Key
+		if s.MultiMetric {
+			if multi == nil {
+				multi = s.dimensionsOf(metric)
+			}
+			multi["metric_name:"+name] = value
+			continue
+		}
+
+		fields := s.dimensionsOf(metric)
+		fields["metric_name"] = name
+		fields["_value"] = value
+		events = append(events, s.createEvent(metric, fields))
+	}
+
+	if multi != nil {
+		events = append(events, s.createEvent(metric, multi))
+	}
+	return events
+}
+
+func (s *serializer) createEvent(metric telegraf.Metric, fields map[string]interface{}) map[string]interface{} {
+	// Splunk expects the time in seconds, with millisecond precision
+	timestamp := float64(metric.Time().UnixNano()/int64(1e6)) / 1e3
+	if !s.HecRouting {
+		fields["time"] = timestamp
+		return fields
+	}
+
+	event := map[string]interface{}{
+		"time":   timestamp,
+		"event":  "metric",
+		"fields": fields,
+	}
+	if host, ok := metric.GetTag("host"); ok {
+		event["host"] = host
+	}
+	return event
+}
+
+// dimensionsOf returns the tags of the metric passing the dimensions filter,
+// with HEC routing the host is set on the event instead.
+func (s *serializer) dimensionsOf(metric telegraf.Metric) map[string]interface{} {
+	dimensions := make(map[string]interface{}, len(metric.TagList()))
+	for _, tag := range metric.TagList() {
+		if s.HecRouting && tag.Key == "host" {
+			continue
+		}
+		if s.dimensions != nil && !s.dimensions.Match(tag.Key) {
+			continue
+		}
+		dimensions[tag.Key] = tag.Value
+	}
+	return dimensions
+}
+
+func floatValue(v interface{}) (float64, bool) {
+	var f float64
+	switch v := v.(type) {
+	case int64:
+		f = float64(v)
+	case uint64:
+		f = float64(v)
+	case float64:
+		f = v
+	case bool:
+		if v {
+			f = 1
+		}
+	default:
+		return 0, false
+	}
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0, false
+	}
+	return f, true
+}
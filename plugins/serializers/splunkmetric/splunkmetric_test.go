@@ -0,0 +1,96 @@
+package splunkmetric
+
+import (
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+)
+
+func MustMetric(v telegraf.Metric, err error) telegraf.Metric {
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+func testMetric() telegraf.Metric {
+	return MustMetric(metric.New(
+		"cpu",
+		map[string]string{"host": "server01", "cpu": "cpu0", "dc": "us-east-1"},
+		map[string]interface{}{
+			"usage_idle": 91.5,
+			"usage_user": int64(2),
+			"state":      "ok",
+		},
+		time.Unix(1529708430, 123456789),
+	))
+}
+
+// events returns the sorted events of buf, the events of the fields of a
+// metric are written in the order of its fields.
+func events(buf []byte) []string {
+	lines := strings.Split(strings.TrimSuffix(string(buf), "\n"), "\n")
+	sort.Strings(lines)
+	return lines
+}
+
+func TestSerializeMetric(t *testing.T) {
+	s := NewSerializer(false, false)
+	buf, err := s.Serialize(testMetric())
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		`{"_value":2,"cpu":"cpu0","dc":"us-east-1","host":"server01","metric_name":"cpu.usage_user","time":1529708430.123}`,
+		`{"_value":91.5,"cpu":"cpu0","dc":"us-east-1","host":"server01","metric_name":"cpu.usage_idle","time":1529708430.123}`,
+	}, events(buf))
+}
+
+func TestSerializeHecRouting(t *testing.T) {
+	s := NewSerializer(true, false)
+	buf, err := s.Serialize(testMetric())
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		`{"event":"metric","fields":{"_value":2,"cpu":"cpu0","dc":"us-east-1","metric_name":"cpu.usage_user"},"host":"server01","time":1529708430.123}`,
+		`{"event":"metric","fields":{"_value":91.5,"cpu":"cpu0","dc":"us-east-1","metric_name":"cpu.usage_idle"},"host":"server01","time":1529708430.123}`,
+	}, events(buf))
+}
+
+func TestSerializeMultiMetric(t *testing.T) {
+	s := NewSerializer(true, true)
+	require.NoError(t, s.SetDimensions(nil, []string{"d*"}))
+
+	buf, err := s.SerializeBatch([]telegraf.Metric{testMetric(), testMetric()})
+	require.NoError(t, err)
+	event := `{"event":"metric","fields":{"cpu":"cpu0","metric_name:cpu.usage_idle":91.5,"metric_name:cpu.usage_user":2},"host":"server01","time":1529708430.123}` + "\n"
+	require.Equal(t, event+event, string(buf))
+}
+
+func TestSerializeDimensions(t *testing.T) {
+	s := NewSerializer(false, true)
+	require.NoError(t, s.SetDimensions([]string{"host", "cpu"}, nil))
+
+	buf, err := s.Serialize(testMetric())
+	require.NoError(t, err)
+	require.Equal(t,
+		`{"cpu":"cpu0","host":"server01","metric_name:cpu.usage_idle":91.5,"metric_name:cpu.usage_user":2,"time":1529708430.123}`+"\n",
+		string(buf))
+}
+
+func TestSerializeNoNumericFields(t *testing.T) {
+	m := MustMetric(metric.New(
+		"log",
+		map[string]string{},
+		map[string]interface{}{"message": "hello"},
+		time.Unix(0, 0),
+	))
+
+	s := NewSerializer(true, true)
+	buf, err := s.Serialize(m)
+	require.NoError(t, err)
+	require.Empty(t, buf)
+}
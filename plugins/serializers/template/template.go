@@ -0,0 +1,70 @@
+package template
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+
+	"github.com/influxdata/telegraf"
+)
+
+// Serializer writes metrics using Go text templates.  The template is
+// executed with each metric, the batch template with the slice of metrics
+// of a batch.
+type Serializer struct {
+	template      *template.Template
+	batchTemplate *template.Template
+}
+
+// NewSerializer parses the templates, without batch template batches are
+// written by executing the template with each metric.
+func NewSerializer(tmpl, batchTmpl string) (*Serializer, error) {
+	if tmpl == "" && batchTmpl == "" {
+		return nil, fmt.Errorf("template or batch_template is required")
+	}
+
+	s := &Serializer{}
+	var err error
+	if tmpl != "" {
+		s.template, err = template.New("template").Parse(tmpl)
+		if err != nil {
+			return nil, fmt.Errorf("invalid template: %s", err)
+		}
+	}
+	if batchTmpl != "" {
+		s.batchTemplate, err = template.New("batch_template").Parse(batchTmpl)
+		if err != nil {
+			return nil, fmt.Errorf("invalid batch_template: %s", err)
+		}
+	}
+	return s, nil
+}
+
+func (s *Serializer) Serialize(metric telegraf.Metric) ([]byte, error) {
+	if s.template == nil {
+		return s.SerializeBatch([]telegraf.Metric{metric})
+	}
+
+	var buf bytes.Buffer
+	if err := s.template.Execute(&buf, metric); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (s *Serializer) SerializeBatch(metrics []telegraf.Metric) ([]byte, error) {
+	var buf bytes.Buffer
+	if s.batchTemplate != nil {
+		if err := s.batchTemplate.Execute(&buf, metrics); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+
+	for _, metric := range metrics {
+		if err := s.template.Execute(&buf, metric); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
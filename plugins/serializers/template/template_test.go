@@ -0,0 +1,88 @@
+package template
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+)
+
+func MustMetric(v telegraf.Metric, err error) telegraf.Metric {
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+func testMetrics() []telegraf.Metric {
+	now := time.Unix(1500000000, 0)
+	return []telegraf.Metric{
+		MustMetric(metric.New(
+			"cpu",
+			map[string]string{"host": "server01"},
+			map[string]interface{}{"usage_idle": 91.5},
+			now,
+		)),
+		MustMetric(metric.New(
+			"mem",
+			map[string]string{"host": "server02"},
+			map[string]interface{}{"free": int64(42)},
+			now,
+		)),
+	}
+}
+
+func TestSerialize(t *testing.T) {
+	s, err := NewSerializer(
+		`{{.Name}}@{{.Tags.host}}{{range $k, $v := .Fields}} {{$k}}={{$v}}{{end}} {{.Time.Unix}}`+"\n", "")
+	require.NoError(t, err)
+
+	metrics := testMetrics()
+	buf, err := s.Serialize(metrics[0])
+	require.NoError(t, err)
+	require.Equal(t, "cpu@server01 usage_idle=91.5 1500000000\n", string(buf))
+
+	buf, err = s.SerializeBatch(metrics)
+	require.NoError(t, err)
+	require.Equal(t, "cpu@server01 usage_idle=91.5 1500000000\nmem@server02 free=42 1500000000\n", string(buf))
+}
+
+func TestSerializeBatchTemplate(t *testing.T) {
+	s, err := NewSerializer("", `BEGIN {{len .}}
+{{range .}}{{.Name}} {{index .Tags "host"}}
+{{end}}END
+`)
+	require.NoError(t, err)
+
+	metrics := testMetrics()
+	buf, err := s.SerializeBatch(metrics)
+	require.NoError(t, err)
+	require.Equal(t, "BEGIN 2\ncpu server01\nmem server02\nEND\n", string(buf))
+
+	// without template a metric is written as a batch of its own
+	buf, err = s.Serialize(metrics[1])
+	require.NoError(t, err)
+	require.Equal(t, "BEGIN 1\nmem server02\nEND\n", string(buf))
+}
+
+func TestNewSerializerErrors(t *testing.T) {
+	_, err := NewSerializer("", "")
+	require.Error(t, err)
+
+	_, err = NewSerializer("{{.Name", "")
+	require.Error(t, err)
+
+	_, err = NewSerializer("{{.Name}}", "{{range}}")
+	require.Error(t, err)
+}
+
+func TestSerializeExecuteError(t *testing.T) {
+	s, err := NewSerializer("{{.Unknown}}", "")
+	require.NoError(t, err)
+
+	_, err = s.Serialize(testMetrics()[0])
+	require.Error(t, err)
+}
@@ -0,0 +1,153 @@
+package selfstat
+
+import (
+	"math/rand"
+	"sort"
+	"sync"
+)
+
+// histogramSamples is the number of values kept by a histogram between two
+// collections, values beyond it replace random samples.
+const histogramSamples = 1024
+
+// histogramQuantiles are the quantiles of the histogram fields, by suffix.
+var histogramQuantiles = []struct {
+	suffix   string
+	quantile float64
+}{
+	{"_p50", 0.50},
+	{"_p90", 0.90},
+	{"_p99", 0.99},
+}
+
+type histogramStat struct {
+	measurement string
+	field       string
+	tags        map[string]string
+	key         uint64
+
+	mu      sync.Mutex
+	samples []int64
+	// count, sum, min and max of all values, including those not sampled
+	count int64
+	sum   int64
+	min   int64
+	max   int64
+	prev  map[string]interface{}
+}
+
+func (s *histogramStat) Incr(v int64) {
+	s.mu.Lock()
+	if s.count == 0 || v < s.min {
+		s.min = v
+	}
+	if s.count == 0 || v > s.max {
+		s.max = v
+	}
+	s.count++
+	s.sum += v
+
+	// reservoir sampling, every value has the same chance to be sampled
+	if len(s.samples) < histogramSamples {
+		s.samples = append(s.samples, v)
+	} else if i := rand.Int63n(s.count); i < histogramSamples {
+		s.samples[i] = v
+	}
+	s.mu.Unlock()
+}
+
+func (s *histogramStat) Set(v int64) {
+	s.Incr(v)
+}
+
+// Get returns the average of the values added since the last collection, as
+// a timing stat does.
+func (s *histogramStat) Get() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.count == 0 {
+		if s.prev == nil {
+			return 0
+		}
+		return s.prev[s.field].(int64)
+	}
+	return s.sum / s.count
+}
+
+// Fields returns the distribution of the values added since the last call,
+// and clears them: the count, average, minimum, maximum and quantiles of the
+// values in the fields of the stat field name with a suffix, the average in
+// the field of the stat field name.  Without new values the previous
+// distribution is returned with a count of 0.
+func (s *histogramStat) Fields() map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	fields := make(map[string]interface{}, len(histogramQuantiles)+4)
+	if s.count == 0 {
+		for k, v := range s.prev {
+			fields[k] = v
+		}
+		if len(fields) == 0 {
+			fields[s.field] = int64(0)
+		}
+		fields[s.field+"_count"] = int64(0)
+		return fields
+	}
+
+	sort.Slice(s.samples, func(i, j int) bool { return s.samples[i] < s.samples[j] })
+	fields[s.field] = s.sum / s.count
+	fields[s.field+"_count"] = s.count
+	fields[s.field+"_min"] = s.min
+	fields[s.field+"_max"] = s.max
+	for _, q := range histogramQuantiles {
+		fields[s.field+q.suffix] = quantile(s.samples, q.quantile)
+	}
+
+	s.prev = fields
+	s.samples = s.samples[:0]
+	s.count, s.sum, s.min, s.max = 0, 0, 0, 0
+
+	out := make(map[string]interface{}, len(fields))
+	for k, v := range fields {
+		out[k] = v
+	}
+	return out
+}
+
+// quantile returns the nearest rank quantile q of the sorted values.
+func quantile(sorted []int64, q float64) int64 {
+	i := int(q*float64(len(sorted))+0.5) - 1
+	if i < 0 {
+		i = 0
+	}
+	if i >= len(sorted) {
+		i = len(sorted) - 1
+	}
+	return sorted[i]
+}
+
+func (s *histogramStat) Name() string {
+	return s.measurement
+}
+
+func (s *histogramStat) FieldName() string {
+	return s.field
+}
+
+// Tags returns a copy of the histogramStat's tags.
+// NOTE this allocates a new map every time it is called.
+func (s *histogramStat) Tags() map[string]string {
+	m := make(map[string]string, len(s.tags))
+	for k, v := range s.tags {
+		m[k] = v
+	}
+	return m
+}
+
+func (s *histogramStat) Key() uint64 {
+	if s.key == 0 {
+		s.key = key(s.measurement, s.tags)
+	}
+	return s.key
+}
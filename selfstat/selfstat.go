@@ -87,6 +87,31 @@ func RegisterTiming(measurement, field string, tags map[string]string) Stat {
 	})
 }
 
+// RegisterHistogram registers the given measurement, field, and tags in the
+// selfstat registry. If given an identical measurement, it will return the
+// stat that's already been registered.
+//
+// Histogram stats are timing stats reporting the distribution of the values
+// added to them since the previous call to Metrics(): along with the average
+// in the field itself, they add the fields with the _count, _min, _max,
+// _p50, _p90 and _p99 suffixes.  The quantiles are computed on a random
+// sample of the values when more than 1024 values are added.
+//
+// The returned Stat can be incremented by the consumer of Register(), and it's
+// value will be returned as a telegraf metric when Metrics() is called.
+func RegisterHistogram(measurement, field string, tags map[string]string) Stat {
+	return registry.register(&histogramStat{
+		measurement: "internal_" + measurement,
+		field:       field,
+		tags:        tags,
+	})
+}
+
+// fieldsStat is a stat with several fields, such as a histogram stat.
+type fieldsStat interface {
+	Fields() map[string]interface{}
+}
+
 // Metrics returns all registered stats as telegraf metrics.
 func Metrics() []telegraf.Metric {
 	registry.mu.Lock()
@@ -104,7 +129,13 @@ func Metrics() []telegraf.Metric {
 					tags = stat.Tags()
 					name = stat.Name()
 				}
-				fields[fieldname] = stat.Get()
+				if fs, ok := stat.(fieldsStat); ok {
+					for k, v := range fs.Fields() {
+						fields[k] = v
+					}
+				} else {
+					fields[fieldname] = stat.Get()
+				}
 				j++
 			}
 			metric, err := metric.New(name, tags, fields, now)
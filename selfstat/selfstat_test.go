@@ -219,3 +219,69 @@ func TestRegisterMetricsAndVerify(t *testing.T) {
 		},
 	)
 }
+
+func TestRegisterHistogram(t *testing.T) {
+	testLock.Lock()
+	defer testCleanup()
+	s := RegisterHistogram("test_histogram", "test_ns", map[string]string{"test": "foo"})
+	// the same field returns the same histogram
+	assert.Equal(t, s, RegisterHistogram("test_histogram", "test_ns", map[string]string{"test": "foo"}))
+	assert.Equal(t, int64(0), s.Get())
+
+	for i := int64(1); i <= 100; i++ {
+		s.Incr(i)
+	}
+	assert.Equal(t, int64(50), s.Get())
+
+	acc := testutil.Accumulator{}
+	acc.AddMetrics(Metrics())
+	acc.AssertContainsTaggedFields(t, "internal_test_histogram",
+		map[string]interface{}{
+			"test_ns":       int64(50),
+			"test_ns_count": int64(100),
+			"test_ns_min":   int64(1),
+			"test_ns_max":   int64(100),
+			"test_ns_p50":   int64(50),
+			"test_ns_p90":   int64(90),
+			"test_ns_p99":   int64(99),
+		},
+		map[string]string{
+			"test": "foo",
+		},
+	)
+
+	// without new values, the previous distribution is kept with a count of 0
+	acc = testutil.Accumulator{}
+	acc.AddMetrics(Metrics())
+	acc.AssertContainsTaggedFields(t, "internal_test_histogram",
+		map[string]interface{}{
+			"test_ns":       int64(50),
+			"test_ns_count": int64(0),
+			"test_ns_min":   int64(1),
+			"test_ns_max":   int64(100),
+			"test_ns_p50":   int64(50),
+			"test_ns_p90":   int64(90),
+			"test_ns_p99":   int64(99),
+		},
+		map[string]string{
+			"test": "foo",
+		},
+	)
+	assert.Equal(t, int64(50), s.Get())
+}
+
+func TestHistogramSampling(t *testing.T) {
+	s := &histogramStat{field: "test_ns"}
+	for i := int64(1); i <= 10*histogramSamples; i++ {
+		s.Incr(i)
+	}
+	fields := s.Fields()
+	assert.Equal(t, int64(10*histogramSamples), fields["test_ns_count"])
+	assert.Equal(t, int64(1), fields["test_ns_min"])
+	assert.Equal(t, int64(10*histogramSamples), fields["test_ns_max"])
+	assert.Len(t, s.samples, 0)
+
+	// the median of the sample is close to the median of the values
+	p50 := fields["test_ns_p50"].(int64)
+	assert.InDelta(t, 5*histogramSamples, p50, histogramSamples)
+}